@@ -9,8 +9,14 @@ package add_user
 import (
 	"context"
 	"fmt"
+	"io"
 	"io/ioutil"
+	"os"
+	"reflect"
+	"sort"
+	"strconv"
 	"strings"
+	"text/tabwriter"
 	"time"
 
 	"github.com/pkg/errors"
@@ -18,17 +24,56 @@ import (
 	core "k8s.io/api/core/v1"
 	rbac "k8s.io/api/rbac/v1"
 	k8s_errors "k8s.io/apimachinery/pkg/api/errors"
+	meta "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/util/wait"
+	k8s_yaml "k8s.io/apimachinery/pkg/util/yaml"
 	"k8s.io/client-go/kubernetes"
 
 	"github.com/codeactual/kubeauth/internal/cage/cli/handler"
 	handler_cobra "github.com/codeactual/kubeauth/internal/cage/cli/handler/cobra"
 	cage_k8s "github.com/codeactual/kubeauth/internal/cage/kubernetes/v1"
+	cage_k8s_csr "github.com/codeactual/kubeauth/internal/cage/kubernetes/v1/certificates/certificate_signing_request"
 	cage_k8s_core "github.com/codeactual/kubeauth/internal/cage/kubernetes/v1/core"
+	cage_k8s_discovery "github.com/codeactual/kubeauth/internal/cage/kubernetes/v1/discovery"
 	cage_k8s_config "github.com/codeactual/kubeauth/internal/cage/kubernetes/v1/kubectl/config"
 	cage_k8s_rbac "github.com/codeactual/kubeauth/internal/cage/kubernetes/v1/rbac"
+	cage_k8s_cluster_role "github.com/codeactual/kubeauth/internal/cage/kubernetes/v1/rbac/cluster_role"
+	cage_k8s_cluster_role_binding "github.com/codeactual/kubeauth/internal/cage/kubernetes/v1/rbac/cluster_role_binding"
+	cage_k8s_risk "github.com/codeactual/kubeauth/internal/cage/kubernetes/v1/rbac/risk"
+	cage_k8s_role "github.com/codeactual/kubeauth/internal/cage/kubernetes/v1/rbac/role"
+	cage_k8s_role_binding "github.com/codeactual/kubeauth/internal/cage/kubernetes/v1/rbac/role_binding"
+	cage_k8s_secret "github.com/codeactual/kubeauth/internal/cage/kubernetes/v1/secret"
+	cage_k8s_sa "github.com/codeactual/kubeauth/internal/cage/kubernetes/v1/service_account"
 	cage_file "github.com/codeactual/kubeauth/internal/cage/os/file"
 	cage_reflect "github.com/codeactual/kubeauth/internal/cage/reflect"
+	"github.com/codeactual/kubeauth/internal/manifest"
+	"github.com/codeactual/kubeauth/internal/ownership"
+)
+
+const (
+	// AuthToken selects a user which authenticates with a service account's bearer token.
+	AuthToken = "token"
+
+	// AuthCert selects a user which authenticates with a client certificate issued by the cluster.
+	AuthCert = "cert"
+
+	// TokenSecretSuffix is appended to the service account name to form the name of the token secret
+	// rendered by --emit-manifests. It follows the "<account name>-token-<random>" convention of
+	// the secrets generated by the token controller.
+	TokenSecretSuffix = "-token-kubeauth"
+
+	// GrantRolePrefix is prepended to the username to form the name of the role, cluster role,
+	// and their bindings which hold the permissions selected by --grant.
+	GrantRolePrefix = "kubeauth-"
+)
+
+// Outcomes of reconciling a selected role binding, listed in the per-namespace summary.
+const (
+	bindingCreated   = "created"
+	bindingReplaced  = "replaced"
+	bindingUnchanged = "unchanged"
+	bindingUpdated   = "updated"
 )
 
 // Handler defines the sub-command flags and logic.
@@ -38,13 +83,32 @@ type Handler struct {
 	KubeApiClientset    *cage_k8s_core.Clientset
 	KubectlConfigClient cage_k8s_config.Client
 
-	Cluster            string   `usage:"cluster of the new context to create (default from current-context)"`
-	ClusterRoles       []string `usage:"cluster role binding to create (<role name>:<binding name>)"`
-	ConfigFile         string   `usage:"kubectl config file to modify"`
-	Namespace          string   `usage:"namespace to receive service account (default from current-context)"`
-	Roles              []string `usage:"role binding to create (<role name>:<binding name>)"`
-	ServiceAccountName string   `usage:"name of service account to create"`
-	Username           string   `usage:"username/context to receive the service account's bearer token"`
+	AllowEscalation        bool          `usage:"bind roles whose rules grant escalation-prone permissions, e.g. reading secrets, or request privileged --groups, e.g. system:masters, with a warning instead of an error"`
+	ApproveCert            bool          `usage:"approve the certificate signing request of a cert user if permitted, instead of waiting for an administrator"`
+	Annotations            []string      `usage:"annotation to add to created objects (<key>=<value>)"`
+	Auth                   string        `usage:"credential type of the user: token (service account bearer token) or cert (client certificate)"`
+	AutomountToken         string        `usage:"set automountServiceAccountToken of the service account (true or false)"`
+	BackupLimit            int           `usage:"number of backups to retain per modified kubectl config file, or 0 to disable backups"`
+	CertTimeout            time.Duration `usage:"duration to wait for the certificate of a cert user to be approved and issued"`
+	Cluster                string        `usage:"cluster of the new context to create (default from current-context)"`
+	ClusterRoles           []string      `usage:"cluster role binding to create (<role name>:<binding name>)"`
+	CommonName             string        `usage:"certificate common name, i.e. API username, of a cert user (default from --user)"`
+	CreateNamespace        bool          `usage:"create the effective namespace and those selected by role binding selectors if they do not exist"`
+	ConfigFile             string        `usage:"kubectl config file to modify"`
+	ConfigDestFile         string        `usage:"kubectl config file to receive a new user/context (default from --kubeconfig or first existing KUBECONFIG file)"`
+	EmitManifests          string        `usage:"write the objects to create as YAML to a directory, with a kustomization, or to stdout with -, instead of creating them (inputs are still checked against the cluster unless --offline is selected)"`
+	Force                  bool          `usage:"replace existing bindings which refer to a different role (requires deleting and recreating them), and the rules of existing --grant roles"`
+	Grants                 []string      `usage:"permissions to grant with a role created for the user (<verb>[,<verb>...]:<resource>[.<group>][/<resource name>])"`
+	Groups                 []string      `usage:"certificate organizations, i.e. API groups, of a cert user"`
+	ImagePullSecrets       []string      `usage:"image pull secret to add to the service account"`
+	Labels                 []string      `usage:"label to add to created objects (<key>=<value>)"`
+	NamespacedClusterRoles []string      `usage:"role binding to create which refers to a cluster role, granting its permissions only in the namespace ([<namespace>/]<cluster role name>:<binding name>)"`
+	Namespace              string        `usage:"namespace to receive service account (default from current-context)"`
+	Offline                bool          `usage:"with --emit-manifests, read neither the kubeconfig nor the API, which skips the namespace, role, escalation-prone permission, and unserved rule checks (requires --namespace, disallows --grant)"`
+	Roles                  []string      `usage:"role binding to create ([<namespace>/]<role name>:<binding name>)"`
+	ServiceAccountFile     string        `usage:"ServiceAccount manifest (YAML or JSON) whose labels, annotations, image pull secrets, and automountServiceAccountToken are merged into the service account"`
+	ServiceAccountName     string        `usage:"name of service account to create (required by token users)"`
+	Username               string        `usage:"username/context to receive the credentials"`
 
 	// Verbosity levels greater than 0 will enable status messages and error stack traces.
 	//
@@ -59,7 +123,7 @@ func (h *Handler) Init() handler_cobra.Init {
 	return handler_cobra.Init{
 		Cmd: &cobra.Command{
 			Use:   "add-user",
-			Short: "Add a service account and user/context to use its credentials",
+			Short: "Add a user/context which authenticates with a service account token or client certificate",
 		},
 		EnvPrefix: "KUBEAUTH",
 	}
@@ -69,15 +133,35 @@ func (h *Handler) Init() handler_cobra.Init {
 //
 // It implements cli/handler/cobra.Handler.
 func (h *Handler) BindFlags(cmd *cobra.Command) []string {
+	cmd.Flags().BoolVarP(&h.AllowEscalation, "allow-escalation", "", false, cage_reflect.GetFieldTag(*h, "AllowEscalation", "usage"))
+	cmd.Flags().BoolVarP(&h.ApproveCert, "approve", "", false, cage_reflect.GetFieldTag(*h, "ApproveCert", "usage"))
+	cmd.Flags().StringSliceVarP(&h.Annotations, "annotation", "", []string{}, cage_reflect.GetFieldTag(*h, "Annotations", "usage"))
+	cmd.Flags().StringVarP(&h.Auth, "auth", "", AuthToken, cage_reflect.GetFieldTag(*h, "Auth", "usage"))
+	cmd.Flags().StringVarP(&h.AutomountToken, "automount-token", "", "", cage_reflect.GetFieldTag(*h, "AutomountToken", "usage"))
+	cmd.Flags().Lookup("automount-token").NoOptDefVal = "true"
+	cmd.Flags().IntVarP(&h.BackupLimit, "backup-limit", "", cage_k8s_config.DefaultBackupLimit, cage_reflect.GetFieldTag(*h, "BackupLimit", "usage"))
+	cmd.Flags().DurationVarP(&h.CertTimeout, "cert-timeout", "", cage_k8s_csr.DefaultIssueTimeout, cage_reflect.GetFieldTag(*h, "CertTimeout", "usage"))
 	cmd.Flags().StringVarP(&h.Cluster, "cluster", "", "", cage_reflect.GetFieldTag(*h, "Cluster", "usage"))
 	cmd.Flags().StringSliceVarP(&h.ClusterRoles, "cluster-role", "", []string{}, cage_reflect.GetFieldTag(*h, "ClusterRoles", "usage"))
+	cmd.Flags().StringVarP(&h.CommonName, "cn", "", "", cage_reflect.GetFieldTag(*h, "CommonName", "usage"))
+	cmd.Flags().BoolVarP(&h.CreateNamespace, "create-namespace", "", false, cage_reflect.GetFieldTag(*h, "CreateNamespace", "usage"))
 	cmd.Flags().StringVarP(&h.ConfigFile, "kubeconfig", "", "", cage_reflect.GetFieldTag(*h, "ConfigFile", "usage"))
+	cmd.Flags().StringVarP(&h.ConfigDestFile, "kubeconfig-dest", "", "", cage_reflect.GetFieldTag(*h, "ConfigDestFile", "usage"))
+	cmd.Flags().StringVarP(&h.EmitManifests, "emit-manifests", "", "", cage_reflect.GetFieldTag(*h, "EmitManifests", "usage"))
+	cmd.Flags().BoolVarP(&h.Force, "force", "", false, cage_reflect.GetFieldTag(*h, "Force", "usage"))
+	cmd.Flags().StringArrayVarP(&h.Grants, "grant", "", []string{}, cage_reflect.GetFieldTag(*h, "Grants", "usage"))
+	cmd.Flags().StringSliceVarP(&h.Groups, "groups", "", []string{}, cage_reflect.GetFieldTag(*h, "Groups", "usage"))
+	cmd.Flags().StringSliceVarP(&h.ImagePullSecrets, "image-pull-secret", "", []string{}, cage_reflect.GetFieldTag(*h, "ImagePullSecrets", "usage"))
+	cmd.Flags().StringSliceVarP(&h.Labels, "label", "l", []string{}, cage_reflect.GetFieldTag(*h, "Labels", "usage"))
+	cmd.Flags().StringSliceVarP(&h.NamespacedClusterRoles, "namespaced-cluster-role", "", []string{}, cage_reflect.GetFieldTag(*h, "NamespacedClusterRoles", "usage"))
 	cmd.Flags().StringVarP(&h.Namespace, "namespace", "n", "", cage_reflect.GetFieldTag(*h, "Namespace", "usage"))
+	cmd.Flags().BoolVarP(&h.Offline, "offline", "", false, cage_reflect.GetFieldTag(*h, "Offline", "usage"))
+	cmd.Flags().StringVarP(&h.ServiceAccountFile, "from-file", "", "", cage_reflect.GetFieldTag(*h, "ServiceAccountFile", "usage"))
 	cmd.Flags().StringVarP(&h.ServiceAccountName, "account", "", "", cage_reflect.GetFieldTag(*h, "ServiceAccountName", "usage"))
 	cmd.Flags().StringSliceVarP(&h.Roles, "role", "", []string{}, cage_reflect.GetFieldTag(*h, "Roles", "usage"))
 	cmd.Flags().StringVarP(&h.Username, "user", "", "", cage_reflect.GetFieldTag(*h, "Username", "usage"))
 	cmd.Flags().IntVarP(&h.Verbosity, "v", "v", 0, cage_reflect.GetFieldTag(*h, "Verbosity", "usage"))
-	return []string{"account", "user"}
+	return []string{"user"}
 }
 
 // Run performs the sub-command logic.
@@ -104,23 +188,61 @@ func (h *Handler) run(ctx context.Context, _ handler.Input) error {
 			fmt.Fprintln(stderr, "kubeauth: "+fmt.Sprintf(format, vArgs...))
 		}
 	}
+	warn := func(format string, vArgs ...interface{}) {
+		fmt.Fprintln(stderr, "kubeauth: warning: "+fmt.Sprintf(format, vArgs...))
+	}
+
+	if h.BackupLimit < 0 {
+		return errors.Errorf("kubeauth: --backup-limit [%d] must not be negative", h.BackupLimit)
+	}
+
+	var roleBindings, clusterRoleBindings, namespacedClusterRoleBindings []*cage_k8s_rbac.BindingSelector
 
-	var roleBindings, clusterRoleBindings []*cage_k8s_rbac.BindingSelector
+	// explicitNamespaces holds the namespaces selected by role binding selectors rather than by --namespace.
+	explicitNamespaces := map[string]bool{}
+
+	// - Validate --offline first, because it selects whether the kubeconfig and API are read at all.
+	if h.Offline {
+		if h.EmitManifests == "" {
+			return errors.New("kubeauth: --offline requires --emit-manifests")
+		}
+		if h.Namespace == "" {
+			return errors.New("kubeauth: --offline requires --namespace, because the current context is not read")
+		}
+		if len(h.Grants) > 0 {
+			return errors.New("kubeauth: --grant requires API discovery, which --offline skips")
+		}
+	}
 
 	// Create clients.
 
 	configClient := h.KubectlConfigClient
 	if configClient == nil {
-		configClient = cage_k8s_config.NewDefaultClient()
+		defaultClient := cage_k8s_config.NewDefaultClient()
+		defaultClient.BackupLimit = h.BackupLimit
+		configClient = defaultClient
 	}
 
-	configFile, err := configClient.Parse(h.ConfigFile)
-	if err != nil {
-		return errors.Wrap(err, "kubeauth") // WithStack alternative to disambiguate from kubectl output
+	var err error
+	var configFile *cage_k8s_config.File
+	if !h.Offline {
+		configFile, err = configClient.Parse(h.ConfigFile)
+		if err != nil {
+			return errors.Wrap(err, "kubeauth") // WithStack alternative to disambiguate from kubectl output
+		}
+
+		// Like kubectl, update existing users/contexts in the files they came from, e.g. one of several
+		// in a KUBECONFIG list, and only apply the selected destination to new ones.
+		if h.ConfigDestFile != "" {
+			configFile.Destination = h.ConfigDestFile
+		}
 	}
 
 	apiClientset := h.KubeApiClientset
-	if apiClientset == nil {
+	if h.Offline {
+		// Leave the clients unset, because --offline never calls them.
+		apiClientset = &cage_k8s_core.Clientset{}
+	} else if apiClientset == nil {
 		rawApiClientset, err := kubernetes.NewForConfig(configFile.RestConfig)
 		if err != nil {
 			return errors.Wrap(err, "kubeauth: failed to create API client")
@@ -129,6 +251,7 @@ func (h *Handler) run(ctx context.Context, _ handler.Input) error {
 		apiClientset = cage_k8s_core.NewClientset(rawApiClientset)
 	}
 
+	namespaceClient := apiClientset.Namespaces
 	roleClient := apiClientset.Roles
 	clusterRoleClient := apiClientset.ClusterRoles
 	roleBindingClient := apiClientset.RoleBindings
@@ -138,7 +261,33 @@ func (h *Handler) run(ctx context.Context, _ handler.Input) error {
 
 	// Validate inputs.
 
-	if h.Cluster == "" {
+	switch h.Auth {
+	case "", AuthToken:
+		h.Auth = AuthToken
+		if h.ServiceAccountName == "" {
+			return errors.New("kubeauth: --account is required by --auth " + AuthToken)
+		}
+		if len(h.Groups) > 0 || h.CommonName != "" || h.ApproveCert {
+			return errors.New("kubeauth: --approve, --cn, and --groups require --auth " + AuthCert)
+		}
+	case AuthCert:
+		if h.ServiceAccountName != "" {
+			return errors.New("kubeauth: --account requires --auth " + AuthToken)
+		}
+		if h.AutomountToken != "" || h.ServiceAccountFile != "" || len(h.ImagePullSecrets) > 0 {
+			return errors.New("kubeauth: --automount-token, --from-file, and --image-pull-secret require --auth " + AuthToken)
+		}
+		if h.EmitManifests != "" {
+			return errors.New("kubeauth: --emit-manifests requires --auth " + AuthToken)
+		}
+		if h.CommonName == "" {
+			h.CommonName = h.Username
+		}
+	default:
+		return errors.Errorf("kubeauth: --auth must be %q or %q", AuthToken, AuthCert)
+	}
+
+	if h.Cluster == "" && !h.Offline {
 		h.Cluster, _, err = configFile.GetCurrentCluster()
 		if err != nil {
 			return errors.Wrap(err, "kubeauth") // WithStack alternative to disambiguate from kubectl output
@@ -155,59 +304,193 @@ func (h *Handler) run(ctx context.Context, _ handler.Input) error {
 		h.Namespace = curContext.Namespace
 	}
 
-	if len(h.Roles) > 0 {
-		var invalid []string
-		for _, r := range h.Roles {
-			binding, err := cage_k8s_rbac.NewBindingSelector(r)
-			if err != nil {
-				invalid = append(invalid, err.Error())
-				continue
+	// - Mark created objects with their owner and any selected metadata. The creator is unknown
+	//   with --offline, because the current context is not read.
+	owner := ownership.Owner{User: h.Username, CreatedAt: time.Now()}
+	if !h.Offline {
+		_, curContext, err := configFile.GetCurrentContext()
+		if err != nil {
+			return errors.Wrap(err, "kubeauth") // WithStack alternative to disambiguate from kubectl output
+		}
+		owner.Creator = curContext.AuthInfo
+	}
+	if owner.Labels, err = ownership.ParseLabels(h.Labels); err != nil {
+		return errors.Wrap(err, "kubeauth: invalid --label selections")
+	}
+	if owner.Annotations, err = ownership.ParseAnnotations(h.Annotations); err != nil {
+		return errors.Wrap(err, "kubeauth: invalid --annotation selections")
+	}
+
+	// - Collect the service account fields to apply whether it is created or already exists.
+	var saCustom *core.ServiceAccount
+	if h.Auth == AuthToken {
+		if saCustom, err = h.serviceAccountCustomization(owner); err != nil {
+			return errors.WithStack(err)
+		}
+	}
+
+	// - Parse the role binding selectors before validating the namespaces they select.
+
+	var invalid []string
+	for _, r := range h.Roles {
+		binding, err := cage_k8s_rbac.NewBindingSelector(r)
+		if err != nil {
+			invalid = append(invalid, err.Error())
+			continue
+		}
+		roleBindings = append(roleBindings, binding)
+	}
+	if len(invalid) > 0 {
+		return errors.Errorf("kubeauth: invalid --role selectors: %q", invalid)
+	}
+
+	invalid = []string{}
+	for _, r := range h.NamespacedClusterRoles {
+		binding, err := cage_k8s_rbac.NewBindingSelector(r)
+		if err != nil {
+			invalid = append(invalid, err.Error())
+			continue
+		}
+		namespacedClusterRoleBindings = append(namespacedClusterRoleBindings, binding)
+	}
+	if len(invalid) > 0 {
+		return errors.Errorf("kubeauth: invalid --namespaced-cluster-role selectors: %q", invalid)
+	}
+
+	invalid = []string{}
+	for _, r := range h.ClusterRoles {
+		binding, err := cage_k8s_rbac.NewBindingSelector(r)
+		if err != nil {
+			invalid = append(invalid, err.Error())
+			continue
+		}
+		if binding.Namespace != "" {
+			invalid = append(invalid, fmt.Sprintf("selector [%s] selects a cluster-scoped binding which does not accept a namespace", r))
+			continue
+		}
+		clusterRoleBindings = append(clusterRoleBindings, binding)
+	}
+	if len(invalid) > 0 {
+		return errors.Errorf("kubeauth: invalid --cluster-role selectors: %q", invalid)
+	}
+
+	grants, err := h.parseGrants()
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	// - Query API discovery, which validates the grants and the rules of the selected roles.
+	var resources *cage_k8s_discovery.Resources
+	if !h.Offline && (len(grants) > 0 || len(roleBindings) > 0 || len(namespacedClusterRoleBindings) > 0 || len(clusterRoleBindings) > 0) {
+		if resources, err = apiClientset.Discovery.Resources(); err != nil {
+			return errors.Wrap(err, "kubeauth") // WithStack alternative to disambiguate from kubectl output
+		}
+	}
+
+	// - Collect the grants into roles, whose scopes are selected by API discovery.
+	var grantRole *rbac.Role
+	var grantClusterRole *rbac.ClusterRole
+	if len(grants) > 0 {
+		if grantRole, grantClusterRole, err = h.grantRoles(resources, grants, owner); err != nil {
+			return errors.WithStack(err)
+		}
+	}
+
+	// - Selectors without a namespace apply to the effective one.
+	//
+	// usedNamespaces holds the namespaces which receive the service account or a role binding.
+	usedNamespaces := map[string]bool{}
+	if h.Auth == AuthToken || grantRole != nil {
+		usedNamespaces[h.Namespace] = true
+	}
+	for _, b := range append(append([]*cage_k8s_rbac.BindingSelector{}, roleBindings...), namespacedClusterRoleBindings...) {
+		if b.Namespace == "" {
+			b.Namespace = h.Namespace
+		} else {
+			explicitNamespaces[b.Namespace] = true
+		}
+		usedNamespaces[b.Namespace] = true
+	}
+
+	// - Validate the namespaces before anything is created in them, instead of relying on the
+	//   errors from the first creation attempt. An empty namespace is resolved by the API server.
+	//
+	//   With --offline, existence is unknown, so --create-namespace renders all of them instead.
+	var missingNamespaces []string
+	missingNamespace := map[string]bool{}
+	for _, ns := range sortedKeys(usedNamespaces) {
+		if ns == "" {
+			continue
+		}
+
+		if h.Offline {
+			if h.CreateNamespace {
+				missingNamespaces = append(missingNamespaces, ns)
 			}
-			roleBindings = append(roleBindings, binding)
+			continue
 		}
-		if len(invalid) > 0 {
-			return errors.Errorf("kubeauth: invalid --role selectors: %q", invalid)
+
+		_, exists, err := namespaceClient.Get(ns)
+		if err != nil {
+			return errors.Wrap(err, "kubeauth: failed to validate namespace")
 		}
 
+		if !exists {
+			missingNamespaces = append(missingNamespaces, ns)
+			missingNamespace[ns] = true
+		}
+	}
+	if len(missingNamespaces) > 0 && !h.CreateNamespace {
+		return errors.Errorf("kubeauth: namespace(s) not found: %q (use --create-namespace to create them)", missingNamespaces)
+	}
+
+	// selectedRoles and selectedClusterRoles hold the existing roles, whose rules are checked below.
+	// With --offline, the roles are neither read nor checked.
+	var selectedRoles []*rbac.Role
+	var selectedClusterRoles []*rbac.ClusterRole
+	selectedClusterRole := map[string]*rbac.ClusterRole{}
+
+	if !h.Offline {
 		invalid = []string{}
 		for _, b := range roleBindings {
-			_, exists, err := roleClient.Get(h.Namespace, b.RoleName)
+			// A namespace which will be created cannot already contain the role.
+			if missingNamespace[b.Namespace] {
+				invalid = append(invalid, b.Namespace+"/"+b.RoleName)
+				continue
+			}
+
+			obj, exists, err := roleClient.Get(b.Namespace, b.RoleName)
 			if err != nil {
 				return errors.Wrap(err, "kubeauth") // WithStack alternative to disambiguate from kubectl output
 			}
 
 			if !exists {
-				invalid = append(invalid, b.RoleName)
-			}
-		}
-		if len(invalid) > 0 {
-			return errors.Errorf("kubeauth: role(s) not found: %q", invalid)
-		}
-	}
-
-	if len(h.ClusterRoles) > 0 {
-		var invalid []string
-		for _, r := range h.ClusterRoles {
-			binding, err := cage_k8s_rbac.NewBindingSelector(r)
-			if err != nil {
-				invalid = append(invalid, err.Error())
+				invalid = append(invalid, b.Namespace+"/"+b.RoleName)
 				continue
 			}
-			clusterRoleBindings = append(clusterRoleBindings, binding)
+
+			selectedRoles = append(selectedRoles, obj)
 		}
 		if len(invalid) > 0 {
-			return errors.Errorf("kubeauth: invalid --cluster-role selectors: %q", invalid)
+			return errors.Errorf("kubeauth: role(s) not found: %q", invalid)
 		}
 
 		invalid = []string{}
-		for _, b := range clusterRoleBindings {
-			_, exists, err := clusterRoleClient.Get(b.RoleName)
+		for _, b := range append(append([]*cage_k8s_rbac.BindingSelector{}, clusterRoleBindings...), namespacedClusterRoleBindings...) {
+			obj, exists, err := clusterRoleClient.Get(b.RoleName)
 			if err != nil {
 				return errors.Wrap(err, "kubeauth") // WithStack alternative to disambiguate from kubectl output
 			}
 
 			if !exists {
 				invalid = append(invalid, b.RoleName)
+				continue
+			}
+
+			// The same cluster role may be selected by both kinds of binding.
+			if _, ok := selectedClusterRole[b.RoleName]; !ok {
+				selectedClusterRoles = append(selectedClusterRoles, obj)
+				selectedClusterRole[b.RoleName] = obj
 			}
 		}
 		if len(invalid) > 0 {
@@ -215,22 +498,729 @@ func (h *Handler) run(ctx context.Context, _ handler.Input) error {
 		}
 	}
 
-	// Create the service account if needed.
+	// - Warn about rules which the API does not serve, e.g. due to a misspelled resource, because
+	//   they grant nothing. The roles may still be valid for other clusters, so they are not rejected.
+	for _, obj := range selectedRoles {
+		if obj == nil {
+			continue
+		}
+		for _, rule := range obj.Rules {
+			for _, p := range resources.CheckRule(rule) {
+				warn("role [%s] in namespace [%s]: %s", obj.Name, obj.Namespace, p)
+			}
+		}
+	}
+	for _, obj := range selectedClusterRoles {
+		if obj == nil {
+			continue
+		}
+		for _, rule := range obj.Rules {
+			for _, p := range resources.CheckRule(rule) {
+				warn("cluster role [%s]: %s", obj.Name, p)
+			}
+		}
+	}
+
+	// - Require --allow-escalation to bind roles whose rules grant escalation-prone permissions
+	//   where they are bound: in the namespace of a role binding, or cluster-wide. Cert users also
+	//   require it to join groups which the API server grants such permissions without bindings.
+	var risks []string
+	addRisks := func(desc, ns string, rules []rbac.PolicyRule) {
+		for _, f := range cage_k8s_risk.Analyze(rules, ns) {
+			risks = append(risks, fmt.Sprintf("%s: %s (%s)", desc, f.Risk.Description, f.Risk.ID))
+		}
+	}
+	for _, obj := range selectedRoles {
+		if obj != nil {
+			addRisks(fmt.Sprintf("role [%s] in namespace [%s]", obj.Name, obj.Namespace), obj.Namespace, obj.Rules)
+		}
+	}
+	for _, b := range namespacedClusterRoleBindings {
+		if obj := selectedClusterRole[b.RoleName]; obj != nil {
+			addRisks(fmt.Sprintf("cluster role [%s] in namespace [%s]", b.RoleName, b.Namespace), b.Namespace, obj.Rules)
+		}
+	}
+	for _, b := range clusterRoleBindings {
+		if obj := selectedClusterRole[b.RoleName]; obj != nil {
+			addRisks(fmt.Sprintf("cluster role [%s]", b.RoleName), "", obj.Rules)
+		}
+	}
+	if grantRole != nil {
+		addRisks("--grant", grantRole.Namespace, grantRole.Rules)
+	}
+	if grantClusterRole != nil {
+		addRisks("--grant", "", grantClusterRole.Rules)
+	}
+	for _, g := range h.Groups {
+		if desc, ok := cage_k8s_risk.PrivilegedGroups[g]; ok {
+			risks = append(risks, fmt.Sprintf("--groups [%s]: %s (privileged-group)", g, desc))
+		}
+	}
+	if len(risks) > 0 {
+		if !h.AllowEscalation {
+			return errors.Errorf("kubeauth: escalation-prone permissions selected: %q (use --allow-escalation to grant them)", risks)
+		}
+		for _, r := range risks {
+			warn("escalation-prone permissions granted by %s", r)
+		}
+	}
+
+	// - Bind the grant roles like selected ones, now that the latter have been validated.
+	if grantRole != nil {
+		roleBindings = append(roleBindings, &cage_k8s_rbac.BindingSelector{Namespace: h.Namespace, RoleName: grantRole.Name, BindingName: grantRole.Name})
+	}
+	if grantClusterRole != nil {
+		clusterRoleBindings = append(clusterRoleBindings, &cage_k8s_rbac.BindingSelector{RoleName: grantClusterRole.Name, BindingName: grantClusterRole.Name})
+	}
 
+	// Render the objects instead of creating them, if selected by --emit-manifests.
+
+	if h.EmitManifests != "" {
+		return h.emitManifests(owner, saCustom, missingNamespaces, grantRole, grantClusterRole, roleBindings, namespacedClusterRoleBindings, clusterRoleBindings, verbose)
+	}
+
+	// Create the missing namespaces, if permitted by --create-namespace.
+
+	for _, ns := range missingNamespaces {
+		if _, err = namespaceClient.Create(newNamespace(owner, ns)); err != nil {
+			return errors.Wrap(err, "kubeauth") // WithStack alternative to disambiguate from kubectl output
+		}
+
+		verbose("created namespace [%s]", ns)
+	}
+
+	// Create the user's credentials.
+
+	var roleSubject, clusterRoleSubject rbac.Subject
 	var saObj *core.ServiceAccount
-	var exists bool
+	var certData, keyData []byte
+
+	if h.Auth == AuthCert {
+		issuer := cage_k8s_csr.NewIssuer(apiClientset.CertificateSigningRequests)
+		issuer.Approve = h.ApproveCert
+		issuer.Timeout = h.CertTimeout
+		issuer.Notify = func(msg string) {
+			fmt.Fprintln(stderr, "kubeauth: "+msg)
+		}
+
+		certData, keyData, err = issuer.IssueClientCert(h.CommonName, h.Groups)
+		if err != nil {
+			return errors.Wrap(err, "kubeauth") // WithStack alternative to disambiguate from kubectl output
+		}
+
+		roleSubject = rbac.Subject{Kind: cage_k8s.KindUser, APIGroup: rbac.GroupName, Name: h.CommonName}
+		clusterRoleSubject = roleSubject
+	} else {
+		saObj, err = h.createServiceAccount(saClient, owner, saCustom, verbose)
+		if err != nil {
+			return errors.WithStack(err)
+		}
+
+		roleSubject, clusterRoleSubject = h.serviceAccountSubjects()
+	}
+
+	// Create the roles which hold the permissions selected by --grant, if any.
+
+	if grantRole != nil {
+		if err = h.upsertRole(roleClient, grantRole, verbose); err != nil {
+			return errors.WithStack(err)
+		}
+	}
+	if grantClusterRole != nil {
+		if err = h.upsertClusterRole(clusterRoleClient, grantClusterRole, verbose); err != nil {
+			return errors.WithStack(err)
+		}
+	}
+
+	// Bind the user to selected roles, if any.
+
+	var summary []bindingSummary
+
+	for _, b := range roleBindings {
+		roleRef := rbac.RoleRef{Kind: cage_k8s.KindRole, Name: b.RoleName}
+		subject := h.roleBindingSubject(b.Namespace, roleSubject, clusterRoleSubject)
+		result, err := h.bindRole(roleBindingClient, owner.BindingMeta(b.Namespace, b.BindingName, subject), roleRef, subject, verbose)
+		if err != nil {
+			return errors.WithStack(err)
+		}
+		summary = append(summary, bindingSummary{Selector: b, RoleRef: roleRef, Result: result})
+	}
+
+	for _, b := range namespacedClusterRoleBindings {
+		roleRef := rbac.RoleRef{Kind: cage_k8s.KindClusterRole, Name: b.RoleName}
+		subject := h.roleBindingSubject(b.Namespace, roleSubject, clusterRoleSubject)
+		result, err := h.bindRole(roleBindingClient, owner.BindingMeta(b.Namespace, b.BindingName, subject), roleRef, subject, verbose)
+		if err != nil {
+			return errors.WithStack(err)
+		}
+		summary = append(summary, bindingSummary{Selector: b, RoleRef: roleRef, Result: result})
+	}
+
+	for _, b := range clusterRoleBindings {
+		if err = h.bindClusterRole(clusterRoleBindingClient, owner.BindingMeta("", b.BindingName, clusterRoleSubject), b.RoleName, clusterRoleSubject, verbose); err != nil {
+			return errors.WithStack(err)
+		}
+	}
+
+	// Add/update a user in the config file which authenticates using the credentials.
+
+	if h.Auth == AuthCert {
+		if err = configClient.UpsertUserCert(ctx, configFile, h.Username, certData, keyData); err != nil {
+			return errors.Wrap(err, "kubeauth: failed to set user certificate")
+		}
+	} else {
+		token, err := h.readServiceAccountToken(secretClient, saObj)
+		if err != nil {
+			return errors.WithStack(err)
+		}
+
+		if err = configClient.UpsertUserToken(ctx, configFile, h.Username, token); err != nil {
+			return errors.Wrap(err, "kubeauth: failed to set user token")
+		}
+	}
+
+	// Name the context after the username.
+	if err = configClient.UpsertContext(ctx, configFile, h.Username, h.Cluster, h.Namespace, h.Username); err != nil {
+		errors.Wrap(err, "kubeauth: failed to set user token")
+	}
+
+	// Summarize the role bindings if some were selected outside of the effective namespace.
+	if len(explicitNamespaces) > 0 {
+		if err = writeBindingSummary(h.Out(), summary); err != nil {
+			return errors.Wrap(err, "kubeauth: failed to write summary")
+		}
+	}
 
-	saObj, exists, err = saClient.Get(h.Namespace, h.ServiceAccountName)
+	return nil
+}
+
+// bindingSummary describes the outcome of reconciling a selected role binding.
+type bindingSummary struct {
+	Selector *cage_k8s_rbac.BindingSelector
+	RoleRef  rbac.RoleRef
+	Result   string
+}
+
+// writeBindingSummary writes a table of role binding outcomes grouped by namespace.
+func writeBindingSummary(out io.Writer, summary []bindingSummary) error {
+	sort.SliceStable(summary, func(i, j int) bool {
+		if summary[i].Selector.Namespace != summary[j].Selector.Namespace {
+			return summary[i].Selector.Namespace < summary[j].Selector.Namespace
+		}
+		return summary[i].Selector.BindingName < summary[j].Selector.BindingName
+	})
+
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "NAMESPACE\tBINDING\tROLE\tRESULT")
+	for _, s := range summary {
+		fmt.Fprintf(w, "%s\t%s\t%s/%s\t%s\n", s.Selector.Namespace, s.Selector.BindingName, s.RoleRef.Kind, s.RoleRef.Name, s.Result)
+	}
+	return w.Flush()
+}
+
+// roleBindingSubject returns the subject to bind in the namespace.
+//
+// Outside of the service account's namespace, the subject must name the latter explicitly.
+func (h *Handler) roleBindingSubject(ns string, roleSubject, clusterRoleSubject rbac.Subject) rbac.Subject {
+	if ns == h.Namespace {
+		return roleSubject
+	}
+	return clusterRoleSubject
+}
+
+// sortedKeys returns the map's keys in ascending order.
+func sortedKeys(m map[string]bool) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// bindRole creates the role binding or reconciles an existing one with the selected role and subject.
+//
+// The reference may select a role or, to grant its permissions only in the namespace, a cluster role.
+//
+// The metadata selects the binding namespace and name, and includes the labels and annotations of a
+// created binding.
+//
+// It returns a summary of the outcome, e.g. bindingCreated.
+//
+// An existing binding which lacks the subject is updated to include it. One which refers to a different
+// role is an error unless --force is used, in which case it is replaced by a binding with only the subject.
+func (h *Handler) bindRole(client cage_k8s_role_binding.Client, objMeta meta.ObjectMeta, roleRef rbac.RoleRef, subject rbac.Subject, verbose func(string, ...interface{})) (result string, _ error) {
+	ns, name := objMeta.Namespace, objMeta.Name
+
+	_, err := client.Create(objMeta, roleRef, subject)
+	if err == nil {
+		return bindingCreated, nil
+	}
+	if !k8s_errors.IsAlreadyExists(err) {
+		return "", errors.Wrap(err, "kubeauth") // WithStack alternative to disambiguate from kubectl output
+	}
+
+	obj, exists, err := client.Get(ns, name)
+	if err != nil {
+		return "", errors.Wrap(err, "kubeauth") // WithStack alternative to disambiguate from kubectl output
+	}
+	if !exists {
+		return "", errors.Errorf("kubeauth: role binding [%s] in namespace [%s] was deleted during creation", name, ns)
+	}
+
+	if obj.RoleRef.Kind != roleRef.Kind || obj.RoleRef.Name != roleRef.Name {
+		if !h.Force {
+			return "", errors.Errorf(
+				"kubeauth: role binding [%s] in namespace [%s] refers to %s [%s], not %s [%s] (use --force to replace it)",
+				name, ns, obj.RoleRef.Kind, obj.RoleRef.Name, roleRef.Kind, roleRef.Name,
+			)
+		}
+
+		// The reference is immutable, so the binding cannot be corrected by an update.
+		if err = client.Delete(ns, name); err != nil {
+			return "", errors.Wrap(err, "kubeauth") // WithStack alternative to disambiguate from kubectl output
+		}
+		if _, err = client.Create(objMeta, roleRef, subject); err != nil {
+			return "", errors.Wrap(err, "kubeauth") // WithStack alternative to disambiguate from kubectl output
+		}
+		verbose(
+			"replaced role binding [%s] in namespace [%s] which referred to %s [%s] and subjects %s",
+			name, ns, obj.RoleRef.Kind, obj.RoleRef.Name, cage_k8s_rbac.SubjectsString(obj.Subjects),
+		)
+		return bindingReplaced, nil
+	}
+
+	// Record the user of a binding created for another one, so that gc keeps it while either exists.
+	updated := obj.DeepCopy()
+	userAdded, err := ownership.AddUser(&updated.ObjectMeta, h.Username, &subject)
+	if err != nil {
+		return "", errors.Wrapf(err, "kubeauth: role binding [%s] in namespace [%s]", name, ns)
+	}
+
+	// Compare service accounts without a namespace as the API server interprets them.
+	_, added := cage_k8s_rbac.MergeSubjects(
+		cage_k8s_rbac.WithBindingNamespace(obj.Subjects, ns),
+		cage_k8s_rbac.WithBindingNamespace([]rbac.Subject{subject}, ns)...,
+	)
+	if len(added) == 0 && !userAdded {
+		verbose("role binding [%s] in namespace [%s] already exists", name, ns)
+		return bindingUnchanged, nil
+	}
+
+	if len(added) > 0 {
+		updated.Subjects = append(updated.Subjects, subject)
+	}
+	if _, err = client.Update(updated); err != nil {
+		return "", errors.Wrap(err, "kubeauth") // WithStack alternative to disambiguate from kubectl output
+	}
+	if len(added) > 0 {
+		verbose("added subject %s to role binding [%s] in namespace [%s]", cage_k8s_rbac.SubjectString(subject), name, ns)
+	}
+	if userAdded {
+		verbose("recorded user [%s] as a user of role binding [%s] in namespace [%s]", h.Username, name, ns)
+	}
+
+	return bindingUpdated, nil
+}
+
+// bindClusterRole creates the cluster role binding or reconciles an existing one with the selected
+// cluster role and subject.
+//
+// It follows the same rules as bindRole.
+func (h *Handler) bindClusterRole(client cage_k8s_cluster_role_binding.Client, objMeta meta.ObjectMeta, role string, subject rbac.Subject, verbose func(string, ...interface{})) error {
+	name := objMeta.Name
+
+	_, err := client.Create(objMeta, role, subject)
+	if err == nil {
+		return nil
+	}
+	if !k8s_errors.IsAlreadyExists(err) {
+		return errors.Wrap(err, "kubeauth") // WithStack alternative to disambiguate from kubectl output
+	}
+
+	obj, exists, err := client.Get(name)
+	if err != nil {
+		return errors.Wrap(err, "kubeauth") // WithStack alternative to disambiguate from kubectl output
+	}
+	if !exists {
+		return errors.Errorf("kubeauth: cluster role binding [%s] was deleted during creation", name)
+	}
+
+	if obj.RoleRef.Kind != cage_k8s.KindClusterRole || obj.RoleRef.Name != role {
+		if !h.Force {
+			return errors.Errorf(
+				"kubeauth: cluster role binding [%s] refers to %s [%s], not %s [%s] (use --force to replace it)",
+				name, obj.RoleRef.Kind, obj.RoleRef.Name, cage_k8s.KindClusterRole, role,
+			)
+		}
+
+		// The reference is immutable, so the binding cannot be corrected by an update.
+		if err = client.Delete(name); err != nil {
+			return errors.Wrap(err, "kubeauth") // WithStack alternative to disambiguate from kubectl output
+		}
+		if _, err = client.Create(objMeta, role, subject); err != nil {
+			return errors.Wrap(err, "kubeauth") // WithStack alternative to disambiguate from kubectl output
+		}
+		verbose(
+			"replaced cluster role binding [%s] which referred to %s [%s] and subjects %s",
+			name, obj.RoleRef.Kind, obj.RoleRef.Name, cage_k8s_rbac.SubjectsString(obj.Subjects),
+		)
+		return nil
+	}
+
+	updated := obj.DeepCopy()
+	userAdded, err := ownership.AddUser(&updated.ObjectMeta, h.Username, &subject)
+	if err != nil {
+		return errors.Wrapf(err, "kubeauth: cluster role binding [%s]", name)
+	}
+
+	_, added := cage_k8s_rbac.MergeSubjects(obj.Subjects, subject)
+	if len(added) == 0 && !userAdded {
+		verbose("cluster role binding [%s] already exists", name)
+		return nil
+	}
+
+	if len(added) > 0 {
+		updated.Subjects = append(updated.Subjects, subject)
+	}
+	if _, err = client.Update(updated); err != nil {
+		return errors.Wrap(err, "kubeauth") // WithStack alternative to disambiguate from kubectl output
+	}
+	if len(added) > 0 {
+		verbose("added subject %s to cluster role binding [%s]", cage_k8s_rbac.SubjectString(subject), name)
+	}
+	if userAdded {
+		verbose("recorded user [%s] as a user of cluster role binding [%s]", h.Username, name)
+	}
+
+	return nil
+}
+
+// parseGrants returns the permissions selected by --grant.
+func (h *Handler) parseGrants() (grants []*cage_k8s_rbac.Grant, _ error) {
+	var invalid []string
+	for _, s := range h.Grants {
+		grant, err := cage_k8s_rbac.NewGrant(s)
+		if err != nil {
+			invalid = append(invalid, err.Error())
+			continue
+		}
+		grants = append(grants, grant)
+	}
+	if len(invalid) > 0 {
+		return nil, errors.Errorf("kubeauth: invalid --grant selections: %q", invalid)
+	}
+	return grants, nil
+}
+
+// grantRoles returns the role and cluster role which hold the permissions selected by --grant.
+//
+// The resource and group of each grant are validated against API discovery, which also selects the
+// role that receives it: namespaced resources are granted by a role in the effective namespace and
+// cluster-scoped resources by a cluster role. Grants which select all resources or groups are treated
+// as namespaced. Either role is nil if it does not receive any grants.
+//
+// A grant in "<resource>/<name>" format selects a subresource, e.g. "pods/exec", instead of an object
+// name if the API serves one by that name.
+func (h *Handler) grantRoles(resources *cage_k8s_discovery.Resources, grants []*cage_k8s_rbac.Grant, owner ownership.Owner) (role *rbac.Role, clusterRole *rbac.ClusterRole, _ error) {
+	var roleRules, clusterRoleRules []rbac.PolicyRule
+	var invalid []string
+	for n, g := range grants {
+		if g.Resource == "*" || g.Group == "*" {
+			roleRules = append(roleRules, g.PolicyRule())
+			continue
+		}
+
+		if resources.FailedGroups[g.Group] {
+			invalid = append(invalid, fmt.Sprintf("grant [%s]: resources of API group [%s] could not be discovered", h.Grants[n], g.Group))
+			continue
+		}
+
+		if g.ResourceName != "" {
+			if _, found := resources.Get(g.Group, g.Resource+"/"+g.ResourceName); found {
+				g.Resource, g.ResourceName = g.Resource+"/"+g.ResourceName, ""
+			}
+		}
+
+		res, found := resources.Get(g.Group, g.Resource)
+		if !found {
+			msg := fmt.Sprintf("grant [%s]: resource [%s] not found in API group [%s]", h.Grants[n], g.Resource, g.Group)
+			if groups := resources.GroupsOf(g.Resource); len(groups) > 0 {
+				msg += fmt.Sprintf(" (served by API group(s) %q)", groups)
+			}
+			invalid = append(invalid, msg)
+			continue
+		}
+
+		if res.Namespaced {
+			roleRules = append(roleRules, g.PolicyRule())
+		} else {
+			clusterRoleRules = append(clusterRoleRules, g.PolicyRule())
+		}
+	}
+	if len(invalid) > 0 {
+		return nil, nil, errors.Errorf("kubeauth: invalid --grant selections: %q", invalid)
+	}
+
+	name := GrantRolePrefix + h.Username
+	if len(roleRules) > 0 {
+		role = &rbac.Role{ObjectMeta: owner.ObjectMeta(h.Namespace, name), Rules: roleRules}
+	}
+	if len(clusterRoleRules) > 0 {
+		clusterRole = &rbac.ClusterRole{ObjectMeta: owner.ObjectMeta("", name), Rules: clusterRoleRules}
+	}
+
+	return role, clusterRole, nil
+}
+
+// upsertRole creates the role or reconciles an existing one with its rules.
+//
+// An existing role with different rules is an error unless --force is used, in which case its rules
+// are replaced.
+func (h *Handler) upsertRole(client cage_k8s_role.Client, role *rbac.Role, verbose func(string, ...interface{})) error {
+	ns, name := role.Namespace, role.Name
+
+	_, err := client.Create(role)
+	if err == nil {
+		return nil
+	}
+	if !k8s_errors.IsAlreadyExists(err) {
+		return errors.Wrap(err, "kubeauth") // WithStack alternative to disambiguate from kubectl output
+	}
+
+	obj, exists, err := client.Get(ns, name)
 	if err != nil {
 		return errors.Wrap(err, "kubeauth") // WithStack alternative to disambiguate from kubectl output
 	}
+	if !exists {
+		return errors.Errorf("kubeauth: role [%s] in namespace [%s] was deleted during creation", name, ns)
+	}
+
+	if reflect.DeepEqual(obj.Rules, role.Rules) {
+		verbose("role [%s] in namespace [%s] already exists", name, ns)
+		return nil
+	}
+	if !h.Force {
+		return errors.Errorf("kubeauth: role [%s] in namespace [%s] already exists with different rules (use --force to replace them)", name, ns)
+	}
+
+	updated := obj.DeepCopy()
+	updated.Rules = role.Rules
+	if _, err = client.Update(updated); err != nil {
+		return errors.Wrap(err, "kubeauth") // WithStack alternative to disambiguate from kubectl output
+	}
+	verbose("replaced rules of role [%s] in namespace [%s]", name, ns)
+
+	return nil
+}
+
+// upsertClusterRole creates the cluster role or reconciles an existing one with its rules.
+//
+// It follows the same rules as upsertRole.
+func (h *Handler) upsertClusterRole(client cage_k8s_cluster_role.Client, role *rbac.ClusterRole, verbose func(string, ...interface{})) error {
+	name := role.Name
+
+	_, err := client.Create(role)
+	if err == nil {
+		return nil
+	}
+	if !k8s_errors.IsAlreadyExists(err) {
+		return errors.Wrap(err, "kubeauth") // WithStack alternative to disambiguate from kubectl output
+	}
+
+	obj, exists, err := client.Get(name)
+	if err != nil {
+		return errors.Wrap(err, "kubeauth") // WithStack alternative to disambiguate from kubectl output
+	}
+	if !exists {
+		return errors.Errorf("kubeauth: cluster role [%s] was deleted during creation", name)
+	}
+
+	if reflect.DeepEqual(obj.Rules, role.Rules) {
+		verbose("cluster role [%s] already exists", name)
+		return nil
+	}
+	if !h.Force {
+		return errors.Errorf("kubeauth: cluster role [%s] already exists with different rules (use --force to replace them)", name)
+	}
+
+	updated := obj.DeepCopy()
+	updated.Rules = role.Rules
+	if _, err = client.Update(updated); err != nil {
+		return errors.Wrap(err, "kubeauth") // WithStack alternative to disambiguate from kubectl output
+	}
+	verbose("replaced rules of cluster role [%s]", name)
+
+	return nil
+}
+
+// serviceAccountCustomization returns a service account which holds only the fields selected by
+// --from-file, --label, --annotation, --image-pull-secret, and --automount-token.
+//
+// Flags take precedence over the manifest selected by --from-file.
+func (h *Handler) serviceAccountCustomization(owner ownership.Owner) (*core.ServiceAccount, error) {
+	custom := &core.ServiceAccount{}
+
+	if h.ServiceAccountFile != "" {
+		f, err := os.Open(h.ServiceAccountFile)
+		if err != nil {
+			return nil, errors.Wrapf(err, "kubeauth: failed to open --from-file [%s]", h.ServiceAccountFile)
+		}
+		defer f.Close()
+
+		if err = k8s_yaml.NewYAMLOrJSONDecoder(f, 4096).Decode(custom); err != nil {
+			return nil, errors.Wrapf(err, "kubeauth: failed to decode --from-file [%s]", h.ServiceAccountFile)
+		}
+
+		if custom.Kind != "" && custom.Kind != cage_k8s.KindServiceAccount {
+			return nil, errors.Errorf("kubeauth: --from-file [%s] contains a %s, not a %s", h.ServiceAccountFile, custom.Kind, cage_k8s.KindServiceAccount)
+		}
+		if custom.Name != "" && custom.Name != h.ServiceAccountName {
+			return nil, errors.Errorf("kubeauth: --from-file [%s] selects service account [%s], not --account [%s]", h.ServiceAccountFile, custom.Name, h.ServiceAccountName)
+		}
+		if custom.Namespace != "" && custom.Namespace != h.Namespace {
+			return nil, errors.Errorf("kubeauth: --from-file [%s] selects namespace [%s], not [%s]", h.ServiceAccountFile, custom.Namespace, h.Namespace)
+		}
+		if err = ownership.CheckReserved(custom.ObjectMeta); err != nil {
+			return nil, errors.Wrapf(err, "kubeauth: invalid --from-file [%s]", h.ServiceAccountFile)
+		}
+	}
+
+	flagCustom := &core.ServiceAccount{ObjectMeta: meta.ObjectMeta{Labels: owner.Labels, Annotations: owner.Annotations}}
+	for _, s := range h.ImagePullSecrets {
+		flagCustom.ImagePullSecrets = append(flagCustom.ImagePullSecrets, core.LocalObjectReference{Name: s})
+	}
+	if h.AutomountToken != "" {
+		automount, err := strconv.ParseBool(h.AutomountToken)
+		if err != nil {
+			return nil, errors.Errorf("kubeauth: --automount-token [%s] must be true or false", h.AutomountToken)
+		}
+		flagCustom.AutomountServiceAccountToken = &automount
+	}
+	cage_k8s_sa.Merge(custom, flagCustom)
+
+	// Omit the manifest's other fields, e.g. its token secrets, because they are managed by the API server.
+	return &core.ServiceAccount{
+		ObjectMeta:                   meta.ObjectMeta{Labels: custom.Labels, Annotations: custom.Annotations},
+		ImagePullSecrets:             custom.ImagePullSecrets,
+		AutomountServiceAccountToken: custom.AutomountServiceAccountToken,
+	}, nil
+}
+
+// newNamespace returns a namespace to create.
+func newNamespace(owner ownership.Owner, ns string) *core.Namespace {
+	return &core.Namespace{ObjectMeta: owner.ObjectMeta("", ns)}
+}
+
+// newServiceAccount returns the service account of a token user to create.
+func (h *Handler) newServiceAccount(owner ownership.Owner, custom *core.ServiceAccount) *core.ServiceAccount {
+	saObj := &core.ServiceAccount{ObjectMeta: owner.ObjectMeta(h.Namespace, h.ServiceAccountName)}
+	cage_k8s_sa.Merge(saObj, custom)
+	return saObj
+}
+
+// serviceAccountSubjects returns the subjects which select the service account of a token user.
+//
+// The role binding subject omits the namespace, which is resolved to the binding's namespace.
+func (h *Handler) serviceAccountSubjects() (roleSubject, clusterRoleSubject rbac.Subject) {
+	roleSubject = rbac.Subject{Kind: cage_k8s.KindServiceAccount, Name: h.ServiceAccountName}
+	clusterRoleSubject = rbac.Subject{Namespace: h.Namespace, Kind: cage_k8s.KindServiceAccount, Name: h.ServiceAccountName}
+	return roleSubject, clusterRoleSubject
+}
+
+// emitManifests writes the objects which would be created for a token user, instead of creating them.
+//
+// Unlike the objects created directly, the token secret is included so that its name is known, and
+// referenced by the service account, once a GitOps tool applies the manifests. Existing objects are
+// not queried, so bindings are rendered with only the user as their subject.
+//
+// The grant roles are optional.
+func (h *Handler) emitManifests(owner ownership.Owner, saCustom *core.ServiceAccount, namespaces []string, grantRole *rbac.Role, grantClusterRole *rbac.ClusterRole, roleBindings, namespacedClusterRoleBindings, clusterRoleBindings []*cage_k8s_rbac.BindingSelector, verbose func(string, ...interface{})) error {
+	var objs []runtime.Object
+
+	for _, ns := range namespaces {
+		objs = append(objs, newNamespace(owner, ns))
+	}
+
+	secretName := h.ServiceAccountName + TokenSecretSuffix
+
+	saObj := h.newServiceAccount(owner, saCustom)
+	saObj.Secrets = []core.ObjectReference{{Name: secretName}}
+
+	secretObj := &core.Secret{ObjectMeta: owner.ObjectMeta(h.Namespace, secretName), Type: core.SecretTypeServiceAccountToken}
+	secretObj.Annotations[core.ServiceAccountNameKey] = h.ServiceAccountName
+
+	objs = append(objs, saObj, secretObj)
+
+	if grantRole != nil {
+		objs = append(objs, grantRole)
+	}
+	if grantClusterRole != nil {
+		objs = append(objs, grantClusterRole)
+	}
+
+	roleSubject, clusterRoleSubject := h.serviceAccountSubjects()
+
+	for _, b := range roleBindings {
+		roleRef := rbac.RoleRef{Kind: cage_k8s.KindRole, Name: b.RoleName}
+		subject := h.roleBindingSubject(b.Namespace, roleSubject, clusterRoleSubject)
+		objs = append(objs, cage_k8s_role_binding.NewObject(owner.BindingMeta(b.Namespace, b.BindingName, subject), roleRef, subject))
+	}
+	for _, b := range namespacedClusterRoleBindings {
+		roleRef := rbac.RoleRef{Kind: cage_k8s.KindClusterRole, Name: b.RoleName}
+		subject := h.roleBindingSubject(b.Namespace, roleSubject, clusterRoleSubject)
+		objs = append(objs, cage_k8s_role_binding.NewObject(owner.BindingMeta(b.Namespace, b.BindingName, subject), roleRef, subject))
+	}
+	for _, b := range clusterRoleBindings {
+		objs = append(objs, cage_k8s_cluster_role_binding.NewObject(owner.BindingMeta("", b.BindingName, clusterRoleSubject), b.RoleName, clusterRoleSubject))
+	}
+
+	written, err := manifest.Write(h.EmitManifests, h.Out(), objs...)
+	if err != nil {
+		return errors.Wrap(err, "kubeauth: failed to write manifests")
+	}
+
+	for _, w := range written {
+		if w != manifest.Stdout {
+			verbose("wrote manifest [%s]", w)
+		}
+	}
+	verbose("once the manifests are applied, run add-user again without --emit-manifests to add the user to the kubeconfig")
+
+	return nil
+}
+
+// createServiceAccount creates the service account of a token user, if needed, and waits for
+// its token to be created.
+//
+// The customizations are applied to a new service account and also merged into an existing one.
+func (h *Handler) createServiceAccount(saClient cage_k8s_sa.Client, owner ownership.Owner, custom *core.ServiceAccount, verbose func(string, ...interface{})) (*core.ServiceAccount, error) {
+	saObj, exists, err := saClient.Get(h.Namespace, h.ServiceAccountName)
+	if err != nil {
+		return nil, errors.Wrap(err, "kubeauth") // WithStack alternative to disambiguate from kubectl output
+	}
 
 	if exists {
-		verbose("service account already exists")
+		// Only add the customizations, not the ownership metadata, because kubeauth did not create it
+		// for this user. If it did so for another user, record this one so that gc keeps it while either exists.
+		updated := saObj.DeepCopy()
+		userAdded, err := ownership.AddUser(&updated.ObjectMeta, h.Username, nil)
+		if err != nil {
+			return nil, errors.Wrapf(err, "kubeauth: service account [%s] in namespace [%s]", h.ServiceAccountName, h.Namespace)
+		}
+		if cage_k8s_sa.Merge(updated, custom) || userAdded {
+			saObj, err = saClient.Update(updated)
+			if err != nil {
+				return nil, errors.Wrap(err, "kubeauth") // WithStack alternative to disambiguate from kubectl output
+			}
+			verbose("updated existing service account [%s] in namespace [%s]", h.ServiceAccountName, h.Namespace)
+		} else {
+			verbose("service account already exists")
+		}
 	} else {
-		saObj, err = saClient.CreateBasic(h.Namespace, h.ServiceAccountName)
+		saObj, err = saClient.Create(h.newServiceAccount(owner, custom))
 		if err != nil {
-			return errors.Wrap(err, "kubeauth") // WithStack alternative to disambiguate from kubectl output
+			return nil, errors.Wrap(err, "kubeauth") // WithStack alternative to disambiguate from kubectl output
 		}
 	}
 
@@ -276,91 +1266,53 @@ func (h *Handler) run(ctx context.Context, _ handler.Input) error {
 
 		err = wait.ExponentialBackoff(backoffOpts, backoffCond)
 		if err != nil {
-			return errors.Wrap(err, "kubeauth: secret with service account's token not found")
-		}
-	}
-
-	// Bind the service account to selected roles, if any.
-
-	for _, b := range roleBindings {
-		_, err = roleBindingClient.Create(
-			h.Namespace, b.BindingName, b.RoleName,
-			rbac.Subject{Kind: cage_k8s.KindServiceAccount, Name: h.ServiceAccountName},
-		)
-		if err != nil {
-			if k8s_errors.IsAlreadyExists(err) {
-				verbose("role binding(s) already exist")
-				continue
-			}
-			return errors.Wrap(err, "kubeauth") // WithStack alternative to disambiguate from kubectl output
+			return nil, errors.Wrap(err, "kubeauth: secret with service account's token not found")
 		}
 	}
 
-	for _, b := range clusterRoleBindings {
-		_, err = clusterRoleBindingClient.Create(
-			b.BindingName, b.RoleName,
-			rbac.Subject{Namespace: h.Namespace, Kind: cage_k8s.KindServiceAccount, Name: h.ServiceAccountName},
-		)
-		if err != nil {
-			if k8s_errors.IsAlreadyExists(err) {
-				verbose("cluster role binding(s) already exist")
-				continue
-			}
-			return errors.Wrap(err, "kubeauth") // WithStack alternative to disambiguate from kubectl output
-		}
-	}
-
-	// Retrieve and write the service account's token to a temporary file.
+	return saObj, nil
+}
 
+// readServiceAccountToken returns the bearer token of a token user's service account.
+func (h *Handler) readServiceAccountToken(secretClient cage_k8s_secret.Client, saObj *core.ServiceAccount) ([]byte, error) {
 	secretObj, exists, err := secretClient.Get(h.Namespace, saObj.Secrets[0].Name)
 	if err != nil {
-		return errors.Wrapf(err, "kubeauth: failed to query for service account's secret [%s]", saObj.Secrets[0].Name)
+		return nil, errors.Wrapf(err, "kubeauth: failed to query for service account's secret [%s]", saObj.Secrets[0].Name)
 	}
 
 	if !exists {
-		return errors.Errorf("kubeauth: service account's secret [%s] not found", saObj.Secrets[0].Name)
+		return nil, errors.Errorf("kubeauth: service account's secret [%s] not found", saObj.Secrets[0].Name)
 	}
 
 	caCrt, ok := secretObj.Data["ca.crt"]
 	if !ok {
-		return errors.Errorf("kubeauth: service account's secret [%s] does not contain 'ca.crt' data", saObj.Secrets[0].Name)
+		return nil, errors.Errorf("kubeauth: service account's secret [%s] does not contain 'ca.crt' data", saObj.Secrets[0].Name)
 	}
 
 	token, ok := secretObj.Data["token"]
 	if !ok {
-		return errors.Errorf("kubeauth: service account's secret [%s] does not contain 'token' data", saObj.Secrets[0].Name)
+		return nil, errors.Errorf("kubeauth: service account's secret [%s] does not contain 'token' data", saObj.Secrets[0].Name)
 	}
 
 	caCrtFile, err := ioutil.TempFile("", "kubeauth.*.ca.crt")
 	if err != nil {
-		return errors.Wrap(err, "kubeuath: failed to create temporary ca.crt file")
+		return nil, errors.Wrap(err, "kubeuath: failed to create temporary ca.crt file")
 	}
 
 	caCrtFilename := caCrtFile.Name()
 	defer cage_file.RemoveSafer(caCrtFilename)
 
 	if _, err = caCrtFile.Write(caCrt); err != nil {
-		return errors.Wrap(err, "kubeauth: failed to write temporary ca.crt file")
+		return nil, errors.Wrap(err, "kubeauth: failed to write temporary ca.crt file")
 	}
 	if err = caCrtFile.Sync(); err != nil {
-		return errors.Wrap(err, "kubeauth: failed to sync temporary ca.crt file")
+		return nil, errors.Wrap(err, "kubeauth: failed to sync temporary ca.crt file")
 	}
 	if err = caCrtFile.Close(); err != nil {
-		return errors.Wrap(err, "kubeauth: failed to close temporary ca.crt file")
-	}
-
-	// Add/update a user in the config file which authenticates using the service account's token.
-
-	if err = configClient.UpsertUserToken(ctx, configFile, h.Username, token); err != nil {
-		return errors.Wrap(err, "kubeauth: failed to set user token")
+		return nil, errors.Wrap(err, "kubeauth: failed to close temporary ca.crt file")
 	}
 
-	// Name the context after the username.
-	if err = configClient.UpsertContext(ctx, configFile, h.Username, h.Cluster, h.Namespace, h.Username); err != nil {
-		errors.Wrap(err, "kubeauth: failed to set user token")
-	}
-
-	return nil
+	return token, nil
 }
 
 // New returns a cobra command instance based on Handler.
@@ -20,16 +20,31 @@
 package add_user_test
 
 import (
+	"bytes"
+	"context"
+	"io/ioutil"
+	"path/filepath"
 	"regexp"
 	"testing"
+	"time"
 
 	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+	certs "k8s.io/api/certificates/v1beta1"
+	core "k8s.io/api/core/v1"
 	rbac "k8s.io/api/rbac/v1"
+	k8s_errors "k8s.io/apimachinery/pkg/api/errors"
+	meta "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
 
 	cli "github.com/codeactual/kubeauth/cmd/kubeauth/add_user"
 	"github.com/codeactual/kubeauth/internal/cage/cli/handler"
 	cage_k8s "github.com/codeactual/kubeauth/internal/cage/kubernetes/v1"
+	cage_k8s_discovery "github.com/codeactual/kubeauth/internal/cage/kubernetes/v1/discovery"
+	cage_k8s_config "github.com/codeactual/kubeauth/internal/cage/kubernetes/v1/kubectl/config"
 	cage_gomock "github.com/codeactual/kubeauth/internal/cage/testkit/gomock"
+	testkit_file "github.com/codeactual/kubeauth/internal/cage/testkit/os/file"
+	"github.com/codeactual/kubeauth/internal/ownership"
 	"github.com/codeactual/kubeauth/internal/testkit"
 )
 
@@ -47,6 +62,8 @@ func NewHandler(kit *HandlerKit) *cli.Handler {
 	// Enable for test troubleshooting and verbose output assertions.
 	h.Verbosity = 1
 
+	h.CertTimeout = time.Second
+
 	return &h
 }
 
@@ -110,6 +127,29 @@ func TestApplyExplicitNamespace(t *testing.T) {
 	h.Run(testkit.Ctx(), handler.Input{})
 }
 
+// TestApplyExplicitConfigDest asserts that an explicit --kubeconfig-dest selection is applied.
+func TestApplyExplicitConfigDest(t *testing.T) {
+	explicit := "some-kubeconfig-dest"
+
+	kit := NewHandlerKit(t)
+	kit.Namespace = testkit.CurrentNamespace
+	kit.ExpectCreatedServiceAccount(kit.Namespace, testkit.ServiceAccountName)
+	kit.UpsertToken = false
+	kit.Finish()
+	defer kit.MockCtrl.Finish()
+
+	kit.ConfigClient.EXPECT().
+		UpsertUserToken(testkit.Ctx(), gomock.Any(), testkit.Username, TokenData()).
+		DoAndReturn(func(_ context.Context, file *cage_k8s_config.File, _ string, _ []byte) error {
+			require.Exactly(t, explicit, file.Destination)
+			return nil
+		})
+
+	h := NewHandler(kit)
+	h.ConfigDestFile = explicit
+	h.Run(testkit.Ctx(), handler.Input{})
+}
+
 // TestErrOnRoleNotFound asserts that the CLI exists with an error if a role in a
 // "--role <role name>:<binding name>" selection does not exist.
 func TestErrOnRoleNotFound(t *testing.T) {
@@ -118,6 +158,7 @@ func TestErrOnRoleNotFound(t *testing.T) {
 	kit.UpsertToken = false
 	kit.UpsertContext = false
 	kit.SecretGet = false
+	kit.ServiceAccountName = testkit.ServiceAccountName
 	kit.Finish()
 	defer kit.MockCtrl.Finish()
 
@@ -142,6 +183,7 @@ func TestErrOnClusterRoleNotFound(t *testing.T) {
 	kit.UpsertToken = false
 	kit.UpsertContext = false
 	kit.SecretGet = false
+	kit.ServiceAccountName = testkit.ServiceAccountName
 	kit.Finish()
 	defer kit.MockCtrl.Finish()
 
@@ -181,7 +223,7 @@ func TestCreateRoleBinding(t *testing.T) {
 
 		// expect: binding created
 		kit.ApiClientset.RoleBindings.EXPECT().
-			Create(kit.Namespace, bindNames[n], roleNames[n], subjects[n]).
+			Create(testkit.ManagedMeta(kit.Namespace, bindNames[n]), rbac.RoleRef{Kind: cage_k8s.KindRole, Name: roleNames[n]}, subjects[n]).
 			Return(cage_gomock.NonSut(), nil)
 	}
 
@@ -213,7 +255,7 @@ func TestCreateClusterRoleBinding(t *testing.T) {
 
 		// expect: binding created
 		kit.ApiClientset.ClusterRoleBindings.EXPECT().
-			Create(bindNames[n], roleNames[n], subjects[n]).
+			Create(testkit.ManagedMeta("", bindNames[n]), roleNames[n], subjects[n]).
 			Return(cage_gomock.NonSut(), nil)
 	}
 
@@ -221,3 +263,1440 @@ func TestCreateClusterRoleBinding(t *testing.T) {
 	h.ClusterRoles = []string{roleNames[0] + ":" + bindNames[0], roleNames[1] + ":" + bindNames[1]}
 	h.Run(testkit.Ctx(), handler.Input{})
 }
+
+// TestCreateNamespacedClusterRoleBinding asserts that a --namespaced-cluster-role selection creates
+// a role binding which refers to the cluster role.
+func TestCreateNamespacedClusterRoleBinding(t *testing.T) {
+	kit := NewHandlerKit(t)
+	kit.Namespace = testkit.CurrentNamespace
+	kit.ExpectCreatedServiceAccount(kit.Namespace, testkit.ServiceAccountName)
+	kit.Finish()
+	defer kit.MockCtrl.Finish()
+
+	subject := rbac.Subject{Kind: cage_k8s.KindServiceAccount, Name: kit.ServiceAccountName}
+
+	// expect: cluster role name validated
+	kit.ApiClientset.ClusterRoles.EXPECT().
+		Get("edit").
+		Return(cage_gomock.NonSut(), testkit.Exists, nil)
+
+	// expect: binding created in the namespace
+	kit.ApiClientset.RoleBindings.EXPECT().
+		Create(testkit.ManagedMeta(kit.Namespace, "bind-a"), rbac.RoleRef{Kind: cage_k8s.KindClusterRole, Name: "edit"}, subject).
+		Return(cage_gomock.NonSut(), nil)
+
+	h := NewHandler(kit)
+	h.NamespacedClusterRoles = []string{"edit:bind-a"}
+	h.Run(testkit.Ctx(), handler.Input{})
+}
+
+// TestErrOnNamespacedClusterRoleNotFound asserts that the CLI exits with an error if a
+// --namespaced-cluster-role selection does not exist.
+func TestErrOnNamespacedClusterRoleNotFound(t *testing.T) {
+	kit := NewHandlerKit(t)
+	kit.ExitOnErr = regexp.MustCompile(`cluster role\(s\) not found:.*invalid-a`)
+	kit.ServiceAccountName = testkit.ServiceAccountName
+	kit.UpsertToken = false
+	kit.UpsertContext = false
+	kit.SecretGet = false
+	kit.Finish()
+	defer kit.MockCtrl.Finish()
+
+	kit.ApiClientset.ClusterRoles.EXPECT().
+		Get("invalid-a").
+		Return(nil, testkit.NotExists, nil)
+
+	h := NewHandler(kit)
+	h.NamespacedClusterRoles = []string{"invalid-a:bind-a"}
+	h.Run(testkit.Ctx(), handler.Input{})
+}
+
+// TestErrOnNamespacedClusterRoleBindingConflict asserts that an existing role binding which refers to
+// a role of the same name, rather than the selected cluster role, is reported as a conflict.
+func TestErrOnNamespacedClusterRoleBindingConflict(t *testing.T) {
+	kit := NewHandlerKit(t)
+	kit.Namespace = testkit.CurrentNamespace
+	kit.ExpectCreatedServiceAccount(kit.Namespace, testkit.ServiceAccountName)
+	kit.ExitOnErr = regexp.MustCompile(`role binding \[bind-a\] in namespace .* refers to Role \[edit\], not ClusterRole \[edit\]`)
+	kit.UpsertToken = false
+	kit.UpsertContext = false
+	kit.SecretGet = false
+	kit.Finish()
+	defer kit.MockCtrl.Finish()
+
+	subject := rbac.Subject{Kind: cage_k8s.KindServiceAccount, Name: kit.ServiceAccountName}
+
+	kit.ApiClientset.ClusterRoles.EXPECT().
+		Get("edit").
+		Return(cage_gomock.NonSut(), testkit.Exists, nil)
+	kit.ApiClientset.RoleBindings.EXPECT().
+		Create(testkit.ManagedMeta(kit.Namespace, "bind-a"), rbac.RoleRef{Kind: cage_k8s.KindClusterRole, Name: "edit"}, subject).
+		Return(nil, roleBindingExists("bind-a"))
+	kit.ApiClientset.RoleBindings.EXPECT().
+		Get(kit.Namespace, "bind-a").
+		Return(&rbac.RoleBinding{RoleRef: rbac.RoleRef{Kind: cage_k8s.KindRole, Name: "edit"}}, testkit.Exists, nil)
+
+	h := NewHandler(kit)
+	h.NamespacedClusterRoles = []string{"edit:bind-a"}
+	h.Run(testkit.Ctx(), handler.Input{})
+}
+
+// TestMultiNamespaceRoleBindings asserts that role binding selectors which include a namespace
+// are applied in that namespace, and that a per-namespace summary is written.
+func TestMultiNamespaceRoleBindings(t *testing.T) {
+	stdout := &bytes.Buffer{}
+
+	kit := NewHandlerKit(t)
+	kit.Namespace = testkit.CurrentNamespace
+	kit.Stdout = stdout
+	kit.ExpectCreatedServiceAccount(kit.Namespace, testkit.ServiceAccountName)
+	kit.Finish()
+	defer kit.MockCtrl.Finish()
+
+	// The service account must be named explicitly outside of its own namespace.
+	localSubject := rbac.Subject{Kind: cage_k8s.KindServiceAccount, Name: kit.ServiceAccountName}
+	remoteSubject := rbac.Subject{Namespace: kit.Namespace, Kind: cage_k8s.KindServiceAccount, Name: kit.ServiceAccountName}
+
+	for _, ns := range []string{"ns-a", "ns-b"} {
+		// expect: namespace validated
+		kit.ApiClientset.Namespaces.EXPECT().
+			Get(ns).
+			Return(cage_gomock.NonSut(), testkit.Exists, nil)
+
+		// expect: role name validated in the namespace
+		kit.ApiClientset.Roles.EXPECT().
+			Get(ns, "role-a").
+			Return(cage_gomock.NonSut(), testkit.Exists, nil)
+	}
+	kit.ApiClientset.Roles.EXPECT().
+		Get(kit.Namespace, "role-a").
+		Return(cage_gomock.NonSut(), testkit.Exists, nil)
+	kit.ApiClientset.ClusterRoles.EXPECT().
+		Get("edit").
+		Return(cage_gomock.NonSut(), testkit.Exists, nil)
+
+	// expect: bindings created in each namespace
+	kit.ApiClientset.RoleBindings.EXPECT().
+		Create(testkit.ManagedMeta("ns-a", "bind-a"), rbac.RoleRef{Kind: cage_k8s.KindRole, Name: "role-a"}, remoteSubject).
+		Return(cage_gomock.NonSut(), nil)
+	kit.ApiClientset.RoleBindings.EXPECT().
+		Create(testkit.ManagedMeta("ns-b", "bind-a"), rbac.RoleRef{Kind: cage_k8s.KindRole, Name: "role-a"}, remoteSubject).
+		Return(nil, roleBindingExists("bind-a"))
+	kit.ApiClientset.RoleBindings.EXPECT().
+		Get("ns-b", "bind-a").
+		Return(&rbac.RoleBinding{
+			RoleRef:  rbac.RoleRef{Kind: cage_k8s.KindRole, Name: "role-a"},
+			Subjects: []rbac.Subject{remoteSubject},
+		}, testkit.Exists, nil)
+	kit.ApiClientset.RoleBindings.EXPECT().
+		Create(testkit.ManagedMeta(kit.Namespace, "bind-a"), rbac.RoleRef{Kind: cage_k8s.KindRole, Name: "role-a"}, localSubject).
+		Return(cage_gomock.NonSut(), nil)
+	kit.ApiClientset.RoleBindings.EXPECT().
+		Create(testkit.ManagedMeta("ns-a", "bind-b"), rbac.RoleRef{Kind: cage_k8s.KindClusterRole, Name: "edit"}, remoteSubject).
+		Return(cage_gomock.NonSut(), nil)
+
+	h := NewHandler(kit)
+	h.Roles = []string{"ns-b/role-a:bind-a", "ns-a/role-a:bind-a", "role-a:bind-a"}
+	h.NamespacedClusterRoles = []string{"ns-a/edit:bind-b"}
+	h.Run(testkit.Ctx(), handler.Input{})
+
+	require.Regexp(
+		t,
+		`(?s)^NAMESPACE +BINDING +ROLE +RESULT\n`+
+			`kubeauth-testkit-current-namespace +bind-a +Role/role-a +created\n`+
+			`ns-a +bind-a +Role/role-a +created\n`+
+			`ns-a +bind-b +ClusterRole/edit +created\n`+
+			`ns-b +bind-a +Role/role-a +unchanged\n$`,
+		stdout.String(),
+	)
+}
+
+// TestErrOnNamespaceNotFound asserts that the CLI exits with an error if a namespace selected
+// by a role binding selector does not exist.
+func TestErrOnNamespaceNotFound(t *testing.T) {
+	kit := NewHandlerKit(t)
+	kit.ExitOnErr = regexp.MustCompile(`namespace\(s\) not found:.*ns-a.*ns-b`)
+	kit.ServiceAccountName = testkit.ServiceAccountName
+	kit.UpsertToken = false
+	kit.UpsertContext = false
+	kit.SecretGet = false
+	kit.Finish()
+	defer kit.MockCtrl.Finish()
+
+	kit.ApiClientset.Namespaces.EXPECT().
+		Get("ns-a").
+		Return(nil, testkit.NotExists, nil)
+	kit.ApiClientset.Namespaces.EXPECT().
+		Get("ns-b").
+		Return(nil, testkit.NotExists, nil)
+
+	h := NewHandler(kit)
+	h.Roles = []string{"ns-b/role-a:bind-a"}
+	h.NamespacedClusterRoles = []string{"ns-a/edit:bind-a"}
+	h.Run(testkit.Ctx(), handler.Input{})
+}
+
+// TestErrOnEffectiveNamespaceNotFound asserts that the CLI exits with an error, before creating
+// the service account, if the effective namespace does not exist.
+func TestErrOnEffectiveNamespaceNotFound(t *testing.T) {
+	kit := NewHandlerKit(t)
+	kit.ExitOnErr = regexp.MustCompile(`namespace\(s\) not found:.*kubeauth-testkit-current-namespace.*--create-namespace`)
+	kit.NamespaceNotFound = true
+	kit.ServiceAccountName = testkit.ServiceAccountName
+	kit.UpsertToken = false
+	kit.UpsertContext = false
+	kit.SecretGet = false
+	kit.Finish()
+	defer kit.MockCtrl.Finish()
+
+	h := NewHandler(kit)
+	h.Run(testkit.Ctx(), handler.Input{})
+}
+
+// TestCreateNamespace asserts that --create-namespace creates the missing effective namespace and
+// those selected by role binding selectors, before creating objects in them.
+func TestCreateNamespace(t *testing.T) {
+	kit := NewHandlerKit(t)
+	kit.Namespace = testkit.CurrentNamespace
+	kit.NamespaceNotFound = true
+	kit.ServiceAccountName = testkit.ServiceAccountName
+	kit.Finish()
+	defer kit.MockCtrl.Finish()
+
+	remoteSubject := rbac.Subject{Namespace: kit.Namespace, Kind: cage_k8s.KindServiceAccount, Name: testkit.ServiceAccountName}
+
+	kit.ApiClientset.Namespaces.EXPECT().
+		Get("ns-a").
+		Return(nil, testkit.NotExists, nil)
+	kit.ApiClientset.ClusterRoles.EXPECT().
+		Get("edit").
+		Return(cage_gomock.NonSut(), testkit.Exists, nil)
+
+	createdSa := &core.ServiceAccount{Secrets: []core.ObjectReference{{Name: SecretName(kit.ServiceAccountName)}}}
+
+	gomock.InOrder(
+		kit.ApiClientset.Namespaces.EXPECT().
+			Create(testkit.ManagedMeta("", kit.Namespace)).
+			Return(cage_gomock.NonSut(), nil),
+		kit.ApiClientset.Namespaces.EXPECT().
+			Create(testkit.ManagedMeta("", "ns-a")).
+			Return(cage_gomock.NonSut(), nil),
+		kit.ApiClientset.ServiceAccounts.EXPECT().
+			Get(kit.Namespace, kit.ServiceAccountName).
+			Return(nil, testkit.NotExists, nil),
+		kit.ApiClientset.ServiceAccounts.EXPECT().
+			Create(testkit.ManagedMeta(kit.Namespace, kit.ServiceAccountName)).
+			Return(createdSa, nil),
+		kit.ApiClientset.RoleBindings.EXPECT().
+			Create(testkit.ManagedMeta("ns-a", "bind-a"), rbac.RoleRef{Kind: cage_k8s.KindClusterRole, Name: "edit"}, remoteSubject).
+			Return(cage_gomock.NonSut(), nil),
+	)
+
+	h := NewHandler(kit)
+	h.CreateNamespace = true
+	h.NamespacedClusterRoles = []string{"ns-a/edit:bind-a"}
+	h.Run(testkit.Ctx(), handler.Input{})
+}
+
+// TestErrOnRoleInCreatedNamespace asserts that a role cannot be selected in a namespace which
+// --create-namespace would create.
+func TestErrOnRoleInCreatedNamespace(t *testing.T) {
+	kit := NewHandlerKit(t)
+	kit.ExitOnErr = regexp.MustCompile(`role\(s\) not found:.*ns-a/role-a`)
+	kit.ServiceAccountName = testkit.ServiceAccountName
+	kit.UpsertToken = false
+	kit.UpsertContext = false
+	kit.SecretGet = false
+	kit.Finish()
+	defer kit.MockCtrl.Finish()
+
+	kit.ApiClientset.Namespaces.EXPECT().
+		Get("ns-a").
+		Return(nil, testkit.NotExists, nil)
+
+	h := NewHandler(kit)
+	h.CreateNamespace = true
+	h.Roles = []string{"ns-a/role-a:bind-a"}
+	h.Run(testkit.Ctx(), handler.Input{})
+}
+
+// TestErrOnClusterRoleSelectorNamespace asserts that --cluster-role selectors cannot include a namespace.
+func TestErrOnClusterRoleSelectorNamespace(t *testing.T) {
+	kit := NewHandlerKit(t)
+	kit.ExitOnErr = regexp.MustCompile(`invalid --cluster-role selectors:.*ns-a/role-a:bind-a.*does not accept a namespace`)
+	kit.ServiceAccountName = testkit.ServiceAccountName
+	kit.UpsertToken = false
+	kit.UpsertContext = false
+	kit.SecretGet = false
+	kit.Finish()
+	defer kit.MockCtrl.Finish()
+
+	h := NewHandler(kit)
+	h.ClusterRoles = []string{"ns-a/role-a:bind-a"}
+	h.Run(testkit.Ctx(), handler.Input{})
+}
+
+// TestLabelsAndAnnotations asserts that --label and --annotation selections are added to created objects
+// alongside the ownership metadata.
+func TestLabelsAndAnnotations(t *testing.T) {
+	kit := NewHandlerKit(t)
+	kit.Namespace = testkit.CurrentNamespace
+	kit.ServiceAccountName = testkit.ServiceAccountName
+	kit.Finish()
+	defer kit.MockCtrl.Finish()
+
+	requireMeta := func(objMeta meta.ObjectMeta) {
+		require.Exactly(t, "a", objMeta.Labels["team"])
+		require.Exactly(t, ownership.ManagedByValue, objMeta.Labels[ownership.ManagedByLabel])
+		require.Exactly(t, "some note", objMeta.Annotations["note"])
+		require.Exactly(t, testkit.Username, objMeta.Annotations[ownership.UserAnnotation])
+	}
+
+	createdObj := &core.ServiceAccount{Secrets: []core.ObjectReference{{Name: SecretName(kit.ServiceAccountName)}}}
+	kit.ApiClientset.ServiceAccounts.EXPECT().
+		Get(kit.Namespace, kit.ServiceAccountName).
+		Return(nil, testkit.NotExists, nil)
+	kit.ApiClientset.ServiceAccounts.EXPECT().
+		Create(testkit.ManagedMeta(kit.Namespace, kit.ServiceAccountName)).
+		DoAndReturn(func(obj *core.ServiceAccount) (*core.ServiceAccount, error) {
+			requireMeta(obj.ObjectMeta)
+			return createdObj, nil
+		})
+
+	kit.ApiClientset.ClusterRoles.EXPECT().
+		Get("role-a").
+		Return(cage_gomock.NonSut(), testkit.Exists, nil)
+	kit.ApiClientset.ClusterRoleBindings.EXPECT().
+		Create(testkit.ManagedMeta("", "bind-a"), "role-a", gomock.Any()).
+		DoAndReturn(func(objMeta meta.ObjectMeta, _ string, _ ...rbac.Subject) (*rbac.ClusterRoleBinding, error) {
+			requireMeta(objMeta)
+			return nil, nil
+		})
+
+	h := NewHandler(kit)
+	h.Labels = []string{"team=a"}
+	h.Annotations = []string{"note=some note"}
+	h.ClusterRoles = []string{"role-a:bind-a"}
+	h.Run(testkit.Ctx(), handler.Input{})
+}
+
+// TestServiceAccountCustomization asserts that a new service account receives the fields selected
+// by --from-file and the flags which take precedence over it.
+func TestServiceAccountCustomization(t *testing.T) {
+	kit := NewHandlerKit(t)
+	kit.Namespace = testkit.CurrentNamespace
+	kit.ServiceAccountName = testkit.ServiceAccountName
+	kit.Finish()
+	defer kit.MockCtrl.Finish()
+
+	automount := false
+	createdObj := &core.ServiceAccount{Secrets: []core.ObjectReference{{Name: SecretName(kit.ServiceAccountName)}}}
+
+	kit.ApiClientset.ServiceAccounts.EXPECT().
+		Get(kit.Namespace, kit.ServiceAccountName).
+		Return(nil, testkit.NotExists, nil)
+	kit.ApiClientset.ServiceAccounts.EXPECT().
+		Create(testkit.ManagedMeta(kit.Namespace, kit.ServiceAccountName)).
+		DoAndReturn(func(obj *core.ServiceAccount) (*core.ServiceAccount, error) {
+			require.Exactly(t, "flag", obj.Labels["team"])
+			require.Exactly(t, "backend", obj.Labels["tier"])
+			require.Exactly(t, "from-file", obj.Annotations["note"])
+			require.Exactly(t, []core.LocalObjectReference{{Name: "registry-a"}, {Name: "registry-b"}}, obj.ImagePullSecrets)
+			require.Exactly(t, &automount, obj.AutomountServiceAccountToken)
+			return createdObj, nil
+		})
+
+	_, fromFile := testkit_file.FixturePath(t, "service-account.yml")
+
+	h := NewHandler(kit)
+	h.ServiceAccountFile = fromFile
+	h.Labels = []string{"team=flag"}
+	h.ImagePullSecrets = []string{"registry-b"}
+	h.AutomountToken = "false"
+	h.Run(testkit.Ctx(), handler.Input{})
+}
+
+// TestUpdateExistingServiceAccount asserts that customizations are merged into an existing
+// service account without adding the ownership metadata.
+func TestUpdateExistingServiceAccount(t *testing.T) {
+	kit := NewHandlerKit(t)
+	kit.Namespace = testkit.CurrentNamespace
+	kit.ServiceAccountName = testkit.ServiceAccountName
+	kit.Finish()
+	defer kit.MockCtrl.Finish()
+
+	existingObj := &core.ServiceAccount{
+		ObjectMeta:       meta.ObjectMeta{Namespace: kit.Namespace, Name: kit.ServiceAccountName, Labels: map[string]string{"team": "a"}},
+		ImagePullSecrets: []core.LocalObjectReference{{Name: "registry-a"}},
+		Secrets:          []core.ObjectReference{{Name: SecretName(kit.ServiceAccountName)}},
+	}
+	automount := false
+	expectObj := existingObj.DeepCopy()
+	expectObj.Labels["tier"] = "backend"
+	expectObj.ImagePullSecrets = append(expectObj.ImagePullSecrets, core.LocalObjectReference{Name: "registry-b"})
+	expectObj.AutomountServiceAccountToken = &automount
+
+	kit.ApiClientset.ServiceAccounts.EXPECT().
+		Get(kit.Namespace, kit.ServiceAccountName).
+		Return(existingObj, testkit.Exists, nil)
+	kit.ApiClientset.ServiceAccounts.EXPECT().
+		Update(expectObj).
+		Return(expectObj, nil)
+
+	h := NewHandler(kit)
+	h.Labels = []string{"tier=backend"}
+	h.ImagePullSecrets = []string{"registry-a", "registry-b"}
+	h.AutomountToken = "false"
+	h.Run(testkit.Ctx(), handler.Input{})
+}
+
+// TestErrOnServiceAccountFileMismatch asserts that the --from-file manifest cannot select
+// a different service account than --account.
+func TestErrOnServiceAccountFileMismatch(t *testing.T) {
+	kit := NewHandlerKit(t)
+	kit.ExitOnErr = regexp.MustCompile(`--from-file .* selects service account \[other-sa\], not --account \[kubeauth-testkit-test-sa\]`)
+	kit.ServiceAccountName = testkit.ServiceAccountName
+	kit.UpsertToken = false
+	kit.UpsertContext = false
+	kit.SecretGet = false
+	kit.Finish()
+	defer kit.MockCtrl.Finish()
+
+	_, fromFile := testkit_file.FixturePath(t, "service-account-other.yml")
+
+	h := NewHandler(kit)
+	h.ServiceAccountFile = fromFile
+	h.Run(testkit.Ctx(), handler.Input{})
+}
+
+// TestErrOnInvalidAutomountToken asserts that --automount-token only accepts boolean values.
+func TestErrOnInvalidAutomountToken(t *testing.T) {
+	kit := NewHandlerKit(t)
+	kit.ExitOnErr = regexp.MustCompile(`--automount-token \[maybe\] must be true or false`)
+	kit.ServiceAccountName = testkit.ServiceAccountName
+	kit.UpsertToken = false
+	kit.UpsertContext = false
+	kit.SecretGet = false
+	kit.Finish()
+	defer kit.MockCtrl.Finish()
+
+	h := NewHandler(kit)
+	h.AutomountToken = "maybe"
+	h.Run(testkit.Ctx(), handler.Input{})
+}
+
+// TestEmitManifestsStdout asserts that --emit-manifests - writes the objects to create, including
+// the token secret, to stdout instead of creating them or modifying the config.
+func TestEmitManifestsStdout(t *testing.T) {
+	stdout := &bytes.Buffer{}
+
+	kit := NewHandlerKit(t)
+	kit.Namespace = testkit.CurrentNamespace
+	kit.Stdout = stdout
+	kit.UpsertToken = false
+	kit.UpsertContext = false
+	kit.SecretGet = false
+	kit.Finish()
+	defer kit.MockCtrl.Finish()
+
+	kit.ApiClientset.Roles.EXPECT().
+		Get(kit.Namespace, "role-a").
+		Return(cage_gomock.NonSut(), testkit.Exists, nil)
+	kit.ApiClientset.ClusterRoles.EXPECT().
+		Get("role-b").
+		Return(cage_gomock.NonSut(), testkit.Exists, nil)
+
+	h := NewHandler(kit)
+	h.ServiceAccountName = testkit.ServiceAccountName
+	h.Roles = []string{"role-a:bind-a"}
+	h.ClusterRoles = []string{"role-b:bind-b"}
+	h.ImagePullSecrets = []string{"registry-a"}
+	h.EmitManifests = "-"
+	h.Run(testkit.Ctx(), handler.Input{})
+
+	docs := regexp.MustCompile(`(?m)^---$`).Split(stdout.String(), -1)
+	require.Len(t, docs, 4)
+	require.Regexp(t, `(?s)^apiVersion: v1\nimagePullSecrets:\n- name: registry-a\nkind: ServiceAccount\n.*  name: kubeauth-testkit-test-sa\n.*secrets:\n- name: kubeauth-testkit-test-sa-token-kubeauth\n`, docs[0])
+	require.Regexp(t, `(?s)kind: Secret\n.*kubernetes.io/service-account.name: kubeauth-testkit-test-sa\n.*type: kubernetes.io/service-account-token\n`, docs[1])
+	require.Regexp(t, `(?s)kind: RoleBinding\n.*app.kubernetes.io/managed-by: kubeauth\n.*name: bind-a\n.*kind: Role\n  name: role-a\n`, docs[2])
+	require.Regexp(t, `(?s)kind: ClusterRoleBinding\n.*name: bind-b\n.*namespace: kubeauth-testkit-current-namespace\n`, docs[3])
+}
+
+// TestEmitManifestsDir asserts that --emit-manifests <dir> writes one file per object, including
+// namespaces which --create-namespace would create, and a kustomization.
+func TestEmitManifestsDir(t *testing.T) {
+	testkit_file.ResetTestdata(t)
+	_, dir := testkit_file.CreatePath(t, "manifests")
+
+	kit := NewHandlerKit(t)
+	kit.Namespace = testkit.CurrentNamespace
+	kit.NamespaceNotFound = true
+	kit.UpsertToken = false
+	kit.UpsertContext = false
+	kit.SecretGet = false
+	kit.Finish()
+	defer kit.MockCtrl.Finish()
+
+	h := NewHandler(kit)
+	h.ServiceAccountName = testkit.ServiceAccountName
+	h.CreateNamespace = true
+	h.EmitManifests = dir
+	h.Run(testkit.Ctx(), handler.Input{})
+
+	kustomization, err := ioutil.ReadFile(filepath.Join(dir, "kustomization.yaml"))
+	require.NoError(t, err)
+	require.Exactly(
+		t,
+		"apiVersion: kustomize.config.k8s.io/v1beta1\nkind: Kustomization\nresources:\n"+
+			"- namespace-kubeauth-testkit-current-namespace.yaml\n"+
+			"- serviceaccount-kubeauth-testkit-current-namespace-kubeauth-testkit-test-sa.yaml\n"+
+			"- secret-kubeauth-testkit-current-namespace-kubeauth-testkit-test-sa-token-kubeauth.yaml\n",
+		string(kustomization),
+	)
+}
+
+// TestEmitManifestsOffline asserts that --offline renders the objects without reading the config
+// or querying the API, e.g. for roles or namespaces, and omits the unknown creator.
+func TestEmitManifestsOffline(t *testing.T) {
+	stdout := &bytes.Buffer{}
+	ns := "some-ns"
+
+	kit := NewHandlerKit(t)
+	kit.Offline = true
+	kit.Stdout = stdout
+	kit.UpsertToken = false
+	kit.UpsertContext = false
+	kit.SecretGet = false
+	kit.Finish()
+	defer kit.MockCtrl.Finish()
+
+	h := NewHandler(kit)
+	h.ServiceAccountName = testkit.ServiceAccountName
+	h.Namespace = ns
+	h.Roles = []string{"role-a:bind-a"}
+	h.ClusterRoles = []string{"role-b:bind-b"}
+	h.CreateNamespace = true
+	h.EmitManifests = "-"
+	h.Offline = true
+	h.Run(testkit.Ctx(), handler.Input{})
+
+	docs := regexp.MustCompile(`(?m)^---$`).Split(stdout.String(), -1)
+	require.Len(t, docs, 5)
+	require.Regexp(t, `(?s)^apiVersion: v1\nkind: Namespace\n.*  name: some-ns\n`, docs[0])
+	require.Regexp(t, `(?s)kind: ServiceAccount\n.*  namespace: some-ns\n`, docs[1])
+	require.Regexp(t, `(?s)kind: RoleBinding\n.*name: bind-a\n  namespace: some-ns\n.*kind: Role\n  name: role-a\n`, docs[3])
+	require.Regexp(t, `(?s)kind: ClusterRoleBinding\n.*name: bind-b\n.*kind: ClusterRole\n  name: role-b\n`, docs[4])
+	require.NotContains(t, stdout.String(), ownership.CreatorAnnotation)
+}
+
+// TestErrOnOfflineFlags asserts that --offline is rejected with flags which need the config or API.
+func TestErrOnOfflineFlags(t *testing.T) {
+	cases := []struct {
+		name  string
+		setup func(h *cli.Handler)
+		err   string
+	}{
+		{
+			name:  "no emit-manifests",
+			setup: func(h *cli.Handler) { h.Namespace = "some-ns" },
+			err:   `--offline requires --emit-manifests`,
+		},
+		{
+			name:  "no namespace",
+			setup: func(h *cli.Handler) { h.EmitManifests = "-" },
+			err:   `--offline requires --namespace`,
+		},
+		{
+			name: "grant",
+			setup: func(h *cli.Handler) {
+				h.EmitManifests = "-"
+				h.Namespace = "some-ns"
+				h.Grants = []string{"get:pods"}
+			},
+			err: `--grant requires API discovery, which --offline skips`,
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			kit := NewHandlerKit(t)
+			kit.Offline = true
+			kit.ExitOnErr = regexp.MustCompile(c.err)
+			kit.UpsertToken = false
+			kit.UpsertContext = false
+			kit.SecretGet = false
+			kit.Finish()
+			defer kit.MockCtrl.Finish()
+
+			h := NewHandler(kit)
+			h.ServiceAccountName = testkit.ServiceAccountName
+			h.Offline = true
+			c.setup(h)
+			h.Run(testkit.Ctx(), handler.Input{})
+		})
+	}
+}
+
+// TestErrOnEmitManifestsWithCert asserts that --emit-manifests requires --auth token.
+func TestErrOnEmitManifestsWithCert(t *testing.T) {
+	kit := NewHandlerKit(t)
+	kit.ExitOnErr = regexp.MustCompile(`--emit-manifests requires --auth token`)
+	kit.UpsertToken = false
+	kit.UpsertContext = false
+	kit.SecretGet = false
+	kit.Finish()
+	defer kit.MockCtrl.Finish()
+
+	h := NewHandler(kit)
+	h.Auth = cli.AuthCert
+	h.EmitManifests = "-"
+	h.Run(testkit.Ctx(), handler.Input{})
+}
+
+// TestErrOnReservedLabel asserts that --label selections cannot replace the ownership label.
+func TestErrOnReservedLabel(t *testing.T) {
+	kit := NewHandlerKit(t)
+	kit.ExitOnErr = regexp.MustCompile(`invalid --label selections: label \[app.kubernetes.io/managed-by\] is reserved`)
+	kit.ServiceAccountName = testkit.ServiceAccountName
+	kit.UpsertToken = false
+	kit.UpsertContext = false
+	kit.SecretGet = false
+	kit.Finish()
+	defer kit.MockCtrl.Finish()
+
+	h := NewHandler(kit)
+	h.Labels = []string{ownership.ManagedByLabel + "=other"}
+	h.Run(testkit.Ctx(), handler.Input{})
+}
+
+// TestErrOnMissingAccount asserts that token users require an --account selection.
+func TestErrOnMissingAccount(t *testing.T) {
+	kit := NewHandlerKit(t)
+	kit.ExitOnErr = regexp.MustCompile(`--account is required`)
+	kit.UpsertToken = false
+	kit.UpsertContext = false
+	kit.SecretGet = false
+	kit.Finish()
+	defer kit.MockCtrl.Finish()
+
+	h := NewHandler(kit)
+	h.Run(testkit.Ctx(), handler.Input{})
+}
+
+// TestErrOnInvalidAuth asserts that the CLI exits with an error if --auth is unrecognized.
+func TestErrOnInvalidAuth(t *testing.T) {
+	kit := NewHandlerKit(t)
+	kit.ExitOnErr = regexp.MustCompile(`--auth must be`)
+	kit.UpsertToken = false
+	kit.UpsertContext = false
+	kit.SecretGet = false
+	kit.Finish()
+	defer kit.MockCtrl.Finish()
+
+	h := NewHandler(kit)
+	h.Auth = "invalid"
+	h.Run(testkit.Ctx(), handler.Input{})
+}
+
+// TestErrOnCertFlagsWithToken asserts that cert-only flags are rejected for token users.
+func TestErrOnCertFlagsWithToken(t *testing.T) {
+	kit := NewHandlerKit(t)
+	kit.ExitOnErr = regexp.MustCompile(`--groups require --auth cert`)
+	kit.ServiceAccountName = testkit.ServiceAccountName
+	kit.UpsertToken = false
+	kit.UpsertContext = false
+	kit.SecretGet = false
+	kit.Finish()
+	defer kit.MockCtrl.Finish()
+
+	h := NewHandler(kit)
+	h.Groups = []string{"group-a"}
+	h.Run(testkit.Ctx(), handler.Input{})
+}
+
+// TestCertUser asserts that a cert user's key and issued certificate are written to the config.
+func TestCertUser(t *testing.T) {
+	groups := []string{"group-a", "group-b"}
+
+	kit := NewHandlerKit(t)
+	kit.Namespace = testkit.CurrentNamespace
+	kit.ExpectCertUser(testkit.Username, groups, false, nil)
+	kit.UpsertToken = false
+	kit.UpsertCert = true
+	kit.SecretGet = false
+	kit.Finish()
+	defer kit.MockCtrl.Finish()
+
+	h := NewHandler(kit)
+	h.Auth = cli.AuthCert
+	h.Groups = groups
+	h.Run(testkit.Ctx(), handler.Input{})
+}
+
+// TestCertUserCommonName asserts that an explicit --cn selection is requested and bound to roles.
+func TestCertUserCommonName(t *testing.T) {
+	explicit := "some-cn"
+
+	kit := NewHandlerKit(t)
+	kit.Namespace = testkit.CurrentNamespace
+	kit.ExpectCertUser(explicit, nil, false, nil)
+	kit.UpsertToken = false
+	kit.UpsertCert = true
+	kit.SecretGet = false
+	kit.Finish()
+	defer kit.MockCtrl.Finish()
+
+	subject := rbac.Subject{Kind: cage_k8s.KindUser, APIGroup: rbac.GroupName, Name: explicit}
+
+	kit.ApiClientset.Roles.EXPECT().
+		Get(kit.Namespace, testkit.RoleName).
+		Return(cage_gomock.NonSut(), testkit.Exists, nil)
+	kit.ApiClientset.RoleBindings.EXPECT().
+		Create(testkit.ManagedMeta(kit.Namespace, testkit.RoleBindName), rbac.RoleRef{Kind: cage_k8s.KindRole, Name: testkit.RoleName}, subject).
+		Return(cage_gomock.NonSut(), nil)
+	kit.ApiClientset.ClusterRoles.EXPECT().
+		Get(testkit.ClusterRoleName).
+		Return(cage_gomock.NonSut(), testkit.Exists, nil)
+	kit.ApiClientset.ClusterRoleBindings.EXPECT().
+		Create(testkit.ManagedMeta("", testkit.ClusterRoleBindName), testkit.ClusterRoleName, subject).
+		Return(cage_gomock.NonSut(), nil)
+
+	h := NewHandler(kit)
+	h.Auth = cli.AuthCert
+	h.CommonName = explicit
+	h.Roles = []string{testkit.RoleName + ":" + testkit.RoleBindName}
+	h.ClusterRoles = []string{testkit.ClusterRoleName + ":" + testkit.ClusterRoleBindName}
+	h.Run(testkit.Ctx(), handler.Input{})
+}
+
+// TestCertUserApprove asserts that --approve approves the request.
+func TestCertUserApprove(t *testing.T) {
+	kit := NewHandlerKit(t)
+	kit.Namespace = testkit.CurrentNamespace
+	kit.ExpectCertUser(testkit.Username, nil, true, nil)
+	kit.UpsertToken = false
+	kit.UpsertCert = true
+	kit.SecretGet = false
+	kit.Finish()
+	defer kit.MockCtrl.Finish()
+
+	h := NewHandler(kit)
+	h.Auth = cli.AuthCert
+	h.ApproveCert = true
+	h.Run(testkit.Ctx(), handler.Input{})
+}
+
+// TestCertUserApproveForbidden asserts that --approve falls back to waiting for another party's
+// approval if the operator lacks permission.
+func TestCertUserApproveForbidden(t *testing.T) {
+	forbidden := k8s_errors.NewForbidden(schema.GroupResource{}, CsrName, nil)
+
+	kit := NewHandlerKit(t)
+	kit.Namespace = testkit.CurrentNamespace
+	kit.ExpectCertUser(testkit.Username, nil, true, forbidden)
+	kit.UpsertToken = false
+	kit.UpsertCert = true
+	kit.SecretGet = false
+	kit.Finish()
+	defer kit.MockCtrl.Finish()
+
+	h := NewHandler(kit)
+	h.Auth = cli.AuthCert
+	h.ApproveCert = true
+	h.Run(testkit.Ctx(), handler.Input{})
+}
+
+// TestErrOnCertDenied asserts that the CLI exits with an error if the request is denied.
+func TestErrOnCertDenied(t *testing.T) {
+	kit := NewHandlerKit(t)
+	kit.ExitOnErr = regexp.MustCompile(`certificate signing request.*was denied`)
+	kit.UpsertToken = false
+	kit.UpsertContext = false
+	kit.SecretGet = false
+	kit.Finish()
+	defer kit.MockCtrl.Finish()
+
+	createdObj := &certs.CertificateSigningRequest{ObjectMeta: meta.ObjectMeta{Name: CsrName}}
+	deniedObj := createdObj.DeepCopy()
+	deniedObj.Status.Conditions = []certs.CertificateSigningRequestCondition{{Type: certs.CertificateDenied}}
+
+	kit.ApiClientset.CertificateSigningRequests.EXPECT().
+		Create(gomock.Any(), gomock.Any(), gomock.Any()).
+		Return(createdObj, nil)
+	kit.ApiClientset.CertificateSigningRequests.EXPECT().
+		Get(CsrName).
+		Return(deniedObj, testkit.Exists, nil)
+
+	h := NewHandler(kit)
+	h.Auth = cli.AuthCert
+	h.Run(testkit.Ctx(), handler.Input{})
+}
+
+// TestErrOnCertTimeout asserts that the CLI exits with an error if the certificate is not issued in time.
+func TestErrOnCertTimeout(t *testing.T) {
+	kit := NewHandlerKit(t)
+	kit.ExitOnErr = regexp.MustCompile(`was not approved and issued after`)
+	kit.UpsertToken = false
+	kit.UpsertContext = false
+	kit.SecretGet = false
+	kit.Finish()
+	defer kit.MockCtrl.Finish()
+
+	createdObj := &certs.CertificateSigningRequest{ObjectMeta: meta.ObjectMeta{Name: CsrName}}
+
+	kit.ApiClientset.CertificateSigningRequests.EXPECT().
+		Create(gomock.Any(), gomock.Any(), gomock.Any()).
+		Return(createdObj, nil)
+	kit.ApiClientset.CertificateSigningRequests.EXPECT().
+		Get(CsrName).
+		Return(createdObj, testkit.Exists, nil).
+		MinTimes(1)
+
+	h := NewHandler(kit)
+	h.Auth = cli.AuthCert
+	h.CertTimeout = 10 * time.Millisecond
+	h.Run(testkit.Ctx(), handler.Input{})
+}
+
+// roleBindingExists returns the error from creating a role binding which already exists.
+func roleBindingExists(name string) error {
+	return k8s_errors.NewAlreadyExists(schema.GroupResource{Group: rbac.GroupName, Resource: "rolebindings"}, name)
+}
+
+// clusterRoleBindingExists returns the error from creating a cluster role binding which already exists.
+func clusterRoleBindingExists(name string) error {
+	return k8s_errors.NewAlreadyExists(schema.GroupResource{Group: rbac.GroupName, Resource: "clusterrolebindings"}, name)
+}
+
+// TestExistingRoleBindingWithSubject asserts that an existing role binding which already refers to the
+// role and subject is not modified.
+func TestExistingRoleBindingWithSubject(t *testing.T) {
+	kit := NewHandlerKit(t)
+	kit.Namespace = testkit.CurrentNamespace
+	kit.ExpectCreatedServiceAccount(kit.Namespace, testkit.ServiceAccountName)
+	kit.Finish()
+	defer kit.MockCtrl.Finish()
+
+	subject := rbac.Subject{Kind: cage_k8s.KindServiceAccount, Name: kit.ServiceAccountName}
+
+	kit.ApiClientset.Roles.EXPECT().
+		Get(kit.Namespace, "role-a").
+		Return(cage_gomock.NonSut(), testkit.Exists, nil)
+	kit.ApiClientset.RoleBindings.EXPECT().
+		Create(testkit.ManagedMeta(kit.Namespace, "bind-a"), rbac.RoleRef{Kind: cage_k8s.KindRole, Name: "role-a"}, subject).
+		Return(nil, roleBindingExists("bind-a"))
+
+	// The API server has defaulted the subject's namespace.
+	kit.ApiClientset.RoleBindings.EXPECT().
+		Get(kit.Namespace, "bind-a").
+		Return(&rbac.RoleBinding{
+			ObjectMeta: meta.ObjectMeta{Namespace: kit.Namespace, Name: "bind-a"},
+			RoleRef:    rbac.RoleRef{Kind: cage_k8s.KindRole, Name: "role-a"},
+			Subjects:   []rbac.Subject{{Kind: cage_k8s.KindServiceAccount, Namespace: kit.Namespace, Name: kit.ServiceAccountName}},
+		}, testkit.Exists, nil)
+
+	h := NewHandler(kit)
+	h.Roles = []string{"role-a:bind-a"}
+	h.Run(testkit.Ctx(), handler.Input{})
+}
+
+// TestSharedObjectsRecordUser asserts that a service account and role binding which kubeauth created for
+// another user record this one as well, so that gc keeps them while either user exists.
+func TestSharedObjectsRecordUser(t *testing.T) {
+	kit := NewHandlerKit(t)
+	kit.Namespace = testkit.CurrentNamespace
+	kit.ServiceAccountName = testkit.ServiceAccountName
+	kit.Finish()
+	defer kit.MockCtrl.Finish()
+
+	owner := ownership.Owner{User: "other-user", Creator: testkit.Username, CreatedAt: time.Now()}
+	subject := rbac.Subject{Kind: cage_k8s.KindServiceAccount, Name: kit.ServiceAccountName}
+	roleRef := rbac.RoleRef{Kind: cage_k8s.KindRole, Name: "role-a"}
+
+	existingSa := &core.ServiceAccount{
+		ObjectMeta: owner.ObjectMeta(kit.Namespace, kit.ServiceAccountName),
+		Secrets:    []core.ObjectReference{{Name: SecretName(kit.ServiceAccountName)}},
+	}
+	expectSa := existingSa.DeepCopy()
+	expectSa.Annotations[ownership.UsersAnnotation] = `["kubeauth-testkit-username","other-user"]`
+
+	existingBinding := &rbac.RoleBinding{
+		ObjectMeta: owner.BindingMeta(kit.Namespace, "bind-a", subject),
+		RoleRef:    roleRef,
+		Subjects:   []rbac.Subject{subject},
+	}
+	expectBinding := existingBinding.DeepCopy()
+	expectBinding.Annotations[ownership.UsersAnnotation] = `["kubeauth-testkit-username","other-user"]`
+	expectBinding.Annotations[ownership.SubjectsAnnotation] = `{"kubeauth-testkit-username":{"kind":"ServiceAccount","name":"kubeauth-testkit-test-sa"},"other-user":{"kind":"ServiceAccount","name":"kubeauth-testkit-test-sa"}}`
+
+	kit.ApiClientset.ServiceAccounts.EXPECT().
+		Get(kit.Namespace, kit.ServiceAccountName).
+		Return(existingSa, testkit.Exists, nil)
+	kit.ApiClientset.ServiceAccounts.EXPECT().
+		Update(expectSa).
+		Return(expectSa, nil)
+	kit.ApiClientset.Roles.EXPECT().
+		Get(kit.Namespace, "role-a").
+		Return(cage_gomock.NonSut(), testkit.Exists, nil)
+	kit.ApiClientset.RoleBindings.EXPECT().
+		Create(testkit.ManagedMeta(kit.Namespace, "bind-a"), roleRef, subject).
+		Return(nil, roleBindingExists("bind-a"))
+	kit.ApiClientset.RoleBindings.EXPECT().
+		Get(kit.Namespace, "bind-a").
+		Return(existingBinding, testkit.Exists, nil)
+	kit.ApiClientset.RoleBindings.EXPECT().
+		Update(expectBinding).
+		Return(expectBinding, nil)
+
+	h := NewHandler(kit)
+	h.Roles = []string{"role-a:bind-a"}
+	h.Run(testkit.Ctx(), handler.Input{})
+}
+
+// TestExistingRoleBindingAddSubject asserts that the subject is added to an existing role binding
+// which refers to the role but lacks the subject.
+func TestExistingRoleBindingAddSubject(t *testing.T) {
+	kit := NewHandlerKit(t)
+	kit.Namespace = testkit.CurrentNamespace
+	kit.ExpectCreatedServiceAccount(kit.Namespace, testkit.ServiceAccountName)
+	kit.Finish()
+	defer kit.MockCtrl.Finish()
+
+	subject := rbac.Subject{Kind: cage_k8s.KindServiceAccount, Name: kit.ServiceAccountName}
+	otherSubject := rbac.Subject{Kind: cage_k8s.KindUser, APIGroup: rbac.GroupName, Name: "other-user"}
+
+	existingObj := &rbac.RoleBinding{
+		ObjectMeta: meta.ObjectMeta{Namespace: kit.Namespace, Name: "bind-a"},
+		RoleRef:    rbac.RoleRef{Kind: cage_k8s.KindRole, Name: "role-a"},
+		Subjects:   []rbac.Subject{otherSubject},
+	}
+	updatedObj := existingObj.DeepCopy()
+	updatedObj.Subjects = []rbac.Subject{otherSubject, subject}
+
+	kit.ApiClientset.Roles.EXPECT().
+		Get(kit.Namespace, "role-a").
+		Return(cage_gomock.NonSut(), testkit.Exists, nil)
+	kit.ApiClientset.RoleBindings.EXPECT().
+		Create(testkit.ManagedMeta(kit.Namespace, "bind-a"), rbac.RoleRef{Kind: cage_k8s.KindRole, Name: "role-a"}, subject).
+		Return(nil, roleBindingExists("bind-a"))
+	kit.ApiClientset.RoleBindings.EXPECT().
+		Get(kit.Namespace, "bind-a").
+		Return(existingObj, testkit.Exists, nil)
+	kit.ApiClientset.RoleBindings.EXPECT().
+		Update(updatedObj).
+		Return(cage_gomock.NonSut(), nil)
+
+	h := NewHandler(kit)
+	h.Roles = []string{"role-a:bind-a"}
+	h.Run(testkit.Ctx(), handler.Input{})
+}
+
+// TestErrOnRoleBindingConflict asserts that the CLI exits with an error if an existing role binding
+// refers to a different role and --force is not used.
+func TestErrOnRoleBindingConflict(t *testing.T) {
+	kit := NewHandlerKit(t)
+	kit.Namespace = testkit.CurrentNamespace
+	kit.ExpectCreatedServiceAccount(kit.Namespace, testkit.ServiceAccountName)
+	kit.ExitOnErr = regexp.MustCompile(`role binding \[bind-a\] in namespace .* refers to Role \[other-role\], not Role \[role-a\] \(use --force`)
+	kit.UpsertToken = false
+	kit.UpsertContext = false
+	kit.SecretGet = false
+	kit.Finish()
+	defer kit.MockCtrl.Finish()
+
+	subject := rbac.Subject{Kind: cage_k8s.KindServiceAccount, Name: kit.ServiceAccountName}
+
+	kit.ApiClientset.Roles.EXPECT().
+		Get(kit.Namespace, "role-a").
+		Return(cage_gomock.NonSut(), testkit.Exists, nil)
+	kit.ApiClientset.RoleBindings.EXPECT().
+		Create(testkit.ManagedMeta(kit.Namespace, "bind-a"), rbac.RoleRef{Kind: cage_k8s.KindRole, Name: "role-a"}, subject).
+		Return(nil, roleBindingExists("bind-a"))
+	kit.ApiClientset.RoleBindings.EXPECT().
+		Get(kit.Namespace, "bind-a").
+		Return(&rbac.RoleBinding{RoleRef: rbac.RoleRef{Kind: cage_k8s.KindRole, Name: "other-role"}}, testkit.Exists, nil)
+
+	h := NewHandler(kit)
+	h.Roles = []string{"role-a:bind-a"}
+	h.Run(testkit.Ctx(), handler.Input{})
+}
+
+// TestForceReplaceRoleBinding asserts that --force replaces an existing role binding which refers
+// to a different role.
+func TestForceReplaceRoleBinding(t *testing.T) {
+	kit := NewHandlerKit(t)
+	kit.Namespace = testkit.CurrentNamespace
+	kit.ExpectCreatedServiceAccount(kit.Namespace, testkit.ServiceAccountName)
+	kit.Finish()
+	defer kit.MockCtrl.Finish()
+
+	subject := rbac.Subject{Kind: cage_k8s.KindServiceAccount, Name: kit.ServiceAccountName}
+
+	kit.ApiClientset.Roles.EXPECT().
+		Get(kit.Namespace, "role-a").
+		Return(cage_gomock.NonSut(), testkit.Exists, nil)
+	gomock.InOrder(
+		kit.ApiClientset.RoleBindings.EXPECT().
+			Create(testkit.ManagedMeta(kit.Namespace, "bind-a"), rbac.RoleRef{Kind: cage_k8s.KindRole, Name: "role-a"}, subject).
+			Return(nil, roleBindingExists("bind-a")),
+		kit.ApiClientset.RoleBindings.EXPECT().
+			Get(kit.Namespace, "bind-a").
+			Return(&rbac.RoleBinding{RoleRef: rbac.RoleRef{Kind: cage_k8s.KindClusterRole, Name: "role-a"}}, testkit.Exists, nil),
+		kit.ApiClientset.RoleBindings.EXPECT().
+			Delete(kit.Namespace, "bind-a").
+			Return(nil),
+		kit.ApiClientset.RoleBindings.EXPECT().
+			Create(testkit.ManagedMeta(kit.Namespace, "bind-a"), rbac.RoleRef{Kind: cage_k8s.KindRole, Name: "role-a"}, subject).
+			Return(cage_gomock.NonSut(), nil),
+	)
+
+	h := NewHandler(kit)
+	h.Force = true
+	h.Roles = []string{"role-a:bind-a"}
+	h.Run(testkit.Ctx(), handler.Input{})
+}
+
+// TestExistingClusterRoleBindingAddSubject asserts that the subject is added to an existing cluster
+// role binding which refers to the cluster role but lacks the subject.
+func TestExistingClusterRoleBindingAddSubject(t *testing.T) {
+	kit := NewHandlerKit(t)
+	kit.Namespace = testkit.CurrentNamespace
+	kit.ExpectCreatedServiceAccount(kit.Namespace, testkit.ServiceAccountName)
+	kit.Finish()
+	defer kit.MockCtrl.Finish()
+
+	subject := rbac.Subject{Namespace: kit.Namespace, Kind: cage_k8s.KindServiceAccount, Name: kit.ServiceAccountName}
+
+	// The same account name in another namespace is a different subject.
+	otherSubject := rbac.Subject{Namespace: "other-ns", Kind: cage_k8s.KindServiceAccount, Name: kit.ServiceAccountName}
+
+	existingObj := &rbac.ClusterRoleBinding{
+		ObjectMeta: meta.ObjectMeta{Name: "bind-a"},
+		RoleRef:    rbac.RoleRef{Kind: cage_k8s.KindClusterRole, Name: "role-a"},
+		Subjects:   []rbac.Subject{otherSubject},
+	}
+	updatedObj := existingObj.DeepCopy()
+	updatedObj.Subjects = []rbac.Subject{otherSubject, subject}
+
+	kit.ApiClientset.ClusterRoles.EXPECT().
+		Get("role-a").
+		Return(cage_gomock.NonSut(), testkit.Exists, nil)
+	kit.ApiClientset.ClusterRoleBindings.EXPECT().
+		Create(testkit.ManagedMeta("", "bind-a"), "role-a", subject).
+		Return(nil, clusterRoleBindingExists("bind-a"))
+	kit.ApiClientset.ClusterRoleBindings.EXPECT().
+		Get("bind-a").
+		Return(existingObj, testkit.Exists, nil)
+	kit.ApiClientset.ClusterRoleBindings.EXPECT().
+		Update(updatedObj).
+		Return(cage_gomock.NonSut(), nil)
+
+	h := NewHandler(kit)
+	h.ClusterRoles = []string{"role-a:bind-a"}
+	h.Run(testkit.Ctx(), handler.Input{})
+}
+
+// TestErrOnClusterRoleBindingConflict asserts that the CLI exits with an error if an existing cluster
+// role binding refers to a different cluster role and --force is not used.
+func TestErrOnClusterRoleBindingConflict(t *testing.T) {
+	kit := NewHandlerKit(t)
+	kit.Namespace = testkit.CurrentNamespace
+	kit.ExpectCreatedServiceAccount(kit.Namespace, testkit.ServiceAccountName)
+	kit.ExitOnErr = regexp.MustCompile(`cluster role binding \[bind-a\] refers to ClusterRole \[other-role\], not ClusterRole \[role-a\] \(use --force`)
+	kit.UpsertToken = false
+	kit.UpsertContext = false
+	kit.SecretGet = false
+	kit.Finish()
+	defer kit.MockCtrl.Finish()
+
+	subject := rbac.Subject{Namespace: kit.Namespace, Kind: cage_k8s.KindServiceAccount, Name: kit.ServiceAccountName}
+
+	kit.ApiClientset.ClusterRoles.EXPECT().
+		Get("role-a").
+		Return(cage_gomock.NonSut(), testkit.Exists, nil)
+	kit.ApiClientset.ClusterRoleBindings.EXPECT().
+		Create(testkit.ManagedMeta("", "bind-a"), "role-a", subject).
+		Return(nil, clusterRoleBindingExists("bind-a"))
+	kit.ApiClientset.ClusterRoleBindings.EXPECT().
+		Get("bind-a").
+		Return(&rbac.ClusterRoleBinding{RoleRef: rbac.RoleRef{Kind: cage_k8s.KindClusterRole, Name: "other-role"}}, testkit.Exists, nil)
+
+	h := NewHandler(kit)
+	h.ClusterRoles = []string{"role-a:bind-a"}
+	h.Run(testkit.Ctx(), handler.Input{})
+}
+
+// TestForceReplaceClusterRoleBinding asserts that --force replaces an existing cluster role binding
+// which refers to a different cluster role.
+func TestForceReplaceClusterRoleBinding(t *testing.T) {
+	kit := NewHandlerKit(t)
+	kit.Namespace = testkit.CurrentNamespace
+	kit.ExpectCreatedServiceAccount(kit.Namespace, testkit.ServiceAccountName)
+	kit.Finish()
+	defer kit.MockCtrl.Finish()
+
+	subject := rbac.Subject{Namespace: kit.Namespace, Kind: cage_k8s.KindServiceAccount, Name: kit.ServiceAccountName}
+
+	kit.ApiClientset.ClusterRoles.EXPECT().
+		Get("role-a").
+		Return(cage_gomock.NonSut(), testkit.Exists, nil)
+	gomock.InOrder(
+		kit.ApiClientset.ClusterRoleBindings.EXPECT().
+			Create(testkit.ManagedMeta("", "bind-a"), "role-a", subject).
+			Return(nil, clusterRoleBindingExists("bind-a")),
+		kit.ApiClientset.ClusterRoleBindings.EXPECT().
+			Get("bind-a").
+			Return(&rbac.ClusterRoleBinding{RoleRef: rbac.RoleRef{Kind: cage_k8s.KindClusterRole, Name: "other-role"}}, testkit.Exists, nil),
+		kit.ApiClientset.ClusterRoleBindings.EXPECT().
+			Delete("bind-a").
+			Return(nil),
+		kit.ApiClientset.ClusterRoleBindings.EXPECT().
+			Create(testkit.ManagedMeta("", "bind-a"), "role-a", subject).
+			Return(cage_gomock.NonSut(), nil),
+	)
+
+	h := NewHandler(kit)
+	h.Force = true
+	h.ClusterRoles = []string{"role-a:bind-a"}
+	h.Run(testkit.Ctx(), handler.Input{})
+}
+
+// discoveredResources returns the resources served by the API in the --grant test cases.
+func discoveredResources() *cage_k8s_discovery.Resources {
+	return cage_k8s_discovery.NewResources([]*meta.APIResourceList{
+		{
+			GroupVersion: "v1",
+			APIResources: []meta.APIResource{
+				{Name: "pods", Namespaced: true, Verbs: meta.Verbs{"get", "list"}},
+				{Name: "pods/exec", Namespaced: true, Verbs: meta.Verbs{"create", "get"}},
+				{Name: "pods/log", Namespaced: true, Verbs: meta.Verbs{"get"}},
+				{Name: "nodes", Verbs: meta.Verbs{"get", "list"}},
+			},
+		},
+		{
+			GroupVersion: "apps/v1",
+			APIResources: []meta.APIResource{
+				{Name: "deployments", Namespaced: true, Verbs: meta.Verbs{"get", "update"}},
+			},
+		},
+	})
+}
+
+// roleExists returns the error from creating a role which already exists.
+func roleExists(name string) error {
+	return k8s_errors.NewAlreadyExists(schema.GroupResource{Group: rbac.GroupName, Resource: "roles"}, name)
+}
+
+// TestGrant asserts that --grant selections create a role for namespaced resources and a cluster role
+// for cluster-scoped ones, and bind the user to both.
+func TestGrant(t *testing.T) {
+	kit := NewHandlerKit(t)
+	kit.Namespace = testkit.CurrentNamespace
+	kit.ExpectCreatedServiceAccount(kit.Namespace, testkit.ServiceAccountName)
+	kit.Resources = discoveredResources()
+	kit.Finish()
+	defer kit.MockCtrl.Finish()
+
+	name := cli.GrantRolePrefix + testkit.Username
+
+	// expect: role created with the namespaced grants, including a subresource
+	kit.ApiClientset.Roles.EXPECT().
+		Create(gomock.Any()).
+		DoAndReturn(func(obj *rbac.Role) (*rbac.Role, error) {
+			require.True(t, testkit.ManagedMeta(kit.Namespace, name).Matches(obj.ObjectMeta))
+			require.Exactly(
+				t,
+				[]rbac.PolicyRule{
+					{Verbs: []string{"get", "list"}, APIGroups: []string{""}, Resources: []string{"pods"}},
+					{Verbs: []string{"get"}, APIGroups: []string{""}, Resources: []string{"pods/log"}},
+					{Verbs: []string{"update"}, APIGroups: []string{"apps"}, Resources: []string{"deployments"}, ResourceNames: []string{"web"}},
+				},
+				obj.Rules,
+			)
+			return obj, nil
+		})
+
+	// expect: cluster role created with the cluster-scoped grants
+	kit.ApiClientset.ClusterRoles.EXPECT().
+		Create(gomock.Any()).
+		DoAndReturn(func(obj *rbac.ClusterRole) (*rbac.ClusterRole, error) {
+			require.True(t, testkit.ManagedMeta("", name).Matches(obj.ObjectMeta))
+			require.Exactly(
+				t,
+				[]rbac.PolicyRule{{Verbs: []string{"get"}, APIGroups: []string{""}, Resources: []string{"nodes"}}},
+				obj.Rules,
+			)
+			return obj, nil
+		})
+
+	// expect: bindings created
+	kit.ApiClientset.RoleBindings.EXPECT().
+		Create(testkit.ManagedMeta(kit.Namespace, name), rbac.RoleRef{Kind: cage_k8s.KindRole, Name: name}, rbac.Subject{Kind: cage_k8s.KindServiceAccount, Name: kit.ServiceAccountName}).
+		Return(cage_gomock.NonSut(), nil)
+	kit.ApiClientset.ClusterRoleBindings.EXPECT().
+		Create(testkit.ManagedMeta("", name), name, rbac.Subject{Namespace: kit.Namespace, Kind: cage_k8s.KindServiceAccount, Name: kit.ServiceAccountName}).
+		Return(cage_gomock.NonSut(), nil)
+
+	h := NewHandler(kit)
+	h.Grants = []string{"get,list:pods", "get:pods/log", "get:nodes", "update:deployments.apps/web"}
+	h.Run(testkit.Ctx(), handler.Input{})
+}
+
+// TestErrOnInvalidGrant asserts that a --grant selection must use the expected format.
+func TestErrOnInvalidGrant(t *testing.T) {
+	kit := NewHandlerKit(t)
+	kit.Namespace = testkit.CurrentNamespace
+	kit.UpsertToken = false
+	kit.UpsertContext = false
+	kit.SecretGet = false
+	kit.ExitOnErr = regexp.MustCompile(`invalid --grant selections: .*grant \[pods\] does not use format`)
+	kit.Finish()
+	defer kit.MockCtrl.Finish()
+
+	h := NewHandler(kit)
+	h.ServiceAccountName = testkit.ServiceAccountName
+	h.Grants = []string{"pods"}
+	h.Run(testkit.Ctx(), handler.Input{})
+}
+
+// TestErrOnGrantResourceNotFound asserts that a --grant resource must be served by the selected group,
+// and that the error suggests the groups which serve it.
+func TestErrOnGrantResourceNotFound(t *testing.T) {
+	kit := NewHandlerKit(t)
+	kit.Namespace = testkit.CurrentNamespace
+	kit.UpsertToken = false
+	kit.UpsertContext = false
+	kit.SecretGet = false
+	kit.ExitOnErr = regexp.MustCompile(`resource \[deployments\] not found in API group \[\] \(served by API group\(s\) .*apps`)
+	kit.Resources = discoveredResources()
+	kit.Finish()
+	defer kit.MockCtrl.Finish()
+
+	h := NewHandler(kit)
+	h.ServiceAccountName = testkit.ServiceAccountName
+	h.Grants = []string{"get:deployments"}
+	h.Run(testkit.Ctx(), handler.Input{})
+}
+
+// TestErrOnGrantRoleConflict asserts that an existing --grant role with different rules is not modified
+// without --force.
+func TestErrOnGrantRoleConflict(t *testing.T) {
+	kit := NewHandlerKit(t)
+	kit.Namespace = testkit.CurrentNamespace
+	kit.ExpectCreatedServiceAccount(kit.Namespace, testkit.ServiceAccountName)
+	kit.UpsertToken = false
+	kit.UpsertContext = false
+	kit.SecretGet = false
+	kit.ExitOnErr = regexp.MustCompile(`role \[kubeauth-kubeauth-testkit-username\] in namespace \[kubeauth-testkit-current-namespace\] already exists with different rules \(use --force to replace them\)`)
+	kit.Resources = discoveredResources()
+	kit.Finish()
+	defer kit.MockCtrl.Finish()
+
+	name := cli.GrantRolePrefix + testkit.Username
+
+	gomock.InOrder(
+		kit.ApiClientset.Roles.EXPECT().
+			Create(gomock.Any()).
+			Return(nil, roleExists(name)),
+		kit.ApiClientset.Roles.EXPECT().
+			Get(kit.Namespace, name).
+			Return(&rbac.Role{Rules: []rbac.PolicyRule{{Verbs: []string{"*"}, APIGroups: []string{"*"}, Resources: []string{"*"}}}}, testkit.Exists, nil),
+	)
+
+	h := NewHandler(kit)
+	h.Grants = []string{"get:pods"}
+	h.Run(testkit.Ctx(), handler.Input{})
+}
+
+// TestForceReplaceGrantRole asserts that --force replaces the rules of an existing --grant role.
+func TestForceReplaceGrantRole(t *testing.T) {
+	kit := NewHandlerKit(t)
+	kit.Namespace = testkit.CurrentNamespace
+	kit.ExpectCreatedServiceAccount(kit.Namespace, testkit.ServiceAccountName)
+	kit.Resources = discoveredResources()
+	kit.Finish()
+	defer kit.MockCtrl.Finish()
+
+	name := cli.GrantRolePrefix + testkit.Username
+	rules := []rbac.PolicyRule{{Verbs: []string{"get"}, APIGroups: []string{""}, Resources: []string{"pods"}}}
+
+	gomock.InOrder(
+		kit.ApiClientset.Roles.EXPECT().
+			Create(gomock.Any()).
+			Return(nil, roleExists(name)),
+		kit.ApiClientset.Roles.EXPECT().
+			Get(kit.Namespace, name).
+			Return(&rbac.Role{ObjectMeta: meta.ObjectMeta{Namespace: kit.Namespace, Name: name}}, testkit.Exists, nil),
+		kit.ApiClientset.Roles.EXPECT().
+			Update(&rbac.Role{ObjectMeta: meta.ObjectMeta{Namespace: kit.Namespace, Name: name}, Rules: rules}).
+			Return(cage_gomock.NonSut(), nil),
+	)
+	kit.ApiClientset.RoleBindings.EXPECT().
+		Create(testkit.ManagedMeta(kit.Namespace, name), rbac.RoleRef{Kind: cage_k8s.KindRole, Name: name}, rbac.Subject{Kind: cage_k8s.KindServiceAccount, Name: kit.ServiceAccountName}).
+		Return(cage_gomock.NonSut(), nil)
+
+	h := NewHandler(kit)
+	h.Force = true
+	h.Grants = []string{"get:pods"}
+	h.Run(testkit.Ctx(), handler.Input{})
+}
+
+// TestWarnOnUnservedRoleRules asserts that rules of selected roles which the API does not serve are
+// reported as warnings without preventing the bindings.
+func TestWarnOnUnservedRoleRules(t *testing.T) {
+	stderr := &bytes.Buffer{}
+
+	kit := NewHandlerKit(t)
+	kit.Namespace = testkit.CurrentNamespace
+	kit.Stderr = stderr
+	kit.Resources = discoveredResources()
+	kit.ExpectCreatedServiceAccount(kit.Namespace, testkit.ServiceAccountName)
+	kit.Finish()
+	defer kit.MockCtrl.Finish()
+
+	kit.ApiClientset.Roles.EXPECT().
+		Get(kit.Namespace, "role-a").
+		Return(&rbac.Role{
+			ObjectMeta: meta.ObjectMeta{Namespace: kit.Namespace, Name: "role-a"},
+			Rules:      []rbac.PolicyRule{{Verbs: []string{"get"}, APIGroups: []string{"apps"}, Resources: []string{"deployment"}}},
+		}, testkit.Exists, nil)
+	kit.ApiClientset.ClusterRoles.EXPECT().
+		Get("role-b").
+		Return(&rbac.ClusterRole{
+			ObjectMeta: meta.ObjectMeta{Name: "role-b"},
+			Rules:      []rbac.PolicyRule{{Verbs: []string{"get", "delete"}, APIGroups: []string{""}, Resources: []string{"nodes"}}},
+		}, testkit.Exists, nil)
+	kit.ApiClientset.RoleBindings.EXPECT().
+		Create(testkit.ManagedMeta(kit.Namespace, "bind-a"), rbac.RoleRef{Kind: cage_k8s.KindRole, Name: "role-a"}, gomock.Any()).
+		Return(cage_gomock.NonSut(), nil)
+	kit.ApiClientset.ClusterRoleBindings.EXPECT().
+		Create(testkit.ManagedMeta("", "bind-b"), "role-b", gomock.Any()).
+		Return(cage_gomock.NonSut(), nil)
+
+	h := NewHandler(kit)
+	h.Roles = []string{"role-a:bind-a"}
+	h.ClusterRoles = []string{"role-b:bind-b"}
+	h.Run(testkit.Ctx(), handler.Input{})
+
+	require.Contains(t, stderr.String(), "kubeauth: warning: role [role-a] in namespace [kubeauth-testkit-current-namespace]: resource [deployment] is not served by API group [apps] (did you mean [deployments]?)\n")
+	require.Contains(t, stderr.String(), "kubeauth: warning: cluster role [role-b]: verb [delete] is not supported by resource [nodes] in API group []\n")
+}
+
+// TestErrOnEscalationRisk asserts that roles which grant escalation-prone permissions where they are
+// bound are not bound without --allow-escalation.
+func TestErrOnEscalationRisk(t *testing.T) {
+	kit := NewHandlerKit(t)
+	kit.Namespace = testkit.CurrentNamespace
+	kit.UpsertToken = false
+	kit.UpsertContext = false
+	kit.SecretGet = false
+	kit.ExitOnErr = regexp.MustCompile(
+		`escalation-prone permissions selected: .*` +
+			`cluster role \[role-a\] in namespace \[kubeauth-testkit-current-namespace\]: read secrets.*\(secrets-read\).*` +
+			`cluster role \[role-a\]: read secrets.*\(secrets-read\).*` +
+			`cluster role \[role-a\]: create workloads in kube-system.*\(system-workloads\).*` +
+			`--grant: exec or attach to pods.*\(pod-exec\).*` +
+			`\(use --allow-escalation to grant them\)`,
+	)
+	kit.Resources = discoveredResources()
+	kit.Finish()
+	defer kit.MockCtrl.Finish()
+
+	kit.ApiClientset.ClusterRoles.EXPECT().
+		Get("role-a").
+		Return(&rbac.ClusterRole{
+			ObjectMeta: meta.ObjectMeta{Name: "role-a"},
+			Rules: []rbac.PolicyRule{
+				{Verbs: []string{"get"}, APIGroups: []string{""}, Resources: []string{"secrets"}},
+				{Verbs: []string{"create"}, APIGroups: []string{"apps"}, Resources: []string{"deployments"}},
+			},
+		}, testkit.Exists, nil).
+		Times(2)
+
+	h := NewHandler(kit)
+	h.ServiceAccountName = testkit.ServiceAccountName
+	h.NamespacedClusterRoles = []string{"role-a:bind-a"}
+	h.ClusterRoles = []string{"role-a:bind-b"}
+	h.Grants = []string{"create:pods/exec"}
+	h.Run(testkit.Ctx(), handler.Input{})
+}
+
+// TestAllowEscalation asserts that --allow-escalation binds roles which grant escalation-prone
+// permissions and reports them as warnings.
+func TestAllowEscalation(t *testing.T) {
+	stderr := &bytes.Buffer{}
+
+	kit := NewHandlerKit(t)
+	kit.Namespace = testkit.CurrentNamespace
+	kit.Stderr = stderr
+	kit.ExpectCreatedServiceAccount(kit.Namespace, testkit.ServiceAccountName)
+	kit.Finish()
+	defer kit.MockCtrl.Finish()
+
+	kit.ApiClientset.Roles.EXPECT().
+		Get(kit.Namespace, "role-a").
+		Return(&rbac.Role{
+			ObjectMeta: meta.ObjectMeta{Namespace: kit.Namespace, Name: "role-a"},
+			Rules:      []rbac.PolicyRule{{Verbs: []string{"*"}, APIGroups: []string{rbac.GroupName}, Resources: []string{"roles"}}},
+		}, testkit.Exists, nil)
+	kit.ApiClientset.RoleBindings.EXPECT().
+		Create(testkit.ManagedMeta(kit.Namespace, "bind-a"), rbac.RoleRef{Kind: cage_k8s.KindRole, Name: "role-a"}, gomock.Any()).
+		Return(cage_gomock.NonSut(), nil)
+
+	h := NewHandler(kit)
+	h.AllowEscalation = true
+	h.Roles = []string{"role-a:bind-a"}
+	h.Run(testkit.Ctx(), handler.Input{})
+
+	require.Contains(t, stderr.String(), "kubeauth: warning: escalation-prone permissions granted by role [role-a] in namespace [kubeauth-testkit-current-namespace]: escalate verb on roles")
+	require.Contains(t, stderr.String(), "kubeauth: warning: escalation-prone permissions granted by role [role-a] in namespace [kubeauth-testkit-current-namespace]: bind verb on roles")
+}
+
+// TestErrOnPrivilegedGroup asserts that a cert user cannot join a group which the API server
+// grants escalation-prone permissions, e.g. system:masters, without --allow-escalation.
+func TestErrOnPrivilegedGroup(t *testing.T) {
+	kit := NewHandlerKit(t)
+	kit.Namespace = testkit.CurrentNamespace
+	kit.ExitOnErr = regexp.MustCompile(
+		`escalation-prone permissions selected: .*` +
+			`--groups \[system:masters\]: all permissions.*\(privileged-group\).*` +
+			`\(use --allow-escalation to grant them\)`,
+	)
+	kit.UpsertToken = false
+	kit.UpsertContext = false
+	kit.SecretGet = false
+	kit.Finish()
+	defer kit.MockCtrl.Finish()
+
+	h := NewHandler(kit)
+	h.Auth = cli.AuthCert
+	h.ApproveCert = true
+	h.Groups = []string{"group-a", "system:masters"}
+	h.Run(testkit.Ctx(), handler.Input{})
+}
+
+// TestAllowPrivilegedGroup asserts that --allow-escalation requests a privileged group and
+// reports it as a warning.
+func TestAllowPrivilegedGroup(t *testing.T) {
+	stderr := &bytes.Buffer{}
+	groups := []string{"system:masters"}
+
+	kit := NewHandlerKit(t)
+	kit.Namespace = testkit.CurrentNamespace
+	kit.Stderr = stderr
+	kit.ExpectCertUser(testkit.Username, groups, true, nil)
+	kit.UpsertToken = false
+	kit.UpsertCert = true
+	kit.SecretGet = false
+	kit.Finish()
+	defer kit.MockCtrl.Finish()
+
+	h := NewHandler(kit)
+	h.AllowEscalation = true
+	h.Auth = cli.AuthCert
+	h.ApproveCert = true
+	h.Groups = groups
+	h.Run(testkit.Ctx(), handler.Input{})
+
+	require.Contains(t, stderr.String(), "kubeauth: warning: escalation-prone permissions granted by --groups [system:masters]: all permissions")
+}
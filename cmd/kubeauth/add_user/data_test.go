@@ -2,6 +2,8 @@ package add_user_test
 
 import "github.com/codeactual/kubeauth/internal/testkit"
 
+const CsrName = testkit.Prefix + "-csr"
+
 func SecretName(prefix string) string {
 	return prefix + testkit.SecretNameSuffix
 }
@@ -13,3 +15,7 @@ func CertData() []byte {
 func TokenData() []byte {
 	return []byte(testkit.Prefix + "-token-data")
 }
+
+func IssuedCertData() []byte {
+	return []byte(testkit.Prefix + "-issued-cert-data")
+}
@@ -7,11 +7,22 @@
 package add_user_test
 
 import (
+	"context"
+	"crypto/x509"
+	"encoding/pem"
+	"strings"
 	"testing"
 
 	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+	certs "k8s.io/api/certificates/v1beta1"
 	core "k8s.io/api/core/v1"
+	meta "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/util/keyutil"
 
+	cage_k8s_csr "github.com/codeactual/kubeauth/internal/cage/kubernetes/v1/certificates/certificate_signing_request"
+	cage_k8s_discovery "github.com/codeactual/kubeauth/internal/cage/kubernetes/v1/discovery"
+	cage_k8s_config "github.com/codeactual/kubeauth/internal/cage/kubernetes/v1/kubectl/config"
 	cage_gomock "github.com/codeactual/kubeauth/internal/cage/testkit/gomock"
 	"github.com/codeactual/kubeauth/internal/testkit"
 )
@@ -23,22 +34,38 @@ import (
 type HandlerKit struct {
 	*testkit.HandlerKit
 
+	T *testing.T
+
 	// UpsertContext is true if ConfigureMocks should include the context creation/update call.
 	UpsertContext bool
 
 	// UpsertContext is true if ConfigureMocks should include the token creation/update call.
 	UpsertToken bool
 
+	// UpsertCert is true if ConfigureMocks should include the certificate creation/update call.
+	UpsertCert bool
+
 	// SecretGet is true if ConfigureMocks should include the secret creation call.
 	SecretGet bool
 
+	// NamespaceNotFound is true if ConfigureMocks should report the effective namespace as missing
+	// instead of allowing any number of calls which find it.
+	NamespaceNotFound bool
+
+	// Offline is true if ConfigureMocks should not expect the config to be parsed, as with --offline.
+	Offline bool
+
 	// ServiceAccountName is the expected effective value after flag/default processing is complete.
 	ServiceAccountName string
+
+	// Resources is returned by any number of API discovery calls. If nil, no resources are discovered.
+	Resources *cage_k8s_discovery.Resources
 }
 
 func NewHandlerKit(t *testing.T) *HandlerKit {
 	return &HandlerKit{
 		HandlerKit:    testkit.NewHandlerKit(t),
+		T:             t,
 		SecretGet:     true,
 		UpsertContext: true,
 		UpsertToken:   true,
@@ -62,9 +89,31 @@ func (k *HandlerKit) Finish() {
 		namespace = testkit.CurrentNamespace
 	}
 
-	k.ConfigClient.EXPECT().
-		Parse("").
-		Return(testkit.NewConfigFile(testkit.ConfigFilename, context, cluster, namespace), nil)
+	if !k.Offline {
+		k.ConfigClient.EXPECT().
+			Parse("").
+			Return(testkit.NewConfigFile(testkit.ConfigFilename, context, cluster, namespace), nil)
+	}
+
+	if k.NamespaceNotFound {
+		k.ApiClientset.Namespaces.EXPECT().
+			Get(namespace).
+			Return(nil, testkit.NotExists, nil)
+	} else {
+		k.ApiClientset.Namespaces.EXPECT().
+			Get(namespace).
+			Return(&core.Namespace{ObjectMeta: meta.ObjectMeta{Name: namespace}}, testkit.Exists, nil).
+			AnyTimes()
+	}
+
+	resources := k.Resources
+	if resources == nil {
+		resources = cage_k8s_discovery.NewResources(nil)
+	}
+	k.ApiClientset.Discovery.EXPECT().
+		Resources().
+		Return(resources, nil).
+		AnyTimes()
 
 	if k.UpsertToken {
 		k.ConfigClient.EXPECT().
@@ -72,6 +121,10 @@ func (k *HandlerKit) Finish() {
 			Return(nil)
 	}
 
+	if k.UpsertCert {
+		k.expectUpsertCert()
+	}
+
 	if k.UpsertContext {
 		k.ConfigClient.EXPECT().
 			UpsertContext(testkit.Ctx(), gomock.Any(), testkit.Username, cluster, namespace, testkit.Username).
@@ -95,6 +148,63 @@ func (k *HandlerKit) Finish() {
 	}
 }
 
+// expectUpsertCert configures the kit to expect the issued certificate and a valid key to be
+// written to the config.
+func (k *HandlerKit) expectUpsertCert() {
+	k.ConfigClient.EXPECT().
+		UpsertUserCert(testkit.Ctx(), gomock.Any(), testkit.Username, IssuedCertData(), gomock.Any()).
+		DoAndReturn(func(_ context.Context, _ *cage_k8s_config.File, _ string, _, key []byte) error {
+			_, err := keyutil.ParsePrivateKeyPEM(key)
+			require.NoError(k.T, err)
+			return nil
+		})
+}
+
+// ExpectCertUser immediately configures the kit to expect a cert user's certificate signing request
+// to be created, optionally approved, and then issued.
+//
+// If approveErr is non-nil, it is returned by the approval call, and the kit expects the
+// request to be approved by another party.
+func (k *HandlerKit) ExpectCertUser(commonName string, groups []string, approve bool, approveErr error) {
+	createdObj := &certs.CertificateSigningRequest{ObjectMeta: meta.ObjectMeta{Name: CsrName}}
+	approvedObj := createdObj.DeepCopy()
+	approvedObj.Status.Conditions = []certs.CertificateSigningRequestCondition{{Type: certs.CertificateApproved}}
+	issuedObj := approvedObj.DeepCopy()
+	issuedObj.Status.Certificate = IssuedCertData()
+
+	calls := []*gomock.Call{
+		k.ApiClientset.CertificateSigningRequests.EXPECT().
+			Create(gomock.Any(), gomock.Any(), cage_k8s_csr.ClientAuthUsages).
+			DoAndReturn(func(name string, request []byte, _ ...certs.KeyUsage) (*certs.CertificateSigningRequest, error) {
+				block, _ := pem.Decode(request)
+				require.NotNil(k.T, block)
+				parsed, err := x509.ParseCertificateRequest(block.Bytes)
+				require.NoError(k.T, err)
+				require.Exactly(k.T, commonName, parsed.Subject.CommonName)
+				require.Exactly(k.T, groups, parsed.Subject.Organization)
+				require.True(k.T, strings.HasPrefix(name, "kubeauth-"), name)
+				return createdObj, nil
+			}),
+	}
+
+	if approve {
+		approveCall := k.ApiClientset.CertificateSigningRequests.EXPECT().Approve(createdObj, gomock.Any(), gomock.Any())
+		if approveErr == nil {
+			approveCall.Return(approvedObj, nil)
+		} else {
+			approveCall.Return(nil, approveErr)
+		}
+		calls = append(calls, approveCall)
+	}
+
+	calls = append(
+		calls,
+		k.ApiClientset.CertificateSigningRequests.EXPECT().Get(CsrName).Return(issuedObj, testkit.Exists, nil),
+	)
+
+	gomock.InOrder(calls...)
+}
+
 // ExistingServiceAccount immediately configures the kit to expect the service account
 // and its secret's name already exist.
 func (k *HandlerKit) ExpectExistingServiceAccount() {
@@ -117,7 +227,7 @@ func (k *HandlerKit) ExpectCreatedServiceAccount(namespace, name string) {
 			Get(namespace, name).
 			Return(createdObj, testkit.NotExists, nil),
 		k.ApiClientset.ServiceAccounts.EXPECT().
-			CreateBasic(namespace, name).
+			Create(testkit.ManagedMeta(namespace, name)).
 			Return(createdObj, nil),
 	)
 
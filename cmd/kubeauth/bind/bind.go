@@ -0,0 +1,341 @@
+// Copyright (C) 2020 The kubeauth Authors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package bind
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+	rbac "k8s.io/api/rbac/v1"
+	"k8s.io/client-go/kubernetes"
+
+	"github.com/codeactual/kubeauth/internal/cage/cli/handler"
+	handler_cobra "github.com/codeactual/kubeauth/internal/cage/cli/handler/cobra"
+	cage_k8s "github.com/codeactual/kubeauth/internal/cage/kubernetes/v1"
+	cage_k8s_core "github.com/codeactual/kubeauth/internal/cage/kubernetes/v1/core"
+	cage_k8s_config "github.com/codeactual/kubeauth/internal/cage/kubernetes/v1/kubectl/config"
+	cage_k8s_rbac "github.com/codeactual/kubeauth/internal/cage/kubernetes/v1/rbac"
+	cage_reflect "github.com/codeactual/kubeauth/internal/cage/reflect"
+	"github.com/codeactual/kubeauth/internal/ownership"
+)
+
+// Handler defines the sub-command flags and logic.
+type Handler struct {
+	handler.Session
+
+	KubeApiClientset    *cage_k8s_core.Clientset
+	KubectlConfigClient cage_k8s_config.Client
+
+	Annotations     []string `usage:"annotation to add to created bindings (<key>=<value>)"`
+	ClusterRoles    []string `usage:"cluster role binding to create/update (<role name>:<binding name>)"`
+	ConfigFile      string   `usage:"kubectl config file to use for API requests"`
+	Groups          []string `usage:"group to bind"`
+	Labels          []string `usage:"label to add to created bindings (<key>=<value>)"`
+	Namespace       string   `usage:"namespace of role bindings whose selectors omit one (default from current-context)"`
+	Roles           []string `usage:"role binding to create/update ([<namespace>/]<role name>:<binding name>)"`
+	ServiceAccounts []string `usage:"service account to bind (<namespace>:<name>)"`
+	Users           []string `usage:"user to bind"`
+
+	// Verbosity levels greater than 0 will enable status messages and error stack traces.
+	//
+	// It is an int for consistency with other commands, even though levels beyond 1 are not used.
+	Verbosity int `usage:"kubectl verbosity level"`
+}
+
+// Init defines the command, its environment variable prefix, etc.
+//
+// It implements cli/handler/cobra.Handler.
+func (h *Handler) Init() handler_cobra.Init {
+	return handler_cobra.Init{
+		Cmd: &cobra.Command{
+			Use:   "bind",
+			Short: "Bind users, groups, and service accounts to roles and cluster roles",
+		},
+		EnvPrefix: "KUBEAUTH",
+	}
+}
+
+// BindFlags binds the flags to Handler fields.
+//
+// It implements cli/handler/cobra.Handler.
+func (h *Handler) BindFlags(cmd *cobra.Command) []string {
+	cmd.Flags().StringSliceVarP(&h.Annotations, "annotation", "", []string{}, cage_reflect.GetFieldTag(*h, "Annotations", "usage"))
+	cmd.Flags().StringSliceVarP(&h.ClusterRoles, "cluster-role", "", []string{}, cage_reflect.GetFieldTag(*h, "ClusterRoles", "usage"))
+	cmd.Flags().StringVarP(&h.ConfigFile, "kubeconfig", "", "", cage_reflect.GetFieldTag(*h, "ConfigFile", "usage"))
+	cmd.Flags().StringSliceVarP(&h.Groups, "group", "", []string{}, cage_reflect.GetFieldTag(*h, "Groups", "usage"))
+	cmd.Flags().StringSliceVarP(&h.Labels, "label", "l", []string{}, cage_reflect.GetFieldTag(*h, "Labels", "usage"))
+	cmd.Flags().StringVarP(&h.Namespace, "namespace", "n", "", cage_reflect.GetFieldTag(*h, "Namespace", "usage"))
+	cmd.Flags().StringSliceVarP(&h.Roles, "role", "", []string{}, cage_reflect.GetFieldTag(*h, "Roles", "usage"))
+	cmd.Flags().StringSliceVarP(&h.ServiceAccounts, "serviceaccount", "", []string{}, cage_reflect.GetFieldTag(*h, "ServiceAccounts", "usage"))
+	cmd.Flags().StringSliceVarP(&h.Users, "user", "", []string{}, cage_reflect.GetFieldTag(*h, "Users", "usage"))
+	cmd.Flags().IntVarP(&h.Verbosity, "v", "v", 0, cage_reflect.GetFieldTag(*h, "Verbosity", "usage"))
+	return []string{}
+}
+
+// Run performs the sub-command logic.
+//
+// It implements cli/handler/cobra.Handler.
+func (h *Handler) Run(ctx context.Context, input handler.Input) {
+	if err := h.run(ctx, input); err != nil {
+		if h.Verbosity > 0 {
+			h.ExitOnErr(err, "", 1)
+		} else {
+			h.ExitOnErrShort(err, "", 1)
+		}
+	}
+}
+
+func (h *Handler) run(ctx context.Context, _ handler.Input) error {
+	stderr := h.Err()
+	verbose := func(format string, vArgs ...interface{}) {
+		if h.Verbosity > 0 {
+			fmt.Fprintln(stderr, "kubeauth: "+fmt.Sprintf(format, vArgs...))
+		}
+	}
+
+	var roleBindings, clusterRoleBindings []*cage_k8s_rbac.BindingSelector
+	var subjects []rbac.Subject
+
+	// Create clients.
+
+	configClient := h.KubectlConfigClient
+	if configClient == nil {
+		configClient = cage_k8s_config.NewDefaultClient()
+	}
+
+	configFile, err := configClient.Parse(h.ConfigFile)
+	if err != nil {
+		return errors.Wrap(err, "kubeauth") // WithStack alternative to disambiguate from kubectl output
+	}
+
+	apiClientset := h.KubeApiClientset
+	if apiClientset == nil {
+		rawApiClientset, err := kubernetes.NewForConfig(configFile.RestConfig)
+		if err != nil {
+			return errors.Wrap(err, "kubeauth: failed to create API client")
+		}
+
+		apiClientset = cage_k8s_core.NewClientset(rawApiClientset)
+	}
+
+	roleClient := apiClientset.Roles
+	clusterRoleClient := apiClientset.ClusterRoles
+	roleBindingClient := apiClientset.RoleBindings
+	clusterRoleBindingClient := apiClientset.ClusterRoleBindings
+
+	// Validate inputs.
+
+	_, curContext, err := configFile.GetCurrentContext()
+	if err != nil {
+		return errors.Wrap(err, "kubeauth") // WithStack alternative to disambiguate from kubectl output
+	}
+
+	// - Mirror the behavior of kubectl regarding --namespace and the current context.
+	if h.Namespace == "" {
+		h.Namespace = curContext.Namespace
+	}
+
+	// - Mark created bindings as managed, like those created by add-user, with any selected metadata.
+	//   They are not created for a kubeconfig user, so they are not subject to gc.
+	owner := ownership.Owner{Creator: curContext.AuthInfo, CreatedAt: time.Now()}
+	if owner.Labels, err = ownership.ParseLabels(h.Labels); err != nil {
+		return errors.Wrap(err, "kubeauth: invalid --label selections")
+	}
+	if owner.Annotations, err = ownership.ParseAnnotations(h.Annotations); err != nil {
+		return errors.Wrap(err, "kubeauth: invalid --annotation selections")
+	}
+
+	for _, u := range h.Users {
+		subjects = append(subjects, cage_k8s_rbac.NewUserSubject(u))
+	}
+	for _, g := range h.Groups {
+		subjects = append(subjects, cage_k8s_rbac.NewGroupSubject(g))
+	}
+	if len(h.ServiceAccounts) > 0 {
+		var invalid []string
+		for _, s := range h.ServiceAccounts {
+			subject, err := cage_k8s_rbac.ParseServiceAccountSubject(s)
+			if err != nil {
+				invalid = append(invalid, err.Error())
+				continue
+			}
+			subjects = append(subjects, subject)
+		}
+		if len(invalid) > 0 {
+			return errors.Errorf("kubeauth: invalid --serviceaccount selectors: %q", invalid)
+		}
+	}
+	if len(subjects) == 0 {
+		return errors.New("kubeauth: at least one --user, --group, or --serviceaccount is required")
+	}
+
+	// Omit subjects repeated by the flags.
+	subjects, _ = cage_k8s_rbac.MergeSubjects(nil, subjects...)
+
+	if len(h.Roles) == 0 && len(h.ClusterRoles) == 0 {
+		return errors.New("kubeauth: at least one --role or --cluster-role is required")
+	}
+
+	if len(h.Roles) > 0 {
+		var invalid []string
+		for _, r := range h.Roles {
+			binding, err := cage_k8s_rbac.NewBindingSelector(r)
+			if err != nil {
+				invalid = append(invalid, err.Error())
+				continue
+			}
+			if binding.Namespace == "" {
+				binding.Namespace = h.Namespace
+			}
+			roleBindings = append(roleBindings, binding)
+		}
+		if len(invalid) > 0 {
+			return errors.Errorf("kubeauth: invalid --role selectors: %q", invalid)
+		}
+
+		invalid = []string{}
+		for _, b := range roleBindings {
+			_, exists, err := roleClient.Get(b.Namespace, b.RoleName)
+			if err != nil {
+				return errors.Wrap(err, "kubeauth") // WithStack alternative to disambiguate from kubectl output
+			}
+
+			if !exists {
+				invalid = append(invalid, b.Namespace+"/"+b.RoleName)
+			}
+		}
+		if len(invalid) > 0 {
+			return errors.Errorf("kubeauth: role(s) not found: %q", invalid)
+		}
+	}
+
+	if len(h.ClusterRoles) > 0 {
+		var invalid []string
+		for _, r := range h.ClusterRoles {
+			binding, err := cage_k8s_rbac.NewBindingSelector(r)
+			if err != nil {
+				invalid = append(invalid, err.Error())
+				continue
+			}
+			if binding.Namespace != "" {
+				invalid = append(invalid, fmt.Sprintf("selector [%s] selects a cluster-scoped binding which does not accept a namespace", r))
+				continue
+			}
+			clusterRoleBindings = append(clusterRoleBindings, binding)
+		}
+		if len(invalid) > 0 {
+			return errors.Errorf("kubeauth: invalid --cluster-role selectors: %q", invalid)
+		}
+
+		invalid = []string{}
+		for _, b := range clusterRoleBindings {
+			_, exists, err := clusterRoleClient.Get(b.RoleName)
+			if err != nil {
+				return errors.Wrap(err, "kubeauth") // WithStack alternative to disambiguate from kubectl output
+			}
+
+			if !exists {
+				invalid = append(invalid, b.RoleName)
+			}
+		}
+		if len(invalid) > 0 {
+			return errors.Errorf("kubeauth: cluster role(s) not found: %q", invalid)
+		}
+	}
+
+	// Create the bindings, or add the subjects to existing ones.
+
+	for _, b := range roleBindings {
+		obj, exists, err := roleBindingClient.Get(b.Namespace, b.BindingName)
+		if err != nil {
+			return errors.Wrap(err, "kubeauth") // WithStack alternative to disambiguate from kubectl output
+		}
+
+		if !exists {
+			if _, err = roleBindingClient.Create(owner.ObjectMeta(b.Namespace, b.BindingName), rbac.RoleRef{Kind: cage_k8s.KindRole, Name: b.RoleName}, subjects...); err != nil {
+				return errors.Wrap(err, "kubeauth") // WithStack alternative to disambiguate from kubectl output
+			}
+			verbose("created role binding [%s] in namespace [%s]", b.BindingName, b.Namespace)
+			continue
+		}
+
+		// The reference is immutable, so the binding cannot be corrected by an update.
+		if obj.RoleRef.Kind != cage_k8s.KindRole || obj.RoleRef.Name != b.RoleName {
+			return errors.Errorf(
+				"kubeauth: role binding [%s] in namespace [%s] refers to %s [%s], not %s [%s]",
+				b.BindingName, b.Namespace, obj.RoleRef.Kind, obj.RoleRef.Name, cage_k8s.KindRole, b.RoleName,
+			)
+		}
+
+		// Compare service accounts without a namespace as the API server interprets them.
+		_, added := cage_k8s_rbac.MergeSubjects(
+			cage_k8s_rbac.WithBindingNamespace(obj.Subjects, b.Namespace),
+			cage_k8s_rbac.WithBindingNamespace(subjects, b.Namespace)...,
+		)
+		if len(added) == 0 {
+			verbose("role binding [%s] in namespace [%s] already includes the subjects", b.BindingName, b.Namespace)
+			continue
+		}
+
+		updated := obj.DeepCopy()
+		updated.Subjects = append(updated.Subjects, added...)
+		if _, err = roleBindingClient.Update(updated); err != nil {
+			return errors.Wrap(err, "kubeauth") // WithStack alternative to disambiguate from kubectl output
+		}
+		verbose("added subjects %s to role binding [%s] in namespace [%s]", cage_k8s_rbac.SubjectsString(added), b.BindingName, b.Namespace)
+	}
+
+	for _, b := range clusterRoleBindings {
+		obj, exists, err := clusterRoleBindingClient.Get(b.BindingName)
+		if err != nil {
+			return errors.Wrap(err, "kubeauth") // WithStack alternative to disambiguate from kubectl output
+		}
+
+		if !exists {
+			if _, err = clusterRoleBindingClient.Create(owner.ObjectMeta("", b.BindingName), b.RoleName, subjects...); err != nil {
+				return errors.Wrap(err, "kubeauth") // WithStack alternative to disambiguate from kubectl output
+			}
+			verbose("created cluster role binding [%s]", b.BindingName)
+			continue
+		}
+
+		// The reference is immutable, so the binding cannot be corrected by an update.
+		if obj.RoleRef.Kind != cage_k8s.KindClusterRole || obj.RoleRef.Name != b.RoleName {
+			return errors.Errorf(
+				"kubeauth: cluster role binding [%s] refers to %s [%s], not %s [%s]",
+				b.BindingName, obj.RoleRef.Kind, obj.RoleRef.Name, cage_k8s.KindClusterRole, b.RoleName,
+			)
+		}
+
+		merged, added := cage_k8s_rbac.MergeSubjects(obj.Subjects, subjects...)
+		if len(added) == 0 {
+			verbose("cluster role binding [%s] already includes the subjects", b.BindingName)
+			continue
+		}
+
+		updated := obj.DeepCopy()
+		updated.Subjects = merged
+		if _, err = clusterRoleBindingClient.Update(updated); err != nil {
+			return errors.Wrap(err, "kubeauth") // WithStack alternative to disambiguate from kubectl output
+		}
+		verbose("added subjects %s to cluster role binding [%s]", cage_k8s_rbac.SubjectsString(added), b.BindingName)
+	}
+
+	return nil
+}
+
+// New returns a cobra command instance based on Handler.
+func NewCommand() *cobra.Command {
+	return handler_cobra.NewHandler(&Handler{
+		Session: &handler.DefaultSession{},
+	})
+}
+
+var _ handler_cobra.Handler = (*Handler)(nil)
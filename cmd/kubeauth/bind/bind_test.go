@@ -0,0 +1,343 @@
+// Copyright (C) 2020 The kubeauth Authors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at https://mozilla.org/MPL/2.0/.
+
+// Package bind_test asserts CLI behavior by running the command handler logic
+// directly (w/o separate processes) with various input scenarios.
+//
+// It uses Handler instances that use mock implementations of the clients used
+// to read kubeconfig files and perform API requests. The tests only verify correct
+// use of the client interfaces. Tests in the cage_k8s package tree verify
+// lower-level client behaviors.
+package bind_test
+
+import (
+	"regexp"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	rbac "k8s.io/api/rbac/v1"
+	meta "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	cli "github.com/codeactual/kubeauth/cmd/kubeauth/bind"
+	"github.com/codeactual/kubeauth/internal/cage/cli/handler"
+	cage_k8s "github.com/codeactual/kubeauth/internal/cage/kubernetes/v1"
+	cage_k8s_rbac "github.com/codeactual/kubeauth/internal/cage/kubernetes/v1/rbac"
+	cage_gomock "github.com/codeactual/kubeauth/internal/cage/testkit/gomock"
+	"github.com/codeactual/kubeauth/internal/ownership"
+	"github.com/codeactual/kubeauth/internal/testkit"
+)
+
+func NewHandler(kit *HandlerKit) *cli.Handler {
+	h := cli.Handler{
+		Session:             kit.Session,
+		KubectlConfigClient: kit.ConfigClient,
+		KubeApiClientset:    kit.ApiClientset.ToReal(),
+	}
+
+	// Enable for test troubleshooting and verbose output assertions.
+	h.Verbosity = 1
+
+	return &h
+}
+
+// allSubjects returns the subjects selected by setAllSubjects.
+func allSubjects() []rbac.Subject {
+	return []rbac.Subject{
+		cage_k8s_rbac.NewUserSubject("user-a"),
+		cage_k8s_rbac.NewUserSubject("user-b"),
+		cage_k8s_rbac.NewGroupSubject("group-a"),
+		cage_k8s_rbac.NewServiceAccountSubject("ns-a", "sa-a"),
+	}
+}
+
+// setAllSubjects selects a subject of each kind.
+func setAllSubjects(h *cli.Handler) {
+	h.Users = []string{"user-a", "user-b"}
+	h.Groups = []string{"group-a"}
+	h.ServiceAccounts = []string{"ns-a:sa-a"}
+}
+
+// TestCreateBindings asserts that missing bindings are created with all selected subjects.
+func TestCreateBindings(t *testing.T) {
+	kit := NewHandlerKit(t)
+	kit.Finish()
+	defer kit.MockCtrl.Finish()
+
+	kit.ExpectRole(testkit.RoleName)
+	kit.ExpectClusterRole(testkit.ClusterRoleName)
+
+	kit.ApiClientset.RoleBindings.EXPECT().
+		Get(testkit.CurrentNamespace, testkit.RoleBindName).
+		Return(nil, testkit.NotExists, nil)
+	kit.ApiClientset.RoleBindings.EXPECT().
+		Create(testkit.UnownedManagedMeta(testkit.CurrentNamespace, testkit.RoleBindName), rbac.RoleRef{Kind: cage_k8s.KindRole, Name: testkit.RoleName}, allSubjects()[0], allSubjects()[1], allSubjects()[2], allSubjects()[3]).
+		Return(cage_gomock.NonSut(), nil)
+
+	kit.ApiClientset.ClusterRoleBindings.EXPECT().
+		Get(testkit.ClusterRoleBindName).
+		Return(nil, testkit.NotExists, nil)
+	kit.ApiClientset.ClusterRoleBindings.EXPECT().
+		Create(testkit.UnownedManagedMeta("", testkit.ClusterRoleBindName), testkit.ClusterRoleName, allSubjects()[0], allSubjects()[1], allSubjects()[2], allSubjects()[3]).
+		Return(cage_gomock.NonSut(), nil)
+
+	h := NewHandler(kit)
+	setAllSubjects(h)
+	h.Roles = []string{testkit.RoleName + ":" + testkit.RoleBindName}
+	h.ClusterRoles = []string{testkit.ClusterRoleName + ":" + testkit.ClusterRoleBindName}
+	h.Run(testkit.Ctx(), handler.Input{})
+}
+
+// TestCreateNamespacedRoleBinding asserts that a --role selector which includes a namespace
+// is applied in that namespace.
+func TestCreateNamespacedRoleBinding(t *testing.T) {
+	kit := NewHandlerKit(t)
+	kit.Finish()
+	defer kit.MockCtrl.Finish()
+
+	kit.ApiClientset.Roles.EXPECT().
+		Get("ns-a", testkit.RoleName).
+		Return(cage_gomock.NonSut(), testkit.Exists, nil)
+	kit.ApiClientset.RoleBindings.EXPECT().
+		Get("ns-a", testkit.RoleBindName).
+		Return(nil, testkit.NotExists, nil)
+	kit.ApiClientset.RoleBindings.EXPECT().
+		Create(testkit.UnownedManagedMeta("ns-a", testkit.RoleBindName), rbac.RoleRef{Kind: cage_k8s.KindRole, Name: testkit.RoleName}, cage_k8s_rbac.NewUserSubject("user-a")).
+		Return(cage_gomock.NonSut(), nil)
+
+	h := NewHandler(kit)
+	h.Users = []string{"user-a"}
+	h.Roles = []string{"ns-a/" + testkit.RoleName + ":" + testkit.RoleBindName}
+	h.Run(testkit.Ctx(), handler.Input{})
+}
+
+// TestCreateWithMetadata asserts that created bindings receive the selected labels and annotations,
+// and each subject once even if it is selected repeatedly.
+func TestCreateWithMetadata(t *testing.T) {
+	kit := NewHandlerKit(t)
+	kit.Finish()
+	defer kit.MockCtrl.Finish()
+
+	kit.ExpectRole(testkit.RoleName)
+
+	kit.ApiClientset.RoleBindings.EXPECT().
+		Get(testkit.CurrentNamespace, testkit.RoleBindName).
+		Return(nil, testkit.NotExists, nil)
+	kit.ApiClientset.RoleBindings.EXPECT().
+		Create(testkit.UnownedManagedMeta(testkit.CurrentNamespace, testkit.RoleBindName), rbac.RoleRef{Kind: cage_k8s.KindRole, Name: testkit.RoleName}, allSubjects()[0], allSubjects()[3]).
+		DoAndReturn(func(objMeta meta.ObjectMeta, _ rbac.RoleRef, _ ...rbac.Subject) (*rbac.RoleBinding, error) {
+			require.Exactly(t, "a", objMeta.Labels["team"])
+			require.Exactly(t, "b", objMeta.Annotations["note"])
+			return &rbac.RoleBinding{}, nil
+		})
+
+	h := NewHandler(kit)
+	h.Users = []string{"user-a", "user-a"}
+	h.ServiceAccounts = []string{"ns-a:sa-a", "ns-a:sa-a"}
+	h.Labels = []string{"team=a"}
+	h.Annotations = []string{"note=b"}
+	h.Roles = []string{testkit.RoleName + ":" + testkit.RoleBindName}
+	h.Run(testkit.Ctx(), handler.Input{})
+}
+
+// TestErrOnReservedLabel asserts that the CLI exits with an error if a --label would replace the
+// managed-by label.
+func TestErrOnReservedLabel(t *testing.T) {
+	kit := NewHandlerKit(t)
+	kit.ExitOnErr = regexp.MustCompile(`invalid --label selections.*is reserved`)
+	kit.Finish()
+	defer kit.MockCtrl.Finish()
+
+	h := NewHandler(kit)
+	h.Users = []string{"user-a"}
+	h.Labels = []string{ownership.ManagedByLabel + "=other"}
+	h.Roles = []string{testkit.RoleName + ":" + testkit.RoleBindName}
+	h.Run(testkit.Ctx(), handler.Input{})
+}
+
+// TestMergeSubjects asserts that the missing subjects are added to existing bindings.
+func TestMergeSubjects(t *testing.T) {
+	kit := NewHandlerKit(t)
+	kit.Finish()
+	defer kit.MockCtrl.Finish()
+
+	kit.ExpectRole(testkit.RoleName)
+	kit.ExpectClusterRole(testkit.ClusterRoleName)
+
+	// Include a subject which omits the API group, as if created by another tool.
+	existingSubjects := []rbac.Subject{
+		{Kind: cage_k8s.KindUser, Name: "user-a"},
+		cage_k8s_rbac.NewUserSubject("user-other"),
+	}
+	expectSubjects := append(append([]rbac.Subject{}, existingSubjects...), allSubjects()[1:]...)
+
+	existingRoleBinding := &rbac.RoleBinding{
+		ObjectMeta: meta.ObjectMeta{Namespace: testkit.CurrentNamespace, Name: testkit.RoleBindName},
+		RoleRef:    rbac.RoleRef{Kind: cage_k8s.KindRole, Name: testkit.RoleName},
+		Subjects:   existingSubjects,
+	}
+	expectRoleBinding := existingRoleBinding.DeepCopy()
+	expectRoleBinding.Subjects = expectSubjects
+
+	kit.ApiClientset.RoleBindings.EXPECT().
+		Get(testkit.CurrentNamespace, testkit.RoleBindName).
+		Return(existingRoleBinding, testkit.Exists, nil)
+	kit.ApiClientset.RoleBindings.EXPECT().
+		Update(expectRoleBinding).
+		Return(cage_gomock.NonSut(), nil)
+
+	existingClusterRoleBinding := &rbac.ClusterRoleBinding{
+		ObjectMeta: meta.ObjectMeta{Name: testkit.ClusterRoleBindName},
+		RoleRef:    rbac.RoleRef{Kind: cage_k8s.KindClusterRole, Name: testkit.ClusterRoleName},
+		Subjects:   existingSubjects,
+	}
+	expectClusterRoleBinding := existingClusterRoleBinding.DeepCopy()
+	expectClusterRoleBinding.Subjects = expectSubjects
+
+	kit.ApiClientset.ClusterRoleBindings.EXPECT().
+		Get(testkit.ClusterRoleBindName).
+		Return(existingClusterRoleBinding, testkit.Exists, nil)
+	kit.ApiClientset.ClusterRoleBindings.EXPECT().
+		Update(expectClusterRoleBinding).
+		Return(cage_gomock.NonSut(), nil)
+
+	h := NewHandler(kit)
+	setAllSubjects(h)
+	h.Roles = []string{testkit.RoleName + ":" + testkit.RoleBindName}
+	h.ClusterRoles = []string{testkit.ClusterRoleName + ":" + testkit.ClusterRoleBindName}
+	h.Run(testkit.Ctx(), handler.Input{})
+}
+
+// TestUnchangedBinding asserts that a binding which already includes the subjects is not updated.
+func TestUnchangedBinding(t *testing.T) {
+	kit := NewHandlerKit(t)
+	kit.Finish()
+	defer kit.MockCtrl.Finish()
+
+	kit.ExpectRole(testkit.RoleName)
+
+	kit.ApiClientset.RoleBindings.EXPECT().
+		Get(testkit.CurrentNamespace, testkit.RoleBindName).
+		Return(&rbac.RoleBinding{
+			RoleRef:  rbac.RoleRef{Kind: cage_k8s.KindRole, Name: testkit.RoleName},
+			Subjects: allSubjects(),
+		}, testkit.Exists, nil)
+
+	h := NewHandler(kit)
+	setAllSubjects(h)
+	h.Roles = []string{testkit.RoleName + ":" + testkit.RoleBindName}
+	h.Run(testkit.Ctx(), handler.Input{})
+}
+
+// TestMergeNamespacelessServiceAccount asserts that an existing service account subject which
+// omits the namespace matches the same account in the role binding's namespace.
+func TestMergeNamespacelessServiceAccount(t *testing.T) {
+	kit := NewHandlerKit(t)
+	kit.Finish()
+	defer kit.MockCtrl.Finish()
+
+	kit.ApiClientset.Roles.EXPECT().
+		Get("ns-a", testkit.RoleName).
+		Return(cage_gomock.NonSut(), testkit.Exists, nil)
+
+	existingSubjects := []rbac.Subject{
+		{Kind: cage_k8s.KindServiceAccount, Name: "sa-a"},
+	}
+
+	existingRoleBinding := &rbac.RoleBinding{
+		ObjectMeta: meta.ObjectMeta{Namespace: "ns-a", Name: testkit.RoleBindName},
+		RoleRef:    rbac.RoleRef{Kind: cage_k8s.KindRole, Name: testkit.RoleName},
+		Subjects:   existingSubjects,
+	}
+	expectRoleBinding := existingRoleBinding.DeepCopy()
+	expectRoleBinding.Subjects = append(append([]rbac.Subject{}, existingSubjects...), cage_k8s_rbac.NewUserSubject("user-a"))
+
+	kit.ApiClientset.RoleBindings.EXPECT().
+		Get("ns-a", testkit.RoleBindName).
+		Return(existingRoleBinding, testkit.Exists, nil)
+	kit.ApiClientset.RoleBindings.EXPECT().
+		Update(expectRoleBinding).
+		Return(cage_gomock.NonSut(), nil)
+
+	h := NewHandler(kit)
+	h.Users = []string{"user-a"}
+	h.ServiceAccounts = []string{"ns-a:sa-a"}
+	h.Roles = []string{"ns-a/" + testkit.RoleName + ":" + testkit.RoleBindName}
+	h.Run(testkit.Ctx(), handler.Input{})
+}
+
+// TestErrOnRoleRefConflict asserts that the CLI exits with an error if an existing binding
+// refers to a different role.
+func TestErrOnRoleRefConflict(t *testing.T) {
+	kit := NewHandlerKit(t)
+	kit.ExitOnErr = regexp.MustCompile(`role binding .* refers to Role \[other-role\], not Role`)
+	kit.Finish()
+	defer kit.MockCtrl.Finish()
+
+	kit.ExpectRole(testkit.RoleName)
+
+	kit.ApiClientset.RoleBindings.EXPECT().
+		Get(testkit.CurrentNamespace, testkit.RoleBindName).
+		Return(&rbac.RoleBinding{RoleRef: rbac.RoleRef{Kind: cage_k8s.KindRole, Name: "other-role"}}, testkit.Exists, nil)
+
+	h := NewHandler(kit)
+	h.Users = []string{"user-a"}
+	h.Roles = []string{testkit.RoleName + ":" + testkit.RoleBindName}
+	h.Run(testkit.Ctx(), handler.Input{})
+}
+
+// TestErrOnMissingSubjects asserts that at least one subject is required.
+func TestErrOnMissingSubjects(t *testing.T) {
+	kit := NewHandlerKit(t)
+	kit.ExitOnErr = regexp.MustCompile(`at least one --user, --group, or --serviceaccount is required`)
+	kit.Finish()
+	defer kit.MockCtrl.Finish()
+
+	h := NewHandler(kit)
+	h.Roles = []string{testkit.RoleName + ":" + testkit.RoleBindName}
+	h.Run(testkit.Ctx(), handler.Input{})
+}
+
+// TestErrOnMissingBindings asserts that at least one binding selection is required.
+func TestErrOnMissingBindings(t *testing.T) {
+	kit := NewHandlerKit(t)
+	kit.ExitOnErr = regexp.MustCompile(`at least one --role or --cluster-role is required`)
+	kit.Finish()
+	defer kit.MockCtrl.Finish()
+
+	h := NewHandler(kit)
+	h.Users = []string{"user-a"}
+	h.Run(testkit.Ctx(), handler.Input{})
+}
+
+// TestErrOnInvalidServiceAccount asserts that --serviceaccount selections must include a namespace.
+func TestErrOnInvalidServiceAccount(t *testing.T) {
+	kit := NewHandlerKit(t)
+	kit.ExitOnErr = regexp.MustCompile(`invalid --serviceaccount selectors:.*sa-a`)
+	kit.Finish()
+	defer kit.MockCtrl.Finish()
+
+	h := NewHandler(kit)
+	h.ServiceAccounts = []string{"sa-a"}
+	h.Roles = []string{testkit.RoleName + ":" + testkit.RoleBindName}
+	h.Run(testkit.Ctx(), handler.Input{})
+}
+
+// TestErrOnRoleNotFound asserts that the CLI exits with an error if a selected role does not exist.
+func TestErrOnRoleNotFound(t *testing.T) {
+	kit := NewHandlerKit(t)
+	kit.ExitOnErr = regexp.MustCompile(`role\(s\) not found:.*invalid-a`)
+	kit.Finish()
+	defer kit.MockCtrl.Finish()
+
+	kit.ApiClientset.Roles.EXPECT().
+		Get(testkit.CurrentNamespace, "invalid-a").
+		Return(nil, testkit.NotExists, nil)
+
+	h := NewHandler(kit)
+	h.Users = []string{"user-a"}
+	h.Roles = []string{"invalid-a:bind-a"}
+	h.Run(testkit.Ctx(), handler.Input{})
+}
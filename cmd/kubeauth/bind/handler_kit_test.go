@@ -0,0 +1,56 @@
+// Copyright (C) 2020 The kubeauth Authors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package bind_test
+
+import (
+	"testing"
+
+	cage_gomock "github.com/codeactual/kubeauth/internal/cage/testkit/gomock"
+	"github.com/codeactual/kubeauth/internal/testkit"
+)
+
+// HandlerKit provides command test cases with data and mock-setup boilerplate.
+//
+// It integrates thc HandlerKit type from the internal/testkit package for additional
+// command-agnostic boilerplate.
+type HandlerKit struct {
+	*testkit.HandlerKit
+}
+
+func NewHandlerKit(t *testing.T) *HandlerKit {
+	return &HandlerKit{
+		HandlerKit: testkit.NewHandlerKit(t),
+	}
+}
+
+// Finish creates the expected calls, based on mock-related HandlerKit fields, that were not
+// already created by other methods.
+func (k *HandlerKit) Finish() {
+	k.HandlerKit.Finish()
+
+	k.ConfigClient.EXPECT().
+		Parse("").
+		Return(testkit.NewConfigFile(testkit.ConfigFilename, testkit.CurrentContextName, testkit.CurrentClusterName, testkit.CurrentNamespace), nil)
+
+	if k.ExitOnErr != nil {
+		k.Session.EXPECT().ExitOnErr(cage_gomock.ErrShortRegexp(k.ExitOnErr), "", 1)
+	}
+}
+
+// ExpectRole immediately configures the kit to expect the role's existence to be validated.
+func (k *HandlerKit) ExpectRole(name string) {
+	k.ApiClientset.Roles.EXPECT().
+		Get(testkit.CurrentNamespace, name).
+		Return(cage_gomock.NonSut(), testkit.Exists, nil)
+}
+
+// ExpectClusterRole immediately configures the kit to expect the cluster role's existence to be validated.
+func (k *HandlerKit) ExpectClusterRole(name string) {
+	k.ApiClientset.ClusterRoles.EXPECT().
+		Get(name).
+		Return(cage_gomock.NonSut(), testkit.Exists, nil)
+}
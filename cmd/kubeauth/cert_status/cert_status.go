@@ -0,0 +1,280 @@
+// Copyright (C) 2020 The kubeauth Authors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package cert_status
+
+import (
+	"context"
+	"crypto/x509"
+	"fmt"
+	"io/ioutil"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/util/cert"
+
+	"github.com/codeactual/kubeauth/internal/cage/cli/handler"
+	handler_cobra "github.com/codeactual/kubeauth/internal/cage/cli/handler/cobra"
+	cage_k8s_csr "github.com/codeactual/kubeauth/internal/cage/kubernetes/v1/certificates/certificate_signing_request"
+	cage_k8s_core "github.com/codeactual/kubeauth/internal/cage/kubernetes/v1/core"
+	cage_k8s_config "github.com/codeactual/kubeauth/internal/cage/kubernetes/v1/kubectl/config"
+	cage_reflect "github.com/codeactual/kubeauth/internal/cage/reflect"
+)
+
+const (
+	// DefaultRenewWithin is the default remaining validity below which --renew replaces a certificate.
+	DefaultRenewWithin = 30 * 24 * time.Hour
+
+	// StatusExpired is reported for certificates whose validity period has ended.
+	StatusExpired = "expired"
+
+	// StatusExpiring is reported for certificates which expire within --renew-within.
+	StatusExpiring = "expiring"
+
+	// StatusRenewed is reported for expired and expiring certificates which --renew replaced.
+	StatusRenewed = "renewed"
+
+	// StatusValid is reported for certificates which do not expire within --renew-within.
+	StatusValid = "valid"
+)
+
+// Handler defines the sub-command flags and logic.
+type Handler struct {
+	handler.Session
+
+	KubeApiClientset    *cage_k8s_core.Clientset
+	KubectlConfigClient cage_k8s_config.Client
+
+	ApproveCert bool          `usage:"approve the certificate signing requests of renewals if permitted, instead of waiting for an administrator"`
+	BackupLimit int           `usage:"number of backups to retain per modified kubectl config file, or 0 to disable backups"`
+	CertTimeout time.Duration `usage:"duration to wait for each renewed certificate to be approved and issued"`
+	ConfigFile  string        `usage:"kubectl config file to inspect/modify"`
+	Renew       bool          `usage:"renew certificates which are expired or expire within --renew-within"`
+	RenewWithin time.Duration `usage:"remaining validity below which a certificate is considered expiring"`
+	Users       []string      `usage:"user to inspect/renew (default: all users with client certificates)"`
+
+	// Verbosity levels greater than 0 will enable status messages and error stack traces.
+	//
+	// It is an int for consistency with other commands, even though levels beyond 1 are not used.
+	Verbosity int `usage:"kubectl verbosity level"`
+}
+
+// Init defines the command, its environment variable prefix, etc.
+//
+// It implements cli/handler/cobra.Handler.
+func (h *Handler) Init() handler_cobra.Init {
+	return handler_cobra.Init{
+		Cmd: &cobra.Command{
+			Use:   "cert-status",
+			Short: "Report the expiry of kubeconfig users' client certificates and optionally renew them",
+		},
+		EnvPrefix: "KUBEAUTH",
+	}
+}
+
+// BindFlags binds the flags to Handler fields.
+//
+// It implements cli/handler/cobra.Handler.
+func (h *Handler) BindFlags(cmd *cobra.Command) []string {
+	cmd.Flags().BoolVarP(&h.ApproveCert, "approve", "", false, cage_reflect.GetFieldTag(*h, "ApproveCert", "usage"))
+	cmd.Flags().IntVarP(&h.BackupLimit, "backup-limit", "", cage_k8s_config.DefaultBackupLimit, cage_reflect.GetFieldTag(*h, "BackupLimit", "usage"))
+	cmd.Flags().DurationVarP(&h.CertTimeout, "cert-timeout", "", cage_k8s_csr.DefaultIssueTimeout, cage_reflect.GetFieldTag(*h, "CertTimeout", "usage"))
+	cmd.Flags().StringVarP(&h.ConfigFile, "kubeconfig", "", "", cage_reflect.GetFieldTag(*h, "ConfigFile", "usage"))
+	cmd.Flags().BoolVarP(&h.Renew, "renew", "", false, cage_reflect.GetFieldTag(*h, "Renew", "usage"))
+	cmd.Flags().DurationVarP(&h.RenewWithin, "renew-within", "", DefaultRenewWithin, cage_reflect.GetFieldTag(*h, "RenewWithin", "usage"))
+	cmd.Flags().StringSliceVarP(&h.Users, "user", "", []string{}, cage_reflect.GetFieldTag(*h, "Users", "usage"))
+	cmd.Flags().IntVarP(&h.Verbosity, "v", "v", 0, cage_reflect.GetFieldTag(*h, "Verbosity", "usage"))
+	return []string{}
+}
+
+// Run performs the sub-command logic.
+//
+// It implements cli/handler/cobra.Handler.
+func (h *Handler) Run(ctx context.Context, input handler.Input) {
+	if err := h.run(ctx, input); err != nil {
+		if h.Verbosity > 0 {
+			h.ExitOnErr(err, "", 1)
+		} else {
+			h.ExitOnErrShort(err, "", 1)
+		}
+	}
+}
+
+// userCert describes the client certificate of a kubeconfig user.
+type userCert struct {
+	user   string
+	cert   *x509.Certificate
+	status string
+}
+
+func (h *Handler) run(ctx context.Context, _ handler.Input) error {
+	stderr := h.Err()
+	verbose := func(format string, vArgs ...interface{}) {
+		if h.Verbosity > 0 {
+			fmt.Fprintln(stderr, "kubeauth: "+fmt.Sprintf(format, vArgs...))
+		}
+	}
+
+	if h.BackupLimit < 0 {
+		return errors.Errorf("kubeauth: --backup-limit [%d] must not be negative", h.BackupLimit)
+	}
+
+	configClient := h.KubectlConfigClient
+	if configClient == nil {
+		defaultClient := cage_k8s_config.NewDefaultClient()
+		defaultClient.BackupLimit = h.BackupLimit
+		configClient = defaultClient
+	}
+
+	configFile, err := configClient.Parse(h.ConfigFile)
+	if err != nil {
+		return errors.Wrap(err, "kubeauth") // WithStack alternative to disambiguate from kubectl output
+	}
+
+	// Select the users.
+
+	var names []string
+	if len(h.Users) > 0 {
+		var invalid []string
+		for _, name := range h.Users {
+			authInfo, ok := configFile.ClientCmdConfig.AuthInfos[name]
+			if !ok || authInfo == nil || (len(authInfo.ClientCertificateData) == 0 && authInfo.ClientCertificate == "") {
+				invalid = append(invalid, name)
+				continue
+			}
+			names = append(names, name)
+		}
+		if len(invalid) > 0 {
+			return errors.Errorf("kubeauth: user(s) with client certificates not found: %q", invalid)
+		}
+	} else {
+		for name, authInfo := range configFile.ClientCmdConfig.AuthInfos {
+			if authInfo != nil && (len(authInfo.ClientCertificateData) > 0 || authInfo.ClientCertificate != "") {
+				names = append(names, name)
+			}
+		}
+	}
+	sort.Strings(names)
+
+	// Inspect each user's certificate.
+
+	now := time.Now()
+	var userCerts []userCert
+
+	for _, name := range names {
+		authInfo := configFile.ClientCmdConfig.AuthInfos[name]
+
+		data := authInfo.ClientCertificateData
+		if len(data) == 0 {
+			if data, err = ioutil.ReadFile(authInfo.ClientCertificate); err != nil {
+				return errors.Wrapf(err, "kubeauth: failed to read client certificate of user [%s]", name)
+			}
+		}
+
+		parsed, err := cert.ParseCertsPEM(data)
+		if err != nil {
+			return errors.Wrapf(err, "kubeauth: failed to parse client certificate of user [%s]", name)
+		}
+
+		userCerts = append(userCerts, userCert{user: name, cert: parsed[0], status: h.status(parsed[0], now)})
+	}
+
+	// Renew the expired and expiring certificates.
+
+	if h.Renew {
+		var issuer *cage_k8s_csr.Issuer
+
+		for n, uc := range userCerts {
+			if uc.status == StatusValid {
+				continue
+			}
+
+			if issuer == nil {
+				apiClientset := h.KubeApiClientset
+				if apiClientset == nil {
+					rawApiClientset, err := kubernetes.NewForConfig(configFile.RestConfig)
+					if err != nil {
+						return errors.Wrap(err, "kubeauth: failed to create API client")
+					}
+
+					apiClientset = cage_k8s_core.NewClientset(rawApiClientset)
+				}
+
+				issuer = cage_k8s_csr.NewIssuer(apiClientset.CertificateSigningRequests)
+				issuer.Approve = h.ApproveCert
+				issuer.Timeout = h.CertTimeout
+				issuer.Notify = func(msg string) {
+					fmt.Fprintln(stderr, "kubeauth: "+msg)
+				}
+			}
+
+			// Request the same identity as the current certificate.
+			certData, keyData, err := issuer.IssueClientCert(uc.cert.Subject.CommonName, uc.cert.Subject.Organization)
+			if err != nil {
+				return errors.Wrapf(err, "kubeauth: failed to renew client certificate of user [%s]", uc.user)
+			}
+
+			parsed, err := cert.ParseCertsPEM(certData)
+			if err != nil {
+				return errors.Wrapf(err, "kubeauth: failed to parse renewed client certificate of user [%s]", uc.user)
+			}
+
+			if err = configClient.UpsertUserCert(ctx, configFile, uc.user, certData, keyData); err != nil {
+				return errors.Wrapf(err, "kubeauth: failed to set renewed client certificate of user [%s]", uc.user)
+			}
+
+			verbose("renewed client certificate of user [%s]", uc.user)
+
+			userCerts[n].cert = parsed[0]
+			userCerts[n].status = StatusRenewed
+		}
+	}
+
+	// Report.
+
+	w := tabwriter.NewWriter(h.Out(), 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "USER\tCOMMON NAME\tGROUPS\tNOT AFTER\tSTATUS")
+	for _, uc := range userCerts {
+		fmt.Fprintf(
+			w, "%s\t%s\t%s\t%s\t%s\n",
+			uc.user,
+			uc.cert.Subject.CommonName,
+			strings.Join(uc.cert.Subject.Organization, ","),
+			uc.cert.NotAfter.UTC().Format(time.RFC3339),
+			uc.status,
+		)
+	}
+	if err = w.Flush(); err != nil {
+		return errors.Wrap(err, "kubeauth: failed to write report")
+	}
+
+	return nil
+}
+
+// status returns the certificate's status at the input time.
+func (h *Handler) status(c *x509.Certificate, now time.Time) string {
+	if !now.Before(c.NotAfter) {
+		return StatusExpired
+	}
+	if c.NotAfter.Sub(now) < h.RenewWithin {
+		return StatusExpiring
+	}
+	return StatusValid
+}
+
+// New returns a cobra command instance based on Handler.
+func NewCommand() *cobra.Command {
+	return handler_cobra.NewHandler(&Handler{
+		Session: &handler.DefaultSession{},
+	})
+}
+
+var _ handler_cobra.Handler = (*Handler)(nil)
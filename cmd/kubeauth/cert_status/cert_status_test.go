@@ -0,0 +1,195 @@
+// Copyright (C) 2020 The kubeauth Authors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at https://mozilla.org/MPL/2.0/.
+
+// Package cert_status_test asserts CLI behavior by running the command handler logic
+// directly (w/o separate processes) with various input scenarios.
+//
+// It uses Handler instances that use mock implementations of the clients used
+// to modify kubeconfig files and perform API requests. The tests only verify correct
+// use of the client interfaces. Tests in the cage_k8s package tree verify
+// lower-level client behaviors.
+package cert_status_test
+
+import (
+	"context"
+	"crypto/x509"
+	"encoding/pem"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+	certs "k8s.io/api/certificates/v1beta1"
+	meta "k8s.io/apimachinery/pkg/apis/meta/v1"
+	clientcmdapi "k8s.io/client-go/tools/clientcmd/api"
+
+	cli "github.com/codeactual/kubeauth/cmd/kubeauth/cert_status"
+	"github.com/codeactual/kubeauth/internal/cage/cli/handler"
+	cage_k8s_config "github.com/codeactual/kubeauth/internal/cage/kubernetes/v1/kubectl/config"
+	"github.com/codeactual/kubeauth/internal/testkit"
+)
+
+const day = 24 * time.Hour
+
+func NewHandler(kit *HandlerKit) *cli.Handler {
+	h := cli.Handler{
+		Session:             kit.Session,
+		KubectlConfigClient: kit.ConfigClient,
+		KubeApiClientset:    kit.ApiClientset.ToReal(),
+	}
+
+	h.CertTimeout = time.Second
+	h.RenewWithin = cli.DefaultRenewWithin
+
+	// Enable for test troubleshooting and verbose output assertions.
+	h.Verbosity = 1
+
+	return &h
+}
+
+// reportLines returns the report's rows, excluding the header, with whitespace collapsed.
+func reportLines(t *testing.T, kit *HandlerKit) []string {
+	lines := strings.Split(strings.TrimSpace(kit.Stdout.String()), "\n")
+	require.True(t, strings.HasPrefix(lines[0], "USER"))
+
+	var rows []string
+	for _, l := range lines[1:] {
+		rows = append(rows, strings.Join(strings.Fields(l), " "))
+	}
+	return rows
+}
+
+// TestReport asserts that all users with client certificates are reported, sorted by name.
+func TestReport(t *testing.T) {
+	now := time.Now()
+	validAfter := now.Add(365 * day)
+
+	kit := NewHandlerKit(t)
+	kit.AddCertUser("user-c", "cn-c", []string{"group-a", "group-b"}, validAfter)
+	kit.AddCertUser("user-b", "cn-b", nil, now.Add(day))
+	kit.AddCertUser("user-a", "cn-a", []string{"group-a"}, now.Add(-day))
+	kit.AuthInfos["token-user"] = &clientcmdapi.AuthInfo{Token: "some-token"}
+	kit.Finish()
+	defer kit.MockCtrl.Finish()
+
+	h := NewHandler(kit)
+	h.Run(testkit.Ctx(), handler.Input{})
+
+	rows := reportLines(t, kit)
+	require.Len(t, rows, 3)
+	require.Regexp(t, `^user-a cn-a group-a \S+ expired$`, rows[0])
+	require.Regexp(t, `^user-b cn-b \S+ expiring$`, rows[1])
+	require.Exactly(t, "user-c cn-c group-a,group-b "+validAfter.UTC().Format(time.RFC3339)+" valid", rows[2])
+}
+
+// TestReportSelected asserts that only --user selections are reported.
+func TestReportSelected(t *testing.T) {
+	now := time.Now()
+
+	kit := NewHandlerKit(t)
+	kit.AddCertUser("user-a", "cn-a", nil, now.Add(365*day))
+	kit.AddCertUser("user-b", "cn-b", nil, now.Add(365*day))
+	kit.Finish()
+	defer kit.MockCtrl.Finish()
+
+	h := NewHandler(kit)
+	h.Users = []string{"user-b"}
+	h.Run(testkit.Ctx(), handler.Input{})
+
+	rows := reportLines(t, kit)
+	require.Len(t, rows, 1)
+	require.True(t, strings.HasPrefix(rows[0], "user-b "))
+}
+
+// TestErrOnUserNotFound asserts that the CLI exits with an error if a --user selection does not
+// exist or lacks a client certificate.
+func TestErrOnUserNotFound(t *testing.T) {
+	kit := NewHandlerKit(t)
+	kit.ExitOnErr = regexp.MustCompile(`user\(s\) with client certificates not found:.*invalid-a.*token-user`)
+	kit.AuthInfos["token-user"] = &clientcmdapi.AuthInfo{Token: "some-token"}
+	kit.Finish()
+	defer kit.MockCtrl.Finish()
+
+	h := NewHandler(kit)
+	h.Users = []string{"invalid-a", "token-user"}
+	h.Run(testkit.Ctx(), handler.Input{})
+}
+
+// TestRenew asserts that --renew requests a certificate with the same subject for each expired
+// or expiring user, and replaces the user's credentials.
+func TestRenew(t *testing.T) {
+	now := time.Now()
+
+	kit := NewHandlerKit(t)
+	kit.AddCertUser("user-a", "cn-a", []string{"group-a"}, now.Add(-day))
+	kit.AddCertUser("user-b", "cn-b", []string{"group-b"}, now.Add(day))
+	kit.AddCertUser("user-c", "cn-c", nil, now.Add(365*day))
+	kit.Finish()
+	defer kit.MockCtrl.Finish()
+
+	for _, u := range []string{"a", "b"} {
+		commonName, groups := "cn-"+u, []string{"group-" + u}
+		csrName := "csr-" + u
+		issuedCert := NewCert(t, commonName, groups, now.Add(365*day))
+
+		created := &certs.CertificateSigningRequest{ObjectMeta: meta.ObjectMeta{Name: csrName}}
+		issued := created.DeepCopy()
+		issued.Status.Certificate = issuedCert
+
+		gomock.InOrder(
+			kit.ApiClientset.CertificateSigningRequests.EXPECT().
+				Create(gomock.Any(), gomock.Any(), gomock.Any()).
+				DoAndReturn(func(_ string, request []byte, _ ...certs.KeyUsage) (*certs.CertificateSigningRequest, error) {
+					block, _ := pem.Decode(request)
+					parsed, err := x509.ParseCertificateRequest(block.Bytes)
+					require.NoError(t, err)
+					require.Exactly(t, commonName, parsed.Subject.CommonName)
+					require.Exactly(t, groups, parsed.Subject.Organization)
+					return created, nil
+				}),
+			kit.ApiClientset.CertificateSigningRequests.EXPECT().
+				Get(csrName).
+				Return(issued, testkit.Exists, nil),
+			kit.ConfigClient.EXPECT().
+				UpsertUserCert(testkit.Ctx(), gomock.Any(), "user-"+u, issuedCert, gomock.Any()).
+				DoAndReturn(func(_ context.Context, _ *cage_k8s_config.File, _ string, _, key []byte) error {
+					require.NotEmpty(t, key)
+					return nil
+				}),
+		)
+	}
+
+	h := NewHandler(kit)
+	h.Renew = true
+	h.Run(testkit.Ctx(), handler.Input{})
+
+	rows := reportLines(t, kit)
+	require.Len(t, rows, 3)
+	require.Regexp(t, `^user-a cn-a group-a \S+ renewed$`, rows[0])
+	require.Regexp(t, `^user-b cn-b group-b \S+ renewed$`, rows[1])
+	require.Regexp(t, `^user-c cn-c \S+ valid$`, rows[2])
+}
+
+// TestRenewWithin asserts that --renew-within selects which certificates are expiring.
+func TestRenewWithin(t *testing.T) {
+	now := time.Now()
+
+	kit := NewHandlerKit(t)
+	kit.AddCertUser("user-a", "cn-a", nil, now.Add(10*day))
+	kit.Finish()
+	defer kit.MockCtrl.Finish()
+
+	h := NewHandler(kit)
+	h.Renew = true
+	h.RenewWithin = 5 * day
+	h.Run(testkit.Ctx(), handler.Input{})
+
+	rows := reportLines(t, kit)
+	require.Len(t, rows, 1)
+	require.Regexp(t, `valid$`, rows[0])
+}
@@ -0,0 +1,98 @@
+// Copyright (C) 2020 The kubeauth Authors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package cert_status_test
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	clientcmdapi "k8s.io/client-go/tools/clientcmd/api"
+
+	cage_gomock "github.com/codeactual/kubeauth/internal/cage/testkit/gomock"
+	"github.com/codeactual/kubeauth/internal/testkit"
+)
+
+// HandlerKit provides command test cases with data and mock-setup boilerplate.
+//
+// It integrates thc HandlerKit type from the internal/testkit package for additional
+// command-agnostic boilerplate.
+type HandlerKit struct {
+	*testkit.HandlerKit
+
+	T *testing.T
+
+	// AuthInfos is added to the config file returned by the Parse call which Finish configures.
+	AuthInfos map[string]*clientcmdapi.AuthInfo
+
+	// Stdout receives the command's report.
+	Stdout *bytes.Buffer
+}
+
+func NewHandlerKit(t *testing.T) *HandlerKit {
+	k := &HandlerKit{
+		HandlerKit: testkit.NewHandlerKit(t),
+		T:          t,
+		AuthInfos:  map[string]*clientcmdapi.AuthInfo{},
+		Stdout:     &bytes.Buffer{},
+	}
+	k.HandlerKit.Stdout = k.Stdout
+	return k
+}
+
+// Finish creates the expected calls, based on mock-related HandlerKit fields, that were not
+// already created by other methods.
+func (k *HandlerKit) Finish() {
+	k.HandlerKit.Finish()
+
+	file := testkit.NewConfigFile(testkit.ConfigFilename, testkit.CurrentContextName, testkit.CurrentClusterName, testkit.CurrentNamespace)
+	file.ClientCmdConfig.AuthInfos = k.AuthInfos
+
+	k.ConfigClient.EXPECT().
+		Parse("").
+		Return(file, nil)
+
+	if k.ExitOnErr != nil {
+		k.Session.EXPECT().ExitOnErr(cage_gomock.ErrShortRegexp(k.ExitOnErr), "", 1)
+	}
+}
+
+// AddCertUser adds a config user whose client certificate has the input subject and expiry.
+//
+// It returns the PEM-encoded certificate.
+func (k *HandlerKit) AddCertUser(user, commonName string, groups []string, notAfter time.Time) []byte {
+	data := NewCert(k.T, commonName, groups, notAfter)
+	k.AuthInfos[user] = &clientcmdapi.AuthInfo{ClientCertificateData: data}
+	return data
+}
+
+// NewCert returns a PEM-encoded self-signed certificate with the input subject and expiry.
+func NewCert(t *testing.T, commonName string, groups []string, notAfter time.Time) []byte {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: commonName, Organization: groups},
+		NotBefore:    notAfter.Add(-365 * 24 * time.Hour),
+		NotAfter:     notAfter,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	require.NoError(t, err)
+
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+}
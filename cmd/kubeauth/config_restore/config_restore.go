@@ -0,0 +1,155 @@
+// Copyright (C) 2020 The kubeauth Authors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package config_restore
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+	"k8s.io/client-go/tools/clientcmd"
+
+	"github.com/codeactual/kubeauth/internal/cage/cli/handler"
+	handler_cobra "github.com/codeactual/kubeauth/internal/cage/cli/handler/cobra"
+	cage_k8s_config "github.com/codeactual/kubeauth/internal/cage/kubernetes/v1/kubectl/config"
+	cage_reflect "github.com/codeactual/kubeauth/internal/cage/reflect"
+)
+
+// Handler defines the sub-command flags and logic.
+type Handler struct {
+	handler.Session
+
+	KubectlConfigClient cage_k8s_config.Client
+
+	Backup      string `usage:"backup to restore, by path or base name (default: most recent)"`
+	BackupLimit int    `usage:"number of backups to retain per modified kubectl config file, or 0 to disable backups"`
+	ConfigFile  string `usage:"kubectl config file to restore (default: first existing KUBECONFIG file)"`
+	List        bool   `usage:"list the file's backups, newest first, instead of restoring one"`
+
+	// Verbosity levels greater than 0 will enable status messages and error stack traces.
+	//
+	// It is an int for consistency with other commands, even though levels beyond 1 are not used.
+	Verbosity int `usage:"kubectl verbosity level"`
+}
+
+// Init defines the command, its environment variable prefix, etc.
+//
+// It implements cli/handler/cobra.Handler.
+func (h *Handler) Init() handler_cobra.Init {
+	return handler_cobra.Init{
+		Cmd: &cobra.Command{
+			Use:   "config-restore",
+			Short: "Restore a kubectl config file from a backup made before kubeauth modified it",
+		},
+		EnvPrefix: "KUBEAUTH",
+	}
+}
+
+// BindFlags binds the flags to Handler fields.
+//
+// It implements cli/handler/cobra.Handler.
+func (h *Handler) BindFlags(cmd *cobra.Command) []string {
+	cmd.Flags().StringVarP(&h.Backup, "backup", "", "", cage_reflect.GetFieldTag(*h, "Backup", "usage"))
+	cmd.Flags().IntVarP(&h.BackupLimit, "backup-limit", "", cage_k8s_config.DefaultBackupLimit, cage_reflect.GetFieldTag(*h, "BackupLimit", "usage"))
+	cmd.Flags().StringVarP(&h.ConfigFile, "kubeconfig", "", "", cage_reflect.GetFieldTag(*h, "ConfigFile", "usage"))
+	cmd.Flags().BoolVarP(&h.List, "list", "", false, cage_reflect.GetFieldTag(*h, "List", "usage"))
+	cmd.Flags().IntVarP(&h.Verbosity, "v", "v", 0, cage_reflect.GetFieldTag(*h, "Verbosity", "usage"))
+	return []string{}
+}
+
+// Run performs the sub-command logic.
+//
+// It implements cli/handler/cobra.Handler.
+func (h *Handler) Run(ctx context.Context, input handler.Input) {
+	if err := h.run(ctx, input); err != nil {
+		if h.Verbosity > 0 {
+			h.ExitOnErr(err, "", 1)
+		} else {
+			h.ExitOnErrShort(err, "", 1)
+		}
+	}
+}
+
+func (h *Handler) run(ctx context.Context, _ handler.Input) error {
+	stderr := h.Err()
+	verbose := func(format string, vArgs ...interface{}) {
+		if h.Verbosity > 0 {
+			fmt.Fprintln(stderr, "kubeauth: "+fmt.Sprintf(format, vArgs...))
+		}
+	}
+
+	if h.BackupLimit < 0 {
+		return errors.Errorf("kubeauth: --backup-limit [%d] must not be negative", h.BackupLimit)
+	}
+
+	configClient := h.KubectlConfigClient
+	if configClient == nil {
+		defaultClient := cage_k8s_config.NewDefaultClient()
+		defaultClient.BackupLimit = h.BackupLimit
+		configClient = defaultClient
+	}
+
+	// The file is not parsed because the reason for the restoration may be that it's invalid.
+	// Instead, select the same default as kubectl.
+	filename := h.ConfigFile
+	if filename == "" {
+		filename = clientcmd.NewDefaultClientConfigLoadingRules().GetDefaultFilename()
+
+		verbose("defaulting to file [%s]", filename)
+	}
+
+	backups, err := configClient.Backups(filename)
+	if err != nil {
+		return errors.Wrap(err, "kubeauth") // WithStack alternative to disambiguate from kubectl output
+	}
+
+	if h.List {
+		for _, b := range backups {
+			fmt.Fprintf(h.Out(), "%s\t%s\n", b.Time.Local().Format(time.RFC3339), b.Name)
+		}
+		return nil
+	}
+
+	if len(backups) == 0 {
+		return errors.Errorf("kubeauth: no backups found for file [%s]", filename)
+	}
+
+	selected := backups[0]
+
+	if h.Backup != "" {
+		var found bool
+		for _, b := range backups {
+			if b.Name == h.Backup || filepath.Base(b.Name) == h.Backup {
+				selected, found = b, true
+				break
+			}
+		}
+		if !found {
+			return errors.Errorf("kubeauth: backup [%s] of file [%s] not found, see --list", h.Backup, filename)
+		}
+	}
+
+	if err = configClient.Restore(ctx, filename, selected); err != nil {
+		return errors.Wrap(err, "kubeauth") // WithStack alternative to disambiguate from kubectl output
+	}
+
+	verbose("restored file [%s] from backup [%s]", filename, selected.Name)
+
+	return nil
+}
+
+// New returns a cobra command instance based on Handler.
+func NewCommand() *cobra.Command {
+	return handler_cobra.NewHandler(&Handler{
+		Session: &handler.DefaultSession{},
+	})
+}
+
+var _ handler_cobra.Handler = (*Handler)(nil)
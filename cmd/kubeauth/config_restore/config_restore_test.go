@@ -0,0 +1,121 @@
+// Copyright (C) 2020 The kubeauth Authors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at https://mozilla.org/MPL/2.0/.
+
+// Package config_restore_test asserts CLI behavior by running the command handler logic
+// directly (w/o separate processes) with various input scenarios.
+//
+// It uses Handler instances that use mock implementations of the client used
+// to modify kubeconfig files. The tests only verify correct use of the client interface.
+// Tests in the cage_k8s package tree verify lower-level client behaviors.
+package config_restore_test
+
+import (
+	"bytes"
+	"regexp"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	cli "github.com/codeactual/kubeauth/cmd/kubeauth/config_restore"
+	"github.com/codeactual/kubeauth/internal/cage/cli/handler"
+	"github.com/codeactual/kubeauth/internal/testkit"
+)
+
+func NewHandler(kit *HandlerKit) *cli.Handler {
+	h := cli.Handler{
+		Session:             kit.Session,
+		KubectlConfigClient: kit.ConfigClient,
+	}
+
+	h.ConfigFile = testkit.ConfigFilename
+
+	// Enable for test troubleshooting and verbose output assertions.
+	h.Verbosity = 1
+
+	return &h
+}
+
+// TestRestoreNewest asserts that the newest backup is restored by default.
+func TestRestoreNewest(t *testing.T) {
+	kit := NewHandlerKit(t)
+	kit.Finish()
+	defer kit.MockCtrl.Finish()
+
+	kit.ConfigClient.EXPECT().
+		Restore(testkit.Ctx(), testkit.ConfigFilename, kit.Backups[0]).
+		Return(nil)
+
+	h := NewHandler(kit)
+	h.Run(testkit.Ctx(), handler.Input{})
+}
+
+// TestRestoreSelected asserts that a --backup selection by base name is restored.
+func TestRestoreSelected(t *testing.T) {
+	kit := NewHandlerKit(t)
+	kit.Finish()
+	defer kit.MockCtrl.Finish()
+
+	kit.ConfigClient.EXPECT().
+		Restore(testkit.Ctx(), testkit.ConfigFilename, kit.Backups[1]).
+		Return(nil)
+
+	h := NewHandler(kit)
+	h.Backup = "config.1"
+	h.Run(testkit.Ctx(), handler.Input{})
+}
+
+// TestErrOnBackupNotFound asserts that the CLI exits with an error if the --backup selection does not exist.
+func TestErrOnBackupNotFound(t *testing.T) {
+	kit := NewHandlerKit(t)
+	kit.ExitOnErr = regexp.MustCompile(`backup \[config.3\] of file .* not found`)
+	kit.Finish()
+	defer kit.MockCtrl.Finish()
+
+	h := NewHandler(kit)
+	h.Backup = "config.3"
+	h.Run(testkit.Ctx(), handler.Input{})
+}
+
+// TestErrOnNoBackups asserts that the CLI exits with an error if the file has no backups.
+func TestErrOnNoBackups(t *testing.T) {
+	kit := NewHandlerKit(t)
+	kit.ExitOnErr = regexp.MustCompile(`no backups found`)
+	kit.Backups = nil
+	kit.Finish()
+	defer kit.MockCtrl.Finish()
+
+	h := NewHandler(kit)
+	h.Run(testkit.Ctx(), handler.Input{})
+}
+
+// TestErrOnNegativeBackupLimit asserts that the CLI exits with an error if --backup-limit is negative.
+func TestErrOnNegativeBackupLimit(t *testing.T) {
+	kit := NewHandlerKit(t)
+	kit.ExitOnErr = regexp.MustCompile(`--backup-limit \[-1\] must not be negative`)
+	kit.SkipBackups = true
+	kit.Finish()
+	defer kit.MockCtrl.Finish()
+
+	h := NewHandler(kit)
+	h.BackupLimit = -1
+	h.Run(testkit.Ctx(), handler.Input{})
+}
+
+// TestList asserts that --list prints the backups instead of restoring one.
+func TestList(t *testing.T) {
+	stdout := &bytes.Buffer{}
+
+	kit := NewHandlerKit(t)
+	kit.Stdout = stdout
+	kit.Finish()
+	defer kit.MockCtrl.Finish()
+
+	h := NewHandler(kit)
+	h.List = true
+	h.Run(testkit.Ctx(), handler.Input{})
+
+	require.Regexp(t, `(?s)config\.2\n.*config\.1\n$`, stdout.String())
+}
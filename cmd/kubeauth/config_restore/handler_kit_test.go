@@ -0,0 +1,57 @@
+// Copyright (C) 2020 The kubeauth Authors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package config_restore_test
+
+import (
+	"testing"
+	"time"
+
+	cage_k8s_config "github.com/codeactual/kubeauth/internal/cage/kubernetes/v1/kubectl/config"
+	cage_gomock "github.com/codeactual/kubeauth/internal/cage/testkit/gomock"
+	"github.com/codeactual/kubeauth/internal/testkit"
+)
+
+// HandlerKit provides command test cases with data and mock-setup boilerplate.
+//
+// It integrates thc HandlerKit type from the internal/testkit package for additional
+// command-agnostic boilerplate.
+type HandlerKit struct {
+	*testkit.HandlerKit
+
+	// Backups is returned by the Backups call which Finish configures.
+	Backups []cage_k8s_config.Backup
+
+	// SkipBackups is true if the command should exit before it lists backups.
+	SkipBackups bool
+}
+
+func NewHandlerKit(t *testing.T) *HandlerKit {
+	now := time.Now()
+	return &HandlerKit{
+		HandlerKit: testkit.NewHandlerKit(t),
+		Backups: []cage_k8s_config.Backup{
+			{Name: "/path/to/backup/config.2", Time: now},
+			{Name: "/path/to/backup/config.1", Time: now.Add(-time.Hour)},
+		},
+	}
+}
+
+// Finish creates the expected calls, based on mock-related HandlerKit fields, that were not
+// already created by other methods.
+func (k *HandlerKit) Finish() {
+	k.HandlerKit.Finish()
+
+	if !k.SkipBackups {
+		k.ConfigClient.EXPECT().
+			Backups(testkit.ConfigFilename).
+			Return(k.Backups, nil)
+	}
+
+	if k.ExitOnErr != nil {
+		k.Session.EXPECT().ExitOnErr(cage_gomock.ErrShortRegexp(k.ExitOnErr), "", 1)
+	}
+}
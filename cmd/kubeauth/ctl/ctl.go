@@ -39,13 +39,21 @@ type Handler struct {
 	// Executor provides an os/exec.Command API for running the kubectl CLI.
 	Executor cage_exec.Executor
 
-	AllNamespaces bool     `usage:"include identities from any/no namespace"`
-	As            string   `usage:"User/ServiceAccount/Role/ClusterRole to impersonate"`
-	AsGroup       []string `usage:"Group(s) to impersonate"`
-	Cluster       string   `usage:"pass to kubctl if effective context's cluster matches, else error (default from current-context)"`
-	ConfigFile    string   `usage:"kubectl config file to modify"`
-	Context       string   `usage:"consider users in this --kubeconfig context (defaults to current-context)"`
-	Namespace     string   `usage:"include identities from only one namespace (default from --context)"`
+	AllNamespaces  bool     `usage:"include identities from any/no namespace"`
+	As             string   `usage:"User/ServiceAccount/Role/ClusterRole to impersonate"`
+	AsGroup        []string `usage:"Group(s) to impersonate"`
+	Cluster        string   `usage:"pass to kubctl if effective context's cluster matches, else error (default from current-context)"`
+	ConfigFile     string   `usage:"kubectl config file to modify"`
+	Context        string   `usage:"consider users in this --kubeconfig context (defaults to current-context)"`
+	DisableQuerier []string `usage:"identity querier(s) to skip, by name, e.g. 'role binding subject'"`
+	GroupFile      []string `usage:"file(s) in 'getent group' format which list groups, and their members, known outside the cluster"`
+	IdentityFile   []string `usage:"YAML file(s), or directories of them, which list users and groups known outside the cluster"`
+	Namespace      string   `usage:"include identities from only one namespace (default from --context)"`
+
+	OIDCGroupsClaim    string `usage:"ID token claim which holds groups, as in the API server's --oidc-groups-claim"`
+	OIDCGroupsPrefix   string `usage:"prefix of OIDC groups, as in the API server's --oidc-groups-prefix, added to --as-group selections which lack it"`
+	OIDCUsernameClaim  string `usage:"ID token claim which holds the username, as in the API server's --oidc-username-claim"`
+	OIDCUsernamePrefix string `usage:"prefix of OIDC usernames, as in the API server's --oidc-username-prefix, added to an --as selection which lacks it"`
 
 	Verbosity int `usage:"kubectl verbosity level (and verbose kubeauth output for any level > 0)"`
 
@@ -85,6 +93,13 @@ func (h *Handler) BindFlags(cmd *cobra.Command) []string {
 	cmd.Flags().StringSliceVarP(&h.AsGroup, "as-group", "", []string{}, cage_reflect.GetFieldTag(*h, "AsGroup", "usage"))
 	cmd.Flags().StringVarP(&h.Namespace, "namespace", "n", "", cage_reflect.GetFieldTag(*h, "Namespace", "usage"))
 	cmd.Flags().BoolVarP(&h.AllNamespaces, "all-namespaces", "", false, cage_reflect.GetFieldTag(*h, "AllNamespaces", "usage"))
+	cmd.Flags().StringSliceVarP(&h.DisableQuerier, "disable-querier", "", []string{}, cage_reflect.GetFieldTag(*h, "DisableQuerier", "usage"))
+	cmd.Flags().StringSliceVarP(&h.GroupFile, "group-file", "", []string{}, cage_reflect.GetFieldTag(*h, "GroupFile", "usage"))
+	cmd.Flags().StringSliceVarP(&h.IdentityFile, "identity-file", "", []string{}, cage_reflect.GetFieldTag(*h, "IdentityFile", "usage"))
+	cmd.Flags().StringVarP(&h.OIDCGroupsClaim, "oidc-groups-claim", "", cage_k8s_identity.DefaultOIDCGroupsClaim, cage_reflect.GetFieldTag(*h, "OIDCGroupsClaim", "usage"))
+	cmd.Flags().StringVarP(&h.OIDCGroupsPrefix, "oidc-groups-prefix", "", "", cage_reflect.GetFieldTag(*h, "OIDCGroupsPrefix", "usage"))
+	cmd.Flags().StringVarP(&h.OIDCUsernameClaim, "oidc-username-claim", "", cage_k8s_identity.DefaultOIDCUsernameClaim, cage_reflect.GetFieldTag(*h, "OIDCUsernameClaim", "usage"))
+	cmd.Flags().StringVarP(&h.OIDCUsernamePrefix, "oidc-username-prefix", "", "", cage_reflect.GetFieldTag(*h, "OIDCUsernamePrefix", "usage"))
 	cmd.Flags().IntVarP(&h.Verbosity, "v", "v", 0, cage_reflect.GetFieldTag(*h, "Verbosity", "usage"))
 
 	h.usage = cmd.UsageString()
@@ -144,6 +159,55 @@ func (h *Handler) run(ctx context.Context, input handler.Input) error {
 		regClient = cage_k8s_identity.NewRegistry(apiClientset)
 	}
 
+	// Add identities known outside the cluster.
+
+	for _, path := range h.IdentityFile {
+		querier, err := cage_k8s_identity.NewYAMLFileQuerier(path)
+		if err != nil {
+			return errors.Wrap(err, "kubeauth") // WithStack alternative to disambiguate from kubectl output
+		}
+		if err = regClient.Register(querier); err != nil {
+			return errors.Wrap(err, "kubeauth") // WithStack alternative to disambiguate from kubectl output
+		}
+		verbose("added identities from [%s]", path)
+	}
+
+	for _, path := range h.GroupFile {
+		querier, err := cage_k8s_identity.NewGroupFileQuerier(path)
+		if err != nil {
+			return errors.Wrap(err, "kubeauth") // WithStack alternative to disambiguate from kubectl output
+		}
+		if err = regClient.Register(querier); err != nil {
+			return errors.Wrap(err, "kubeauth") // WithStack alternative to disambiguate from kubectl output
+		}
+		verbose("added groups from [%s]", path)
+	}
+
+	oidcConfig := cage_k8s_identity.OIDCConfig{
+		GroupsClaim:    h.OIDCGroupsClaim,
+		GroupsPrefix:   h.OIDCGroupsPrefix,
+		UsernameClaim:  h.OIDCUsernameClaim,
+		UsernamePrefix: h.OIDCUsernamePrefix,
+	}
+	// Only read ID tokens if OIDC is configured, so that kubeconfig files without OIDC users are not affected.
+	if oidcConfig.Customized() || cage_k8s_identity.HasOIDCAuthInfo(&configFile.ClientCmdConfig) {
+		oidcQuerier := cage_k8s_identity.NewOIDCQuerier(oidcConfig)
+		oidcQuerier.Notify = func(msg string) {
+			verbose("%s", msg)
+		}
+		if err = regClient.Register(oidcQuerier); err != nil {
+			return errors.Wrap(err, "kubeauth") // WithStack alternative to disambiguate from kubectl output
+		}
+		verbose("added identities from OIDC tokens")
+	}
+
+	for _, name := range h.DisableQuerier {
+		if err = regClient.Disable(name); err != nil {
+			return errors.Wrap(err, "kubeauth") // WithStack alternative to disambiguate from kubectl output
+		}
+		verbose("disabled identity querier [%s]", name)
+	}
+
 	nsClient := apiClientset.Namespaces
 
 	// Validate inputs.
@@ -224,16 +288,56 @@ func (h *Handler) run(ctx context.Context, input handler.Input) error {
 	//
 	// Each QueryOption passed to Query expands the kind-scope of queriers used and/or
 	// potentially further limits the results.
+	//
+	// If a name is not found, retry with the OIDC name the API server would assign, e.g. "oidc:alice"
+	// for "alice", and pass the found name to kubectl.
+	find := func(kind, name string) (found string, _ *cage_k8s_identity.IdentityList, _ error) {
+		query := func(name string) (*cage_k8s_identity.IdentityList, error) {
+			return regClient.Query(
+				ctx,
+				cage_k8s_identity.QueryKind(kind),
+				cage_k8s_identity.QueryNamespace(h.Namespace),
+				cage_k8s_identity.QueryName(name),
+				cage_k8s_identity.QueryClientCmdConfig(&configFile.ClientCmdConfig),
+			)
+		}
+
+		list, err := query(name)
+		if err != nil {
+			return name, nil, err
+		}
+
+		normalized := oidcConfig.NormalizeGroup(name)
+		if kind == cage_k8s.KindUser {
+			normalized = oidcConfig.NormalizeUsername(name)
+		}
+		if normalized == name {
+			return name, list, nil
+		}
+
+		// Prefer the name as given, then the OIDC name if a querier resolved it, e.g. from an ID token.
+		for _, item := range list.Items {
+			if item.Name == name {
+				return name, list, nil
+			}
+		}
+		for _, item := range list.Items {
+			if item.Name == normalized {
+				return normalized, list, nil
+			}
+		}
+		if len(list.Items) > 0 {
+			return name, list, nil
+		}
+
+		list, err = query(normalized)
+		return normalized, list, err
+	}
+
 	if h.As != "" {
 		verbose("validating --as [%s]", h.As)
 
-		list, err := regClient.Query(
-			ctx,
-			cage_k8s_identity.QueryKind(cage_k8s.KindUser),
-			cage_k8s_identity.QueryNamespace(h.Namespace),
-			cage_k8s_identity.QueryName(h.As),
-			cage_k8s_identity.QueryClientCmdConfig(&configFile.ClientCmdConfig),
-		)
+		as, list, err := find(cage_k8s.KindUser, h.As)
 		if err != nil {
 			return errors.Wrap(err, "kubeauth: query did not complete")
 		}
@@ -242,6 +346,11 @@ func (h *Handler) run(ctx context.Context, input handler.Input) error {
 			return errors.Errorf("kubeauth: --as identity [%s] not found", h.As)
 		}
 
+		if as != h.As {
+			verbose("using OIDC --as [%s] for [%s]", as, h.As)
+			h.As = as
+		}
+
 		for _, item := range list.Items {
 			verbose("--as identity found in [%s]", item)
 		}
@@ -250,13 +359,8 @@ func (h *Handler) run(ctx context.Context, input handler.Input) error {
 	if len(h.AsGroup) > 0 {
 		verbose("validating --as-group %v", h.AsGroup)
 
-		for _, group := range h.AsGroup {
-			list, err := regClient.Query(
-				ctx,
-				cage_k8s_identity.QueryKind(cage_k8s.KindGroup),
-				cage_k8s_identity.QueryNamespace(h.Namespace),
-				cage_k8s_identity.QueryName(group),
-			)
+		for n, group := range h.AsGroup {
+			found, list, err := find(cage_k8s.KindGroup, group)
 			if err != nil {
 				return errors.Wrap(err, "kubeauth: query did not complete")
 			}
@@ -265,6 +369,12 @@ func (h *Handler) run(ctx context.Context, input handler.Input) error {
 				return errors.Errorf("kubeauth: --as-group identity [%s] not found", group)
 			}
 
+			if found != group {
+				verbose("using OIDC --as-group [%s] for [%s]", found, group)
+				h.AsGroup[n] = found
+				group = found
+			}
+
 			for _, item := range list.Items {
 				verbose("--as-group [%s] identity found in [%s]", group, item)
 			}
@@ -20,15 +20,22 @@
 package ctl_test
 
 import (
+	"bytes"
 	"context"
+	"encoding/base64"
+	"encoding/json"
 	"regexp"
 	"testing"
 
 	"github.com/stretchr/testify/require"
+	meta "k8s.io/apimachinery/pkg/apis/meta/v1"
+	clientcmdapi "k8s.io/client-go/tools/clientcmd/api"
 
 	cli "github.com/codeactual/kubeauth/cmd/kubeauth/ctl"
 	"github.com/codeactual/kubeauth/internal/cage/cli/handler"
 	cage_k8s "github.com/codeactual/kubeauth/internal/cage/kubernetes/v1"
+	cage_k8s_identity "github.com/codeactual/kubeauth/internal/cage/kubernetes/v1/rbac/identity"
+	testkit_file "github.com/codeactual/kubeauth/internal/cage/testkit/os/file"
 	"github.com/codeactual/kubeauth/internal/testkit"
 )
 
@@ -271,6 +278,25 @@ func TestCoreUser(t *testing.T) {
 	require.Contains(t, stderr.String(), "User "+username+" via [system-defined user] querier")
 }
 
+func TestNodeUser(t *testing.T) {
+	// Expected query's parameters and results.
+
+	nodeName := "worker-1"
+	username := cage_k8s_identity.NodeUserPrefix + nodeName
+	namespace := "" // node users are namespace agnostic
+
+	resultset := testkit.NewQueryResultset()
+	resultset.Node.Add(namespace, cage_k8s.KindUser, username, &cage_k8s_identity.IdentitySource{
+		TypeMeta:   meta.TypeMeta{Kind: cage_k8s.KindNode},
+		ObjectMeta: meta.ObjectMeta{Name: nodeName},
+	})
+
+	// Run the CLI handler.
+
+	_, stderr := RequireUserQueryWithDefaultFlags(t, username, resultset)
+	require.Contains(t, stderr.String(), "User "+username+" (from Node "+nodeName+") via [node] querier")
+}
+
 func TestServiceAccountUser(t *testing.T) {
 	// Expected query's parameters and results.
 
@@ -375,3 +401,230 @@ func TestClusterRoleSubjectGroup(t *testing.T) {
 	_, stderr := RequireGroupQueryWithDefaultFlags(t, group, resultset)
 	require.Contains(t, stderr.String(), "Group "+group+" of namespace "+namespace+" via [cluster role binding subject] querier")
 }
+
+func TestIdentityFileUser(t *testing.T) {
+	// Expected query's parameters and results.
+
+	username := testkit.Username
+	identityFile, _ := testkit_file.FixturePath(t, "identities.yaml")
+
+	stdout, stderr := &bytes.Buffer{}, &bytes.Buffer{}
+
+	kit := NewHandlerKit(t)
+	kit.Stdout = stdout
+	kit.Stderr = stderr
+	kit.UserQueryWithDefaultFlags(username, testkit.NewQueryResultset())
+	kit.StandardCommand(
+		"kubectl", "auth", "can-i",
+		"--kubeconfig", testkit.ConfigFilename,
+		"--as", username,
+	)
+	kit.Finish()
+	defer kit.MockCtrl.Finish()
+
+	// Run the CLI handler.
+
+	h := NewHandler(kit)
+	h.As = username
+	h.IdentityFile = []string{identityFile}
+	h.Run(testkit.Ctx(), handler.Input{ArgsBeforeDash: []string{"auth", "can-i"}})
+
+	require.Contains(t, stderr.String(), "User "+username+" via [identity file ["+identityFile+"]] querier")
+}
+
+func TestGroupFileGroup(t *testing.T) {
+	// Expected query's parameters and results.
+
+	group := testkit.GroupName
+	groupFile, _ := testkit_file.FixturePath(t, "group")
+
+	stdout, stderr := &bytes.Buffer{}, &bytes.Buffer{}
+
+	kit := NewHandlerKit(t)
+	kit.Stdout = stdout
+	kit.Stderr = stderr
+	kit.GroupQueryWithDefaultFlags(group, testkit.NewQueryResultset())
+	kit.StandardCommand(
+		"kubectl", "auth", "can-i",
+		"--kubeconfig", testkit.ConfigFilename,
+		"--as-group", group,
+	)
+	kit.Finish()
+	defer kit.MockCtrl.Finish()
+
+	// Run the CLI handler.
+
+	h := NewHandler(kit)
+	h.AsGroup = []string{group}
+	h.GroupFile = []string{groupFile}
+	h.Run(testkit.Ctx(), handler.Input{ArgsBeforeDash: []string{"auth", "can-i"}})
+
+	require.Contains(t, stderr.String(), "Group "+group+" via [group file ["+groupFile+"]] querier")
+}
+
+// TestErrOnUnknownQuerier asserts that the command stops if --disable-querier does not select a registered querier.
+func TestErrOnUnknownQuerier(t *testing.T) {
+	kit := NewHandlerKit(t)
+	kit.ExitOnErr = regexp.MustCompile(`querier \[does-not-exist\] is not registered`)
+	kit.NamespaceValidated = false
+	kit.Finish()
+	defer kit.MockCtrl.Finish()
+
+	h := NewHandler(kit)
+	h.As = testkit.Username
+	h.DisableQuerier = []string{"does-not-exist"}
+	h.Run(context.Background(), handler.Input{ArgsBeforeDash: []string{"auth", "can-i"}})
+}
+
+// TestOIDCGroup asserts that --as-group selections receive the --oidc-groups-prefix and are found in
+// the ID tokens of kubeconfig users.
+func TestOIDCGroup(t *testing.T) {
+	// Expected query's parameters and results.
+
+	group := "qa"
+	prefixedGroup := "oidc:" + group
+
+	claims, err := json.Marshal(map[string]interface{}{"iss": "https://issuer.example.com", "sub": "alice", "groups": []string{group}})
+	require.NoError(t, err)
+	token := "e30." + base64.RawURLEncoding.EncodeToString(claims) + ".sig"
+
+	stdout, stderr := &bytes.Buffer{}, &bytes.Buffer{}
+
+	kit := NewHandlerKit(t)
+	kit.Stdout = stdout
+	kit.Stderr = stderr
+	kit.AuthInfos = map[string]*clientcmdapi.AuthInfo{
+		"alice-oidc": {
+			AuthProvider: &clientcmdapi.AuthProviderConfig{
+				Name:   cage_k8s_identity.OIDCAuthProvider,
+				Config: map[string]string{"id-token": token},
+			},
+		},
+	}
+	// The group is queried as given, and the OIDC querier resolves the prefixed name from the ID token.
+	kit.GroupQueryWithDefaultFlags(group, testkit.NewQueryResultset())
+	kit.StandardCommand(
+		"kubectl", "auth", "can-i",
+		"--kubeconfig", testkit.ConfigFilename,
+		"--as-group", prefixedGroup,
+	)
+	kit.Finish()
+	defer kit.MockCtrl.Finish()
+
+	// Run the CLI handler.
+
+	h := NewHandler(kit)
+	h.AsGroup = []string{group}
+	h.OIDCGroupsPrefix = "oidc:"
+	h.Run(testkit.Ctx(), handler.Input{ArgsBeforeDash: []string{"auth", "can-i"}})
+
+	require.Contains(t, stderr.String(), "Group "+prefixedGroup+" (from AuthInfo alice-oidc) via [oidc token] querier")
+}
+
+func TestOIDCPrefixSkipsFoundUser(t *testing.T) {
+	// Expected query's parameters and results.
+
+	username := testkit.Username
+
+	resultset := testkit.NewQueryResultset()
+	resultset.ConfigUser.Add(testkit.CurrentNamespace, cage_k8s.KindUser, username, nil)
+
+	stdout, stderr := &bytes.Buffer{}, &bytes.Buffer{}
+
+	kit := NewHandlerKit(t)
+	kit.Stdout = stdout
+	kit.Stderr = stderr
+	kit.UserQueryWithDefaultFlags(username, resultset)
+	kit.StandardCommand(
+		"kubectl", "auth", "can-i",
+		"--kubeconfig", testkit.ConfigFilename,
+		"--as", username,
+	)
+	kit.Finish()
+	defer kit.MockCtrl.Finish()
+
+	// Run the CLI handler.
+
+	h := NewHandler(kit)
+	h.As = username
+	h.OIDCUsernamePrefix = "oidc:"
+	h.Run(testkit.Ctx(), handler.Input{ArgsBeforeDash: []string{"auth", "can-i"}})
+
+	require.NotContains(t, stderr.String(), "using OIDC --as")
+}
+
+func TestSkipInvalidOIDCToken(t *testing.T) {
+	// Expected query's parameters and results.
+
+	group := "system:masters"
+
+	resultset := testkit.NewQueryResultset()
+	resultset.CoreGroup.Add("", cage_k8s.KindGroup, group, nil)
+
+	stdout, stderr := &bytes.Buffer{}, &bytes.Buffer{}
+
+	kit := NewHandlerKit(t)
+	kit.Stdout = stdout
+	kit.Stderr = stderr
+	kit.AuthInfos = map[string]*clientcmdapi.AuthInfo{
+		"alice-oidc": {
+			AuthProvider: &clientcmdapi.AuthProviderConfig{
+				Name:   cage_k8s_identity.OIDCAuthProvider,
+				Config: map[string]string{"id-token": "not-a-jwt"},
+			},
+		},
+	}
+	kit.GroupQueryWithDefaultFlags(group, resultset)
+	kit.StandardCommand(
+		"kubectl", "auth", "can-i",
+		"--kubeconfig", testkit.ConfigFilename,
+		"--as-group", group,
+	)
+	kit.Finish()
+	defer kit.MockCtrl.Finish()
+
+	// Run the CLI handler.
+
+	h := NewHandler(kit)
+	h.AsGroup = []string{group}
+	h.Run(testkit.Ctx(), handler.Input{ArgsBeforeDash: []string{"auth", "can-i"}})
+
+	require.Contains(t, stderr.String(), "skipped ID token of kubeconfig user [alice-oidc]: ID token is not a JWT")
+	require.Contains(t, stderr.String(), "Group "+group+" via [system-defined group] querier")
+}
+
+func TestOIDCPrefixFallback(t *testing.T) {
+	// Expected query's parameters and results.
+
+	group := "qa"
+	prefixedGroup := "oidc:" + group
+
+	resultset := testkit.NewQueryResultset()
+	resultset.RoleSubject.Add("", cage_k8s.KindGroup, prefixedGroup, nil)
+
+	stdout, stderr := &bytes.Buffer{}, &bytes.Buffer{}
+
+	kit := NewHandlerKit(t)
+	kit.Stdout = stdout
+	kit.Stderr = stderr
+
+	// The group is first queried as given, and then with the prefix.
+	kit.GroupQueryWithDefaultFlags(group, testkit.NewQueryResultset())
+	kit.GroupQueryWithDefaultFlags(prefixedGroup, resultset)
+	kit.StandardCommand(
+		"kubectl", "auth", "can-i",
+		"--kubeconfig", testkit.ConfigFilename,
+		"--as-group", prefixedGroup,
+	)
+	kit.Finish()
+	defer kit.MockCtrl.Finish()
+
+	// Run the CLI handler.
+
+	h := NewHandler(kit)
+	h.AsGroup = []string{group}
+	h.OIDCGroupsPrefix = "oidc:"
+	h.Run(testkit.Ctx(), handler.Input{ArgsBeforeDash: []string{"auth", "can-i"}})
+
+	require.Contains(t, stderr.String(), "using OIDC --as-group ["+prefixedGroup+"] for ["+group+"]")
+}
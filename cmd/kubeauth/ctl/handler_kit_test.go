@@ -9,8 +9,11 @@ package ctl_test
 import (
 	"bytes"
 	"os/exec"
+	"strings"
 	"testing"
 
+	clientcmdapi "k8s.io/client-go/tools/clientcmd/api"
+
 	"github.com/codeactual/kubeauth/internal/cage/cli/handler"
 	mock_core "github.com/codeactual/kubeauth/internal/cage/kubernetes/v1/core/mock"
 	cage_k8s_identity "github.com/codeactual/kubeauth/internal/cage/kubernetes/v1/rbac/identity"
@@ -32,6 +35,9 @@ type HandlerKit struct {
 
 	// NamespaceValidated is true if the Get call should be mocked during Finish.
 	NamespaceValidated bool
+
+	// AuthInfos are added to the users of the parsed config file.
+	AuthInfos map[string]*clientcmdapi.AuthInfo
 }
 
 func NewHandlerKit(t *testing.T) *HandlerKit {
@@ -60,9 +66,14 @@ func (k *HandlerKit) Finish() {
 	}
 
 	if k.ConfigParsed {
+		configFile := testkit.NewConfigFile(testkit.ConfigFilename, context, cluster, namespace)
+		if len(k.AuthInfos) > 0 {
+			configFile.ClientCmdConfig.AuthInfos = k.AuthInfos
+		}
+
 		k.ConfigClient.EXPECT().
 			Parse("").
-			Return(testkit.NewConfigFile(testkit.ConfigFilename, context, cluster, namespace), nil)
+			Return(configFile, nil)
 	}
 
 	if k.NamespaceValidated {
@@ -95,6 +106,12 @@ func (k *HandlerKit) UserQuery(allNamspaces bool, context, cluster, namespace, u
 	k.IdentityRegistry.CoreUser.EXPECT().
 		Do(cage_gomock.ContextNonNil(), mock_core.MatchClientset(expectClientset), query).
 		Return(resultset.CoreUser, nil)
+	// The node querier only serves queries of node users.
+	if strings.HasPrefix(username, cage_k8s_identity.NodeUserPrefix) {
+		k.IdentityRegistry.Node.EXPECT().
+			Do(cage_gomock.ContextNonNil(), mock_core.MatchClientset(expectClientset), query).
+			Return(resultset.Node, nil)
+	}
 	k.IdentityRegistry.RoleSubject.EXPECT().
 		Do(cage_gomock.ContextNonNil(), mock_core.MatchClientset(expectClientset), query).
 		Return(resultset.RoleSubject, nil)
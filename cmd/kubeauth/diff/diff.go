@@ -0,0 +1,205 @@
+// Copyright (C) 2020 The kubeauth Authors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package diff
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"text/tabwriter"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+	"k8s.io/client-go/kubernetes"
+
+	"github.com/codeactual/kubeauth/internal/cage/cli/handler"
+	handler_cobra "github.com/codeactual/kubeauth/internal/cage/cli/handler/cobra"
+	cage_k8s "github.com/codeactual/kubeauth/internal/cage/kubernetes/v1"
+	cage_k8s_core "github.com/codeactual/kubeauth/internal/cage/kubernetes/v1/core"
+	cage_k8s_config "github.com/codeactual/kubeauth/internal/cage/kubernetes/v1/kubectl/config"
+	cage_reflect "github.com/codeactual/kubeauth/internal/cage/reflect"
+	"github.com/codeactual/kubeauth/internal/snapshot"
+)
+
+const (
+	OutputJSON  = "json"
+	OutputTable = "table"
+)
+
+// Handler defines the sub-command flags and logic.
+type Handler struct {
+	handler.Session
+
+	KubeApiClientset    *cage_k8s_core.Clientset
+	KubectlConfigClient cage_k8s_config.Client
+
+	ConfigFile string `usage:"kubectl config file of the cluster to compare against if only one snapshot is selected"`
+	Output     string `usage:"report format: table or json"`
+
+	// Verbosity levels greater than 0 will enable status messages and error stack traces.
+	//
+	// It is an int for consistency with other commands, even though levels beyond 1 are not used.
+	Verbosity int `usage:"kubectl verbosity level"`
+}
+
+// Init defines the command, its environment variable prefix, etc.
+//
+// It implements cli/handler/cobra.Handler.
+func (h *Handler) Init() handler_cobra.Init {
+	return handler_cobra.Init{
+		Cmd: &cobra.Command{
+			Use:   "diff <snapshot file> [<snapshot file>]",
+			Short: "Report the grants added or removed per subject between two snapshots, or a snapshot and the cluster",
+		},
+		EnvPrefix: "KUBEAUTH",
+	}
+}
+
+// BindFlags binds the flags to Handler fields.
+//
+// It implements cli/handler/cobra.Handler.
+func (h *Handler) BindFlags(cmd *cobra.Command) []string {
+	cmd.Flags().StringVarP(&h.ConfigFile, "kubeconfig", "", "", cage_reflect.GetFieldTag(*h, "ConfigFile", "usage"))
+	cmd.Flags().StringVarP(&h.Output, "output", "o", OutputTable, cage_reflect.GetFieldTag(*h, "Output", "usage"))
+	cmd.Flags().IntVarP(&h.Verbosity, "v", "v", 0, cage_reflect.GetFieldTag(*h, "Verbosity", "usage"))
+	return []string{}
+}
+
+// Run performs the sub-command logic.
+//
+// It implements cli/handler/cobra.Handler.
+func (h *Handler) Run(ctx context.Context, input handler.Input) {
+	if err := h.run(ctx, input); err != nil {
+		if h.Verbosity > 0 {
+			h.ExitOnErr(err, "", 1)
+		} else {
+			h.ExitOnErrShort(err, "", 1)
+		}
+	}
+}
+
+func (h *Handler) run(ctx context.Context, input handler.Input) error {
+	stderr := h.Err()
+	verbose := func(format string, vArgs ...interface{}) {
+		if h.Verbosity > 0 {
+			fmt.Fprintln(stderr, "kubeauth: "+fmt.Sprintf(format, vArgs...))
+		}
+	}
+
+	switch h.Output {
+	case "":
+		h.Output = OutputTable
+	case OutputTable, OutputJSON:
+	default:
+		return errors.Errorf("kubeauth: --output must be %q or %q", OutputTable, OutputJSON)
+	}
+
+	if len(input.Args) < 1 || len(input.Args) > 2 {
+		return errors.Errorf("kubeauth: expected one or two snapshot files, found %d", len(input.Args))
+	}
+
+	// Read the snapshots. If only one was selected, compare it against the cluster.
+
+	before, err := h.read(input.Args[0])
+	if err != nil {
+		return errors.Wrap(err, "kubeauth")
+	}
+	verbose("read snapshot [%s]", input.Args[0])
+
+	var after *snapshot.Snapshot
+
+	if len(input.Args) == 2 {
+		if after, err = h.read(input.Args[1]); err != nil {
+			return errors.Wrap(err, "kubeauth")
+		}
+		verbose("read snapshot [%s]", input.Args[1])
+	} else {
+		configClient := h.KubectlConfigClient
+		if configClient == nil {
+			configClient = cage_k8s_config.NewDefaultClient()
+		}
+
+		configFile, err := configClient.Parse(h.ConfigFile)
+		if err != nil {
+			return errors.Wrap(err, "kubeauth") // WithStack alternative to disambiguate from kubectl output
+		}
+
+		apiClientset := h.KubeApiClientset
+		if apiClientset == nil {
+			rawApiClientset, err := kubernetes.NewForConfig(configFile.RestConfig)
+			if err != nil {
+				return errors.Wrap(err, "kubeauth: failed to create API client")
+			}
+
+			apiClientset = cage_k8s_core.NewClientset(rawApiClientset)
+		}
+
+		if after, err = snapshot.List(apiClientset); err != nil {
+			return errors.Wrap(err, "kubeauth") // WithStack alternative to disambiguate from kubectl output
+		}
+		verbose("captured snapshot of the cluster in kubeconfig [%s]", configFile.Name)
+	}
+
+	diffs := snapshot.Diff(before, after)
+
+	// Report.
+
+	switch h.Output {
+	case OutputJSON:
+		enc := json.NewEncoder(h.Out())
+		enc.SetIndent("", "  ")
+		if err = enc.Encode(diffs); err != nil {
+			return errors.Wrap(err, "kubeauth: failed to write report")
+		}
+	default:
+		w := tabwriter.NewWriter(h.Out(), 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "KIND\tNAMESPACE\tNAME\tSTATUS\tCHANGE\tGRANT")
+		for _, d := range diffs {
+			ns := d.Subject.Namespace
+			if ns == "" {
+				ns = cage_k8s.EmptyNamespace
+			}
+			for _, g := range d.Added {
+				fmt.Fprintf(w, "%s\t%s\t%s\t%s\t+\t%s\n", d.Subject.Kind, ns, d.Subject.Name, d.Status, g)
+			}
+			for _, g := range d.Removed {
+				fmt.Fprintf(w, "%s\t%s\t%s\t%s\t-\t%s\n", d.Subject.Kind, ns, d.Subject.Name, d.Status, g)
+			}
+		}
+		if err = w.Flush(); err != nil {
+			return errors.Wrap(err, "kubeauth: failed to write report")
+		}
+	}
+
+	return nil
+}
+
+// read returns the snapshot in the file.
+func (h *Handler) read(name string) (*snapshot.Snapshot, error) {
+	f, err := os.Open(name)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to open snapshot [%s]", name)
+	}
+	defer f.Close()
+
+	s, err := snapshot.Read(f)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to read snapshot [%s]", name)
+	}
+
+	return s, nil
+}
+
+// New returns a cobra command instance based on Handler.
+func NewCommand() *cobra.Command {
+	return handler_cobra.NewHandler(&Handler{
+		Session: &handler.DefaultSession{},
+	})
+}
+
+var _ handler_cobra.Handler = (*Handler)(nil)
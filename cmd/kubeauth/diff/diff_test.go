@@ -0,0 +1,218 @@
+// Copyright (C) 2020 The kubeauth Authors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at https://mozilla.org/MPL/2.0/.
+
+// Package diff_test asserts CLI behavior by running the command handler logic
+// directly (w/o separate processes) with various input scenarios.
+//
+// It uses Handler instances that use mock implementations of the clients used
+// to modify kubeconfig files and perform API requests. The tests only verify correct
+// use of the client interfaces. Tests in the internal/snapshot package verify the
+// grant comparisons in more detail.
+package diff_test
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	rbac "k8s.io/api/rbac/v1"
+	meta "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	cli "github.com/codeactual/kubeauth/cmd/kubeauth/diff"
+	"github.com/codeactual/kubeauth/internal/audit"
+	"github.com/codeactual/kubeauth/internal/cage/cli/handler"
+	cage_k8s "github.com/codeactual/kubeauth/internal/cage/kubernetes/v1"
+	testkit_file "github.com/codeactual/kubeauth/internal/cage/testkit/os/file"
+	"github.com/codeactual/kubeauth/internal/snapshot"
+)
+
+func NewHandler(kit *HandlerKit) *cli.Handler {
+	h := cli.Handler{
+		Session:             kit.Session,
+		KubectlConfigClient: kit.ConfigClient,
+		KubeApiClientset:    kit.ApiClientset.ToReal(),
+	}
+
+	// Enable for test troubleshooting and verbose output assertions.
+	h.Verbosity = 1
+
+	return &h
+}
+
+// reportLines returns the table's rows, excluding the header, with whitespace collapsed.
+func reportLines(t *testing.T, kit *HandlerKit) []string {
+	lines := strings.Split(strings.TrimSpace(kit.Stdout.String()), "\n")
+	require.True(t, strings.HasPrefix(lines[0], "KIND"))
+
+	var rows []string
+	for _, l := range lines[1:] {
+		rows = append(rows, strings.Join(strings.Fields(l), " "))
+	}
+	return rows
+}
+
+// newObjects returns a role which grants pod access, bound to the subjects.
+func newObjects(verbs []string, subjects ...rbac.Subject) audit.Objects {
+	return audit.Objects{
+		Roles: []rbac.Role{
+			{
+				ObjectMeta: meta.ObjectMeta{Namespace: "dev", Name: "pods"},
+				Rules:      []rbac.PolicyRule{{Verbs: verbs, APIGroups: []string{""}, Resources: []string{"pods"}}},
+			},
+		},
+		RoleBindings: []rbac.RoleBinding{
+			{
+				ObjectMeta: meta.ObjectMeta{Namespace: "dev", Name: "pods"},
+				RoleRef:    rbac.RoleRef{Kind: cage_k8s.KindRole, Name: "pods"},
+				Subjects:   subjects,
+			},
+		},
+	}
+}
+
+// writeSnapshot writes a snapshot of the objects and returns its path.
+func writeSnapshot(t *testing.T, name string, objs audit.Objects) string {
+	_, path := testkit_file.CreatePath(t, name)
+
+	f, err := os.Create(path)
+	require.NoError(t, err)
+	defer f.Close()
+
+	require.NoError(t, snapshot.Write(f, snapshot.New(objs)))
+
+	return path
+}
+
+var (
+	userA = rbac.Subject{Kind: cage_k8s.KindUser, Name: "user-a"}
+	userB = rbac.Subject{Kind: cage_k8s.KindUser, Name: "user-b"}
+)
+
+func TestFiles(t *testing.T) {
+	testkit_file.ResetTestdata(t)
+	before := writeSnapshot(t, "before.json", newObjects([]string{"get", "list"}, userA))
+	after := writeSnapshot(t, "after.json", newObjects([]string{"get"}, userA, userB))
+
+	kit := NewHandlerKit(t)
+	kit.Finish()
+	defer kit.MockCtrl.Finish()
+
+	h := NewHandler(kit)
+	h.Run(context.Background(), handler.Input{Args: []string{before, after}})
+
+	require.Exactly(
+		t,
+		[]string{
+			"User <no namespace> user-a changed - list pods in namespace [dev]",
+			"User <no namespace> user-b added + get pods in namespace [dev]",
+		},
+		reportLines(t, kit),
+	)
+}
+
+func TestCluster(t *testing.T) {
+	testkit_file.ResetTestdata(t)
+	before := writeSnapshot(t, "before.json", newObjects([]string{"get"}, userA))
+
+	kit := NewHandlerKit(t)
+	kit.Live = true
+	objs := newObjects([]string{"get"}, userB)
+	kit.Roles = objs.Roles
+	kit.RoleBindings = objs.RoleBindings
+	kit.Finish()
+	defer kit.MockCtrl.Finish()
+
+	h := NewHandler(kit)
+	h.Run(context.Background(), handler.Input{Args: []string{before}})
+
+	require.Exactly(
+		t,
+		[]string{
+			"User <no namespace> user-a removed - get pods in namespace [dev]",
+			"User <no namespace> user-b added + get pods in namespace [dev]",
+		},
+		reportLines(t, kit),
+	)
+}
+
+func TestJSON(t *testing.T) {
+	testkit_file.ResetTestdata(t)
+	before := writeSnapshot(t, "before.json", newObjects([]string{"get"}, userA))
+	after := writeSnapshot(t, "after.json", newObjects([]string{"get"}))
+
+	kit := NewHandlerKit(t)
+	kit.Finish()
+	defer kit.MockCtrl.Finish()
+
+	h := NewHandler(kit)
+	h.Output = cli.OutputJSON
+	h.Run(context.Background(), handler.Input{Args: []string{before, after}})
+
+	var diffs []snapshot.SubjectDiff
+	require.NoError(t, json.Unmarshal(kit.Stdout.Bytes(), &diffs))
+	require.Exactly(
+		t,
+		[]snapshot.SubjectDiff{
+			{
+				Subject: userA,
+				Status:  snapshot.StatusRemoved,
+				Removed: []snapshot.Grant{{Namespace: "dev", Verb: "get", Resource: "pods"}},
+			},
+		},
+		diffs,
+	)
+}
+
+// TestJSONEmpty asserts that an empty list, rather than null, is written if there are no differences.
+func TestJSONEmpty(t *testing.T) {
+	testkit_file.ResetTestdata(t)
+	before := writeSnapshot(t, "before.json", newObjects([]string{"get"}, userA))
+
+	kit := NewHandlerKit(t)
+	kit.Finish()
+	defer kit.MockCtrl.Finish()
+
+	h := NewHandler(kit)
+	h.Output = cli.OutputJSON
+	h.Run(context.Background(), handler.Input{Args: []string{before, before}})
+
+	require.Exactly(t, "[]\n", kit.Stdout.String())
+}
+
+func TestErrOnArgs(t *testing.T) {
+	kit := NewHandlerKit(t)
+	kit.ExitOnErr = regexp.MustCompile(regexp.QuoteMeta("expected one or two snapshot files, found 0"))
+	kit.Finish()
+	defer kit.MockCtrl.Finish()
+
+	h := NewHandler(kit)
+	h.Run(context.Background(), handler.Input{})
+}
+
+func TestErrOnInvalidOutput(t *testing.T) {
+	kit := NewHandlerKit(t)
+	kit.ExitOnErr = regexp.MustCompile(regexp.QuoteMeta(`--output must be "table" or "json"`))
+	kit.Finish()
+	defer kit.MockCtrl.Finish()
+
+	h := NewHandler(kit)
+	h.Output = "yaml"
+	h.Run(context.Background(), handler.Input{Args: []string{"a.json", "b.json"}})
+}
+
+func TestErrOnMissingFile(t *testing.T) {
+	kit := NewHandlerKit(t)
+	kit.ExitOnErr = regexp.MustCompile(regexp.QuoteMeta("failed to open snapshot [does-not-exist.json]"))
+	kit.Finish()
+	defer kit.MockCtrl.Finish()
+
+	h := NewHandler(kit)
+	h.Run(context.Background(), handler.Input{Args: []string{"does-not-exist.json", "b.json"}})
+}
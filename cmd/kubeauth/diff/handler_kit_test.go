@@ -0,0 +1,85 @@
+// Copyright (C) 2020 The kubeauth Authors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package diff_test
+
+import (
+	"bytes"
+	"testing"
+
+	core "k8s.io/api/core/v1"
+	rbac "k8s.io/api/rbac/v1"
+
+	cage_gomock "github.com/codeactual/kubeauth/internal/cage/testkit/gomock"
+	"github.com/codeactual/kubeauth/internal/testkit"
+)
+
+// HandlerKit provides command test cases with data and mock-setup boilerplate.
+//
+// It integrates thc HandlerKit type from the internal/testkit package for additional
+// command-agnostic boilerplate.
+type HandlerKit struct {
+	*testkit.HandlerKit
+
+	T *testing.T
+
+	// Live is true if the command should compare a snapshot against the cluster.
+	Live bool
+
+	// Roles, ClusterRoles, RoleBindings, ClusterRoleBindings, and ServiceAccounts are returned by
+	// the List calls which Finish configures if Live is true.
+	Roles               []rbac.Role
+	ClusterRoles        []rbac.ClusterRole
+	RoleBindings        []rbac.RoleBinding
+	ClusterRoleBindings []rbac.ClusterRoleBinding
+	ServiceAccounts     []core.ServiceAccount
+
+	// Stdout receives the command's report.
+	Stdout *bytes.Buffer
+}
+
+func NewHandlerKit(t *testing.T) *HandlerKit {
+	k := &HandlerKit{
+		HandlerKit: testkit.NewHandlerKit(t),
+		T:          t,
+		Stdout:     &bytes.Buffer{},
+	}
+	k.HandlerKit.Stdout = k.Stdout
+	return k
+}
+
+// Finish creates the expected calls, based on mock-related HandlerKit fields, that were not
+// already created by other methods.
+func (k *HandlerKit) Finish() {
+	k.HandlerKit.Finish()
+
+	if k.ExitOnErr != nil {
+		k.Session.EXPECT().ExitOnErr(cage_gomock.ErrShortRegexp(k.ExitOnErr), "", 1)
+	}
+
+	if !k.Live {
+		return
+	}
+
+	k.ConfigClient.EXPECT().
+		Parse("").
+		Return(testkit.NewConfigFile(testkit.ConfigFilename, testkit.CurrentContextName, testkit.CurrentClusterName, testkit.CurrentNamespace), nil)
+	k.ApiClientset.Roles.EXPECT().
+		List("").
+		Return(&rbac.RoleList{Items: k.Roles}, nil)
+	k.ApiClientset.ClusterRoles.EXPECT().
+		List().
+		Return(&rbac.ClusterRoleList{Items: k.ClusterRoles}, nil)
+	k.ApiClientset.RoleBindings.EXPECT().
+		List("").
+		Return(&rbac.RoleBindingList{Items: k.RoleBindings}, nil)
+	k.ApiClientset.ClusterRoleBindings.EXPECT().
+		List().
+		Return(&rbac.ClusterRoleBindingList{Items: k.ClusterRoleBindings}, nil)
+	k.ApiClientset.ServiceAccounts.EXPECT().
+		List("").
+		Return(&core.ServiceAccountList{Items: k.ServiceAccounts}, nil)
+}
@@ -0,0 +1,406 @@
+// Copyright (C) 2020 The kubeauth Authors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package gc
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+	rbac "k8s.io/api/rbac/v1"
+	meta "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+
+	"github.com/codeactual/kubeauth/internal/cage/cli/handler"
+	handler_cobra "github.com/codeactual/kubeauth/internal/cage/cli/handler/cobra"
+	cage_k8s "github.com/codeactual/kubeauth/internal/cage/kubernetes/v1"
+	cage_k8s_core "github.com/codeactual/kubeauth/internal/cage/kubernetes/v1/core"
+	cage_k8s_config "github.com/codeactual/kubeauth/internal/cage/kubernetes/v1/kubectl/config"
+	cage_k8s_rbac "github.com/codeactual/kubeauth/internal/cage/kubernetes/v1/rbac"
+	cage_reflect "github.com/codeactual/kubeauth/internal/cage/reflect"
+	"github.com/codeactual/kubeauth/internal/ownership"
+)
+
+const (
+	// StatusDeleted is reported for orphaned objects which --delete removed.
+	StatusDeleted = "deleted"
+
+	// StatusOrphaned is reported for objects created by add-user none of whose kubeconfig users still exist.
+	StatusOrphaned = "orphaned"
+
+	// StatusPruned is reported for shared bindings from which --delete removed the subjects of the
+	// users which no longer exist.
+	StatusPruned = "pruned"
+
+	// StatusShared is reported for bindings created by add-user which are shared by users that still
+	// exist and users that no longer do. --delete removes the latter's subjects instead of the binding.
+	StatusShared = "shared"
+)
+
+// Handler defines the sub-command flags and logic.
+type Handler struct {
+	handler.Session
+
+	KubeApiClientset    *cage_k8s_core.Clientset
+	KubectlConfigClient cage_k8s_config.Client
+
+	AllCreators bool   `usage:"consider objects created with the credentials of any user, not only the current context's"`
+	ConfigFile  string `usage:"kubectl config file whose users are considered in use"`
+	Delete      bool   `usage:"delete the orphaned objects, and remove the subjects of missing users from shared bindings, instead of only listing them"`
+
+	// Verbosity levels greater than 0 will enable status messages and error stack traces.
+	//
+	// It is an int for consistency with other commands, even though levels beyond 1 are not used.
+	Verbosity int `usage:"kubectl verbosity level"`
+}
+
+// Init defines the command, its environment variable prefix, etc.
+//
+// It implements cli/handler/cobra.Handler.
+func (h *Handler) Init() handler_cobra.Init {
+	return handler_cobra.Init{
+		Cmd: &cobra.Command{
+			Use:   "gc",
+			Short: "List or delete objects created by kubeauth for users no longer in the kubeconfig",
+			Long: "List or delete objects created by kubeauth for users no longer in the kubeconfig.\n\n" +
+				"Only objects whose kubeauth/created-by annotation names the current context's user are considered, " +
+				"so that objects created by other administrators, e.g. for users in their own kubeconfigs, are left alone. " +
+				"Select --all-creators to consider all of them.",
+		},
+		EnvPrefix: "KUBEAUTH",
+	}
+}
+
+// BindFlags binds the flags to Handler fields.
+//
+// It implements cli/handler/cobra.Handler.
+func (h *Handler) BindFlags(cmd *cobra.Command) []string {
+	cmd.Flags().BoolVarP(&h.AllCreators, "all-creators", "", false, cage_reflect.GetFieldTag(*h, "AllCreators", "usage"))
+	cmd.Flags().StringVarP(&h.ConfigFile, "kubeconfig", "", "", cage_reflect.GetFieldTag(*h, "ConfigFile", "usage"))
+	cmd.Flags().BoolVarP(&h.Delete, "delete", "", false, cage_reflect.GetFieldTag(*h, "Delete", "usage"))
+	cmd.Flags().IntVarP(&h.Verbosity, "v", "v", 0, cage_reflect.GetFieldTag(*h, "Verbosity", "usage"))
+	return []string{}
+}
+
+// Run performs the sub-command logic.
+//
+// It implements cli/handler/cobra.Handler.
+func (h *Handler) Run(ctx context.Context, input handler.Input) {
+	if err := h.run(ctx, input); err != nil {
+		if h.Verbosity > 0 {
+			h.ExitOnErr(err, "", 1)
+		} else {
+			h.ExitOnErrShort(err, "", 1)
+		}
+	}
+}
+
+// orphan describes an object created by kubeauth for users which are no longer in the kubeconfig.
+type orphan struct {
+	kind    string
+	objMeta meta.ObjectMeta
+	status  string
+
+	// departed holds the object's users which are no longer in the kubeconfig.
+	departed []string
+
+	// roleBinding and clusterRoleBinding hold the update of a shared binding, without the subjects
+	// and records of the departed users.
+	roleBinding        *rbac.RoleBinding
+	clusterRoleBinding *rbac.ClusterRoleBinding
+}
+
+func (h *Handler) run(ctx context.Context, _ handler.Input) error {
+	stderr := h.Err()
+	verbose := func(format string, vArgs ...interface{}) {
+		if h.Verbosity > 0 {
+			fmt.Fprintln(stderr, "kubeauth: "+fmt.Sprintf(format, vArgs...))
+		}
+	}
+	warn := func(format string, vArgs ...interface{}) {
+		fmt.Fprintln(stderr, "kubeauth: warning: "+fmt.Sprintf(format, vArgs...))
+	}
+
+	configClient := h.KubectlConfigClient
+	if configClient == nil {
+		configClient = cage_k8s_config.NewDefaultClient()
+	}
+
+	configFile, err := configClient.Parse(h.ConfigFile)
+	if err != nil {
+		return errors.Wrap(err, "kubeauth") // WithStack alternative to disambiguate from kubectl output
+	}
+
+	apiClientset := h.KubeApiClientset
+	if apiClientset == nil {
+		rawApiClientset, err := kubernetes.NewForConfig(configFile.RestConfig)
+		if err != nil {
+			return errors.Wrap(err, "kubeauth: failed to create API client")
+		}
+
+		apiClientset = cage_k8s_core.NewClientset(rawApiClientset)
+	}
+
+	// Only consider the objects created with the current context's credentials, unless --all-creators is selected.
+
+	_, curContext, err := configFile.GetCurrentContext()
+	if err != nil {
+		return errors.Wrap(err, "kubeauth") // WithStack alternative to disambiguate from kubectl output
+	}
+
+	// Find the managed objects whose users are not in the config.
+	//
+	// Bindings are listed first so that they are deleted before the roles and service accounts they refer to.
+
+	listOptions := meta.ListOptions{LabelSelector: ownership.Selector}
+	var orphans []orphan
+
+	// departedUsers returns the object's users which are not in the config, and those which are.
+	// It returns false if the object cannot be considered, e.g. because it has no users.
+	departedUsers := func(kind string, objMeta meta.ObjectMeta) (departed, remaining []string, ok bool) {
+		if creator := objMeta.Annotations[ownership.CreatorAnnotation]; !h.AllCreators && creator != curContext.AuthInfo {
+			verbose("skipped %s [%s] in namespace [%s] created by [%s] (use --all-creators to include it)", kind, objMeta.Name, objMeta.Namespace, creator)
+			return nil, nil, false
+		}
+
+		users, err := ownership.Users(objMeta)
+		if err != nil {
+			warn("skipped %s [%s] in namespace [%s]: %s", kind, objMeta.Name, objMeta.Namespace, err)
+			return nil, nil, false
+		}
+		if len(users) == 0 {
+			verbose("skipped %s [%s] in namespace [%s] which lacks annotation [%s]", kind, objMeta.Name, objMeta.Namespace, ownership.UserAnnotation)
+			return nil, nil, false
+		}
+		for _, u := range users {
+			if authInfo, ok := configFile.ClientCmdConfig.AuthInfos[u]; ok && authInfo != nil {
+				remaining = append(remaining, u)
+			} else {
+				departed = append(departed, u)
+			}
+		}
+		if len(departed) > 0 && len(remaining) > 0 {
+			verbose("kept %s [%s] in namespace [%s] which is still used by %q", kind, objMeta.Name, objMeta.Namespace, remaining)
+		}
+		return departed, remaining, true
+	}
+
+	// isOrphan returns the object's users if none of them are in the config.
+	isOrphan := func(kind string, objMeta meta.ObjectMeta) (users []string, ok bool) {
+		departed, remaining, ok := departedUsers(kind, objMeta)
+		return departed, ok && len(departed) > 0 && len(remaining) == 0
+	}
+
+	// pruneBinding removes the departed users, and the subjects bound for them which are not also
+	// bound for remaining users, from the metadata and subjects of a shared binding. It returns the
+	// users which were removed, which omit those whose subjects are not recorded.
+	pruneBinding := func(kind string, objMeta *meta.ObjectMeta, subjects *[]rbac.Subject, departed, remaining []string) (pruned []string) {
+		recorded, err := ownership.Subjects(*objMeta)
+		if err != nil {
+			warn("skipped %s [%s] in namespace [%s]: %s", kind, objMeta.Name, objMeta.Namespace, err)
+			return nil
+		}
+
+		// Compare service accounts without a namespace as the API server interprets them.
+		normalize := func(s rbac.Subject) rbac.Subject {
+			return cage_k8s_rbac.WithBindingNamespace([]rbac.Subject{s}, objMeta.Namespace)[0]
+		}
+
+		keep := map[rbac.Subject]bool{}
+		for _, u := range remaining {
+			if s, ok := recorded[u]; ok {
+				keep[normalize(s)] = true
+			}
+		}
+		remove := map[rbac.Subject]bool{}
+		for _, u := range departed {
+			s, ok := recorded[u]
+			if !ok {
+				verbose("kept user [%s] of %s [%s] in namespace [%s] whose subject is not recorded", u, kind, objMeta.Name, objMeta.Namespace)
+				continue
+			}
+			pruned = append(pruned, u)
+			if !keep[normalize(s)] {
+				remove[normalize(s)] = true
+			}
+		}
+		if len(pruned) == 0 {
+			return nil
+		}
+
+		kept := []rbac.Subject{}
+		for _, s := range *subjects {
+			if !remove[normalize(s)] {
+				kept = append(kept, s)
+			}
+		}
+		*subjects = kept
+
+		if err = ownership.RemoveUsers(objMeta, pruned); err != nil {
+			warn("skipped %s [%s] in namespace [%s]: %s", kind, objMeta.Name, objMeta.Namespace, err)
+			return nil
+		}
+		return pruned
+	}
+
+	roleBindings, err := apiClientset.RoleBindings.List("", listOptions)
+	if err != nil {
+		return errors.Wrap(err, "kubeauth") // WithStack alternative to disambiguate from kubectl output
+	}
+	if roleBindings != nil {
+		for _, obj := range roleBindings.Items {
+			departed, remaining, ok := departedUsers(cage_k8s.KindRoleBinding, obj.ObjectMeta)
+			if !ok || len(departed) == 0 {
+				continue
+			}
+			if len(remaining) == 0 {
+				orphans = append(orphans, orphan{kind: cage_k8s.KindRoleBinding, objMeta: obj.ObjectMeta, status: StatusOrphaned, departed: departed})
+				continue
+			}
+			updated := obj.DeepCopy()
+			if pruned := pruneBinding(cage_k8s.KindRoleBinding, &updated.ObjectMeta, &updated.Subjects, departed, remaining); len(pruned) > 0 {
+				orphans = append(orphans, orphan{kind: cage_k8s.KindRoleBinding, objMeta: obj.ObjectMeta, status: StatusShared, departed: pruned, roleBinding: updated})
+			}
+		}
+	}
+
+	clusterRoleBindings, err := apiClientset.ClusterRoleBindings.List(listOptions)
+	if err != nil {
+		return errors.Wrap(err, "kubeauth") // WithStack alternative to disambiguate from kubectl output
+	}
+	if clusterRoleBindings != nil {
+		for _, obj := range clusterRoleBindings.Items {
+			departed, remaining, ok := departedUsers(cage_k8s.KindClusterRoleBinding, obj.ObjectMeta)
+			if !ok || len(departed) == 0 {
+				continue
+			}
+			if len(remaining) == 0 {
+				orphans = append(orphans, orphan{kind: cage_k8s.KindClusterRoleBinding, objMeta: obj.ObjectMeta, status: StatusOrphaned, departed: departed})
+				continue
+			}
+			updated := obj.DeepCopy()
+			if pruned := pruneBinding(cage_k8s.KindClusterRoleBinding, &updated.ObjectMeta, &updated.Subjects, departed, remaining); len(pruned) > 0 {
+				orphans = append(orphans, orphan{kind: cage_k8s.KindClusterRoleBinding, objMeta: obj.ObjectMeta, status: StatusShared, departed: pruned, clusterRoleBinding: updated})
+			}
+		}
+	}
+
+	roles, err := apiClientset.Roles.List("", listOptions)
+	if err != nil {
+		return errors.Wrap(err, "kubeauth") // WithStack alternative to disambiguate from kubectl output
+	}
+	if roles != nil {
+		for _, obj := range roles.Items {
+			if users, ok := isOrphan(cage_k8s.KindRole, obj.ObjectMeta); ok {
+				orphans = append(orphans, orphan{kind: cage_k8s.KindRole, objMeta: obj.ObjectMeta, status: StatusOrphaned, departed: users})
+			}
+		}
+	}
+
+	clusterRoles, err := apiClientset.ClusterRoles.List(listOptions)
+	if err != nil {
+		return errors.Wrap(err, "kubeauth") // WithStack alternative to disambiguate from kubectl output
+	}
+	if clusterRoles != nil {
+		for _, obj := range clusterRoles.Items {
+			if users, ok := isOrphan(cage_k8s.KindClusterRole, obj.ObjectMeta); ok {
+				orphans = append(orphans, orphan{kind: cage_k8s.KindClusterRole, objMeta: obj.ObjectMeta, status: StatusOrphaned, departed: users})
+			}
+		}
+	}
+
+	serviceAccounts, err := apiClientset.ServiceAccounts.List("", listOptions)
+	if err != nil {
+		return errors.Wrap(err, "kubeauth") // WithStack alternative to disambiguate from kubectl output
+	}
+	if serviceAccounts != nil {
+		for _, obj := range serviceAccounts.Items {
+			if users, ok := isOrphan(cage_k8s.KindServiceAccount, obj.ObjectMeta); ok {
+				orphans = append(orphans, orphan{kind: cage_k8s.KindServiceAccount, objMeta: obj.ObjectMeta, status: StatusOrphaned, departed: users})
+			}
+		}
+	}
+
+	// Delete the orphans, and prune the shared bindings.
+
+	if h.Delete {
+		for n, o := range orphans {
+			if o.status == StatusShared {
+				if o.roleBinding != nil {
+					_, err = apiClientset.RoleBindings.Update(o.roleBinding)
+				} else {
+					_, err = apiClientset.ClusterRoleBindings.Update(o.clusterRoleBinding)
+				}
+				if err != nil {
+					return errors.Wrap(err, "kubeauth") // WithStack alternative to disambiguate from kubectl output
+				}
+
+				verbose("removed users %q from %s [%s] in namespace [%s]", o.departed, o.kind, o.objMeta.Name, o.objMeta.Namespace)
+
+				orphans[n].status = StatusPruned
+				continue
+			}
+
+			switch o.kind {
+			case cage_k8s.KindRoleBinding:
+				err = apiClientset.RoleBindings.Delete(o.objMeta.Namespace, o.objMeta.Name)
+			case cage_k8s.KindClusterRoleBinding:
+				err = apiClientset.ClusterRoleBindings.Delete(o.objMeta.Name)
+			case cage_k8s.KindRole:
+				err = apiClientset.Roles.Delete(o.objMeta.Namespace, o.objMeta.Name)
+			case cage_k8s.KindClusterRole:
+				err = apiClientset.ClusterRoles.Delete(o.objMeta.Name)
+			case cage_k8s.KindServiceAccount:
+				err = apiClientset.ServiceAccounts.Delete(o.objMeta.Namespace, o.objMeta.Name)
+			}
+			if err != nil {
+				return errors.Wrap(err, "kubeauth") // WithStack alternative to disambiguate from kubectl output
+			}
+
+			verbose("deleted %s [%s] in namespace [%s]", o.kind, o.objMeta.Name, o.objMeta.Namespace)
+
+			orphans[n].status = StatusDeleted
+		}
+	}
+
+	// Report.
+
+	w := tabwriter.NewWriter(h.Out(), 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "KIND\tNAMESPACE\tNAME\tUSER\tCREATED\tSTATUS")
+	for _, o := range orphans {
+		ns := o.objMeta.Namespace
+		if ns == "" {
+			ns = cage_k8s.EmptyNamespace
+		}
+		fmt.Fprintf(
+			w, "%s\t%s\t%s\t%s\t%s\t%s\n",
+			o.kind,
+			ns,
+			o.objMeta.Name,
+			strings.Join(o.departed, ","),
+			o.objMeta.Annotations[ownership.CreatedAtAnnotation],
+			o.status,
+		)
+	}
+	if err = w.Flush(); err != nil {
+		return errors.Wrap(err, "kubeauth: failed to write report")
+	}
+
+	return nil
+}
+
+// New returns a cobra command instance based on Handler.
+func NewCommand() *cobra.Command {
+	return handler_cobra.NewHandler(&Handler{
+		Session: &handler.DefaultSession{},
+	})
+}
+
+var _ handler_cobra.Handler = (*Handler)(nil)
@@ -0,0 +1,280 @@
+// Copyright (C) 2020 The kubeauth Authors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at https://mozilla.org/MPL/2.0/.
+
+// Package gc_test asserts CLI behavior by running the command handler logic
+// directly (w/o separate processes) with various input scenarios.
+//
+// It uses Handler instances that use mock implementations of the clients used
+// to modify kubeconfig files and perform API requests. The tests only verify correct
+// use of the client interfaces. Tests in the cage_k8s package tree verify
+// lower-level client behaviors.
+package gc_test
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/require"
+	core "k8s.io/api/core/v1"
+	rbac "k8s.io/api/rbac/v1"
+	meta "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	cli "github.com/codeactual/kubeauth/cmd/kubeauth/gc"
+	"github.com/codeactual/kubeauth/internal/cage/cli/handler"
+	"github.com/codeactual/kubeauth/internal/ownership"
+)
+
+func NewHandler(kit *HandlerKit) *cli.Handler {
+	h := cli.Handler{
+		Session:             kit.Session,
+		KubectlConfigClient: kit.ConfigClient,
+		KubeApiClientset:    kit.ApiClientset.ToReal(),
+	}
+
+	// Enable for test troubleshooting and verbose output assertions.
+	h.Verbosity = 1
+
+	return &h
+}
+
+// reportLines returns the report's rows, excluding the header, with whitespace collapsed.
+func reportLines(t *testing.T, kit *HandlerKit) []string {
+	lines := strings.Split(strings.TrimSpace(kit.Stdout.String()), "\n")
+	require.True(t, strings.HasPrefix(lines[0], "KIND"))
+
+	var rows []string
+	for _, l := range lines[1:] {
+		rows = append(rows, strings.Join(strings.Fields(l), " "))
+	}
+	return rows
+}
+
+func addObjects(kit *HandlerKit) {
+	kit.AddUser("user-a")
+
+	kit.RoleBindings = []rbac.RoleBinding{
+		{ObjectMeta: ManagedMeta("ns-a", "rb-a", "user-a")},
+		{ObjectMeta: ManagedMeta("ns-b", "rb-b", "user-b")},
+	}
+	kit.ClusterRoleBindings = []rbac.ClusterRoleBinding{
+		{ObjectMeta: ManagedMeta("", "crb-a", "user-a")},
+		{ObjectMeta: ManagedMeta("", "crb-b", "user-b")},
+	}
+	kit.Roles = []rbac.Role{
+		{ObjectMeta: ManagedMeta("ns-a", "kubeauth-user-a", "user-a")},
+		{ObjectMeta: ManagedMeta("ns-b", "kubeauth-user-b", "user-b")},
+	}
+	kit.ClusterRoles = []rbac.ClusterRole{
+		{ObjectMeta: ManagedMeta("", "kubeauth-user-b", "user-b")},
+	}
+	kit.ServiceAccounts = []core.ServiceAccount{
+		{ObjectMeta: ManagedMeta("ns-a", "sa-a", "user-a")},
+		{ObjectMeta: ManagedMeta("ns-b", "sa-b", "user-b")},
+		// Lacks the user annotation, so it cannot be considered orphaned.
+		{ObjectMeta: meta.ObjectMeta{
+			Namespace: "ns-b",
+			Name:      "sa-c",
+			Labels:    map[string]string{ownership.ManagedByLabel: ownership.ManagedByValue},
+		}},
+	}
+}
+
+// TestList asserts that only objects of users missing from the config are reported.
+func TestList(t *testing.T) {
+	kit := NewHandlerKit(t)
+	addObjects(kit)
+	kit.Finish()
+	defer kit.MockCtrl.Finish()
+
+	h := NewHandler(kit)
+	h.Run(context.Background(), handler.Input{})
+
+	require.Exactly(
+		t,
+		[]string{
+			"RoleBinding ns-b rb-b user-b 2020-03-01T12:00:00Z orphaned",
+			"ClusterRoleBinding <no namespace> crb-b user-b 2020-03-01T12:00:00Z orphaned",
+			"Role ns-b kubeauth-user-b user-b 2020-03-01T12:00:00Z orphaned",
+			"ClusterRole <no namespace> kubeauth-user-b user-b 2020-03-01T12:00:00Z orphaned",
+			"ServiceAccount ns-b sa-b user-b 2020-03-01T12:00:00Z orphaned",
+		},
+		reportLines(t, kit),
+	)
+}
+
+// TestDelete asserts that orphaned objects are deleted, bindings before the roles and service accounts
+// they refer to.
+func TestDelete(t *testing.T) {
+	kit := NewHandlerKit(t)
+	addObjects(kit)
+	kit.Finish()
+	defer kit.MockCtrl.Finish()
+
+	gomock.InOrder(
+		kit.ApiClientset.RoleBindings.EXPECT().Delete("ns-b", "rb-b").Return(nil),
+		kit.ApiClientset.ClusterRoleBindings.EXPECT().Delete("crb-b").Return(nil),
+		kit.ApiClientset.Roles.EXPECT().Delete("ns-b", "kubeauth-user-b").Return(nil),
+		kit.ApiClientset.ClusterRoles.EXPECT().Delete("kubeauth-user-b").Return(nil),
+		kit.ApiClientset.ServiceAccounts.EXPECT().Delete("ns-b", "sa-b").Return(nil),
+	)
+
+	h := NewHandler(kit)
+	h.Delete = true
+	h.Run(context.Background(), handler.Input{})
+
+	require.Exactly(
+		t,
+		[]string{
+			"RoleBinding ns-b rb-b user-b 2020-03-01T12:00:00Z deleted",
+			"ClusterRoleBinding <no namespace> crb-b user-b 2020-03-01T12:00:00Z deleted",
+			"Role ns-b kubeauth-user-b user-b 2020-03-01T12:00:00Z deleted",
+			"ClusterRole <no namespace> kubeauth-user-b user-b 2020-03-01T12:00:00Z deleted",
+			"ServiceAccount ns-b sa-b user-b 2020-03-01T12:00:00Z deleted",
+		},
+		reportLines(t, kit),
+	)
+}
+
+// sharedMeta returns the metadata of an object which kubeauth created for the first user and then reused
+// for the others, recording the subject bound for each user if the object is a binding.
+func sharedMeta(t *testing.T, namespace, name string, users []string, subjects map[string]rbac.Subject) meta.ObjectMeta {
+	objMeta := ManagedMeta(namespace, name, users[0])
+	for _, u := range users {
+		var subject *rbac.Subject
+		if s, ok := subjects[u]; ok {
+			subject = &s
+		}
+		_, err := ownership.AddUser(&objMeta, u, subject)
+		require.NoError(t, err)
+	}
+	return objMeta
+}
+
+// TestSharedObjects asserts that a service account and bindings shared by users are only deleted once none of
+// their users remain, and that shared bindings instead lose the subjects bound only for the missing users.
+func TestSharedObjects(t *testing.T) {
+	sa := rbac.Subject{Kind: rbac.ServiceAccountKind, Name: "sa-shared"}
+	saWithNs := rbac.Subject{Kind: rbac.ServiceAccountKind, Name: "sa-shared", Namespace: "ns-a"}
+	certUser := rbac.Subject{Kind: rbac.UserKind, APIGroup: rbac.GroupName, Name: "cn-c"}
+
+	kit := NewHandlerKit(t)
+	kit.AddUser("user-a")
+
+	// user-a and user-b share the service account, so only the cert user's subject is removed.
+	sharedBinding := rbac.RoleBinding{
+		ObjectMeta: sharedMeta(t, "ns-a", "rb-shared", []string{"user-a", "user-b", "user-c"}, map[string]rbac.Subject{"user-a": sa, "user-b": sa, "user-c": certUser}),
+		Subjects:   []rbac.Subject{saWithNs, certUser},
+	}
+	kit.RoleBindings = []rbac.RoleBinding{
+		sharedBinding,
+		{ObjectMeta: sharedMeta(t, "ns-b", "rb-departed", []string{"user-b", "user-c"}, map[string]rbac.Subject{"user-b": sa, "user-c": certUser})},
+	}
+	kit.ClusterRoleBindings = []rbac.ClusterRoleBinding{
+		// The subject of user-b is not recorded, e.g. because add-user created the binding before subjects were.
+		{ObjectMeta: sharedMeta(t, "", "crb-unrecorded", []string{"user-b", "user-a"}, map[string]rbac.Subject{"user-a": saWithNs})},
+	}
+	kit.ServiceAccounts = []core.ServiceAccount{
+		{ObjectMeta: sharedMeta(t, "ns-a", "sa-shared", []string{"user-a", "user-b"}, nil)},
+		{ObjectMeta: sharedMeta(t, "ns-b", "sa-departed", []string{"user-b", "user-c"}, nil)},
+	}
+	kit.Finish()
+	defer kit.MockCtrl.Finish()
+
+	prunedBinding := sharedBinding.DeepCopy()
+	prunedBinding.Subjects = []rbac.Subject{saWithNs}
+	prunedBinding.Annotations[ownership.UsersAnnotation] = `["user-a"]`
+	prunedBinding.Annotations[ownership.SubjectsAnnotation] = `{"user-a":{"kind":"ServiceAccount","name":"sa-shared"}}`
+
+	gomock.InOrder(
+		kit.ApiClientset.RoleBindings.EXPECT().Update(prunedBinding).Return(prunedBinding, nil),
+		kit.ApiClientset.RoleBindings.EXPECT().Delete("ns-b", "rb-departed").Return(nil),
+		kit.ApiClientset.ServiceAccounts.EXPECT().Delete("ns-b", "sa-departed").Return(nil),
+	)
+
+	h := NewHandler(kit)
+	h.Delete = true
+	h.Run(context.Background(), handler.Input{})
+
+	require.Exactly(
+		t,
+		[]string{
+			"RoleBinding ns-a rb-shared user-b,user-c 2020-03-01T12:00:00Z pruned",
+			"RoleBinding ns-b rb-departed user-b,user-c 2020-03-01T12:00:00Z deleted",
+			"ServiceAccount ns-b sa-departed user-b,user-c 2020-03-01T12:00:00Z deleted",
+		},
+		reportLines(t, kit),
+	)
+}
+
+// TestAllCreators asserts that objects created with another user's credentials are only considered with --all-creators.
+func TestAllCreators(t *testing.T) {
+	otherCreator := ManagedMeta("ns-b", "rb-other", "user-b")
+	otherCreator.Annotations[ownership.CreatorAnnotation] = "other-admin"
+	unknownCreator := ManagedMeta("ns-b", "rb-unknown", "user-b")
+	delete(unknownCreator.Annotations, ownership.CreatorAnnotation)
+
+	for _, allCreators := range []bool{false, true} {
+		t.Run(fmt.Sprintf("%t", allCreators), func(t *testing.T) {
+			kit := NewHandlerKit(t)
+			kit.RoleBindings = []rbac.RoleBinding{
+				{ObjectMeta: ManagedMeta("ns-b", "rb-b", "user-b")},
+				{ObjectMeta: otherCreator},
+				{ObjectMeta: unknownCreator},
+			}
+			kit.Finish()
+			defer kit.MockCtrl.Finish()
+
+			h := NewHandler(kit)
+			h.AllCreators = allCreators
+			h.Run(context.Background(), handler.Input{})
+
+			expected := []string{"RoleBinding ns-b rb-b user-b 2020-03-01T12:00:00Z orphaned"}
+			if allCreators {
+				expected = append(
+					expected,
+					"RoleBinding ns-b rb-other user-b 2020-03-01T12:00:00Z orphaned",
+					"RoleBinding ns-b rb-unknown user-b 2020-03-01T12:00:00Z orphaned",
+				)
+			}
+			require.Exactly(t, expected, reportLines(t, kit))
+		})
+	}
+}
+
+// TestNoOrphans asserts that only the header is reported when all users are in the config.
+func TestNoOrphans(t *testing.T) {
+	kit := NewHandlerKit(t)
+	kit.AddUser("user-a")
+	kit.RoleBindings = []rbac.RoleBinding{{ObjectMeta: ManagedMeta("ns-a", "rb-a", "user-a")}}
+	kit.Finish()
+	defer kit.MockCtrl.Finish()
+
+	h := NewHandler(kit)
+	h.Delete = true
+	h.Run(context.Background(), handler.Input{})
+
+	require.Empty(t, reportLines(t, kit))
+}
+
+// TestErrOnList asserts that a failed list exits with the error.
+func TestErrOnList(t *testing.T) {
+	kit := NewHandlerKit(t)
+	kit.ExitOnErr = regexp.MustCompile("failed to list role bindings")
+	kit.Finish()
+	defer kit.MockCtrl.Finish()
+
+	kit.ApiClientset.RoleBindings.EXPECT().
+		List("", meta.ListOptions{LabelSelector: ownership.Selector}).
+		Return(nil, errors.New("failed to list role bindings"))
+
+	h := NewHandler(kit)
+	h.Run(context.Background(), handler.Input{})
+}
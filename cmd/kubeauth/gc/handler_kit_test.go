@@ -0,0 +1,111 @@
+// Copyright (C) 2020 The kubeauth Authors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package gc_test
+
+import (
+	"bytes"
+	"testing"
+
+	core "k8s.io/api/core/v1"
+	rbac "k8s.io/api/rbac/v1"
+	meta "k8s.io/apimachinery/pkg/apis/meta/v1"
+	clientcmdapi "k8s.io/client-go/tools/clientcmd/api"
+
+	cage_gomock "github.com/codeactual/kubeauth/internal/cage/testkit/gomock"
+	"github.com/codeactual/kubeauth/internal/ownership"
+	"github.com/codeactual/kubeauth/internal/testkit"
+)
+
+// HandlerKit provides command test cases with data and mock-setup boilerplate.
+//
+// It integrates thc HandlerKit type from the internal/testkit package for additional
+// command-agnostic boilerplate.
+type HandlerKit struct {
+	*testkit.HandlerKit
+
+	T *testing.T
+
+	// AuthInfos is added to the config file returned by the Parse call which Finish configures.
+	AuthInfos map[string]*clientcmdapi.AuthInfo
+
+	// RoleBindings, ClusterRoleBindings, Roles, ClusterRoles, and ServiceAccounts are returned by the List calls
+	// which Finish configures.
+	RoleBindings        []rbac.RoleBinding
+	ClusterRoleBindings []rbac.ClusterRoleBinding
+	Roles               []rbac.Role
+	ClusterRoles        []rbac.ClusterRole
+	ServiceAccounts     []core.ServiceAccount
+
+	// Stdout receives the command's report.
+	Stdout *bytes.Buffer
+}
+
+func NewHandlerKit(t *testing.T) *HandlerKit {
+	k := &HandlerKit{
+		HandlerKit: testkit.NewHandlerKit(t),
+		T:          t,
+		AuthInfos:  map[string]*clientcmdapi.AuthInfo{},
+		Stdout:     &bytes.Buffer{},
+	}
+	k.HandlerKit.Stdout = k.Stdout
+	return k
+}
+
+// Finish creates the expected calls, based on mock-related HandlerKit fields, that were not
+// already created by other methods.
+func (k *HandlerKit) Finish() {
+	k.HandlerKit.Finish()
+
+	file := testkit.NewConfigFile(testkit.ConfigFilename, testkit.CurrentContextName, testkit.CurrentClusterName, testkit.CurrentNamespace)
+	file.ClientCmdConfig.AuthInfos = k.AuthInfos
+
+	k.ConfigClient.EXPECT().
+		Parse("").
+		Return(file, nil)
+
+	if k.ExitOnErr != nil {
+		k.Session.EXPECT().ExitOnErr(cage_gomock.ErrShortRegexp(k.ExitOnErr), "", 1)
+		return
+	}
+
+	listOptions := meta.ListOptions{LabelSelector: ownership.Selector}
+
+	k.ApiClientset.RoleBindings.EXPECT().
+		List("", listOptions).
+		Return(&rbac.RoleBindingList{Items: k.RoleBindings}, nil)
+	k.ApiClientset.ClusterRoleBindings.EXPECT().
+		List(listOptions).
+		Return(&rbac.ClusterRoleBindingList{Items: k.ClusterRoleBindings}, nil)
+	k.ApiClientset.Roles.EXPECT().
+		List("", listOptions).
+		Return(&rbac.RoleList{Items: k.Roles}, nil)
+	k.ApiClientset.ClusterRoles.EXPECT().
+		List(listOptions).
+		Return(&rbac.ClusterRoleList{Items: k.ClusterRoles}, nil)
+	k.ApiClientset.ServiceAccounts.EXPECT().
+		List("", listOptions).
+		Return(&core.ServiceAccountList{Items: k.ServiceAccounts}, nil)
+}
+
+// AddUser adds a config user, e.g. to mark objects created for it as in use.
+func (k *HandlerKit) AddUser(user string) {
+	k.AuthInfos[user] = &clientcmdapi.AuthInfo{Token: "some-token"}
+}
+
+// ManagedMeta returns the metadata of an object which kubeauth created for the input user.
+func ManagedMeta(namespace, name, user string) meta.ObjectMeta {
+	return meta.ObjectMeta{
+		Namespace: namespace,
+		Name:      name,
+		Labels:    map[string]string{ownership.ManagedByLabel: ownership.ManagedByValue},
+		Annotations: map[string]string{
+			ownership.UserAnnotation:      user,
+			ownership.CreatorAnnotation:   testkit.Username,
+			ownership.CreatedAtAnnotation: "2020-03-01T12:00:00Z",
+		},
+	}
+}
@@ -0,0 +1,293 @@
+// Copyright (C) 2020 The kubeauth Authors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package graph
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+	rbac "k8s.io/api/rbac/v1"
+	"k8s.io/client-go/kubernetes"
+
+	"github.com/codeactual/kubeauth/internal/cage/cli/handler"
+	handler_cobra "github.com/codeactual/kubeauth/internal/cage/cli/handler/cobra"
+	cage_k8s "github.com/codeactual/kubeauth/internal/cage/kubernetes/v1"
+	cage_k8s_core "github.com/codeactual/kubeauth/internal/cage/kubernetes/v1/core"
+	cage_k8s_config "github.com/codeactual/kubeauth/internal/cage/kubernetes/v1/kubectl/config"
+	cage_k8s_rbac "github.com/codeactual/kubeauth/internal/cage/kubernetes/v1/rbac"
+	cage_k8s_identity "github.com/codeactual/kubeauth/internal/cage/kubernetes/v1/rbac/identity"
+	cage_reflect "github.com/codeactual/kubeauth/internal/cage/reflect"
+	"github.com/codeactual/kubeauth/internal/graph"
+)
+
+const (
+	OutputDOT     = "dot"
+	OutputMermaid = "mermaid"
+)
+
+// Handler defines the sub-command flags and logic.
+type Handler struct {
+	handler.Session
+
+	KubeApiClientset    *cage_k8s_core.Clientset
+	KubectlConfigClient cage_k8s_config.Client
+	IdentityRegistry    *cage_k8s_identity.Registry
+
+	As             string `usage:"only include this user, group, or service account (system:serviceaccount:<namespace>:<name>)"`
+	CollapseSystem bool   `usage:"replace system identities, e.g. system:masters and kube-system service accounts, with a single node"`
+	ConfigFile     string `usage:"kubectl config file"`
+	Namespace      string `usage:"only include role bindings in this namespace"`
+	Output         string `usage:"graph format: dot or mermaid"`
+
+	// Verbosity levels greater than 0 will enable status messages and error stack traces.
+	//
+	// It is an int for consistency with other commands, even though levels beyond 1 are not used.
+	Verbosity int `usage:"kubectl verbosity level"`
+}
+
+// Init defines the command, its environment variable prefix, etc.
+//
+// It implements cli/handler/cobra.Handler.
+func (h *Handler) Init() handler_cobra.Init {
+	return handler_cobra.Init{
+		Cmd: &cobra.Command{
+			Use:   "graph",
+			Short: "Write a graph of subjects, their bindings, roles, and rules in Graphviz DOT or Mermaid format",
+		},
+		EnvPrefix: "KUBEAUTH",
+	}
+}
+
+// BindFlags binds the flags to Handler fields.
+//
+// It implements cli/handler/cobra.Handler.
+func (h *Handler) BindFlags(cmd *cobra.Command) []string {
+	cmd.Flags().StringVarP(&h.As, "as", "", "", cage_reflect.GetFieldTag(*h, "As", "usage"))
+	cmd.Flags().BoolVarP(&h.CollapseSystem, "collapse-system", "", false, cage_reflect.GetFieldTag(*h, "CollapseSystem", "usage"))
+	cmd.Flags().StringVarP(&h.ConfigFile, "kubeconfig", "", "", cage_reflect.GetFieldTag(*h, "ConfigFile", "usage"))
+	cmd.Flags().StringVarP(&h.Namespace, "namespace", "n", "", cage_reflect.GetFieldTag(*h, "Namespace", "usage"))
+	cmd.Flags().StringVarP(&h.Output, "output", "o", OutputDOT, cage_reflect.GetFieldTag(*h, "Output", "usage"))
+	cmd.Flags().IntVarP(&h.Verbosity, "v", "v", 0, cage_reflect.GetFieldTag(*h, "Verbosity", "usage"))
+	return []string{}
+}
+
+// Run performs the sub-command logic.
+//
+// It implements cli/handler/cobra.Handler.
+func (h *Handler) Run(ctx context.Context, input handler.Input) {
+	if err := h.run(ctx, input); err != nil {
+		if h.Verbosity > 0 {
+			h.ExitOnErr(err, "", 1)
+		} else {
+			h.ExitOnErrShort(err, "", 1)
+		}
+	}
+}
+
+func (h *Handler) run(ctx context.Context, _ handler.Input) error {
+	stderr := h.Err()
+	verbose := func(format string, vArgs ...interface{}) {
+		if h.Verbosity > 0 {
+			fmt.Fprintln(stderr, "kubeauth: "+fmt.Sprintf(format, vArgs...))
+		}
+	}
+
+	switch h.Output {
+	case "":
+		h.Output = OutputDOT
+	case OutputDOT, OutputMermaid:
+	default:
+		return errors.Errorf("kubeauth: --output must be %q or %q", OutputDOT, OutputMermaid)
+	}
+
+	configClient := h.KubectlConfigClient
+	if configClient == nil {
+		configClient = cage_k8s_config.NewDefaultClient()
+	}
+
+	configFile, err := configClient.Parse(h.ConfigFile)
+	if err != nil {
+		return errors.Wrap(err, "kubeauth") // WithStack alternative to disambiguate from kubectl output
+	}
+
+	apiClientset := h.KubeApiClientset
+	if apiClientset == nil {
+		rawApiClientset, err := kubernetes.NewForConfig(configFile.RestConfig)
+		if err != nil {
+			return errors.Wrap(err, "kubeauth: failed to create API client")
+		}
+
+		apiClientset = cage_k8s_core.NewClientset(rawApiClientset)
+	}
+
+	regClient := h.IdentityRegistry
+	if regClient == nil {
+		regClient = cage_k8s_identity.NewRegistry(apiClientset)
+	}
+
+	// Validate inputs.
+
+	if h.Namespace != "" {
+		_, exists, err := apiClientset.Namespaces.Get(h.Namespace)
+		if err != nil {
+			return errors.Wrap(err, "kubeauth") // WithStack alternative to disambiguate from kubectl output
+		}
+		if !exists {
+			return errors.Errorf("kubeauth: namespace [%s] not found", h.Namespace)
+		}
+	}
+
+	// Find the subjects of bindings.
+	//
+	// The namespace is applied below, instead of in the query, because role subject queries only
+	// match subjects in that namespace, which excludes users and groups.
+	//
+	// The raw results are kept because each subject is added to the graph once per binding.
+
+	ids, err := regClient.Query(ctx, cage_k8s_identity.QueryName(h.As), cage_k8s_identity.QueryRaw(true))
+	if err != nil {
+		return errors.Wrap(err, "kubeauth") // WithStack alternative to disambiguate from kubectl output
+	}
+
+	// Binding subject queries match service accounts by name alone, so the namespace of a selected
+	// service account is applied below.
+	asSaNamespace, _, asSaIsGroup, asSaIsValid := cage_k8s_rbac.ParseServiceAccount(h.As)
+
+	// Add each subject's path to the rules of its bindings' roles.
+
+	g := graph.New(graph.Options{CollapseSystem: h.CollapseSystem})
+	roleRefs := map[string]*rbac.RoleRef{}
+	roles := map[string]*graph.Object{}
+	rules := map[string][]rbac.PolicyRule{}
+
+	for _, id := range ids.Items {
+		// Skip identities which are not bound by themselves, e.g. hard-coded system identities and nodes.
+		if id.Source == nil || (id.Source.Kind != cage_k8s.KindRoleBinding && id.Source.Kind != cage_k8s.KindClusterRoleBinding) {
+			continue
+		}
+
+		source := id.Source
+		if h.Namespace != "" && (source.Kind != cage_k8s.KindRoleBinding || source.Namespace != h.Namespace) {
+			continue
+		}
+
+		if asSaIsValid && !asSaIsGroup && id.Kind == cage_k8s.KindServiceAccount {
+			// Like the API server, interpret a role binding's service account without a namespace as
+			// one in the binding's namespace.
+			saNamespace := id.Namespace
+			if saNamespace == "" && source.Kind == cage_k8s.KindRoleBinding {
+				saNamespace = source.Namespace
+			}
+			if saNamespace != asSaNamespace {
+				continue
+			}
+		}
+
+		bindingKey := source.Kind + "/" + source.Namespace + "/" + source.Name
+		roleRef, ok := roleRefs[bindingKey]
+		if !ok {
+			if roleRef, err = getRoleRef(apiClientset, source); err != nil {
+				return errors.Wrap(err, "kubeauth") // WithStack alternative to disambiguate from kubectl output
+			}
+			roleRefs[bindingKey] = roleRef
+		}
+		if roleRef == nil {
+			verbose("skipped %s which was not found", source)
+			continue
+		}
+
+		// A role binding's role is in the same namespace, but a cluster role is not namespaced.
+		roleNamespace := source.Namespace
+		if roleRef.Kind == cage_k8s.KindClusterRole {
+			roleNamespace = ""
+		}
+
+		roleKey := roleRef.Kind + "/" + roleNamespace + "/" + roleRef.Name
+		role, ok := roles[roleKey]
+		if !ok {
+			role = &graph.Object{Kind: roleRef.Kind, Namespace: roleNamespace, Name: roleRef.Name}
+
+			var exists bool
+			if rules[roleKey], exists, err = getRules(apiClientset, *role); err != nil {
+				return errors.Wrap(err, "kubeauth") // WithStack alternative to disambiguate from kubectl output
+			}
+
+			role.Missing = !exists
+			roles[roleKey] = role
+		}
+
+		g.Add(
+			rbac.Subject{Kind: id.Kind, Namespace: id.Namespace, Name: id.Name},
+			graph.Object{Kind: source.Kind, Namespace: source.Namespace, Name: source.Name},
+			*role,
+			rules[roleKey],
+		)
+	}
+
+	verbose("graph has %d nodes and %d edges", len(g.Nodes()), len(g.Edges()))
+
+	switch h.Output {
+	case OutputMermaid:
+		err = graph.WriteMermaid(h.Out(), g)
+	default:
+		err = graph.WriteDOT(h.Out(), g)
+	}
+	if err != nil {
+		return errors.Wrap(err, "kubeauth")
+	}
+
+	return nil
+}
+
+// getRoleRef returns the role reference of the binding, or nil if the binding does not exist.
+func getRoleRef(apiClientset *cage_k8s_core.Clientset, source *cage_k8s_identity.IdentitySource) (*rbac.RoleRef, error) {
+	switch source.Kind {
+	case cage_k8s.KindRoleBinding:
+		obj, exists, err := apiClientset.RoleBindings.Get(source.Namespace, source.Name)
+		if err != nil || !exists {
+			return nil, errors.WithStack(err)
+		}
+		return &obj.RoleRef, nil
+	case cage_k8s.KindClusterRoleBinding:
+		obj, exists, err := apiClientset.ClusterRoleBindings.Get(source.Name)
+		if err != nil || !exists {
+			return nil, errors.WithStack(err)
+		}
+		return &obj.RoleRef, nil
+	}
+	return nil, errors.Errorf("identity source [%s] is not a binding", source)
+}
+
+// getRules returns the rules of the role, and false if the role does not exist.
+func getRules(apiClientset *cage_k8s_core.Clientset, role graph.Object) ([]rbac.PolicyRule, bool, error) {
+	switch role.Kind {
+	case cage_k8s.KindRole:
+		obj, exists, err := apiClientset.Roles.Get(role.Namespace, role.Name)
+		if err != nil || !exists {
+			return nil, false, errors.WithStack(err)
+		}
+		return obj.Rules, true, nil
+	case cage_k8s.KindClusterRole:
+		obj, exists, err := apiClientset.ClusterRoles.Get(role.Name)
+		if err != nil || !exists {
+			return nil, false, errors.WithStack(err)
+		}
+		return obj.Rules, true, nil
+	}
+	return nil, false, errors.Errorf("binding refers to unsupported role kind [%s]", role.Kind)
+}
+
+// New returns a cobra command instance based on Handler.
+func NewCommand() *cobra.Command {
+	return handler_cobra.NewHandler(&Handler{
+		Session: &handler.DefaultSession{},
+	})
+}
+
+var _ handler_cobra.Handler = (*Handler)(nil)
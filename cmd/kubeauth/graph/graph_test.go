@@ -0,0 +1,272 @@
+// Copyright (C) 2020 The kubeauth Authors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at https://mozilla.org/MPL/2.0/.
+
+// Package graph_test asserts CLI behavior by running the command handler logic
+// directly (w/o separate processes) with various input scenarios.
+//
+// It uses Handler instances that use mock implementations of the clients used
+// to modify kubeconfig files and perform API requests. The tests only verify correct
+// use of the client interfaces. Tests in the internal/graph package verify the
+// output formats in more detail.
+package graph_test
+
+import (
+	"context"
+	"regexp"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	core "k8s.io/api/core/v1"
+	rbac "k8s.io/api/rbac/v1"
+	meta "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	cli "github.com/codeactual/kubeauth/cmd/kubeauth/graph"
+	"github.com/codeactual/kubeauth/internal/cage/cli/handler"
+	cage_k8s "github.com/codeactual/kubeauth/internal/cage/kubernetes/v1"
+	cage_k8s_identity "github.com/codeactual/kubeauth/internal/cage/kubernetes/v1/rbac/identity"
+	"github.com/codeactual/kubeauth/internal/testkit"
+)
+
+func NewHandler(kit *HandlerKit) *cli.Handler {
+	apiClientset := kit.ApiClientset.ToReal()
+
+	h := cli.Handler{
+		Session:             kit.Session,
+		KubectlConfigClient: kit.ConfigClient,
+		KubeApiClientset:    apiClientset,
+		IdentityRegistry:    kit.IdentityRegistry.ToReal(apiClientset),
+	}
+
+	// Enable for test troubleshooting and verbose output assertions.
+	h.Verbosity = 1
+
+	return &h
+}
+
+var podsRule = rbac.PolicyRule{Verbs: []string{"get"}, APIGroups: []string{""}, Resources: []string{"pods"}}
+
+// addSubjects adds a user bound to a role in namespace dev, and a system group bound to a cluster role
+// which no longer exists.
+func addSubjects(kit *HandlerKit) {
+	kit.AddRoleSubject("dev", "pods", cage_k8s.KindUser, "", "tester")
+	kit.AddClusterRoleSubject("masters", cage_k8s.KindGroup, "", "system:masters")
+
+	// Hard-coded identities are not bound by themselves, so they are excluded.
+	kit.Resultset.CoreGroup.Add("", cage_k8s.KindGroup, "system:masters", nil)
+}
+
+func expectGets(kit *HandlerKit) {
+	kit.ApiClientset.RoleBindings.EXPECT().
+		Get("dev", "pods").
+		Return(&rbac.RoleBinding{RoleRef: rbac.RoleRef{Kind: cage_k8s.KindRole, Name: "pods"}}, testkit.Exists, nil)
+	kit.ApiClientset.Roles.EXPECT().
+		Get("dev", "pods").
+		Return(&rbac.Role{Rules: []rbac.PolicyRule{podsRule}}, testkit.Exists, nil)
+}
+
+func TestDOT(t *testing.T) {
+	kit := NewHandlerKit(t)
+	addSubjects(kit)
+	kit.Finish()
+	defer kit.MockCtrl.Finish()
+
+	expectGets(kit)
+	kit.ApiClientset.ClusterRoleBindings.EXPECT().
+		Get("masters").
+		Return(&rbac.ClusterRoleBinding{RoleRef: rbac.RoleRef{Kind: cage_k8s.KindClusterRole, Name: "deleted"}}, testkit.Exists, nil)
+	var nonSut *rbac.ClusterRole
+	kit.ApiClientset.ClusterRoles.EXPECT().
+		Get("deleted").
+		Return(nonSut, testkit.NotExists, nil)
+
+	h := NewHandler(kit)
+	h.Run(context.Background(), handler.Input{})
+
+	require.Exactly(
+		t,
+		`digraph rbac {
+  rankdir=LR;
+  n0 [label="ClusterRole deleted (not found)" shape=hexagon];
+  n1 [label="ClusterRoleBinding masters" shape=box];
+  n2 [label="Group system:masters" shape=ellipse];
+  n3 [label="Role dev/pods" shape=hexagon];
+  n4 [label="get pods" shape=note];
+  n5 [label="RoleBinding dev/pods" shape=box];
+  n6 [label="User tester" shape=ellipse];
+  n1 -> n0;
+  n2 -> n1;
+  n3 -> n4;
+  n5 -> n3;
+  n6 -> n5;
+}
+`,
+		kit.Stdout.String(),
+	)
+}
+
+// TestMermaidNamespace asserts that --namespace excludes cluster role bindings and role bindings
+// in other namespaces.
+func TestMermaidNamespace(t *testing.T) {
+	kit := NewHandlerKit(t)
+	addSubjects(kit)
+	kit.AddRoleSubject("prod", "pods", cage_k8s.KindUser, "", "tester")
+	kit.Finish()
+	defer kit.MockCtrl.Finish()
+
+	kit.ApiClientset.Namespaces.EXPECT().
+		Get("dev").
+		Return(testkit.NewNamespace("dev"), testkit.Exists, nil)
+	expectGets(kit)
+
+	h := NewHandler(kit)
+	h.Namespace = "dev"
+	h.Output = cli.OutputMermaid
+	h.Run(context.Background(), handler.Input{})
+
+	require.Exactly(
+		t,
+		`flowchart LR
+  n0{{"Role dev/pods"}}
+  n1>"get pods"]
+  n2["RoleBinding dev/pods"]
+  n3(["User tester"])
+  n0 --> n1
+  n2 --> n0
+  n3 --> n2
+`,
+		kit.Stdout.String(),
+	)
+}
+
+// TestCollapseSystem asserts that system identities share a node, and that the bindings and roles
+// shared by multiple subjects are only queried once.
+func TestCollapseSystem(t *testing.T) {
+	kit := NewHandlerKit(t)
+	kit.As = "some-name"
+	kit.AddRoleSubject("dev", "pods", cage_k8s.KindServiceAccount, "kube-system", "coredns")
+	kit.AddRoleSubject("dev", "pods", cage_k8s.KindGroup, "", "system:nodes")
+	kit.Finish()
+	defer kit.MockCtrl.Finish()
+
+	expectGets(kit)
+
+	h := NewHandler(kit)
+	h.As = "some-name"
+	h.CollapseSystem = true
+	h.Output = cli.OutputMermaid
+	h.Run(context.Background(), handler.Input{})
+
+	require.Exactly(
+		t,
+		`flowchart LR
+  n0{{"Role dev/pods"}}
+  n1>"get pods"]
+  n2["RoleBinding dev/pods"]
+  n3(["system identities"])
+  n0 --> n1
+  n2 --> n0
+  n3 --> n2
+`,
+		kit.Stdout.String(),
+	)
+}
+
+// TestSkipMissingBinding asserts that subjects of bindings deleted after the query are excluded.
+func TestSkipMissingBinding(t *testing.T) {
+	kit := NewHandlerKit(t)
+	kit.AddRoleSubject("dev", "pods", cage_k8s.KindUser, "", "tester")
+	kit.Finish()
+	defer kit.MockCtrl.Finish()
+
+	var nonSut *rbac.RoleBinding
+	kit.ApiClientset.RoleBindings.EXPECT().
+		Get("dev", "pods").
+		Return(nonSut, testkit.NotExists, nil)
+
+	h := NewHandler(kit)
+	h.Run(context.Background(), handler.Input{})
+
+	require.Exactly(t, "digraph rbac {\n  rankdir=LR;\n}\n", kit.Stdout.String())
+}
+
+func TestSkipNode(t *testing.T) {
+	kit := NewHandlerKit(t)
+	kit.As = cage_k8s_identity.NodeUserPrefix + "worker-1"
+	kit.Resultset.Node.Add("", cage_k8s.KindUser, kit.As, &cage_k8s_identity.IdentitySource{
+		TypeMeta:   meta.TypeMeta{Kind: cage_k8s.KindNode},
+		ObjectMeta: meta.ObjectMeta{Name: "worker-1"},
+	})
+	kit.Finish()
+	defer kit.MockCtrl.Finish()
+
+	h := NewHandler(kit)
+	h.As = kit.As
+	h.Run(context.Background(), handler.Input{})
+
+	require.Exactly(t, "digraph rbac {\n  rankdir=LR;\n}\n", kit.Stdout.String())
+}
+
+// TestServiceAccountNamespace asserts that a service account selected by --as excludes the
+// same-named service accounts of other namespaces.
+func TestServiceAccountNamespace(t *testing.T) {
+	kit := NewHandlerKit(t)
+	kit.As = "system:serviceaccount:dev:ci"
+	kit.AddRoleSubject("dev", "pods", cage_k8s.KindServiceAccount, "dev", "ci")
+	kit.AddRoleSubject("prod", "pods", cage_k8s.KindServiceAccount, "prod", "ci")
+	kit.AddRoleSubject("prod", "deploy", cage_k8s.KindServiceAccount, "", "ci")
+	kit.AddClusterRoleSubject("ci-view", cage_k8s.KindServiceAccount, "prod", "ci")
+	kit.Finish()
+	defer kit.MockCtrl.Finish()
+
+	expectGets(kit)
+
+	h := NewHandler(kit)
+	h.As = kit.As
+	h.Output = cli.OutputMermaid
+	h.Run(context.Background(), handler.Input{})
+
+	require.Exactly(
+		t,
+		`flowchart LR
+  n0{{"Role dev/pods"}}
+  n1>"get pods"]
+  n2["RoleBinding dev/pods"]
+  n3(["ServiceAccount dev/ci"])
+  n0 --> n1
+  n2 --> n0
+  n3 --> n2
+`,
+		kit.Stdout.String(),
+	)
+}
+
+func TestErrOnInvalidOutput(t *testing.T) {
+	kit := NewHandlerKit(t)
+	kit.InvalidFlags = true
+	kit.ExitOnErr = regexp.MustCompile(regexp.QuoteMeta(`--output must be "dot" or "mermaid"`))
+	kit.Finish()
+	defer kit.MockCtrl.Finish()
+
+	h := NewHandler(kit)
+	h.Output = "svg"
+	h.Run(context.Background(), handler.Input{})
+}
+
+func TestErrOnNamespaceNotFound(t *testing.T) {
+	kit := NewHandlerKit(t)
+	kit.ExitOnErr = regexp.MustCompile(regexp.QuoteMeta("namespace [dev] not found"))
+	kit.Finish()
+	defer kit.MockCtrl.Finish()
+
+	var nonSut *core.Namespace
+	kit.ApiClientset.Namespaces.EXPECT().
+		Get("dev").
+		Return(nonSut, testkit.NotExists, nil)
+
+	h := NewHandler(kit)
+	h.Namespace = "dev"
+	h.Run(context.Background(), handler.Input{})
+}
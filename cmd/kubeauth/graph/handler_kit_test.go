@@ -0,0 +1,121 @@
+// Copyright (C) 2020 The kubeauth Authors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package graph_test
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	meta "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	cage_k8s "github.com/codeactual/kubeauth/internal/cage/kubernetes/v1"
+	mock_core "github.com/codeactual/kubeauth/internal/cage/kubernetes/v1/core/mock"
+	cage_k8s_identity "github.com/codeactual/kubeauth/internal/cage/kubernetes/v1/rbac/identity"
+	mock_identity "github.com/codeactual/kubeauth/internal/cage/kubernetes/v1/rbac/identity/mock"
+	cage_gomock "github.com/codeactual/kubeauth/internal/cage/testkit/gomock"
+	"github.com/codeactual/kubeauth/internal/testkit"
+)
+
+// HandlerKit provides command test cases with data and mock-setup boilerplate.
+//
+// It integrates thc HandlerKit type from the internal/testkit package for additional
+// command-agnostic boilerplate.
+type HandlerKit struct {
+	*testkit.HandlerKit
+
+	T *testing.T
+
+	// As is the expected query name.
+	As string
+
+	// Resultset holds the identities returned by the query which Finish configures.
+	Resultset testkit.QueryResultset
+
+	// InvalidFlags is true if the command should exit before it queries identities.
+	InvalidFlags bool
+
+	// Stdout receives the graph.
+	Stdout *bytes.Buffer
+}
+
+func NewHandlerKit(t *testing.T) *HandlerKit {
+	k := &HandlerKit{
+		HandlerKit: testkit.NewHandlerKit(t),
+		T:          t,
+		Resultset:  testkit.NewQueryResultset(),
+		Stdout:     &bytes.Buffer{},
+	}
+	k.HandlerKit.Stdout = k.Stdout
+	return k
+}
+
+// Finish creates the expected calls, based on mock-related HandlerKit fields, that were not
+// already created by other methods.
+func (k *HandlerKit) Finish() {
+	k.HandlerKit.Finish()
+
+	if k.ExitOnErr != nil {
+		k.Session.EXPECT().ExitOnErr(cage_gomock.ErrShortRegexp(k.ExitOnErr), "", 1)
+	}
+
+	if k.InvalidFlags {
+		return
+	}
+
+	k.ConfigClient.EXPECT().
+		Parse("").
+		Return(testkit.NewConfigFile(testkit.ConfigFilename, testkit.CurrentContextName, testkit.CurrentClusterName, testkit.CurrentNamespace), nil)
+
+	if k.ExitOnErr != nil {
+		return
+	}
+
+	query := mock_identity.MatchQuery(false, &cage_k8s_identity.Query{Name: k.As})
+	expectClientset := mock_core.MatchClientset(k.ApiClientset.ToReal())
+
+	k.IdentityRegistry.CoreGroup.EXPECT().
+		Do(cage_gomock.ContextNonNil(), expectClientset, query).
+		Return(k.Resultset.CoreGroup, nil)
+	k.IdentityRegistry.CoreUser.EXPECT().
+		Do(cage_gomock.ContextNonNil(), expectClientset, query).
+		Return(k.Resultset.CoreUser, nil)
+	// The node querier only serves queries of node users.
+	if strings.HasPrefix(k.As, cage_k8s_identity.NodeUserPrefix) {
+		k.IdentityRegistry.Node.EXPECT().
+			Do(cage_gomock.ContextNonNil(), expectClientset, query).
+			Return(k.Resultset.Node, nil)
+	}
+	k.IdentityRegistry.RoleSubject.EXPECT().
+		Do(cage_gomock.ContextNonNil(), expectClientset, query).
+		Return(k.Resultset.RoleSubject, nil)
+	k.IdentityRegistry.ClusterRoleSubject.EXPECT().
+		Do(cage_gomock.ContextNonNil(), expectClientset, query).
+		Return(k.Resultset.ClusterRoleSubject, nil)
+	k.IdentityRegistry.ServiceAccountUser.EXPECT().
+		Do(cage_gomock.ContextNonNil(), expectClientset, query).
+		Return(k.Resultset.ServiceAccountUser, nil)
+	k.IdentityRegistry.ServiceAccountGroup.EXPECT().
+		Do(cage_gomock.ContextNonNil(), expectClientset, query).
+		Return(k.Resultset.ServiceAccountGroup, nil)
+}
+
+// AddRoleSubject adds a subject of a role binding to the query results.
+func (k *HandlerKit) AddRoleSubject(bindingNamespace, binding, kind, namespace, name string) {
+	k.Resultset.RoleSubject.Add(namespace, kind, name, &cage_k8s_identity.IdentitySource{
+		TypeMeta:   meta.TypeMeta{Kind: cage_k8s.KindRoleBinding},
+		ObjectMeta: meta.ObjectMeta{Namespace: bindingNamespace, Name: binding},
+	})
+}
+
+// AddClusterRoleSubject adds a subject of a cluster role binding to the query results.
+func (k *HandlerKit) AddClusterRoleSubject(binding, kind, namespace, name string) {
+	k.Resultset.ClusterRoleSubject.Add(namespace, kind, name, &cage_k8s_identity.IdentitySource{
+		TypeMeta:   meta.TypeMeta{Kind: cage_k8s.KindClusterRoleBinding},
+		ObjectMeta: meta.ObjectMeta{Name: binding},
+	})
+}
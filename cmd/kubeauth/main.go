@@ -18,7 +18,17 @@ import (
 	"github.com/spf13/cobra"
 
 	"github.com/codeactual/kubeauth/cmd/kubeauth/add_user"
+	"github.com/codeactual/kubeauth/cmd/kubeauth/bind"
+	"github.com/codeactual/kubeauth/cmd/kubeauth/cert_status"
+	"github.com/codeactual/kubeauth/cmd/kubeauth/config_restore"
 	"github.com/codeactual/kubeauth/cmd/kubeauth/ctl"
+	"github.com/codeactual/kubeauth/cmd/kubeauth/diff"
+	"github.com/codeactual/kubeauth/cmd/kubeauth/gc"
+	"github.com/codeactual/kubeauth/cmd/kubeauth/graph"
+	"github.com/codeactual/kubeauth/cmd/kubeauth/orphans"
+	"github.com/codeactual/kubeauth/cmd/kubeauth/rbac_audit"
+	"github.com/codeactual/kubeauth/cmd/kubeauth/sa_usage"
+	"github.com/codeactual/kubeauth/cmd/kubeauth/snapshot"
 	"github.com/codeactual/kubeauth/internal/cage/cli/handler"
 )
 
@@ -29,7 +39,17 @@ func main() {
 
 	rootCmd.Version = handler.Version()
 	rootCmd.AddCommand(add_user.NewCommand())
+	rootCmd.AddCommand(bind.NewCommand())
+	rootCmd.AddCommand(cert_status.NewCommand())
+	rootCmd.AddCommand(config_restore.NewCommand())
 	rootCmd.AddCommand(ctl.NewCommand())
+	rootCmd.AddCommand(diff.NewCommand())
+	rootCmd.AddCommand(gc.NewCommand())
+	rootCmd.AddCommand(graph.NewCommand())
+	rootCmd.AddCommand(orphans.NewCommand())
+	rootCmd.AddCommand(rbac_audit.NewCommand())
+	rootCmd.AddCommand(sa_usage.NewCommand())
+	rootCmd.AddCommand(snapshot.NewCommand())
 
 	if err := rootCmd.Execute(); err != nil {
 		fmt.Fprintf(os.Stderr, "%s\n%+v\n", rootCmd.UsageString(), err)
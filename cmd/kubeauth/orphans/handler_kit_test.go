@@ -0,0 +1,107 @@
+// Copyright (C) 2020 The kubeauth Authors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package orphans_test
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	meta "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	cage_k8s "github.com/codeactual/kubeauth/internal/cage/kubernetes/v1"
+	mock_core "github.com/codeactual/kubeauth/internal/cage/kubernetes/v1/core/mock"
+	cage_k8s_identity "github.com/codeactual/kubeauth/internal/cage/kubernetes/v1/rbac/identity"
+	mock_identity "github.com/codeactual/kubeauth/internal/cage/kubernetes/v1/rbac/identity/mock"
+	cage_gomock "github.com/codeactual/kubeauth/internal/cage/testkit/gomock"
+	"github.com/codeactual/kubeauth/internal/testkit"
+)
+
+// HandlerKit provides command test cases with data and mock-setup boilerplate.
+//
+// It integrates thc HandlerKit type from the internal/testkit package for additional
+// command-agnostic boilerplate.
+type HandlerKit struct {
+	*testkit.HandlerKit
+
+	T *testing.T
+
+	// Resultset holds the identities returned by the dangling queries which Finish configures.
+	Resultset testkit.QueryResultset
+
+	// Stdout receives the command's report.
+	Stdout *bytes.Buffer
+}
+
+func NewHandlerKit(t *testing.T) *HandlerKit {
+	k := &HandlerKit{
+		HandlerKit: testkit.NewHandlerKit(t),
+		T:          t,
+		Resultset:  testkit.NewQueryResultset(),
+		Stdout:     &bytes.Buffer{},
+	}
+	k.HandlerKit.Stdout = k.Stdout
+	return k
+}
+
+// Finish creates the expected calls, based on mock-related HandlerKit fields, that were not
+// already created by other methods.
+func (k *HandlerKit) Finish() {
+	k.HandlerKit.Finish()
+
+	k.ConfigClient.EXPECT().
+		Parse("").
+		Return(testkit.NewConfigFile(testkit.ConfigFilename, testkit.CurrentContextName, testkit.CurrentClusterName, testkit.CurrentNamespace), nil)
+
+	if k.ExitOnErr != nil {
+		k.Session.EXPECT().ExitOnErr(cage_gomock.ErrShortRegexp(k.ExitOnErr), "", 1)
+		return
+	}
+
+	query := DanglingQuery()
+	expectClientset := mock_core.MatchClientset(k.ApiClientset.ToReal())
+
+	k.IdentityRegistry.RoleSubject.EXPECT().
+		Do(cage_gomock.ContextNonNil(), expectClientset, query).
+		Return(k.Resultset.RoleSubject, nil)
+	k.IdentityRegistry.ClusterRoleSubject.EXPECT().
+		Do(cage_gomock.ContextNonNil(), expectClientset, query).
+		Return(k.Resultset.ClusterRoleSubject, nil)
+}
+
+// DanglingQuery returns a matcher of the query which the command performs.
+func DanglingQuery() gomock.Matcher {
+	return mock_identity.MatchQuery(false, &cage_k8s_identity.Query{Dangling: true})
+}
+
+// AddRoleSubject adds a dangling subject of a role binding to the query results.
+func (k *HandlerKit) AddRoleSubject(bindingNamespace, binding, kind, namespace, name string, reasons ...string) {
+	k.Resultset.RoleSubject.Items = append(
+		k.Resultset.RoleSubject.Items,
+		danglingIdentity(cage_k8s.KindRoleBinding, bindingNamespace, binding, kind, namespace, name, reasons),
+	)
+}
+
+// AddClusterRoleSubject adds a dangling subject of a cluster role binding to the query results.
+func (k *HandlerKit) AddClusterRoleSubject(binding, kind, namespace, name string, reasons ...string) {
+	k.Resultset.ClusterRoleSubject.Items = append(
+		k.Resultset.ClusterRoleSubject.Items,
+		danglingIdentity(cage_k8s.KindClusterRoleBinding, "", binding, kind, namespace, name, reasons),
+	)
+}
+
+func danglingIdentity(bindingKind, bindingNamespace, binding, kind, namespace, name string, reasons []string) cage_k8s_identity.Identity {
+	return cage_k8s_identity.Identity{
+		TypeMeta:   meta.TypeMeta{Kind: kind},
+		ObjectMeta: meta.ObjectMeta{Namespace: namespace, Name: name},
+		Source: &cage_k8s_identity.IdentitySource{
+			TypeMeta:   meta.TypeMeta{Kind: bindingKind},
+			ObjectMeta: meta.ObjectMeta{Namespace: bindingNamespace, Name: binding},
+		},
+		Dangling: reasons,
+	}
+}
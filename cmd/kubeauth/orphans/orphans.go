@@ -0,0 +1,300 @@
+// Copyright (C) 2020 The kubeauth Authors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package orphans
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+	rbac "k8s.io/api/rbac/v1"
+	"k8s.io/client-go/kubernetes"
+
+	"github.com/codeactual/kubeauth/internal/cage/cli/handler"
+	handler_cobra "github.com/codeactual/kubeauth/internal/cage/cli/handler/cobra"
+	cage_k8s "github.com/codeactual/kubeauth/internal/cage/kubernetes/v1"
+	cage_k8s_core "github.com/codeactual/kubeauth/internal/cage/kubernetes/v1/core"
+	cage_k8s_config "github.com/codeactual/kubeauth/internal/cage/kubernetes/v1/kubectl/config"
+	cage_k8s_identity "github.com/codeactual/kubeauth/internal/cage/kubernetes/v1/rbac/identity"
+	cage_reflect "github.com/codeactual/kubeauth/internal/cage/reflect"
+)
+
+const (
+	StatusDangling       = "dangling"
+	StatusBindingDeleted = "binding deleted"
+	StatusSubjectRemoved = "subject removed"
+)
+
+// Handler defines the sub-command flags and logic.
+type Handler struct {
+	handler.Session
+
+	KubeApiClientset    *cage_k8s_core.Clientset
+	KubectlConfigClient cage_k8s_config.Client
+	IdentityRegistry    *cage_k8s_identity.Registry
+
+	ConfigFile string `usage:"kubectl config file"`
+	Delete     bool   `usage:"remove the dangling subjects from their bindings, and delete bindings which no longer grant anything"`
+
+	// Verbosity levels greater than 0 will enable status messages and error stack traces.
+	//
+	// It is an int for consistency with other commands, even though levels beyond 1 are not used.
+	Verbosity int `usage:"kubectl verbosity level"`
+}
+
+// Init defines the command, its environment variable prefix, etc.
+//
+// It implements cli/handler/cobra.Handler.
+func (h *Handler) Init() handler_cobra.Init {
+	return handler_cobra.Init{
+		Cmd: &cobra.Command{
+			Use:   "orphans",
+			Short: "List or clean up binding subjects and role references which no longer resolve to existing objects",
+		},
+		EnvPrefix: "KUBEAUTH",
+	}
+}
+
+// BindFlags binds the flags to Handler fields.
+//
+// It implements cli/handler/cobra.Handler.
+func (h *Handler) BindFlags(cmd *cobra.Command) []string {
+	cmd.Flags().StringVarP(&h.ConfigFile, "kubeconfig", "", "", cage_reflect.GetFieldTag(*h, "ConfigFile", "usage"))
+	cmd.Flags().BoolVarP(&h.Delete, "delete", "", false, cage_reflect.GetFieldTag(*h, "Delete", "usage"))
+	cmd.Flags().IntVarP(&h.Verbosity, "v", "v", 0, cage_reflect.GetFieldTag(*h, "Verbosity", "usage"))
+	return []string{}
+}
+
+// Run performs the sub-command logic.
+//
+// It implements cli/handler/cobra.Handler.
+func (h *Handler) Run(ctx context.Context, input handler.Input) {
+	if err := h.run(ctx, input); err != nil {
+		if h.Verbosity > 0 {
+			h.ExitOnErr(err, "", 1)
+		} else {
+			h.ExitOnErrShort(err, "", 1)
+		}
+	}
+}
+
+// orphan describes a binding subject which no longer resolves to existing objects.
+type orphan struct {
+	id     cage_k8s_identity.Identity
+	status string
+}
+
+// bindingKey returns a value which groups the orphans found in the same binding.
+func (o orphan) bindingKey() string {
+	return o.id.Source.Kind + "/" + o.id.Source.Namespace + "/" + o.id.Source.Name
+}
+
+// matches returns true if the subject is the one described by the orphan.
+func (o orphan) matches(s rbac.Subject) bool {
+	return s.Kind == o.id.Kind && s.Namespace == o.id.Namespace && s.Name == o.id.Name
+}
+
+func (h *Handler) run(ctx context.Context, _ handler.Input) error {
+	stderr := h.Err()
+	verbose := func(format string, vArgs ...interface{}) {
+		if h.Verbosity > 0 {
+			fmt.Fprintln(stderr, "kubeauth: "+fmt.Sprintf(format, vArgs...))
+		}
+	}
+
+	configClient := h.KubectlConfigClient
+	if configClient == nil {
+		configClient = cage_k8s_config.NewDefaultClient()
+	}
+
+	configFile, err := configClient.Parse(h.ConfigFile)
+	if err != nil {
+		return errors.Wrap(err, "kubeauth") // WithStack alternative to disambiguate from kubectl output
+	}
+
+	apiClientset := h.KubeApiClientset
+	if apiClientset == nil {
+		rawApiClientset, err := kubernetes.NewForConfig(configFile.RestConfig)
+		if err != nil {
+			return errors.Wrap(err, "kubeauth: failed to create API client")
+		}
+
+		apiClientset = cage_k8s_core.NewClientset(rawApiClientset)
+	}
+
+	regClient := h.IdentityRegistry
+	if regClient == nil {
+		regClient = cage_k8s_identity.NewRegistry(apiClientset)
+	}
+
+	// Find the dangling subjects.
+
+	// Keep the raw results because each binding of a subject is reported separately.
+	list, err := regClient.Query(ctx, cage_k8s_identity.QueryDangling(true), cage_k8s_identity.QueryRaw(true))
+	if err != nil {
+		return errors.Wrap(err, "kubeauth") // WithStack alternative to disambiguate from kubectl output
+	}
+
+	var orphans []orphan
+	for _, id := range list.Items {
+		if id.Source == nil { // only expected from queriers which do not support QueryDangling
+			continue
+		}
+		orphans = append(orphans, orphan{id: id, status: StatusDangling})
+	}
+
+	// Sort the orphans because the registry's queriers run in parallel.
+	sort.SliceStable(orphans, func(i, j int) bool {
+		a, b := orphans[i], orphans[j]
+		if a.bindingKey() != b.bindingKey() {
+			return a.bindingKey() < b.bindingKey()
+		}
+		return a.id.Kind+"/"+a.id.Namespace+"/"+a.id.Name < b.id.Kind+"/"+b.id.Namespace+"/"+b.id.Name
+	})
+
+	// Remove the dangling subjects from their bindings. If none remain, delete the binding instead
+	// because the API server rejects updates which would leave it without subjects.
+
+	if h.Delete {
+		for start := 0; start < len(orphans); {
+			end := start + 1
+			for end < len(orphans) && orphans[end].bindingKey() == orphans[start].bindingKey() {
+				end++
+			}
+
+			status, err := h.clean(apiClientset, orphans[start:end], verbose)
+			if err != nil {
+				return errors.Wrap(err, "kubeauth") // WithStack alternative to disambiguate from kubectl output
+			}
+			for n := start; n < end; n++ {
+				orphans[n].status = status
+			}
+
+			start = end
+		}
+	}
+
+	// Report.
+
+	w := tabwriter.NewWriter(h.Out(), 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "KIND\tNAMESPACE\tNAME\tSUBJECT\tREASON\tSTATUS")
+	for _, o := range orphans {
+		ns := o.id.Source.Namespace
+		if ns == "" {
+			ns = cage_k8s.EmptyNamespace
+		}
+		subject := o.id.Kind + " " + o.id.Name
+		if o.id.Namespace != "" {
+			subject = o.id.Kind + " " + o.id.Namespace + "/" + o.id.Name
+		}
+		fmt.Fprintf(
+			w, "%s\t%s\t%s\t%s\t%s\t%s\n",
+			o.id.Source.Kind,
+			ns,
+			o.id.Source.Name,
+			subject,
+			strings.Join(o.id.Dangling, "; "),
+			o.status,
+		)
+	}
+	if err = w.Flush(); err != nil {
+		return errors.Wrap(err, "kubeauth: failed to write report")
+	}
+
+	return nil
+}
+
+// clean removes the orphans, which must share the same binding, from the binding.
+//
+// It returns the status which applies to all the orphans.
+func (h *Handler) clean(apiClientset *cage_k8s_core.Clientset, orphans []orphan, verbose func(string, ...interface{})) (string, error) {
+	source := orphans[0].id.Source
+
+	remaining := func(subjects []rbac.Subject) (kept []rbac.Subject) {
+		for _, s := range subjects {
+			var dangling bool
+			for _, o := range orphans {
+				if o.matches(s) {
+					dangling = true
+					break
+				}
+			}
+			if !dangling {
+				kept = append(kept, s)
+			}
+		}
+		return kept
+	}
+
+	switch source.Kind {
+	case cage_k8s.KindRoleBinding:
+		binding, exists, err := apiClientset.RoleBindings.Get(source.Namespace, source.Name)
+		if err != nil {
+			return "", errors.WithStack(err)
+		}
+		if !exists {
+			verbose("skipped role binding [%s] in namespace [%s] which no longer exists", source.Name, source.Namespace)
+			return StatusBindingDeleted, nil
+		}
+
+		kept := remaining(binding.Subjects)
+		if len(kept) == 0 {
+			if err = apiClientset.RoleBindings.Delete(source.Namespace, source.Name); err != nil {
+				return "", errors.WithStack(err)
+			}
+			verbose("deleted role binding [%s] in namespace [%s]", source.Name, source.Namespace)
+			return StatusBindingDeleted, nil
+		}
+
+		binding.Subjects = kept
+		if _, err = apiClientset.RoleBindings.Update(binding); err != nil {
+			return "", errors.WithStack(err)
+		}
+		verbose("removed %d subject(s) from role binding [%s] in namespace [%s]", len(orphans), source.Name, source.Namespace)
+	case cage_k8s.KindClusterRoleBinding:
+		binding, exists, err := apiClientset.ClusterRoleBindings.Get(source.Name)
+		if err != nil {
+			return "", errors.WithStack(err)
+		}
+		if !exists {
+			verbose("skipped cluster role binding [%s] which no longer exists", source.Name)
+			return StatusBindingDeleted, nil
+		}
+
+		kept := remaining(binding.Subjects)
+		if len(kept) == 0 {
+			if err = apiClientset.ClusterRoleBindings.Delete(source.Name); err != nil {
+				return "", errors.WithStack(err)
+			}
+			verbose("deleted cluster role binding [%s]", source.Name)
+			return StatusBindingDeleted, nil
+		}
+
+		binding.Subjects = kept
+		if _, err = apiClientset.ClusterRoleBindings.Update(binding); err != nil {
+			return "", errors.WithStack(err)
+		}
+		verbose("removed %d subject(s) from cluster role binding [%s]", len(orphans), source.Name)
+	default:
+		return "", errors.Errorf("cannot clean up binding of unsupported kind [%s]", source.Kind)
+	}
+
+	return StatusSubjectRemoved, nil
+}
+
+// New returns a cobra command instance based on Handler.
+func NewCommand() *cobra.Command {
+	return handler_cobra.NewHandler(&Handler{
+		Session: &handler.DefaultSession{},
+	})
+}
+
+var _ handler_cobra.Handler = (*Handler)(nil)
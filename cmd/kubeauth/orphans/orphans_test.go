@@ -0,0 +1,175 @@
+// Copyright (C) 2020 The kubeauth Authors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at https://mozilla.org/MPL/2.0/.
+
+// Package orphans_test asserts CLI behavior by running the command handler logic
+// directly (w/o separate processes) with various input scenarios.
+//
+// It uses Handler instances that use mock implementations of the clients used
+// to modify kubeconfig files and perform API requests. The tests only verify correct
+// use of the client interfaces. Tests in the cage_k8s package tree verify
+// lower-level client behaviors, e.g. how dangling subjects are detected.
+package orphans_test
+
+import (
+	"context"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/require"
+	rbac "k8s.io/api/rbac/v1"
+	meta "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	cli "github.com/codeactual/kubeauth/cmd/kubeauth/orphans"
+	"github.com/codeactual/kubeauth/internal/cage/cli/handler"
+	cage_k8s "github.com/codeactual/kubeauth/internal/cage/kubernetes/v1"
+	cage_gomock "github.com/codeactual/kubeauth/internal/cage/testkit/gomock"
+)
+
+const (
+	ReasonRoleMissing = "ClusterRole [gone-role] not found"
+	ReasonSaMissing   = "ServiceAccount [sa-gone] not found in namespace [ns-a]"
+)
+
+func NewHandler(kit *HandlerKit) *cli.Handler {
+	apiClientset := kit.ApiClientset.ToReal()
+
+	h := cli.Handler{
+		Session:             kit.Session,
+		KubectlConfigClient: kit.ConfigClient,
+		KubeApiClientset:    apiClientset,
+		IdentityRegistry:    kit.IdentityRegistry.ToReal(apiClientset),
+	}
+
+	// Enable for test troubleshooting and verbose output assertions.
+	h.Verbosity = 1
+
+	return &h
+}
+
+// reportLines returns the report's rows, excluding the header, with whitespace collapsed.
+func reportLines(t *testing.T, kit *HandlerKit) []string {
+	lines := strings.Split(strings.TrimSpace(kit.Stdout.String()), "\n")
+	require.True(t, strings.HasPrefix(lines[0], "KIND"))
+
+	var rows []string
+	for _, l := range lines[1:] {
+		rows = append(rows, strings.Join(strings.Fields(l), " "))
+	}
+	return rows
+}
+
+// addSubjects adds a role binding with one dangling service account, and a cluster role binding whose
+// cluster role is missing, which makes all its subjects dangling.
+func addSubjects(kit *HandlerKit) {
+	kit.AddRoleSubject("ns-a", "rb-a", cage_k8s.KindServiceAccount, "ns-a", "sa-gone", ReasonSaMissing)
+	kit.AddClusterRoleSubject("crb-a", cage_k8s.KindUser, "", "user-b", ReasonRoleMissing)
+	kit.AddClusterRoleSubject("crb-a", cage_k8s.KindUser, "", "user-a", ReasonRoleMissing)
+}
+
+func TestList(t *testing.T) {
+	kit := NewHandlerKit(t)
+	addSubjects(kit)
+	kit.Finish()
+	defer kit.MockCtrl.Finish()
+
+	h := NewHandler(kit)
+	h.Run(context.Background(), handler.Input{})
+
+	require.Exactly(
+		t,
+		[]string{
+			"ClusterRoleBinding <no namespace> crb-a User user-a " + ReasonRoleMissing + " dangling",
+			"ClusterRoleBinding <no namespace> crb-a User user-b " + ReasonRoleMissing + " dangling",
+			"RoleBinding ns-a rb-a ServiceAccount ns-a/sa-gone " + ReasonSaMissing + " dangling",
+		},
+		reportLines(t, kit),
+	)
+}
+
+// TestDelete asserts that dangling subjects are removed from bindings which still have other subjects,
+// and that bindings are deleted if all their subjects are dangling.
+func TestDelete(t *testing.T) {
+	kit := NewHandlerKit(t)
+	addSubjects(kit)
+	kit.Finish()
+	defer kit.MockCtrl.Finish()
+
+	roleRef := rbac.RoleRef{Kind: cage_k8s.KindClusterRole, Name: "gone-role"}
+	saRemains := rbac.Subject{Kind: cage_k8s.KindServiceAccount, Namespace: "ns-a", Name: "sa-a"}
+	saGone := rbac.Subject{Kind: cage_k8s.KindServiceAccount, Namespace: "ns-a", Name: "sa-gone"}
+
+	roleBinding := rbac.RoleBinding{
+		ObjectMeta: meta.ObjectMeta{Namespace: "ns-a", Name: "rb-a"},
+		RoleRef:    rbac.RoleRef{Kind: cage_k8s.KindRole, Name: "role-a"},
+		Subjects:   []rbac.Subject{saRemains, saGone},
+	}
+	updated := roleBinding
+	updated.Subjects = []rbac.Subject{saRemains}
+
+	clusterRoleBinding := rbac.ClusterRoleBinding{
+		ObjectMeta: meta.ObjectMeta{Name: "crb-a"},
+		RoleRef:    roleRef,
+		Subjects: []rbac.Subject{
+			{Kind: cage_k8s.KindUser, Name: "user-a"},
+			{Kind: cage_k8s.KindUser, Name: "user-b"},
+		},
+	}
+
+	kit.ApiClientset.ClusterRoleBindings.EXPECT().Get("crb-a").Return(&clusterRoleBinding, true, nil)
+	kit.ApiClientset.ClusterRoleBindings.EXPECT().Delete("crb-a").Return(nil)
+	kit.ApiClientset.RoleBindings.EXPECT().Get("ns-a", "rb-a").Return(&roleBinding, true, nil)
+	kit.ApiClientset.RoleBindings.EXPECT().Update(&updated).Return(&updated, nil)
+
+	h := NewHandler(kit)
+	h.Delete = true
+	h.Run(context.Background(), handler.Input{})
+
+	require.Exactly(
+		t,
+		[]string{
+			"ClusterRoleBinding <no namespace> crb-a User user-a " + ReasonRoleMissing + " binding deleted",
+			"ClusterRoleBinding <no namespace> crb-a User user-b " + ReasonRoleMissing + " binding deleted",
+			"RoleBinding ns-a rb-a ServiceAccount ns-a/sa-gone " + ReasonSaMissing + " subject removed",
+		},
+		reportLines(t, kit),
+	)
+}
+
+func TestNoOrphans(t *testing.T) {
+	kit := NewHandlerKit(t)
+	kit.Finish()
+	defer kit.MockCtrl.Finish()
+
+	h := NewHandler(kit)
+	h.Delete = true
+	h.Run(context.Background(), handler.Input{})
+
+	require.Empty(t, reportLines(t, kit))
+}
+
+// TestErrOnQuery asserts that a failed query exits with the error.
+func TestErrOnQuery(t *testing.T) {
+	kit := NewHandlerKit(t)
+	kit.ExitOnErr = regexp.MustCompile("failed to get role binding list")
+	kit.Finish()
+	defer kit.MockCtrl.Finish()
+
+	kit.IdentityRegistry.RoleSubject.EXPECT().
+		Do(cage_gomock.ContextNonNil(), gomock.Any(), DanglingQuery()).
+		Return(nil, errors.New("failed to get role binding list"))
+
+	// The queriers run in parallel, so this one may or may not run before the other fails.
+	kit.IdentityRegistry.ClusterRoleSubject.EXPECT().
+		Do(cage_gomock.ContextNonNil(), gomock.Any(), DanglingQuery()).
+		Return(kit.Resultset.ClusterRoleSubject, nil).
+		AnyTimes()
+
+	h := NewHandler(kit)
+	h.Run(context.Background(), handler.Input{})
+}
@@ -0,0 +1,218 @@
+// Copyright (C) 2020 The kubeauth Authors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package rbac_audit
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"text/tabwriter"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+	"k8s.io/client-go/kubernetes"
+
+	"github.com/codeactual/kubeauth/internal/audit"
+	"github.com/codeactual/kubeauth/internal/cage/cli/handler"
+	handler_cobra "github.com/codeactual/kubeauth/internal/cage/cli/handler/cobra"
+	cage_k8s "github.com/codeactual/kubeauth/internal/cage/kubernetes/v1"
+	cage_k8s_core "github.com/codeactual/kubeauth/internal/cage/kubernetes/v1/core"
+	cage_k8s_config "github.com/codeactual/kubeauth/internal/cage/kubernetes/v1/kubectl/config"
+	cage_reflect "github.com/codeactual/kubeauth/internal/cage/reflect"
+	"github.com/codeactual/kubeauth/internal/ldflags"
+)
+
+const (
+	OutputJSON  = "json"
+	OutputSarif = "sarif"
+	OutputTable = "table"
+)
+
+// Handler defines the sub-command flags and logic.
+type Handler struct {
+	handler.Session
+
+	KubeApiClientset    *cage_k8s_core.Clientset
+	KubectlConfigClient cage_k8s_config.Client
+
+	All        bool   `usage:"include the roles and bindings which the API server creates, e.g. cluster-admin"`
+	ConfigFile string `usage:"kubectl config file"`
+	Output     string `usage:"report format: table, json, or sarif"`
+
+	// Verbosity levels greater than 0 will enable status messages and error stack traces.
+	//
+	// It is an int for consistency with other commands, even though levels beyond 1 are not used.
+	Verbosity int `usage:"kubectl verbosity level"`
+}
+
+// Init defines the command, its environment variable prefix, etc.
+//
+// It implements cli/handler/cobra.Handler.
+func (h *Handler) Init() handler_cobra.Init {
+	return handler_cobra.Init{
+		Cmd: &cobra.Command{
+			Use:   "rbac-audit",
+			Short: "Report risky patterns in the cluster's roles and bindings",
+		},
+		EnvPrefix: "KUBEAUTH",
+	}
+}
+
+// BindFlags binds the flags to Handler fields.
+//
+// It implements cli/handler/cobra.Handler.
+func (h *Handler) BindFlags(cmd *cobra.Command) []string {
+	cmd.Flags().BoolVarP(&h.All, "all", "", false, cage_reflect.GetFieldTag(*h, "All", "usage"))
+	cmd.Flags().StringVarP(&h.ConfigFile, "kubeconfig", "", "", cage_reflect.GetFieldTag(*h, "ConfigFile", "usage"))
+	cmd.Flags().StringVarP(&h.Output, "output", "o", OutputTable, cage_reflect.GetFieldTag(*h, "Output", "usage"))
+	cmd.Flags().IntVarP(&h.Verbosity, "v", "v", 0, cage_reflect.GetFieldTag(*h, "Verbosity", "usage"))
+	return []string{}
+}
+
+// Run performs the sub-command logic.
+//
+// It implements cli/handler/cobra.Handler.
+func (h *Handler) Run(ctx context.Context, input handler.Input) {
+	if err := h.run(ctx, input); err != nil {
+		if h.Verbosity > 0 {
+			h.ExitOnErr(err, "", 1)
+		} else {
+			h.ExitOnErrShort(err, "", 1)
+		}
+	}
+}
+
+func (h *Handler) run(ctx context.Context, _ handler.Input) error {
+	stderr := h.Err()
+	verbose := func(format string, vArgs ...interface{}) {
+		if h.Verbosity > 0 {
+			fmt.Fprintln(stderr, "kubeauth: "+fmt.Sprintf(format, vArgs...))
+		}
+	}
+
+	switch h.Output {
+	case "":
+		h.Output = OutputTable
+	case OutputTable, OutputJSON, OutputSarif:
+	default:
+		return errors.Errorf("kubeauth: --output must be %q, %q, or %q", OutputTable, OutputJSON, OutputSarif)
+	}
+
+	configClient := h.KubectlConfigClient
+	if configClient == nil {
+		configClient = cage_k8s_config.NewDefaultClient()
+	}
+
+	configFile, err := configClient.Parse(h.ConfigFile)
+	if err != nil {
+		return errors.Wrap(err, "kubeauth") // WithStack alternative to disambiguate from kubectl output
+	}
+
+	apiClientset := h.KubeApiClientset
+	if apiClientset == nil {
+		rawApiClientset, err := kubernetes.NewForConfig(configFile.RestConfig)
+		if err != nil {
+			return errors.Wrap(err, "kubeauth: failed to create API client")
+		}
+
+		apiClientset = cage_k8s_core.NewClientset(rawApiClientset)
+	}
+
+	// List the objects in all namespaces.
+
+	var objs audit.Objects
+
+	roles, err := apiClientset.Roles.List("")
+	if err != nil {
+		return errors.Wrap(err, "kubeauth") // WithStack alternative to disambiguate from kubectl output
+	}
+	if roles != nil {
+		objs.Roles = roles.Items
+	}
+
+	clusterRoles, err := apiClientset.ClusterRoles.List()
+	if err != nil {
+		return errors.Wrap(err, "kubeauth") // WithStack alternative to disambiguate from kubectl output
+	}
+	if clusterRoles != nil {
+		objs.ClusterRoles = clusterRoles.Items
+	}
+
+	roleBindings, err := apiClientset.RoleBindings.List("")
+	if err != nil {
+		return errors.Wrap(err, "kubeauth") // WithStack alternative to disambiguate from kubectl output
+	}
+	if roleBindings != nil {
+		objs.RoleBindings = roleBindings.Items
+	}
+
+	clusterRoleBindings, err := apiClientset.ClusterRoleBindings.List()
+	if err != nil {
+		return errors.Wrap(err, "kubeauth") // WithStack alternative to disambiguate from kubectl output
+	}
+	if clusterRoleBindings != nil {
+		objs.ClusterRoleBindings = clusterRoleBindings.Items
+	}
+
+	serviceAccounts, err := apiClientset.ServiceAccounts.List("")
+	if err != nil {
+		return errors.Wrap(err, "kubeauth") // WithStack alternative to disambiguate from kubectl output
+	}
+	if serviceAccounts != nil {
+		objs.ServiceAccounts = serviceAccounts.Items
+	}
+
+	verbose(
+		"auditing %d roles, %d cluster roles, %d role bindings, and %d cluster role bindings",
+		len(objs.Roles), len(objs.ClusterRoles), len(objs.RoleBindings), len(objs.ClusterRoleBindings),
+	)
+
+	findings := audit.Audit(objs, audit.Options{IncludeDefaults: h.All})
+
+	// Report.
+
+	switch h.Output {
+	case OutputJSON:
+		// Encode an empty list, rather than null, if there are no findings.
+		if findings == nil {
+			findings = []audit.Finding{}
+		}
+		enc := json.NewEncoder(h.Out())
+		enc.SetIndent("", "  ")
+		if err = enc.Encode(findings); err != nil {
+			return errors.Wrap(err, "kubeauth: failed to write report")
+		}
+	case OutputSarif:
+		if err = audit.WriteSarif(h.Out(), ldflags.Version, findings); err != nil {
+			return errors.Wrap(err, "kubeauth: failed to write report")
+		}
+	default:
+		w := tabwriter.NewWriter(h.Out(), 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "LEVEL\tRULE\tKIND\tNAMESPACE\tNAME\tMESSAGE")
+		for _, f := range findings {
+			ns := f.Namespace
+			if ns == "" {
+				ns = cage_k8s.EmptyNamespace
+			}
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\n", f.Level, f.Rule, f.Kind, ns, f.Name, f.Message)
+		}
+		if err = w.Flush(); err != nil {
+			return errors.Wrap(err, "kubeauth: failed to write report")
+		}
+	}
+
+	return nil
+}
+
+// New returns a cobra command instance based on Handler.
+func NewCommand() *cobra.Command {
+	return handler_cobra.NewHandler(&Handler{
+		Session: &handler.DefaultSession{},
+	})
+}
+
+var _ handler_cobra.Handler = (*Handler)(nil)
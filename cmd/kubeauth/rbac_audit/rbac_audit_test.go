@@ -0,0 +1,211 @@
+// Copyright (C) 2020 The kubeauth Authors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at https://mozilla.org/MPL/2.0/.
+
+// Package rbac_audit_test asserts CLI behavior by running the command handler logic
+// directly (w/o separate processes) with various input scenarios.
+//
+// It uses Handler instances that use mock implementations of the clients used
+// to modify kubeconfig files and perform API requests. The tests only verify correct
+// use of the client interfaces. Tests in the internal/audit package verify the findings
+// in more detail.
+package rbac_audit_test
+
+import (
+	"context"
+	"encoding/json"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/require"
+	core "k8s.io/api/core/v1"
+	rbac "k8s.io/api/rbac/v1"
+	meta "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	cli "github.com/codeactual/kubeauth/cmd/kubeauth/rbac_audit"
+	"github.com/codeactual/kubeauth/internal/audit"
+	"github.com/codeactual/kubeauth/internal/cage/cli/handler"
+	cage_k8s "github.com/codeactual/kubeauth/internal/cage/kubernetes/v1"
+)
+
+func NewHandler(kit *HandlerKit) *cli.Handler {
+	h := cli.Handler{
+		Session:             kit.Session,
+		KubectlConfigClient: kit.ConfigClient,
+		KubeApiClientset:    kit.ApiClientset.ToReal(),
+	}
+
+	// Enable for test troubleshooting and verbose output assertions.
+	h.Verbosity = 1
+
+	return &h
+}
+
+// reportLines returns the table's rows, excluding the header, with whitespace collapsed.
+func reportLines(t *testing.T, kit *HandlerKit) []string {
+	lines := strings.Split(strings.TrimSpace(kit.Stdout.String()), "\n")
+	require.True(t, strings.HasPrefix(lines[0], "LEVEL"))
+
+	var rows []string
+	for _, l := range lines[1:] {
+		rows = append(rows, strings.Join(strings.Fields(l), " "))
+	}
+	return rows
+}
+
+func addObjects(kit *HandlerKit) {
+	kit.ClusterRoles = []rbac.ClusterRole{
+		{
+			ObjectMeta: meta.ObjectMeta{Name: audit.ClusterAdmin, Labels: map[string]string{audit.BootstrapLabel: audit.BootstrapValue}},
+			Rules:      []rbac.PolicyRule{{Verbs: []string{"*"}, APIGroups: []string{"*"}, Resources: []string{"*"}}},
+		},
+	}
+	kit.RoleBindings = []rbac.RoleBinding{
+		{
+			ObjectMeta: meta.ObjectMeta{Namespace: "dev", Name: "ci"},
+			RoleRef:    rbac.RoleRef{Kind: cage_k8s.KindClusterRole, Name: "edit"},
+			Subjects:   []rbac.Subject{{Kind: cage_k8s.KindServiceAccount, Name: "ci"}},
+		},
+	}
+	kit.ClusterRoleBindings = []rbac.ClusterRoleBinding{
+		{
+			ObjectMeta: meta.ObjectMeta{Name: "ops-admin"},
+			RoleRef:    rbac.RoleRef{Kind: cage_k8s.KindClusterRole, Name: audit.ClusterAdmin},
+			Subjects:   []rbac.Subject{{Kind: cage_k8s.KindGroup, APIGroup: rbac.GroupName, Name: "ops"}},
+		},
+	}
+	kit.ServiceAccounts = []core.ServiceAccount{{ObjectMeta: meta.ObjectMeta{Namespace: "ci", Name: "ci"}}}
+}
+
+// TestTable asserts that findings are reported as a table by default, without the default roles
+// and bindings.
+func TestTable(t *testing.T) {
+	kit := NewHandlerKit(t)
+	addObjects(kit)
+	kit.Finish()
+	defer kit.MockCtrl.Finish()
+
+	h := NewHandler(kit)
+	h.Run(context.Background(), handler.Input{})
+
+	require.Exactly(
+		t,
+		[]string{
+			"warning missing-subject RoleBinding dev ci service account [ci] in namespace [dev] does not exist",
+			"error cluster-admin-binding ClusterRoleBinding <no namespace> ops-admin grants cluster-admin cluster-wide to [ops (kind: Group ns: <no namespace>)]",
+		},
+		reportLines(t, kit),
+	)
+}
+
+// TestAll asserts that --all includes the default roles and bindings.
+func TestAll(t *testing.T) {
+	kit := NewHandlerKit(t)
+	addObjects(kit)
+	kit.Finish()
+	defer kit.MockCtrl.Finish()
+
+	h := NewHandler(kit)
+	h.All = true
+	h.Run(context.Background(), handler.Input{})
+
+	lines := reportLines(t, kit)
+	require.Len(t, lines, 3)
+	require.True(t, strings.HasPrefix(lines[0], "warning wildcard-rule ClusterRole <no namespace> cluster-admin"))
+}
+
+// TestJSON asserts that --output json writes the findings as a list.
+func TestJSON(t *testing.T) {
+	kit := NewHandlerKit(t)
+	addObjects(kit)
+	kit.Finish()
+	defer kit.MockCtrl.Finish()
+
+	h := NewHandler(kit)
+	h.Output = cli.OutputJSON
+	h.Run(context.Background(), handler.Input{})
+
+	var findings []audit.Finding
+	require.NoError(t, json.Unmarshal(kit.Stdout.Bytes(), &findings))
+	require.Len(t, findings, 2)
+	require.Exactly(
+		t,
+		audit.Finding{
+			Rule: "missing-subject", Level: audit.LevelWarning, Kind: cage_k8s.KindRoleBinding, Namespace: "dev", Name: "ci",
+			Message: "service account [ci] in namespace [dev] does not exist",
+		},
+		findings[0],
+	)
+}
+
+// TestJSONEmpty asserts that --output json writes an empty list if there are no findings.
+func TestJSONEmpty(t *testing.T) {
+	kit := NewHandlerKit(t)
+	kit.Finish()
+	defer kit.MockCtrl.Finish()
+
+	h := NewHandler(kit)
+	h.Output = cli.OutputJSON
+	h.Run(context.Background(), handler.Input{})
+
+	require.Exactly(t, "[]\n", kit.Stdout.String())
+}
+
+// TestSarif asserts that --output sarif writes a SARIF log.
+func TestSarif(t *testing.T) {
+	kit := NewHandlerKit(t)
+	addObjects(kit)
+	kit.Finish()
+	defer kit.MockCtrl.Finish()
+
+	h := NewHandler(kit)
+	h.Output = cli.OutputSarif
+	h.Run(context.Background(), handler.Input{})
+
+	var log struct {
+		Version string
+		Runs    []struct {
+			Results []struct {
+				RuleID string
+			}
+		}
+	}
+	require.NoError(t, json.Unmarshal(kit.Stdout.Bytes(), &log))
+	require.Exactly(t, audit.SarifVersion, log.Version)
+	require.Len(t, log.Runs, 1)
+	require.Len(t, log.Runs[0].Results, 2)
+	require.Exactly(t, "missing-subject", log.Runs[0].Results[0].RuleID)
+	require.Exactly(t, "cluster-admin-binding", log.Runs[0].Results[1].RuleID)
+}
+
+// TestErrOnInvalidOutput asserts that --output must select a supported format.
+func TestErrOnInvalidOutput(t *testing.T) {
+	kit := NewHandlerKit(t)
+	kit.InvalidFlags = true
+	kit.ExitOnErr = regexp.MustCompile(`--output must be "table", "json", or "sarif"`)
+	kit.Finish()
+	defer kit.MockCtrl.Finish()
+
+	h := NewHandler(kit)
+	h.Output = "yaml"
+	h.Run(context.Background(), handler.Input{})
+}
+
+// TestErrOnList asserts that a failed list exits with the error.
+func TestErrOnList(t *testing.T) {
+	kit := NewHandlerKit(t)
+	kit.ExitOnErr = regexp.MustCompile("failed to list roles")
+	kit.Finish()
+	defer kit.MockCtrl.Finish()
+
+	kit.ApiClientset.Roles.EXPECT().
+		List("").
+		Return(nil, errors.New("failed to list roles"))
+
+	h := NewHandler(kit)
+	h.Run(context.Background(), handler.Input{})
+}
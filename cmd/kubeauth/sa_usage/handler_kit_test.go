@@ -0,0 +1,88 @@
+// Copyright (C) 2020 The kubeauth Authors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package sa_usage_test
+
+import (
+	"bytes"
+	"testing"
+
+	meta "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	cage_k8s "github.com/codeactual/kubeauth/internal/cage/kubernetes/v1"
+	mock_core "github.com/codeactual/kubeauth/internal/cage/kubernetes/v1/core/mock"
+	cage_k8s_identity "github.com/codeactual/kubeauth/internal/cage/kubernetes/v1/rbac/identity"
+	mock_identity "github.com/codeactual/kubeauth/internal/cage/kubernetes/v1/rbac/identity/mock"
+	cage_gomock "github.com/codeactual/kubeauth/internal/cage/testkit/gomock"
+	"github.com/codeactual/kubeauth/internal/testkit"
+)
+
+// HandlerKit provides command test cases with data and mock-setup boilerplate.
+//
+// It integrates thc HandlerKit type from the internal/testkit package for additional
+// command-agnostic boilerplate.
+type HandlerKit struct {
+	*testkit.HandlerKit
+
+	T *testing.T
+
+	// Namespace is the expected query namespace.
+	Namespace string
+
+	// Resultset holds the identities returned by the workload query which Finish configures.
+	Resultset testkit.QueryResultset
+
+	// SkipQuery is true if the command should exit before it queries workloads.
+	SkipQuery bool
+
+	// Stdout receives the command's report.
+	Stdout *bytes.Buffer
+}
+
+func NewHandlerKit(t *testing.T) *HandlerKit {
+	k := &HandlerKit{
+		HandlerKit: testkit.NewHandlerKit(t),
+		T:          t,
+		Resultset:  testkit.NewQueryResultset(),
+		Stdout:     &bytes.Buffer{},
+	}
+	k.HandlerKit.Stdout = k.Stdout
+	return k
+}
+
+// Finish creates the expected calls, based on mock-related HandlerKit fields, that were not
+// already created by other methods.
+func (k *HandlerKit) Finish() {
+	k.HandlerKit.Finish()
+
+	k.ConfigClient.EXPECT().
+		Parse("").
+		Return(testkit.NewConfigFile(testkit.ConfigFilename, testkit.CurrentContextName, testkit.CurrentClusterName, testkit.CurrentNamespace), nil)
+
+	if k.ExitOnErr != nil {
+		k.Session.EXPECT().ExitOnErr(cage_gomock.ErrShortRegexp(k.ExitOnErr), "", 1)
+	}
+
+	if k.SkipQuery {
+		return
+	}
+
+	k.IdentityRegistry.ServiceAccountWorkload.EXPECT().
+		Do(
+			cage_gomock.ContextNonNil(),
+			mock_core.MatchClientset(k.ApiClientset.ToReal()),
+			mock_identity.MatchQuery(false, &cage_k8s_identity.Query{Namespace: k.Namespace, Workloads: true}),
+		).
+		Return(k.Resultset.ServiceAccountWorkload, nil)
+}
+
+// AddWorkload adds a workload which uses the service account to the query results.
+func (k *HandlerKit) AddWorkload(namespace, serviceAccount, kind, name string) {
+	k.Resultset.ServiceAccountWorkload.Add(namespace, cage_k8s.KindServiceAccount, serviceAccount, &cage_k8s_identity.IdentitySource{
+		TypeMeta:   meta.TypeMeta{Kind: kind},
+		ObjectMeta: meta.ObjectMeta{Namespace: namespace, Name: name},
+	})
+}
@@ -0,0 +1,218 @@
+// Copyright (C) 2020 The kubeauth Authors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package sa_usage
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+	core "k8s.io/api/core/v1"
+	meta "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+
+	"github.com/codeactual/kubeauth/internal/cage/cli/handler"
+	handler_cobra "github.com/codeactual/kubeauth/internal/cage/cli/handler/cobra"
+	cage_k8s_core "github.com/codeactual/kubeauth/internal/cage/kubernetes/v1/core"
+	cage_k8s_config "github.com/codeactual/kubeauth/internal/cage/kubernetes/v1/kubectl/config"
+	cage_k8s_identity "github.com/codeactual/kubeauth/internal/cage/kubernetes/v1/rbac/identity"
+	cage_reflect "github.com/codeactual/kubeauth/internal/cage/reflect"
+	"github.com/codeactual/kubeauth/internal/ownership"
+)
+
+const (
+	StatusUsed   = "used"
+	StatusUnused = "unused"
+)
+
+// Handler defines the sub-command flags and logic.
+type Handler struct {
+	handler.Session
+
+	KubeApiClientset    *cage_k8s_core.Clientset
+	KubectlConfigClient cage_k8s_config.Client
+	IdentityRegistry    *cage_k8s_identity.Registry
+
+	ConfigFile string `usage:"kubectl config file"`
+	Managed    bool   `usage:"only report service accounts created by add-user"`
+	Namespace  string `usage:"only report service accounts in this namespace (default: all namespaces)"`
+	Unused     bool   `usage:"only report service accounts which no workload uses"`
+
+	// Verbosity levels greater than 0 will enable status messages and error stack traces.
+	//
+	// It is an int for consistency with other commands, even though levels beyond 1 are not used.
+	Verbosity int `usage:"kubectl verbosity level"`
+}
+
+// Init defines the command, its environment variable prefix, etc.
+//
+// It implements cli/handler/cobra.Handler.
+func (h *Handler) Init() handler_cobra.Init {
+	return handler_cobra.Init{
+		Cmd: &cobra.Command{
+			Use:   "sa-usage",
+			Short: "Report which Pods, Deployments, StatefulSets, DaemonSets, Jobs, and CronJobs use each service account",
+		},
+		EnvPrefix: "KUBEAUTH",
+	}
+}
+
+// BindFlags binds the flags to Handler fields.
+//
+// It implements cli/handler/cobra.Handler.
+func (h *Handler) BindFlags(cmd *cobra.Command) []string {
+	cmd.Flags().StringVarP(&h.ConfigFile, "kubeconfig", "", "", cage_reflect.GetFieldTag(*h, "ConfigFile", "usage"))
+	cmd.Flags().BoolVarP(&h.Managed, "managed", "", false, cage_reflect.GetFieldTag(*h, "Managed", "usage"))
+	cmd.Flags().StringVarP(&h.Namespace, "namespace", "n", "", cage_reflect.GetFieldTag(*h, "Namespace", "usage"))
+	cmd.Flags().BoolVarP(&h.Unused, "unused", "", false, cage_reflect.GetFieldTag(*h, "Unused", "usage"))
+	cmd.Flags().IntVarP(&h.Verbosity, "v", "v", 0, cage_reflect.GetFieldTag(*h, "Verbosity", "usage"))
+	return []string{}
+}
+
+// Run performs the sub-command logic.
+//
+// It implements cli/handler/cobra.Handler.
+func (h *Handler) Run(ctx context.Context, input handler.Input) {
+	if err := h.run(ctx, input); err != nil {
+		if h.Verbosity > 0 {
+			h.ExitOnErr(err, "", 1)
+		} else {
+			h.ExitOnErrShort(err, "", 1)
+		}
+	}
+}
+
+func (h *Handler) run(ctx context.Context, _ handler.Input) error {
+	stderr := h.Err()
+	verbose := func(format string, vArgs ...interface{}) {
+		if h.Verbosity > 0 {
+			fmt.Fprintln(stderr, "kubeauth: "+fmt.Sprintf(format, vArgs...))
+		}
+	}
+	warn := func(format string, vArgs ...interface{}) {
+		fmt.Fprintln(stderr, "kubeauth: warning: "+fmt.Sprintf(format, vArgs...))
+	}
+
+	configClient := h.KubectlConfigClient
+	if configClient == nil {
+		configClient = cage_k8s_config.NewDefaultClient()
+	}
+
+	configFile, err := configClient.Parse(h.ConfigFile)
+	if err != nil {
+		return errors.Wrap(err, "kubeauth") // WithStack alternative to disambiguate from kubectl output
+	}
+
+	apiClientset := h.KubeApiClientset
+	if apiClientset == nil {
+		rawApiClientset, err := kubernetes.NewForConfig(configFile.RestConfig)
+		if err != nil {
+			return errors.Wrap(err, "kubeauth: failed to create API client")
+		}
+
+		apiClientset = cage_k8s_core.NewClientset(rawApiClientset)
+	}
+
+	regClient := h.IdentityRegistry
+	if regClient == nil {
+		regClient = cage_k8s_identity.NewRegistry(apiClientset)
+
+		// The report omits cron jobs if the API server does not serve them.
+		regClient.ServiceAccountWorkload = cage_k8s_identity.ServiceAccountWorkloadQuerier{
+			Notify: func(msg string) { warn("%s", msg) },
+		}
+	}
+
+	// Find the service accounts.
+
+	var listOptions meta.ListOptions
+	if h.Managed {
+		listOptions.LabelSelector = ownership.Selector
+	}
+
+	serviceAccounts, err := apiClientset.ServiceAccounts.List(h.Namespace, listOptions)
+	if err != nil {
+		return errors.Wrap(err, "kubeauth") // WithStack alternative to disambiguate from kubectl output
+	}
+
+	// Find the workloads which use them.
+
+	list, err := regClient.Query(
+		ctx,
+		cage_k8s_identity.QueryWorkloads(true),
+		cage_k8s_identity.QueryNamespace(h.Namespace),
+	)
+	if err != nil {
+		return errors.Wrap(err, "kubeauth") // WithStack alternative to disambiguate from kubectl output
+	}
+
+	workloads := map[string][]string{}
+	for _, id := range list.Items {
+		key := id.Namespace + "/" + id.Name
+		for _, source := range id.Sources { // only empty from queriers which do not support QueryWorkloads
+			workloads[key] = append(workloads[key], source.Kind+"/"+source.Name)
+		}
+	}
+
+	// Report.
+
+	if serviceAccounts == nil {
+		serviceAccounts = &core.ServiceAccountList{}
+	}
+	sort.Slice(serviceAccounts.Items, func(i, j int) bool {
+		a, b := serviceAccounts.Items[i], serviceAccounts.Items[j]
+		return a.Namespace+"/"+a.Name < b.Namespace+"/"+b.Name
+	})
+
+	w := tabwriter.NewWriter(h.Out(), 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "NAMESPACE\tNAME\tUSER\tSTATUS\tWORKLOADS")
+	for _, sa := range serviceAccounts.Items {
+		used := workloads[sa.Namespace+"/"+sa.Name]
+
+		status := StatusUnused
+		if len(used) > 0 {
+			status = StatusUsed
+		} else {
+			verbose("service account [%s] in namespace [%s] is not used by any workload", sa.Name, sa.Namespace)
+		}
+
+		if h.Unused && status == StatusUsed {
+			continue
+		}
+
+		user := ownership.User(sa.ObjectMeta)
+		if user == "" {
+			user = "-"
+		}
+
+		sort.Strings(used)
+		usedStr := strings.Join(used, ",")
+		if usedStr == "" {
+			usedStr = "-"
+		}
+
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n", sa.Namespace, sa.Name, user, status, usedStr)
+	}
+	if err = w.Flush(); err != nil {
+		return errors.Wrap(err, "kubeauth: failed to write report")
+	}
+
+	return nil
+}
+
+// New returns a cobra command instance based on Handler.
+func NewCommand() *cobra.Command {
+	return handler_cobra.NewHandler(&Handler{
+		Session: &handler.DefaultSession{},
+	})
+}
+
+var _ handler_cobra.Handler = (*Handler)(nil)
@@ -0,0 +1,141 @@
+// Copyright (C) 2020 The kubeauth Authors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at https://mozilla.org/MPL/2.0/.
+
+// Package sa_usage_test asserts CLI behavior by running the command handler logic
+// directly (w/o separate processes) with various input scenarios.
+//
+// It uses Handler instances that use mock implementations of the clients used
+// to modify kubeconfig files and perform API requests. The tests only verify correct
+// use of the client interfaces.
+package sa_usage_test
+
+import (
+	"context"
+	"errors"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	core "k8s.io/api/core/v1"
+	meta "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	cli "github.com/codeactual/kubeauth/cmd/kubeauth/sa_usage"
+	"github.com/codeactual/kubeauth/internal/cage/cli/handler"
+	cage_k8s "github.com/codeactual/kubeauth/internal/cage/kubernetes/v1"
+	"github.com/codeactual/kubeauth/internal/ownership"
+)
+
+func NewHandler(kit *HandlerKit) *cli.Handler {
+	apiClientset := kit.ApiClientset.ToReal()
+
+	h := cli.Handler{
+		Session:             kit.Session,
+		KubectlConfigClient: kit.ConfigClient,
+		KubeApiClientset:    apiClientset,
+		IdentityRegistry:    kit.IdentityRegistry.ToReal(apiClientset),
+	}
+
+	// Enable for test troubleshooting and verbose output assertions.
+	h.Verbosity = 1
+
+	return &h
+}
+
+func reportLines(kit *HandlerKit) []string {
+	var lines []string
+	for _, line := range strings.Split(strings.TrimSpace(kit.Stdout.String()), "\n") {
+		lines = append(lines, strings.Join(strings.Fields(line), " "))
+	}
+	return lines
+}
+
+func newServiceAccount(namespace, name, user string) core.ServiceAccount {
+	if user == "" {
+		return core.ServiceAccount{ObjectMeta: meta.ObjectMeta{Namespace: namespace, Name: name}}
+	}
+	return core.ServiceAccount{ObjectMeta: ownership.Owner{User: user}.ObjectMeta(namespace, name)}
+}
+
+// addWorkloads adds a service account used by a deployment and its pod, and one which is not used.
+func addWorkloads(kit *HandlerKit) *core.ServiceAccountList {
+	kit.AddWorkload("dev", "web", cage_k8s.KindPod, "web-abc")
+	kit.AddWorkload("dev", "web", cage_k8s.KindDeployment, "web")
+
+	return &core.ServiceAccountList{
+		Items: []core.ServiceAccount{
+			newServiceAccount("dev", "web", ""),
+			newServiceAccount("dev", "tester", "tester"),
+		},
+	}
+}
+
+func TestReport(t *testing.T) {
+	kit := NewHandlerKit(t)
+	serviceAccounts := addWorkloads(kit)
+	kit.Finish()
+	defer kit.MockCtrl.Finish()
+
+	kit.ApiClientset.ServiceAccounts.EXPECT().
+		List("", meta.ListOptions{}).
+		Return(serviceAccounts, nil)
+
+	h := NewHandler(kit)
+	h.Run(context.Background(), handler.Input{})
+
+	require.Exactly(
+		t,
+		[]string{
+			"NAMESPACE NAME USER STATUS WORKLOADS",
+			"dev tester tester unused -",
+			"dev web - used Deployment/web,Pod/web-abc",
+		},
+		reportLines(kit),
+	)
+}
+
+func TestUnusedManagedInNamespace(t *testing.T) {
+	kit := NewHandlerKit(t)
+	kit.Namespace = "dev"
+	serviceAccounts := addWorkloads(kit)
+	kit.Finish()
+	defer kit.MockCtrl.Finish()
+
+	kit.ApiClientset.ServiceAccounts.EXPECT().
+		List("dev", meta.ListOptions{LabelSelector: ownership.Selector}).
+		Return(serviceAccounts, nil)
+
+	h := NewHandler(kit)
+	h.Managed = true
+	h.Namespace = "dev"
+	h.Unused = true
+	h.Run(context.Background(), handler.Input{})
+
+	require.Exactly(
+		t,
+		[]string{
+			"NAMESPACE NAME USER STATUS WORKLOADS",
+			"dev tester tester unused -",
+		},
+		reportLines(kit),
+	)
+}
+
+func TestErrOnList(t *testing.T) {
+	kit := NewHandlerKit(t)
+	kit.SkipQuery = true
+	kit.ExitOnErr = regexp.MustCompile("some list error")
+	kit.Finish()
+	defer kit.MockCtrl.Finish()
+
+	var nonSut *core.ServiceAccountList
+	kit.ApiClientset.ServiceAccounts.EXPECT().
+		List("", meta.ListOptions{}).
+		Return(nonSut, errors.New("some list error"))
+
+	h := NewHandler(kit)
+	h.Run(context.Background(), handler.Input{})
+}
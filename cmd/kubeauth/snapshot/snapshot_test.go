@@ -0,0 +1,110 @@
+// Copyright (C) 2020 The kubeauth Authors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at https://mozilla.org/MPL/2.0/.
+
+// Package snapshot_test asserts CLI behavior by running the command handler logic
+// directly (w/o separate processes) with various input scenarios.
+//
+// It uses Handler instances that use mock implementations of the clients used
+// to modify kubeconfig files and perform API requests. The tests only verify correct
+// use of the client interfaces. Tests in the internal/snapshot package verify the
+// document's normalization in more detail.
+package snapshot_test
+
+import (
+	"context"
+	"regexp"
+	"testing"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/require"
+	core "k8s.io/api/core/v1"
+	rbac "k8s.io/api/rbac/v1"
+	meta "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	cli "github.com/codeactual/kubeauth/cmd/kubeauth/snapshot"
+	"github.com/codeactual/kubeauth/internal/cage/cli/handler"
+	cage_k8s "github.com/codeactual/kubeauth/internal/cage/kubernetes/v1"
+	"github.com/codeactual/kubeauth/internal/snapshot"
+)
+
+func NewHandler(kit *HandlerKit) *cli.Handler {
+	h := cli.Handler{
+		Session:             kit.Session,
+		KubectlConfigClient: kit.ConfigClient,
+		KubeApiClientset:    kit.ApiClientset.ToReal(),
+	}
+
+	// Enable for test troubleshooting and verbose output assertions.
+	h.Verbosity = 1
+
+	return &h
+}
+
+func TestSnapshot(t *testing.T) {
+	kit := NewHandlerKit(t)
+	kit.Roles = []rbac.Role{
+		{
+			ObjectMeta: meta.ObjectMeta{Namespace: "dev", Name: "pods", ResourceVersion: "1"},
+			Rules:      []rbac.PolicyRule{{Verbs: []string{"list", "get"}, APIGroups: []string{""}, Resources: []string{"pods"}}},
+		},
+	}
+	kit.RoleBindings = []rbac.RoleBinding{
+		{
+			ObjectMeta: meta.ObjectMeta{Namespace: "dev", Name: "pods"},
+			RoleRef:    rbac.RoleRef{Kind: cage_k8s.KindRole, Name: "pods"},
+			Subjects:   []rbac.Subject{{Kind: cage_k8s.KindServiceAccount, Namespace: "dev", Name: "ci"}},
+		},
+	}
+	kit.ServiceAccounts = []core.ServiceAccount{{ObjectMeta: meta.ObjectMeta{Namespace: "dev", Name: "ci"}}}
+	kit.Finish()
+	defer kit.MockCtrl.Finish()
+
+	h := NewHandler(kit)
+	h.Run(context.Background(), handler.Input{})
+
+	s, err := snapshot.Read(kit.Stdout)
+	require.NoError(t, err)
+	require.Exactly(
+		t,
+		&snapshot.Snapshot{
+			Version: snapshot.Version,
+			Roles: []snapshot.Role{
+				{
+					Namespace: "dev",
+					Name:      "pods",
+					Rules:     []rbac.PolicyRule{{Verbs: []string{"get", "list"}, APIGroups: []string{""}, Resources: []string{"pods"}}},
+				},
+			},
+			ClusterRoles: []snapshot.Role{},
+			RoleBindings: []snapshot.Binding{
+				{
+					Namespace: "dev",
+					Name:      "pods",
+					RoleRef:   rbac.RoleRef{Kind: cage_k8s.KindRole, Name: "pods"},
+					Subjects:  []rbac.Subject{{Kind: cage_k8s.KindServiceAccount, Namespace: "dev", Name: "ci"}},
+				},
+			},
+			ClusterRoleBindings: []snapshot.Binding{},
+			ServiceAccounts:     []snapshot.ServiceAccount{{Namespace: "dev", Name: "ci"}},
+		},
+		s,
+	)
+}
+
+// TestErrOnList asserts that a failed list exits with the error.
+func TestErrOnList(t *testing.T) {
+	kit := NewHandlerKit(t)
+	kit.ExitOnErr = regexp.MustCompile("failed to list roles")
+	kit.Finish()
+	defer kit.MockCtrl.Finish()
+
+	kit.ApiClientset.Roles.EXPECT().
+		List("").
+		Return(nil, errors.New("failed to list roles"))
+
+	h := NewHandler(kit)
+	h.Run(context.Background(), handler.Input{})
+}
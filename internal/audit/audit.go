@@ -0,0 +1,288 @@
+// Copyright (C) 2020 The kubeauth Authors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at https://mozilla.org/MPL/2.0/.
+
+// Package audit reports risky RBAC patterns in a cluster's roles and bindings.
+package audit
+
+import (
+	"fmt"
+	"strings"
+
+	core "k8s.io/api/core/v1"
+	rbac "k8s.io/api/rbac/v1"
+
+	cage_k8s "github.com/codeactual/kubeauth/internal/cage/kubernetes/v1"
+	cage_k8s_rbac "github.com/codeactual/kubeauth/internal/cage/kubernetes/v1/rbac"
+	cage_k8s_risk "github.com/codeactual/kubeauth/internal/cage/kubernetes/v1/rbac/risk"
+)
+
+// Finding levels, named after their SARIF equivalents.
+const (
+	LevelError   = "error"
+	LevelWarning = "warning"
+)
+
+const (
+	// ClusterAdmin is the name of the default cluster role which grants all permissions.
+	ClusterAdmin = "cluster-admin"
+
+	// DefaultServiceAccount is the name of the service account which every namespace receives and
+	// whose token is mounted into pods which do not select a different one.
+	DefaultServiceAccount = "default"
+
+	// DefaultNamespace is the namespace of objects created without selecting one.
+	DefaultNamespace = "default"
+
+	// AnonymousUser is the username of requests which are not authenticated.
+	AnonymousUser = "system:anonymous"
+
+	// UnauthenticatedGroup is the group of requests which are not authenticated.
+	UnauthenticatedGroup = "system:unauthenticated"
+
+	// BootstrapLabel and BootstrapValue mark the roles and bindings which the API server creates.
+	BootstrapLabel = "kubernetes.io/bootstrapping"
+	BootstrapValue = "rbac-defaults"
+)
+
+// Rule describes a risky pattern.
+type Rule struct {
+	ID          string
+	Level       string
+	Description string
+}
+
+// Rules lists the patterns which Audit reports.
+var Rules = []Rule{
+	{ID: "wildcard-rule", Level: LevelWarning, Description: "A role rule grants all verbs, resources, or API groups."},
+	{ID: "cluster-admin-binding", Level: LevelError, Description: "A binding grants the cluster-admin cluster role."},
+	{ID: "anonymous-binding", Level: LevelError, Description: "A binding grants permissions to unauthenticated or anonymous requests."},
+	{ID: "default-service-account", Level: LevelError, Description: "A binding grants escalation-prone permissions to a default service account or one in the default namespace."},
+	{ID: "missing-subject", Level: LevelWarning, Description: "A binding refers to a service account which does not exist."},
+}
+
+// Objects holds the objects to audit, e.g. from list queries across all namespaces.
+type Objects struct {
+	Roles               []rbac.Role
+	ClusterRoles        []rbac.ClusterRole
+	RoleBindings        []rbac.RoleBinding
+	ClusterRoleBindings []rbac.ClusterRoleBinding
+	ServiceAccounts     []core.ServiceAccount
+}
+
+// Options customizes Audit.
+type Options struct {
+	// IncludeDefaults selects the roles and bindings which the API server creates, which are
+	// otherwise skipped because some, e.g. cluster-admin, are risky by design.
+	IncludeDefaults bool
+}
+
+// Finding describes a risky pattern in an object.
+type Finding struct {
+	Rule      string `json:"rule"`
+	Level     string `json:"level"`
+	Kind      string `json:"kind"`
+	Namespace string `json:"namespace,omitempty"`
+	Name      string `json:"name"`
+	Message   string `json:"message"`
+}
+
+// Audit returns the findings for the objects, ordered by object kind (roles, cluster roles,
+// role bindings, cluster role bindings) and then by their input order.
+func Audit(objs Objects, opts Options) (findings []Finding) {
+	a := auditor{
+		opts:           opts,
+		roles:          map[string]rbac.Role{},
+		clusterRoles:   map[string]rbac.ClusterRole{},
+		serviceAccount: map[string]bool{},
+	}
+	for _, obj := range objs.Roles {
+		a.roles[obj.Namespace+"/"+obj.Name] = obj
+	}
+	for _, obj := range objs.ClusterRoles {
+		a.clusterRoles[obj.Name] = obj
+	}
+	for _, obj := range objs.ServiceAccounts {
+		a.serviceAccount[obj.Namespace+"/"+obj.Name] = true
+	}
+
+	for _, obj := range objs.Roles {
+		if a.skip(obj.Labels) {
+			continue
+		}
+		findings = append(findings, wildcardFindings(cage_k8s.KindRole, obj.Namespace, obj.Name, obj.Rules)...)
+	}
+	for _, obj := range objs.ClusterRoles {
+		if a.skip(obj.Labels) {
+			continue
+		}
+		findings = append(findings, wildcardFindings(cage_k8s.KindClusterRole, "", obj.Name, obj.Rules)...)
+	}
+	for _, obj := range objs.RoleBindings {
+		if a.skip(obj.Labels) {
+			continue
+		}
+		findings = append(findings, a.bindingFindings(cage_k8s.KindRoleBinding, obj.Namespace, obj.Name, obj.RoleRef, obj.Subjects)...)
+	}
+	for _, obj := range objs.ClusterRoleBindings {
+		if a.skip(obj.Labels) {
+			continue
+		}
+		findings = append(findings, a.bindingFindings(cage_k8s.KindClusterRoleBinding, "", obj.Name, obj.RoleRef, obj.Subjects)...)
+	}
+
+	return findings
+}
+
+// auditor holds indexes of the audited objects.
+type auditor struct {
+	opts Options
+
+	// roles is indexed by "<namespace>/<name>".
+	roles map[string]rbac.Role
+
+	// clusterRoles is indexed by name.
+	clusterRoles map[string]rbac.ClusterRole
+
+	// serviceAccount is indexed by "<namespace>/<name>".
+	serviceAccount map[string]bool
+}
+
+// skip reports whether an object with the labels should not be audited.
+func (a auditor) skip(labels map[string]string) bool {
+	return !a.opts.IncludeDefaults && labels[BootstrapLabel] == BootstrapValue
+}
+
+// rules returns the rules of the role which the binding refers to, if it exists.
+func (a auditor) rules(ns string, roleRef rbac.RoleRef) (_ []rbac.PolicyRule, exists bool) {
+	switch roleRef.Kind {
+	case cage_k8s.KindRole:
+		obj, ok := a.roles[ns+"/"+roleRef.Name]
+		return obj.Rules, ok
+	case cage_k8s.KindClusterRole:
+		obj, ok := a.clusterRoles[roleRef.Name]
+		return obj.Rules, ok
+	}
+	return nil, false
+}
+
+// bindingFindings returns the findings for a binding in the namespace, which is empty for
+// cluster role bindings.
+func (a auditor) bindingFindings(kind, ns, name string, roleRef rbac.RoleRef, subjects []rbac.Subject) (findings []Finding) {
+	newFinding := func(rule, format string, vArgs ...interface{}) Finding {
+		return Finding{Rule: rule, Level: ruleLevel(rule), Kind: kind, Namespace: ns, Name: name, Message: fmt.Sprintf(format, vArgs...)}
+	}
+
+	if roleRef.Kind == cage_k8s.KindClusterRole && roleRef.Name == ClusterAdmin {
+		scope := "cluster-wide"
+		if ns != "" {
+			scope = "in namespace [" + ns + "]"
+		}
+		findings = append(findings, newFinding("cluster-admin-binding", "grants %s %s to %s", ClusterAdmin, scope, cage_k8s_rbac.SubjectsString(subjects)))
+	}
+
+	for _, s := range subjects {
+		if (s.Kind == cage_k8s.KindGroup && s.Name == UnauthenticatedGroup) || (s.Kind == cage_k8s.KindUser && s.Name == AnonymousUser) {
+			findings = append(findings, newFinding("anonymous-binding", "grants %s [%s] to %s", roleRef.Kind, roleRef.Name, cage_k8s_rbac.SubjectString(s)))
+		}
+	}
+
+	// Elevated rights are evaluated where the binding grants them, i.e. a role binding only grants
+	// a cluster role's rules in its namespace.
+	rules, roleExists := a.rules(ns, roleRef)
+	var riskIDs []string
+	if roleExists {
+		for _, f := range cage_k8s_risk.Analyze(rules, ns) {
+			riskIDs = append(riskIDs, f.Risk.ID)
+		}
+	}
+
+	for _, s := range subjects {
+		if s.Kind != cage_k8s.KindServiceAccount {
+			continue
+		}
+
+		saNamespace := s.Namespace
+		if saNamespace == "" {
+			saNamespace = ns
+		}
+
+		if !a.serviceAccount[saNamespace+"/"+s.Name] {
+			findings = append(findings, newFinding("missing-subject", "service account [%s] in namespace [%s] does not exist", s.Name, saNamespace))
+			continue
+		}
+
+		if len(riskIDs) > 0 && (s.Name == DefaultServiceAccount || saNamespace == DefaultNamespace) {
+			findings = append(
+				findings,
+				newFinding(
+					"default-service-account", "grants %s [%s] with escalation-prone permissions [%s] to service account [%s] in namespace [%s]",
+					roleRef.Kind, roleRef.Name, strings.Join(riskIDs, ","), s.Name, saNamespace,
+				),
+			)
+		}
+	}
+
+	return findings
+}
+
+// wildcardFindings returns a finding for each rule of the role which selects all verbs, resources,
+// or API groups.
+func wildcardFindings(kind, ns, name string, rules []rbac.PolicyRule) (findings []Finding) {
+	for _, r := range rules {
+		if !hasString(r.Verbs, rbac.VerbAll) && !hasString(r.Resources, rbac.ResourceAll) && !hasString(r.APIGroups, rbac.APIGroupAll) {
+			continue
+		}
+		findings = append(findings, Finding{
+			Rule:      "wildcard-rule",
+			Level:     ruleLevel("wildcard-rule"),
+			Kind:      kind,
+			Namespace: ns,
+			Name:      name,
+			Message:   "rule grants " + RuleString(r),
+		})
+	}
+	return findings
+}
+
+// RuleString returns a compact description of the rule, e.g. for messages.
+func RuleString(r rbac.PolicyRule) string {
+	var parts []string
+	if len(r.Verbs) > 0 {
+		parts = append(parts, "verbs ["+strings.Join(r.Verbs, ",")+"]")
+	}
+	if len(r.APIGroups) > 0 {
+		parts = append(parts, "API groups ["+strings.Join(r.APIGroups, ",")+"]")
+	}
+	if len(r.Resources) > 0 {
+		parts = append(parts, "resources ["+strings.Join(r.Resources, ",")+"]")
+	}
+	if len(r.ResourceNames) > 0 {
+		parts = append(parts, "resource names ["+strings.Join(r.ResourceNames, ",")+"]")
+	}
+	if len(r.NonResourceURLs) > 0 {
+		parts = append(parts, "non-resource URLs ["+strings.Join(r.NonResourceURLs, ",")+"]")
+	}
+	return strings.Join(parts, " ")
+}
+
+// ruleLevel returns the level of the rule with the ID.
+func ruleLevel(id string) string {
+	for _, r := range Rules {
+		if r.ID == id {
+			return r.Level
+		}
+	}
+	return LevelWarning
+}
+
+func hasString(list []string, s string) bool {
+	for _, l := range list {
+		if l == s {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,148 @@
+// Copyright (C) 2020 The kubeauth Authors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package audit_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	core "k8s.io/api/core/v1"
+	rbac "k8s.io/api/rbac/v1"
+	meta "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/codeactual/kubeauth/internal/audit"
+	cage_k8s "github.com/codeactual/kubeauth/internal/cage/kubernetes/v1"
+)
+
+var bootstrapLabels = map[string]string{audit.BootstrapLabel: audit.BootstrapValue}
+
+func newObjects() audit.Objects {
+	secretsRule := rbac.PolicyRule{Verbs: []string{"get"}, APIGroups: []string{""}, Resources: []string{"secrets"}}
+
+	return audit.Objects{
+		Roles: []rbac.Role{
+			{
+				ObjectMeta: meta.ObjectMeta{Namespace: "dev", Name: "all-pods"},
+				Rules:      []rbac.PolicyRule{{Verbs: []string{"*"}, APIGroups: []string{""}, Resources: []string{"pods"}}},
+			},
+			{
+				ObjectMeta: meta.ObjectMeta{Namespace: "dev", Name: "read-secrets"},
+				Rules:      []rbac.PolicyRule{secretsRule},
+			},
+		},
+		ClusterRoles: []rbac.ClusterRole{
+			{
+				ObjectMeta: meta.ObjectMeta{Name: audit.ClusterAdmin, Labels: bootstrapLabels},
+				Rules:      []rbac.PolicyRule{{Verbs: []string{"*"}, APIGroups: []string{"*"}, Resources: []string{"*"}}},
+			},
+			{
+				ObjectMeta: meta.ObjectMeta{Name: "view-pods"},
+				Rules:      []rbac.PolicyRule{{Verbs: []string{"get"}, APIGroups: []string{""}, Resources: []string{"pods"}}},
+			},
+		},
+		RoleBindings: []rbac.RoleBinding{
+			{
+				// Elevated rights for a default service account.
+				ObjectMeta: meta.ObjectMeta{Namespace: "dev", Name: "default-secrets"},
+				RoleRef:    rbac.RoleRef{Kind: cage_k8s.KindRole, Name: "read-secrets"},
+				Subjects:   []rbac.Subject{{Kind: cage_k8s.KindServiceAccount, Name: "default"}},
+			},
+			{
+				// No elevated rights.
+				ObjectMeta: meta.ObjectMeta{Namespace: "default", Name: "view-pods"},
+				RoleRef:    rbac.RoleRef{Kind: cage_k8s.KindClusterRole, Name: "view-pods"},
+				Subjects:   []rbac.Subject{{Kind: cage_k8s.KindServiceAccount, Name: "app"}},
+			},
+			{
+				ObjectMeta: meta.ObjectMeta{Namespace: "dev", Name: "admin"},
+				RoleRef:    rbac.RoleRef{Kind: cage_k8s.KindClusterRole, Name: audit.ClusterAdmin},
+				Subjects:   []rbac.Subject{{Kind: cage_k8s.KindServiceAccount, Namespace: "ci", Name: "deleted"}},
+			},
+		},
+		ClusterRoleBindings: []rbac.ClusterRoleBinding{
+			{
+				ObjectMeta: meta.ObjectMeta{Name: audit.ClusterAdmin, Labels: bootstrapLabels},
+				RoleRef:    rbac.RoleRef{Kind: cage_k8s.KindClusterRole, Name: audit.ClusterAdmin},
+				Subjects:   []rbac.Subject{{Kind: cage_k8s.KindGroup, Name: "system:masters"}},
+			},
+			{
+				ObjectMeta: meta.ObjectMeta{Name: "public"},
+				RoleRef:    rbac.RoleRef{Kind: cage_k8s.KindClusterRole, Name: "view-pods"},
+				Subjects: []rbac.Subject{
+					{Kind: cage_k8s.KindGroup, APIGroup: rbac.GroupName, Name: audit.UnauthenticatedGroup},
+					{Kind: cage_k8s.KindUser, APIGroup: rbac.GroupName, Name: audit.AnonymousUser},
+				},
+			},
+			{
+				ObjectMeta: meta.ObjectMeta{Name: "app-admin"},
+				RoleRef:    rbac.RoleRef{Kind: cage_k8s.KindClusterRole, Name: audit.ClusterAdmin},
+				Subjects:   []rbac.Subject{{Kind: cage_k8s.KindServiceAccount, Namespace: "default", Name: "app"}},
+			},
+		},
+		ServiceAccounts: []core.ServiceAccount{
+			{ObjectMeta: meta.ObjectMeta{Namespace: "dev", Name: "default"}},
+			{ObjectMeta: meta.ObjectMeta{Namespace: "default", Name: "app"}},
+		},
+	}
+}
+
+func TestAudit(t *testing.T) {
+	findings := audit.Audit(newObjects(), audit.Options{})
+
+	require.Exactly(
+		t,
+		[]audit.Finding{
+			{
+				Rule: "wildcard-rule", Level: audit.LevelWarning, Kind: cage_k8s.KindRole, Namespace: "dev", Name: "all-pods",
+				Message: "rule grants verbs [*] API groups [] resources [pods]",
+			},
+			{
+				Rule: "default-service-account", Level: audit.LevelError, Kind: cage_k8s.KindRoleBinding, Namespace: "dev", Name: "default-secrets",
+				Message: "grants Role [read-secrets] with escalation-prone permissions [secrets-read] to service account [default] in namespace [dev]",
+			},
+			{
+				Rule: "cluster-admin-binding", Level: audit.LevelError, Kind: cage_k8s.KindRoleBinding, Namespace: "dev", Name: "admin",
+				Message: "grants cluster-admin in namespace [dev] to [deleted (kind: ServiceAccount ns: ci)]",
+			},
+			{
+				Rule: "missing-subject", Level: audit.LevelWarning, Kind: cage_k8s.KindRoleBinding, Namespace: "dev", Name: "admin",
+				Message: "service account [deleted] in namespace [ci] does not exist",
+			},
+			{
+				Rule: "anonymous-binding", Level: audit.LevelError, Kind: cage_k8s.KindClusterRoleBinding, Name: "public",
+				Message: "grants ClusterRole [view-pods] to system:unauthenticated (kind: Group ns: <no namespace>)",
+			},
+			{
+				Rule: "anonymous-binding", Level: audit.LevelError, Kind: cage_k8s.KindClusterRoleBinding, Name: "public",
+				Message: "grants ClusterRole [view-pods] to system:anonymous (kind: User ns: <no namespace>)",
+			},
+			{
+				Rule: "cluster-admin-binding", Level: audit.LevelError, Kind: cage_k8s.KindClusterRoleBinding, Name: "app-admin",
+				Message: "grants cluster-admin cluster-wide to [app (kind: ServiceAccount ns: default)]",
+			},
+			{
+				Rule: "default-service-account", Level: audit.LevelError, Kind: cage_k8s.KindClusterRoleBinding, Name: "app-admin",
+				Message: "grants ClusterRole [cluster-admin] with escalation-prone permissions [wildcard,escalate,bind,impersonate,secrets-read,pod-exec,node-proxy,system-workloads] to service account [app] in namespace [default]",
+			},
+		},
+		findings,
+	)
+}
+
+func TestAuditIncludeDefaults(t *testing.T) {
+	objs := newObjects()
+	objs.Roles, objs.RoleBindings = nil, nil
+	objs.ClusterRoleBindings = objs.ClusterRoleBindings[:1]
+
+	var rules []string
+	for _, f := range audit.Audit(objs, audit.Options{IncludeDefaults: true}) {
+		rules = append(rules, f.Kind+"/"+f.Name+":"+f.Rule)
+	}
+	require.Exactly(t, []string{"ClusterRole/cluster-admin:wildcard-rule", "ClusterRoleBinding/cluster-admin:cluster-admin-binding"}, rules)
+
+	require.Empty(t, audit.Audit(objs, audit.Options{}))
+}
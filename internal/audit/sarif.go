@@ -0,0 +1,143 @@
+// Copyright (C) 2020 The kubeauth Authors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package audit
+
+import (
+	"encoding/json"
+	"io"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	// SarifVersion is the version of the SARIF format written by WriteSarif.
+	SarifVersion = "2.1.0"
+
+	// SarifSchema is the JSON schema of the SARIF format written by WriteSarif.
+	SarifSchema = "https://json.schemastore.org/sarif-2.1.0.json"
+
+	// ToolName and ToolURI identify kubeauth as the SARIF tool which produced the findings.
+	ToolName = "kubeauth"
+	ToolURI  = "https://github.com/codeactual/kubeauth"
+)
+
+// The types below define the subset of SARIF 2.1.0 which WriteSarif uses.
+//
+// https://docs.oasis-open.org/sarif/sarif/v2.1.0/sarif-v2.1.0.html
+
+type sarifLog struct {
+	Version string     `json:"version"`
+	Schema  string     `json:"$schema"`
+	Runs    []sarifRun `json:"runs"`
+}
+
+type sarifRun struct {
+	Tool    sarifTool     `json:"tool"`
+	Results []sarifResult `json:"results"`
+}
+
+type sarifTool struct {
+	Driver sarifDriver `json:"driver"`
+}
+
+type sarifDriver struct {
+	Name           string      `json:"name"`
+	InformationURI string      `json:"informationUri"`
+	Version        string      `json:"version,omitempty"`
+	Rules          []sarifRule `json:"rules"`
+}
+
+type sarifRule struct {
+	ID                   string             `json:"id"`
+	ShortDescription     sarifMessage       `json:"shortDescription"`
+	DefaultConfiguration sarifConfiguration `json:"defaultConfiguration"`
+}
+
+type sarifConfiguration struct {
+	Level string `json:"level"`
+}
+
+type sarifResult struct {
+	RuleID    string          `json:"ruleId"`
+	RuleIndex int             `json:"ruleIndex"`
+	Level     string          `json:"level"`
+	Message   sarifMessage    `json:"message"`
+	Locations []sarifLocation `json:"locations"`
+}
+
+type sarifMessage struct {
+	Text string `json:"text"`
+}
+
+type sarifLocation struct {
+	LogicalLocations []sarifLogicalLocation `json:"logicalLocations"`
+}
+
+type sarifLogicalLocation struct {
+	Name               string `json:"name"`
+	FullyQualifiedName string `json:"fullyQualifiedName"`
+	Kind               string `json:"kind"`
+}
+
+// WriteSarif writes the findings as a SARIF log with a single run.
+//
+// Each object is identified by a logical location whose fully qualified name is
+// "<kind>/<namespace>/<name>", or "<kind>/<name>" for cluster-scoped objects.
+func WriteSarif(w io.Writer, version string, findings []Finding) error {
+	driver := sarifDriver{Name: ToolName, InformationURI: ToolURI, Version: version}
+	ruleIndex := map[string]int{}
+	for n, r := range Rules {
+		driver.Rules = append(driver.Rules, sarifRule{
+			ID:                   r.ID,
+			ShortDescription:     sarifMessage{Text: r.Description},
+			DefaultConfiguration: sarifConfiguration{Level: r.Level},
+		})
+		ruleIndex[r.ID] = n
+	}
+
+	// Encode an empty list, rather than null, if there are no findings.
+	results := []sarifResult{}
+	for _, f := range findings {
+		results = append(results, sarifResult{
+			RuleID:    f.Rule,
+			RuleIndex: ruleIndex[f.Rule],
+			Level:     f.Level,
+			Message:   sarifMessage{Text: f.Message},
+			Locations: []sarifLocation{{
+				LogicalLocations: []sarifLogicalLocation{{
+					Name:               f.Name,
+					FullyQualifiedName: ObjectPath(f),
+					Kind:               "resource",
+				}},
+			}},
+		})
+	}
+
+	log := sarifLog{
+		Version: SarifVersion,
+		Schema:  SarifSchema,
+		Runs:    []sarifRun{{Tool: sarifTool{Driver: driver}, Results: results}},
+	}
+
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(log); err != nil {
+		return errors.Wrap(err, "failed to encode SARIF log")
+	}
+	return nil
+}
+
+// ObjectPath returns "<kind>/<namespace>/<name>", or "<kind>/<name>" for cluster-scoped objects,
+// which identifies the object of the finding.
+func ObjectPath(f Finding) string {
+	parts := []string{f.Kind}
+	if f.Namespace != "" {
+		parts = append(parts, f.Namespace)
+	}
+	return strings.Join(append(parts, f.Name), "/")
+}
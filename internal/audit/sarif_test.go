@@ -0,0 +1,63 @@
+// Copyright (C) 2020 The kubeauth Authors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package audit_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/codeactual/kubeauth/internal/audit"
+	cage_k8s "github.com/codeactual/kubeauth/internal/cage/kubernetes/v1"
+)
+
+func TestWriteSarif(t *testing.T) {
+	findings := []audit.Finding{
+		{Rule: "missing-subject", Level: audit.LevelWarning, Kind: cage_k8s.KindRoleBinding, Namespace: "dev", Name: "some-binding", Message: "some message"},
+		{Rule: "cluster-admin-binding", Level: audit.LevelError, Kind: cage_k8s.KindClusterRoleBinding, Name: "other-binding", Message: "other message"},
+	}
+
+	var buf bytes.Buffer
+	require.NoError(t, audit.WriteSarif(&buf, "some-version", findings))
+
+	var log map[string]interface{}
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &log))
+	require.Exactly(t, audit.SarifVersion, log["version"])
+	require.Exactly(t, audit.SarifSchema, log["$schema"])
+
+	run := log["runs"].([]interface{})[0].(map[string]interface{})
+
+	driver := run["tool"].(map[string]interface{})["driver"].(map[string]interface{})
+	require.Exactly(t, audit.ToolName, driver["name"])
+	require.Exactly(t, "some-version", driver["version"])
+	rules := driver["rules"].([]interface{})
+	require.Len(t, rules, len(audit.Rules))
+	require.Exactly(t, audit.Rules[0].ID, rules[0].(map[string]interface{})["id"])
+
+	results := run["results"].([]interface{})
+	require.Len(t, results, 2)
+
+	result := results[0].(map[string]interface{})
+	require.Exactly(t, "missing-subject", result["ruleId"])
+	require.Exactly(t, "warning", result["level"])
+	require.Exactly(t, "some message", result["message"].(map[string]interface{})["text"])
+	location := result["locations"].([]interface{})[0].(map[string]interface{})["logicalLocations"].([]interface{})[0].(map[string]interface{})
+	require.Exactly(t, "some-binding", location["name"])
+	require.Exactly(t, "RoleBinding/dev/some-binding", location["fullyQualifiedName"])
+
+	result = results[1].(map[string]interface{})
+	require.Exactly(t, "cluster-admin-binding", result["ruleId"])
+	require.Exactly(t, "ClusterRoleBinding/other-binding", result["locations"].([]interface{})[0].(map[string]interface{})["logicalLocations"].([]interface{})[0].(map[string]interface{})["fullyQualifiedName"])
+}
+
+func TestWriteSarifEmpty(t *testing.T) {
+	var buf bytes.Buffer
+	require.NoError(t, audit.WriteSarif(&buf, "some-version", nil))
+	require.Contains(t, buf.String(), `"results": []`)
+}
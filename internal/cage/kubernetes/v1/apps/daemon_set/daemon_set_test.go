@@ -0,0 +1,85 @@
+// Copyright (C) 2020 The CodeActual Go Environment Authors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package daemon_set_test
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+	apps "k8s.io/api/apps/v1"
+	meta "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/codeactual/kubeauth/internal/cage/kubernetes/v1/apps/daemon_set"
+	mock_daemon_set "github.com/codeactual/kubeauth/internal/cage/kubernetes/v1/apps/daemon_set/mock"
+	cage_k8s_testkit "github.com/codeactual/kubeauth/internal/cage/kubernetes/v1/testkit"
+	cage_require "github.com/codeactual/kubeauth/internal/cage/testkit/testify/require"
+)
+
+const (
+	Namespace = "some-namespace"
+	Name      = "some-daemonset"
+)
+
+func newClient(mockCtrl *gomock.Controller, namespace string) (*mock_daemon_set.MockDaemonSetInterface, *daemon_set.DefaultClient) {
+	mockInterface := mock_daemon_set.NewMockDaemonSetInterface(mockCtrl)
+	mockGetter := mock_daemon_set.NewMockDaemonSetsGetter(mockCtrl)
+	mockGetter.EXPECT().DaemonSets(namespace).Return(mockInterface)
+	return mockInterface, daemon_set.NewDefaultClient(mockGetter)
+}
+
+func TestList(t *testing.T) {
+	t.Run("found", func(t *testing.T) {
+		mockCtrl := gomock.NewController(t)
+		defer mockCtrl.Finish()
+
+		expectOptions := meta.ListOptions{}
+		expectList := &apps.DaemonSetList{
+			Items: []apps.DaemonSet{
+				{ObjectMeta: meta.ObjectMeta{Name: Name}},
+			},
+		}
+
+		mockInterface, wrapperClient := newClient(mockCtrl, Namespace)
+		mockInterface.EXPECT().List(expectOptions).Return(expectList, nil)
+
+		actualList, actualErr := wrapperClient.List(Namespace, expectOptions)
+		require.NoError(t, actualErr)
+		require.Exactly(t, expectList, actualList)
+	})
+
+	t.Run("not found", func(t *testing.T) {
+		mockCtrl := gomock.NewController(t)
+		defer mockCtrl.Finish()
+
+		expectOptions := meta.ListOptions{}
+
+		mockInterface, wrapperClient := newClient(mockCtrl, Namespace)
+		mockInterface.EXPECT().List(expectOptions).Return(nil, cage_k8s_testkit.NotFound())
+
+		actualList, actualErr := wrapperClient.List(Namespace, expectOptions)
+		require.NoError(t, actualErr)
+		require.Nil(t, actualList)
+	})
+
+	t.Run("error", func(t *testing.T) {
+		mockCtrl := gomock.NewController(t)
+		defer mockCtrl.Finish()
+
+		expectOptions := meta.ListOptions{}
+		expectErr := errors.New("expectErr")
+
+		mockInterface, wrapperClient := newClient(mockCtrl, Namespace)
+		mockInterface.EXPECT().List(expectOptions).Return(nil, expectErr)
+
+		actualList, actualErr := wrapperClient.List(Namespace, expectOptions)
+		cage_require.MatchRegexp(t, fmt.Sprintf("%v", actualErr), "failed to list daemon sets.*expectErr")
+		require.Nil(t, actualList)
+	})
+}
@@ -0,0 +1,55 @@
+// Copyright (C) 2020 The CodeActual Go Environment Authors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at https://mozilla.org/MPL/2.0/.
+//
+
+// Code generated by MockGen. DO NOT EDIT.
+// Source: k8s.io/client-go/kubernetes/typed/apps/v1 (interfaces: DaemonSetsGetter)
+
+// Package mock is a generated GoMock package.
+package mock
+
+import (
+	gomock "github.com/golang/mock/gomock"
+	v1 "k8s.io/client-go/kubernetes/typed/apps/v1"
+	reflect "reflect"
+)
+
+// MockDaemonSetsGetter is a mock of DaemonSetsGetter interface
+type MockDaemonSetsGetter struct {
+	ctrl     *gomock.Controller
+	recorder *MockDaemonSetsGetterMockRecorder
+}
+
+// MockDaemonSetsGetterMockRecorder is the mock recorder for MockDaemonSetsGetter
+type MockDaemonSetsGetterMockRecorder struct {
+	mock *MockDaemonSetsGetter
+}
+
+// NewMockDaemonSetsGetter creates a new mock instance
+func NewMockDaemonSetsGetter(ctrl *gomock.Controller) *MockDaemonSetsGetter {
+	mock := &MockDaemonSetsGetter{ctrl: ctrl}
+	mock.recorder = &MockDaemonSetsGetterMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use
+func (m *MockDaemonSetsGetter) EXPECT() *MockDaemonSetsGetterMockRecorder {
+	return m.recorder
+}
+
+// DaemonSets mocks base method
+func (m *MockDaemonSetsGetter) DaemonSets(arg0 string) v1.DaemonSetInterface {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DaemonSets", arg0)
+	ret0, _ := ret[0].(v1.DaemonSetInterface)
+	return ret0
+}
+
+// DaemonSets indicates an expected call of DaemonSets
+func (mr *MockDaemonSetsGetterMockRecorder) DaemonSets(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DaemonSets", reflect.TypeOf((*MockDaemonSetsGetter)(nil).DaemonSets), arg0)
+}
@@ -0,0 +1,55 @@
+// Copyright (C) 2020 The CodeActual Go Environment Authors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at https://mozilla.org/MPL/2.0/.
+//
+
+// Code generated by MockGen. DO NOT EDIT.
+// Source: k8s.io/client-go/kubernetes/typed/apps/v1 (interfaces: DeploymentsGetter)
+
+// Package mock is a generated GoMock package.
+package mock
+
+import (
+	gomock "github.com/golang/mock/gomock"
+	v1 "k8s.io/client-go/kubernetes/typed/apps/v1"
+	reflect "reflect"
+)
+
+// MockDeploymentsGetter is a mock of DeploymentsGetter interface
+type MockDeploymentsGetter struct {
+	ctrl     *gomock.Controller
+	recorder *MockDeploymentsGetterMockRecorder
+}
+
+// MockDeploymentsGetterMockRecorder is the mock recorder for MockDeploymentsGetter
+type MockDeploymentsGetterMockRecorder struct {
+	mock *MockDeploymentsGetter
+}
+
+// NewMockDeploymentsGetter creates a new mock instance
+func NewMockDeploymentsGetter(ctrl *gomock.Controller) *MockDeploymentsGetter {
+	mock := &MockDeploymentsGetter{ctrl: ctrl}
+	mock.recorder = &MockDeploymentsGetterMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use
+func (m *MockDeploymentsGetter) EXPECT() *MockDeploymentsGetterMockRecorder {
+	return m.recorder
+}
+
+// Deployments mocks base method
+func (m *MockDeploymentsGetter) Deployments(arg0 string) v1.DeploymentInterface {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Deployments", arg0)
+	ret0, _ := ret[0].(v1.DeploymentInterface)
+	return ret0
+}
+
+// Deployments indicates an expected call of Deployments
+func (mr *MockDeploymentsGetterMockRecorder) Deployments(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Deployments", reflect.TypeOf((*MockDeploymentsGetter)(nil).Deployments), arg0)
+}
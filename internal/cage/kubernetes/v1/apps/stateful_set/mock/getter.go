@@ -0,0 +1,55 @@
+// Copyright (C) 2020 The CodeActual Go Environment Authors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at https://mozilla.org/MPL/2.0/.
+//
+
+// Code generated by MockGen. DO NOT EDIT.
+// Source: k8s.io/client-go/kubernetes/typed/apps/v1 (interfaces: StatefulSetsGetter)
+
+// Package mock is a generated GoMock package.
+package mock
+
+import (
+	gomock "github.com/golang/mock/gomock"
+	v1 "k8s.io/client-go/kubernetes/typed/apps/v1"
+	reflect "reflect"
+)
+
+// MockStatefulSetsGetter is a mock of StatefulSetsGetter interface
+type MockStatefulSetsGetter struct {
+	ctrl     *gomock.Controller
+	recorder *MockStatefulSetsGetterMockRecorder
+}
+
+// MockStatefulSetsGetterMockRecorder is the mock recorder for MockStatefulSetsGetter
+type MockStatefulSetsGetterMockRecorder struct {
+	mock *MockStatefulSetsGetter
+}
+
+// NewMockStatefulSetsGetter creates a new mock instance
+func NewMockStatefulSetsGetter(ctrl *gomock.Controller) *MockStatefulSetsGetter {
+	mock := &MockStatefulSetsGetter{ctrl: ctrl}
+	mock.recorder = &MockStatefulSetsGetterMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use
+func (m *MockStatefulSetsGetter) EXPECT() *MockStatefulSetsGetterMockRecorder {
+	return m.recorder
+}
+
+// StatefulSets mocks base method
+func (m *MockStatefulSetsGetter) StatefulSets(arg0 string) v1.StatefulSetInterface {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "StatefulSets", arg0)
+	ret0, _ := ret[0].(v1.StatefulSetInterface)
+	return ret0
+}
+
+// StatefulSets indicates an expected call of StatefulSets
+func (mr *MockStatefulSetsGetterMockRecorder) StatefulSets(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "StatefulSets", reflect.TypeOf((*MockStatefulSetsGetter)(nil).StatefulSets), arg0)
+}
@@ -0,0 +1,55 @@
+// Copyright (C) 2020 The CodeActual Go Environment Authors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at https://mozilla.org/MPL/2.0/.
+
+//go:generate mockgen -copyright_file=$LICENSE_HEADER -package=mock -destination=$GODIR/mock/wrapper.go -source=$GODIR/$GOFILE
+//go:generate mockgen -copyright_file $CAPATH/LICENSE_HEADER -package=mock -destination=$GODIR/mock/getter.go k8s.io/client-go/kubernetes/typed/apps/v1 StatefulSetsGetter
+//go:generate mockgen -copyright_file $CAPATH/LICENSE_HEADER -package=mock -destination=$GODIR/mock/interface.go k8s.io/client-go/kubernetes/typed/apps/v1 StatefulSetInterface
+package stateful_set
+
+import (
+	apps "k8s.io/api/apps/v1"
+	k8s_errors "k8s.io/apimachinery/pkg/api/errors"
+	meta "k8s.io/apimachinery/pkg/apis/meta/v1"
+	apps_type "k8s.io/client-go/kubernetes/typed/apps/v1"
+
+	"github.com/pkg/errors"
+
+	cage_k8s "github.com/codeactual/kubeauth/internal/cage/kubernetes/v1"
+)
+
+// Client provides an interface to stateful sets.
+type Client interface {
+	List(ns string, options ...meta.ListOptions) (*apps.StatefulSetList, error)
+}
+
+// DefaultClient implementation of Client operates on a real kubernetes API.
+type DefaultClient struct {
+	apps_type.StatefulSetsGetter
+}
+
+// NewDefaultClient returns an initialized DefaultClient.
+func NewDefaultClient(getter apps_type.StatefulSetsGetter) *DefaultClient {
+	return &DefaultClient{StatefulSetsGetter: getter}
+}
+
+// List returns the matching objects.
+//
+// A single ListOptions value can be passed as the final argument to customize the query.
+//
+// It implements Client.
+func (c *DefaultClient) List(ns string, options ...meta.ListOptions) (*apps.StatefulSetList, error) {
+	list, err := c.StatefulSets(ns).List(cage_k8s.ListOptionsFromVariadic(options))
+	if err != nil {
+		if k8s_errors.IsNotFound(err) {
+			return nil, nil
+		}
+		return nil, errors.Wrapf(err, "failed to list stateful sets in namespace [%s]", ns)
+	}
+
+	return list, nil
+}
+
+var _ Client = (*DefaultClient)(nil)
@@ -0,0 +1,53 @@
+// Copyright (C) 2020 The CodeActual Go Environment Authors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at https://mozilla.org/MPL/2.0/.
+
+//go:generate mockgen -copyright_file=$LICENSE_HEADER -package=mock -destination=$GODIR/mock/wrapper.go -source=$GODIR/$GOFILE
+//go:generate mockgen -copyright_file $CAPATH/LICENSE_HEADER -package=mock -destination=$GODIR/mock/getter.go k8s.io/client-go/kubernetes/typed/batch/v1beta1 CronJobsGetter
+//go:generate mockgen -copyright_file $CAPATH/LICENSE_HEADER -package=mock -destination=$GODIR/mock/interface.go k8s.io/client-go/kubernetes/typed/batch/v1beta1 CronJobInterface
+package cron_job
+
+import (
+	batch "k8s.io/api/batch/v1beta1"
+	meta "k8s.io/apimachinery/pkg/apis/meta/v1"
+	batch_type "k8s.io/client-go/kubernetes/typed/batch/v1beta1"
+
+	"github.com/pkg/errors"
+
+	cage_k8s "github.com/codeactual/kubeauth/internal/cage/kubernetes/v1"
+)
+
+// Client provides an interface to cron jobs.
+type Client interface {
+	List(ns string, options ...meta.ListOptions) (*batch.CronJobList, error)
+}
+
+// DefaultClient implementation of Client operates on a real kubernetes API.
+type DefaultClient struct {
+	batch_type.CronJobsGetter
+}
+
+// NewDefaultClient returns an initialized DefaultClient.
+func NewDefaultClient(getter batch_type.CronJobsGetter) *DefaultClient {
+	return &DefaultClient{CronJobsGetter: getter}
+}
+
+// List returns the matching objects.
+//
+// A single ListOptions value can be passed as the final argument to customize the query.
+//
+// The returned error wraps a NotFound error if the API server does not serve batch/v1beta1 cron jobs.
+//
+// It implements Client.
+func (c *DefaultClient) List(ns string, options ...meta.ListOptions) (*batch.CronJobList, error) {
+	list, err := c.CronJobs(ns).List(cage_k8s.ListOptionsFromVariadic(options))
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to list cron jobs in namespace [%s]", ns)
+	}
+
+	return list, nil
+}
+
+var _ Client = (*DefaultClient)(nil)
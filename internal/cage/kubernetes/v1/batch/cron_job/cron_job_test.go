@@ -0,0 +1,89 @@
+// Copyright (C) 2020 The CodeActual Go Environment Authors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package cron_job_test
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+	pkg_errors "github.com/pkg/errors"
+	batch "k8s.io/api/batch/v1beta1"
+	k8s_errors "k8s.io/apimachinery/pkg/api/errors"
+	meta "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/codeactual/kubeauth/internal/cage/kubernetes/v1/batch/cron_job"
+	mock_cron_job "github.com/codeactual/kubeauth/internal/cage/kubernetes/v1/batch/cron_job/mock"
+	cage_k8s_testkit "github.com/codeactual/kubeauth/internal/cage/kubernetes/v1/testkit"
+	cage_require "github.com/codeactual/kubeauth/internal/cage/testkit/testify/require"
+)
+
+const (
+	Namespace = "some-namespace"
+	Name      = "some-cronjob"
+)
+
+func newClient(mockCtrl *gomock.Controller, namespace string) (*mock_cron_job.MockCronJobInterface, *cron_job.DefaultClient) {
+	mockInterface := mock_cron_job.NewMockCronJobInterface(mockCtrl)
+	mockGetter := mock_cron_job.NewMockCronJobsGetter(mockCtrl)
+	mockGetter.EXPECT().CronJobs(namespace).Return(mockInterface)
+	return mockInterface, cron_job.NewDefaultClient(mockGetter)
+}
+
+func TestList(t *testing.T) {
+	t.Run("found", func(t *testing.T) {
+		mockCtrl := gomock.NewController(t)
+		defer mockCtrl.Finish()
+
+		expectOptions := meta.ListOptions{}
+		expectList := &batch.CronJobList{
+			Items: []batch.CronJob{
+				{ObjectMeta: meta.ObjectMeta{Name: Name}},
+			},
+		}
+
+		mockInterface, wrapperClient := newClient(mockCtrl, Namespace)
+		mockInterface.EXPECT().List(expectOptions).Return(expectList, nil)
+
+		actualList, actualErr := wrapperClient.List(Namespace, expectOptions)
+		require.NoError(t, actualErr)
+		require.Exactly(t, expectList, actualList)
+	})
+
+	t.Run("not found", func(t *testing.T) {
+		mockCtrl := gomock.NewController(t)
+		defer mockCtrl.Finish()
+
+		expectOptions := meta.ListOptions{}
+
+		mockInterface, wrapperClient := newClient(mockCtrl, Namespace)
+		mockInterface.EXPECT().List(expectOptions).Return(nil, cage_k8s_testkit.NotFound())
+
+		// A missing API must not be mistaken for an empty list.
+		actualList, actualErr := wrapperClient.List(Namespace, expectOptions)
+		cage_require.MatchRegexp(t, fmt.Sprintf("%v", actualErr), "failed to list cron jobs")
+		require.True(t, k8s_errors.IsNotFound(pkg_errors.Cause(actualErr)))
+		require.Nil(t, actualList)
+	})
+
+	t.Run("error", func(t *testing.T) {
+		mockCtrl := gomock.NewController(t)
+		defer mockCtrl.Finish()
+
+		expectOptions := meta.ListOptions{}
+		expectErr := errors.New("expectErr")
+
+		mockInterface, wrapperClient := newClient(mockCtrl, Namespace)
+		mockInterface.EXPECT().List(expectOptions).Return(nil, expectErr)
+
+		actualList, actualErr := wrapperClient.List(Namespace, expectOptions)
+		cage_require.MatchRegexp(t, fmt.Sprintf("%v", actualErr), "failed to list cron jobs.*expectErr")
+		require.Nil(t, actualList)
+	})
+}
@@ -0,0 +1,55 @@
+// Copyright (C) 2020 The CodeActual Go Environment Authors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at https://mozilla.org/MPL/2.0/.
+//
+
+// Code generated by MockGen. DO NOT EDIT.
+// Source: k8s.io/client-go/kubernetes/typed/batch/v1beta1 (interfaces: CronJobsGetter)
+
+// Package mock is a generated GoMock package.
+package mock
+
+import (
+	gomock "github.com/golang/mock/gomock"
+	v1beta1 "k8s.io/client-go/kubernetes/typed/batch/v1beta1"
+	reflect "reflect"
+)
+
+// MockCronJobsGetter is a mock of CronJobsGetter interface
+type MockCronJobsGetter struct {
+	ctrl     *gomock.Controller
+	recorder *MockCronJobsGetterMockRecorder
+}
+
+// MockCronJobsGetterMockRecorder is the mock recorder for MockCronJobsGetter
+type MockCronJobsGetterMockRecorder struct {
+	mock *MockCronJobsGetter
+}
+
+// NewMockCronJobsGetter creates a new mock instance
+func NewMockCronJobsGetter(ctrl *gomock.Controller) *MockCronJobsGetter {
+	mock := &MockCronJobsGetter{ctrl: ctrl}
+	mock.recorder = &MockCronJobsGetterMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use
+func (m *MockCronJobsGetter) EXPECT() *MockCronJobsGetterMockRecorder {
+	return m.recorder
+}
+
+// CronJobs mocks base method
+func (m *MockCronJobsGetter) CronJobs(arg0 string) v1beta1.CronJobInterface {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CronJobs", arg0)
+	ret0, _ := ret[0].(v1beta1.CronJobInterface)
+	return ret0
+}
+
+// CronJobs indicates an expected call of CronJobs
+func (mr *MockCronJobsGetterMockRecorder) CronJobs(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CronJobs", reflect.TypeOf((*MockCronJobsGetter)(nil).CronJobs), arg0)
+}
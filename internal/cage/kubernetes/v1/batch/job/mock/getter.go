@@ -0,0 +1,55 @@
+// Copyright (C) 2020 The CodeActual Go Environment Authors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at https://mozilla.org/MPL/2.0/.
+//
+
+// Code generated by MockGen. DO NOT EDIT.
+// Source: k8s.io/client-go/kubernetes/typed/batch/v1 (interfaces: JobsGetter)
+
+// Package mock is a generated GoMock package.
+package mock
+
+import (
+	gomock "github.com/golang/mock/gomock"
+	v1 "k8s.io/client-go/kubernetes/typed/batch/v1"
+	reflect "reflect"
+)
+
+// MockJobsGetter is a mock of JobsGetter interface
+type MockJobsGetter struct {
+	ctrl     *gomock.Controller
+	recorder *MockJobsGetterMockRecorder
+}
+
+// MockJobsGetterMockRecorder is the mock recorder for MockJobsGetter
+type MockJobsGetterMockRecorder struct {
+	mock *MockJobsGetter
+}
+
+// NewMockJobsGetter creates a new mock instance
+func NewMockJobsGetter(ctrl *gomock.Controller) *MockJobsGetter {
+	mock := &MockJobsGetter{ctrl: ctrl}
+	mock.recorder = &MockJobsGetterMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use
+func (m *MockJobsGetter) EXPECT() *MockJobsGetterMockRecorder {
+	return m.recorder
+}
+
+// Jobs mocks base method
+func (m *MockJobsGetter) Jobs(arg0 string) v1.JobInterface {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Jobs", arg0)
+	ret0, _ := ret[0].(v1.JobInterface)
+	return ret0
+}
+
+// Jobs indicates an expected call of Jobs
+func (mr *MockJobsGetterMockRecorder) Jobs(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Jobs", reflect.TypeOf((*MockJobsGetter)(nil).Jobs), arg0)
+}
@@ -0,0 +1,135 @@
+// Copyright (C) 2020 The CodeActual Go Environment Authors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at https://mozilla.org/MPL/2.0/.
+
+//go:generate mockgen -copyright_file=$LICENSE_HEADER -package=mock -destination=$GODIR/mock/wrapper.go -source=$GODIR/$GOFILE
+//go:generate mockgen -copyright_file $CAPATH/LICENSE_HEADER -package=mock -destination=$GODIR/mock/getter.go k8s.io/client-go/kubernetes/typed/certificates/v1beta1 CertificateSigningRequestsGetter
+//go:generate mockgen -copyright_file $CAPATH/LICENSE_HEADER -package=mock -destination=$GODIR/mock/interface.go k8s.io/client-go/kubernetes/typed/certificates/v1beta1 CertificateSigningRequestInterface
+package certificate_signing_request
+
+import (
+	certs "k8s.io/api/certificates/v1beta1"
+	k8s_errors "k8s.io/apimachinery/pkg/api/errors"
+	meta "k8s.io/apimachinery/pkg/apis/meta/v1"
+	certs_type "k8s.io/client-go/kubernetes/typed/certificates/v1beta1"
+
+	"github.com/pkg/errors"
+
+	cage_k8s "github.com/codeactual/kubeauth/internal/cage/kubernetes/v1"
+)
+
+// Client provides an interface to certificate signing requests.
+type Client interface {
+	Create(name string, request []byte, usages ...certs.KeyUsage) (*certs.CertificateSigningRequest, error)
+	Get(name string, options ...meta.GetOptions) (_ *certs.CertificateSigningRequest, exists bool, _ error)
+	Approve(csr *certs.CertificateSigningRequest, reason, message string) (*certs.CertificateSigningRequest, error)
+	Delete(name string) error
+}
+
+// DefaultClient implementation of Client operates on a real kubernetes API.
+type DefaultClient struct {
+	certs_type.CertificateSigningRequestsGetter
+}
+
+// NewDefaultClient returns an initialized DefaultClient.
+func NewDefaultClient(getter certs_type.CertificateSigningRequestsGetter) *DefaultClient {
+	return &DefaultClient{CertificateSigningRequestsGetter: getter}
+}
+
+// Create adds a request based on a PEM-encoded PKCS#10 certificate request.
+//
+// It implements Client.
+func (c *DefaultClient) Create(name string, request []byte, usages ...certs.KeyUsage) (*certs.CertificateSigningRequest, error) {
+	created, err := c.CertificateSigningRequests().Create(&certs.CertificateSigningRequest{
+		ObjectMeta: meta.ObjectMeta{Name: name},
+		Spec: certs.CertificateSigningRequestSpec{
+			Request: request,
+			Usages:  usages,
+		},
+	})
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to create certificate signing request [%s]", name)
+	}
+
+	return created, nil
+}
+
+// Get returns the object if found, reports that the object does not exist, or returns an error.
+//
+// A single GetOptions value can be passed as the final argument to customize the query.
+//
+// It implements Client.
+func (c *DefaultClient) Get(name string, options ...meta.GetOptions) (_ *certs.CertificateSigningRequest, exists bool, _ error) {
+	obj, err := c.CertificateSigningRequests().Get(name, cage_k8s.GetOptionsFromVariadic(options))
+	if err != nil {
+		if k8s_errors.IsNotFound(err) {
+			return nil, false, nil
+		}
+		return nil, false, errors.Wrapf(err, "failed to get certificate signing request [%s]", name)
+	}
+
+	return obj, true, nil
+}
+
+// Approve adds an approval condition to the request.
+//
+// The request's signer issues the certificate asynchronously, so the returned object's status
+// may not include it yet.
+//
+// It implements Client.
+func (c *DefaultClient) Approve(csr *certs.CertificateSigningRequest, reason, message string) (*certs.CertificateSigningRequest, error) {
+	approved := csr.DeepCopy()
+	approved.Status.Conditions = append(approved.Status.Conditions, certs.CertificateSigningRequestCondition{
+		Type:           certs.CertificateApproved,
+		Reason:         reason,
+		Message:        message,
+		LastUpdateTime: meta.Now(),
+	})
+
+	updated, err := c.CertificateSigningRequests().UpdateApproval(approved)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to approve certificate signing request [%s]", csr.Name)
+	}
+
+	return updated, nil
+}
+
+// Delete removes the request.
+//
+// It implements Client.
+func (c *DefaultClient) Delete(name string) error {
+	err := c.CertificateSigningRequests().Delete(name, &meta.DeleteOptions{})
+	if err != nil {
+		return errors.Wrapf(err, "failed to delete certificate signing request [%s]", name)
+	}
+
+	return nil
+}
+
+// IsApproved returns true if the request has an approval condition.
+func IsApproved(csr *certs.CertificateSigningRequest) bool {
+	return hasCondition(csr, certs.CertificateApproved)
+}
+
+// IsDenied returns true if the request has a denial condition.
+func IsDenied(csr *certs.CertificateSigningRequest) bool {
+	return hasCondition(csr, certs.CertificateDenied)
+}
+
+// IsIssued returns true if the request's signer has issued the certificate.
+func IsIssued(csr *certs.CertificateSigningRequest) bool {
+	return len(csr.Status.Certificate) > 0
+}
+
+func hasCondition(csr *certs.CertificateSigningRequest, t certs.RequestConditionType) bool {
+	for _, c := range csr.Status.Conditions {
+		if c.Type == t {
+			return true
+		}
+	}
+	return false
+}
+
+var _ Client = (*DefaultClient)(nil)
@@ -0,0 +1,226 @@
+// Copyright (C) 2020 The CodeActual Go Environment Authors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package certificate_signing_request_test
+
+import (
+	"crypto/ecdsa"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+	certs "k8s.io/api/certificates/v1beta1"
+	meta "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/util/keyutil"
+
+	csr "github.com/codeactual/kubeauth/internal/cage/kubernetes/v1/certificates/certificate_signing_request"
+	mock_csr "github.com/codeactual/kubeauth/internal/cage/kubernetes/v1/certificates/certificate_signing_request/mock"
+	cage_k8s_testkit "github.com/codeactual/kubeauth/internal/cage/kubernetes/v1/testkit"
+	cage_require "github.com/codeactual/kubeauth/internal/cage/testkit/testify/require"
+)
+
+const (
+	Name = "some-csr"
+)
+
+func newClient(mockCtrl *gomock.Controller) (*mock_csr.MockCertificateSigningRequestInterface, *csr.DefaultClient) {
+	mockInterface := mock_csr.NewMockCertificateSigningRequestInterface(mockCtrl)
+	mockGetter := mock_csr.NewMockCertificateSigningRequestsGetter(mockCtrl)
+	mockGetter.EXPECT().CertificateSigningRequests().Return(mockInterface)
+	return mockInterface, csr.NewDefaultClient(mockGetter)
+}
+
+func TestCreate(t *testing.T) {
+	t.Run("created", func(t *testing.T) {
+		mockCtrl := gomock.NewController(t)
+		defer mockCtrl.Finish()
+
+		expectRequest := []byte("some-request")
+		expectObj := &certs.CertificateSigningRequest{
+			ObjectMeta: meta.ObjectMeta{Name: Name},
+			Spec: certs.CertificateSigningRequestSpec{
+				Request: expectRequest,
+				Usages:  csr.ClientAuthUsages,
+			},
+		}
+
+		mockInterface, wrapperClient := newClient(mockCtrl)
+		mockInterface.EXPECT().Create(expectObj).Return(expectObj, nil)
+
+		actualObj, err := wrapperClient.Create(Name, expectRequest, csr.ClientAuthUsages...)
+		require.NoError(t, err)
+		require.Exactly(t, expectObj, actualObj)
+	})
+
+	t.Run("error", func(t *testing.T) {
+		mockCtrl := gomock.NewController(t)
+		defer mockCtrl.Finish()
+
+		expectErr := errors.New("expectErr")
+
+		mockInterface, wrapperClient := newClient(mockCtrl)
+		mockInterface.EXPECT().Create(gomock.Any()).Return(nil, expectErr)
+
+		actualObj, actualErr := wrapperClient.Create(Name, []byte("some-request"))
+		cage_require.MatchRegexp(t, fmt.Sprintf("%v", actualErr), "failed to create certificate signing request.*expectErr")
+		require.Nil(t, actualObj)
+	})
+}
+
+func TestGet(t *testing.T) {
+	t.Run("found", func(t *testing.T) {
+		mockCtrl := gomock.NewController(t)
+		defer mockCtrl.Finish()
+
+		expectOptions := meta.GetOptions{}
+		expectObj := &certs.CertificateSigningRequest{ObjectMeta: meta.ObjectMeta{Name: Name}}
+
+		mockInterface, wrapperClient := newClient(mockCtrl)
+		mockInterface.EXPECT().Get(Name, expectOptions).Return(expectObj, nil)
+
+		actualObj, exists, err := wrapperClient.Get(Name, expectOptions)
+		require.NoError(t, err)
+		require.True(t, exists)
+		require.Exactly(t, expectObj, actualObj)
+	})
+
+	t.Run("not found", func(t *testing.T) {
+		mockCtrl := gomock.NewController(t)
+		defer mockCtrl.Finish()
+
+		expectOptions := meta.GetOptions{}
+
+		mockInterface, wrapperClient := newClient(mockCtrl)
+		mockInterface.EXPECT().Get(Name, expectOptions).Return(nil, cage_k8s_testkit.NotFound())
+
+		actualObj, exists, err := wrapperClient.Get(Name, expectOptions)
+		require.NoError(t, err)
+		require.False(t, exists)
+		require.Nil(t, actualObj)
+	})
+
+	t.Run("error", func(t *testing.T) {
+		mockCtrl := gomock.NewController(t)
+		defer mockCtrl.Finish()
+
+		expectOptions := meta.GetOptions{}
+		expectErr := errors.New("expectErr")
+
+		mockInterface, wrapperClient := newClient(mockCtrl)
+		mockInterface.EXPECT().Get(Name, expectOptions).Return(nil, expectErr)
+
+		actualObj, exists, actualErr := wrapperClient.Get(Name, expectOptions)
+		cage_require.MatchRegexp(t, fmt.Sprintf("%v", actualErr), "failed to get certificate signing request.*expectErr")
+		require.False(t, exists)
+		require.Nil(t, actualObj)
+	})
+}
+
+func TestApprove(t *testing.T) {
+	t.Run("approved", func(t *testing.T) {
+		mockCtrl := gomock.NewController(t)
+		defer mockCtrl.Finish()
+
+		origObj := &certs.CertificateSigningRequest{ObjectMeta: meta.ObjectMeta{Name: Name}}
+
+		mockInterface, wrapperClient := newClient(mockCtrl)
+		mockInterface.EXPECT().
+			UpdateApproval(gomock.Any()).
+			DoAndReturn(func(obj *certs.CertificateSigningRequest) (*certs.CertificateSigningRequest, error) {
+				require.Len(t, obj.Status.Conditions, 1)
+				require.Exactly(t, certs.CertificateApproved, obj.Status.Conditions[0].Type)
+				require.Exactly(t, "some-reason", obj.Status.Conditions[0].Reason)
+				require.Exactly(t, "some-message", obj.Status.Conditions[0].Message)
+				return obj, nil
+			})
+
+		actualObj, err := wrapperClient.Approve(origObj, "some-reason", "some-message")
+		require.NoError(t, err)
+		require.True(t, csr.IsApproved(actualObj))
+		require.False(t, csr.IsApproved(origObj)) // input not modified
+	})
+
+	t.Run("error", func(t *testing.T) {
+		mockCtrl := gomock.NewController(t)
+		defer mockCtrl.Finish()
+
+		expectErr := errors.New("expectErr")
+
+		mockInterface, wrapperClient := newClient(mockCtrl)
+		mockInterface.EXPECT().UpdateApproval(gomock.Any()).Return(nil, expectErr)
+
+		actualObj, actualErr := wrapperClient.Approve(&certs.CertificateSigningRequest{}, "some-reason", "some-message")
+		cage_require.MatchRegexp(t, fmt.Sprintf("%v", actualErr), "failed to approve certificate signing request.*expectErr")
+		require.Nil(t, actualObj)
+	})
+}
+
+func TestDelete(t *testing.T) {
+	t.Run("deleted", func(t *testing.T) {
+		mockCtrl := gomock.NewController(t)
+		defer mockCtrl.Finish()
+
+		mockInterface, wrapperClient := newClient(mockCtrl)
+		mockInterface.EXPECT().Delete(Name, &meta.DeleteOptions{}).Return(nil)
+
+		require.NoError(t, wrapperClient.Delete(Name))
+	})
+
+	t.Run("error", func(t *testing.T) {
+		mockCtrl := gomock.NewController(t)
+		defer mockCtrl.Finish()
+
+		expectErr := errors.New("expectErr")
+
+		mockInterface, wrapperClient := newClient(mockCtrl)
+		mockInterface.EXPECT().Delete(Name, &meta.DeleteOptions{}).Return(expectErr)
+
+		actualErr := wrapperClient.Delete(Name)
+		cage_require.MatchRegexp(t, fmt.Sprintf("%v", actualErr), "failed to delete certificate signing request.*expectErr")
+	})
+}
+
+func TestConditions(t *testing.T) {
+	obj := &certs.CertificateSigningRequest{}
+	require.False(t, csr.IsApproved(obj))
+	require.False(t, csr.IsDenied(obj))
+	require.False(t, csr.IsIssued(obj))
+
+	obj.Status.Conditions = []certs.CertificateSigningRequestCondition{{Type: certs.CertificateDenied}}
+	require.False(t, csr.IsApproved(obj))
+	require.True(t, csr.IsDenied(obj))
+
+	obj.Status.Conditions = []certs.CertificateSigningRequestCondition{{Type: certs.CertificateApproved}}
+	obj.Status.Certificate = []byte("some-cert")
+	require.True(t, csr.IsApproved(obj))
+	require.False(t, csr.IsDenied(obj))
+	require.True(t, csr.IsIssued(obj))
+}
+
+func TestNewClientRequest(t *testing.T) {
+	key, request, err := csr.NewClientRequest("some-user", []string{"group-a", "group-b"})
+	require.NoError(t, err)
+
+	privateKey, err := keyutil.ParsePrivateKeyPEM(key)
+	require.NoError(t, err)
+
+	block, _ := pem.Decode(request)
+	require.NotNil(t, block)
+	require.Exactly(t, "CERTIFICATE REQUEST", block.Type)
+
+	parsed, err := x509.ParseCertificateRequest(block.Bytes)
+	require.NoError(t, err)
+	require.NoError(t, parsed.CheckSignature())
+	require.Exactly(t, "some-user", parsed.Subject.CommonName)
+	require.Exactly(t, []string{"group-a", "group-b"}, parsed.Subject.Organization)
+	ecKey, ok := privateKey.(*ecdsa.PrivateKey)
+	require.True(t, ok)
+	require.Equal(t, &ecKey.PublicKey, parsed.PublicKey)
+}
@@ -0,0 +1,139 @@
+// Copyright (C) 2020 The CodeActual Go Environment Authors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package certificate_signing_request
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	k8s_errors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/util/wait"
+)
+
+const (
+	// DefaultIssueTimeout is the default duration to wait for a certificate to be approved and issued.
+	DefaultIssueTimeout = 5 * time.Minute
+
+	// DefaultPollInterval is the default delay between queries for a request's status.
+	DefaultPollInterval = time.Second
+
+	// ApprovalReason is recorded in the approval conditions which Issuer adds.
+	ApprovalReason = "KubeauthApprove"
+)
+
+// Issuer obtains client certificates by submitting requests and waiting for them to be issued.
+type Issuer struct {
+	Client Client
+
+	// Approve is true if the Issuer should approve its own requests.
+	//
+	// If the approval is forbidden, e.g. because the caller lacks permission, the Issuer waits for
+	// another party to approve the request.
+	Approve bool
+
+	// Timeout is the duration to wait for a request to be approved and issued.
+	Timeout time.Duration
+
+	// PollInterval is the delay between queries for a request's status.
+	PollInterval time.Duration
+
+	// Notify, if non-nil, receives status messages, e.g. that a request is waiting for approval.
+	Notify func(msg string)
+}
+
+// NewIssuer returns an Issuer with default timing settings.
+func NewIssuer(client Client) *Issuer {
+	return &Issuer{
+		Client:       client,
+		Timeout:      DefaultIssueTimeout,
+		PollInterval: DefaultPollInterval,
+	}
+}
+
+// IssueClientCert generates a private key, requests a client certificate for it, and waits for
+// the certificate to be issued.
+//
+// Both return values are PEM-encoded.
+func (i *Issuer) IssueClientCert(commonName string, organizations []string) (cert, key []byte, _ error) {
+	key, request, err := NewClientRequest(commonName, organizations)
+	if err != nil {
+		return nil, nil, errors.WithStack(err)
+	}
+
+	csr, err := i.Client.Create(RequestName(commonName, request), request, ClientAuthUsages...)
+	if err != nil {
+		return nil, nil, errors.WithStack(err)
+	}
+	name := csr.Name
+
+	if i.Approve {
+		approved, err := i.Client.Approve(csr, ApprovalReason, "approved by its requester")
+		if err == nil {
+			csr = approved
+			i.notify("certificate signing request [%s] approved", name)
+		} else if k8s_errors.IsForbidden(errors.Cause(err)) {
+			i.notify("not permitted to approve certificate signing request [%s]", name)
+		} else {
+			return nil, nil, errors.WithStack(err)
+		}
+	}
+
+	if !IsApproved(csr) {
+		i.notify(
+			"waiting up to %s for approval of certificate signing request [%s], e.g. by: kubectl certificate approve %s",
+			i.Timeout, name, name,
+		)
+	}
+
+	// The signer issues the certificate asynchronously after approval.
+	err = wait.PollImmediate(i.PollInterval, i.Timeout, func() (done bool, _ error) {
+		var exists bool
+		var getErr error
+		csr, exists, getErr = i.Client.Get(name)
+		if getErr != nil {
+			return false, errors.WithStack(getErr)
+		}
+		if !exists {
+			return false, errors.Errorf("certificate signing request [%s] was deleted", name)
+		}
+		if IsDenied(csr) {
+			return false, errors.Errorf("certificate signing request [%s] was denied", name)
+		}
+		return IsIssued(csr), nil
+	})
+	if err != nil {
+		if err == wait.ErrWaitTimeout {
+			return nil, nil, errors.Errorf("certificate signing request [%s] was not approved and issued after [%s]", name, i.Timeout)
+		}
+		return nil, nil, errors.WithStack(err)
+	}
+
+	return csr.Status.Certificate, key, nil
+}
+
+func (i *Issuer) notify(format string, vArgs ...interface{}) {
+	if i.Notify != nil {
+		i.Notify(fmt.Sprintf(format, vArgs...))
+	}
+}
+
+// RequestName returns a name which identifies the requester and is unique to the request.
+func RequestName(commonName string, request []byte) string {
+	sanitized := strings.Map(func(r rune) rune {
+		if (r >= 'a' && r <= 'z') || (r >= '0' && r <= '9') || r == '-' || r == '.' {
+			return r
+		}
+		return '-'
+	}, strings.ToLower(commonName))
+
+	sum := sha256.Sum256(request)
+
+	return fmt.Sprintf("kubeauth-%s-%x", strings.Trim(sanitized, "-."), sum[:5])
+}
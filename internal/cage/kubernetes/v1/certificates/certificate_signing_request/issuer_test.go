@@ -0,0 +1,196 @@
+// Copyright (C) 2020 The CodeActual Go Environment Authors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package certificate_signing_request_test
+
+import (
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+	certs "k8s.io/api/certificates/v1beta1"
+	k8s_errors "k8s.io/apimachinery/pkg/api/errors"
+	meta "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+
+	csr "github.com/codeactual/kubeauth/internal/cage/kubernetes/v1/certificates/certificate_signing_request"
+	mock_csr "github.com/codeactual/kubeauth/internal/cage/kubernetes/v1/certificates/certificate_signing_request/mock"
+	cage_require "github.com/codeactual/kubeauth/internal/cage/testkit/testify/require"
+)
+
+func newIssuer(mockCtrl *gomock.Controller) (*mock_csr.MockClient, *csr.Issuer, *[]string) {
+	var notices []string
+
+	mockClient := mock_csr.NewMockClient(mockCtrl)
+	issuer := csr.NewIssuer(mockClient)
+	issuer.PollInterval = time.Millisecond
+	issuer.Notify = func(msg string) {
+		notices = append(notices, msg)
+	}
+
+	return mockClient, issuer, &notices
+}
+
+func newRequestObjects() (created, approved, issued *certs.CertificateSigningRequest) {
+	created = &certs.CertificateSigningRequest{ObjectMeta: meta.ObjectMeta{Name: Name}}
+	approved = created.DeepCopy()
+	approved.Status.Conditions = []certs.CertificateSigningRequestCondition{{Type: certs.CertificateApproved}}
+	issued = approved.DeepCopy()
+	issued.Status.Certificate = []byte("some-cert")
+	return created, approved, issued
+}
+
+func TestIssueClientCert(t *testing.T) {
+	t.Run("approved by other party", func(t *testing.T) {
+		mockCtrl := gomock.NewController(t)
+		defer mockCtrl.Finish()
+
+		created, approved, issued := newRequestObjects()
+
+		mockClient, issuer, notices := newIssuer(mockCtrl)
+		gomock.InOrder(
+			mockClient.EXPECT().
+				Create(gomock.Any(), gomock.Any(), csr.ClientAuthUsages).
+				DoAndReturn(func(name string, request []byte, _ ...certs.KeyUsage) (*certs.CertificateSigningRequest, error) {
+					require.Exactly(t, csr.RequestName("some-user", request), name)
+
+					block, _ := pem.Decode(request)
+					require.NotNil(t, block)
+					parsed, err := x509.ParseCertificateRequest(block.Bytes)
+					require.NoError(t, err)
+					require.Exactly(t, "some-user", parsed.Subject.CommonName)
+					require.Exactly(t, []string{"group-a"}, parsed.Subject.Organization)
+
+					return created, nil
+				}),
+			mockClient.EXPECT().Get(Name).Return(created, true, nil),
+			mockClient.EXPECT().Get(Name).Return(approved, true, nil),
+			mockClient.EXPECT().Get(Name).Return(issued, true, nil),
+		)
+
+		cert, key, err := issuer.IssueClientCert("some-user", []string{"group-a"})
+		require.NoError(t, err)
+		require.Exactly(t, issued.Status.Certificate, cert)
+		require.NotEmpty(t, key)
+		require.Len(t, *notices, 1)
+		require.Contains(t, (*notices)[0], "waiting up to")
+	})
+
+	t.Run("approved by requester", func(t *testing.T) {
+		mockCtrl := gomock.NewController(t)
+		defer mockCtrl.Finish()
+
+		created, approved, issued := newRequestObjects()
+
+		mockClient, issuer, notices := newIssuer(mockCtrl)
+		issuer.Approve = true
+		gomock.InOrder(
+			mockClient.EXPECT().Create(gomock.Any(), gomock.Any(), gomock.Any()).Return(created, nil),
+			mockClient.EXPECT().Approve(created, csr.ApprovalReason, gomock.Any()).Return(approved, nil),
+			mockClient.EXPECT().Get(Name).Return(issued, true, nil),
+		)
+
+		cert, _, err := issuer.IssueClientCert("some-user", nil)
+		require.NoError(t, err)
+		require.Exactly(t, issued.Status.Certificate, cert)
+		require.Exactly(t, []string{"certificate signing request [some-csr] approved"}, *notices)
+	})
+
+	t.Run("approval forbidden", func(t *testing.T) {
+		mockCtrl := gomock.NewController(t)
+		defer mockCtrl.Finish()
+
+		created, _, issued := newRequestObjects()
+		forbidden := k8s_errors.NewForbidden(schema.GroupResource{}, Name, nil)
+
+		mockClient, issuer, notices := newIssuer(mockCtrl)
+		issuer.Approve = true
+		gomock.InOrder(
+			mockClient.EXPECT().Create(gomock.Any(), gomock.Any(), gomock.Any()).Return(created, nil),
+			mockClient.EXPECT().Approve(created, gomock.Any(), gomock.Any()).Return(nil, forbidden),
+			mockClient.EXPECT().Get(Name).Return(issued, true, nil),
+		)
+
+		cert, _, err := issuer.IssueClientCert("some-user", nil)
+		require.NoError(t, err)
+		require.Exactly(t, issued.Status.Certificate, cert)
+		require.Len(t, *notices, 2)
+		require.Contains(t, (*notices)[0], "not permitted to approve")
+	})
+
+	t.Run("approval error", func(t *testing.T) {
+		mockCtrl := gomock.NewController(t)
+		defer mockCtrl.Finish()
+
+		created, _, _ := newRequestObjects()
+
+		mockClient, issuer, _ := newIssuer(mockCtrl)
+		issuer.Approve = true
+		mockClient.EXPECT().Create(gomock.Any(), gomock.Any(), gomock.Any()).Return(created, nil)
+		mockClient.EXPECT().Approve(created, gomock.Any(), gomock.Any()).Return(nil, errors.New("expectErr"))
+
+		_, _, actualErr := issuer.IssueClientCert("some-user", nil)
+		cage_require.MatchRegexp(t, fmt.Sprintf("%v", actualErr), "expectErr")
+	})
+
+	t.Run("denied", func(t *testing.T) {
+		mockCtrl := gomock.NewController(t)
+		defer mockCtrl.Finish()
+
+		created, _, _ := newRequestObjects()
+		denied := created.DeepCopy()
+		denied.Status.Conditions = []certs.CertificateSigningRequestCondition{{Type: certs.CertificateDenied}}
+
+		mockClient, issuer, _ := newIssuer(mockCtrl)
+		mockClient.EXPECT().Create(gomock.Any(), gomock.Any(), gomock.Any()).Return(created, nil)
+		mockClient.EXPECT().Get(Name).Return(denied, true, nil)
+
+		_, _, actualErr := issuer.IssueClientCert("some-user", nil)
+		cage_require.MatchRegexp(t, fmt.Sprintf("%v", actualErr), `\[some-csr\] was denied`)
+	})
+
+	t.Run("deleted", func(t *testing.T) {
+		mockCtrl := gomock.NewController(t)
+		defer mockCtrl.Finish()
+
+		created, _, _ := newRequestObjects()
+
+		mockClient, issuer, _ := newIssuer(mockCtrl)
+		mockClient.EXPECT().Create(gomock.Any(), gomock.Any(), gomock.Any()).Return(created, nil)
+		mockClient.EXPECT().Get(Name).Return(nil, false, nil)
+
+		_, _, actualErr := issuer.IssueClientCert("some-user", nil)
+		cage_require.MatchRegexp(t, fmt.Sprintf("%v", actualErr), `\[some-csr\] was deleted`)
+	})
+
+	t.Run("timeout", func(t *testing.T) {
+		mockCtrl := gomock.NewController(t)
+		defer mockCtrl.Finish()
+
+		created, _, _ := newRequestObjects()
+
+		mockClient, issuer, _ := newIssuer(mockCtrl)
+		issuer.Timeout = 10 * time.Millisecond
+		mockClient.EXPECT().Create(gomock.Any(), gomock.Any(), gomock.Any()).Return(created, nil)
+		mockClient.EXPECT().Get(Name).Return(created, true, nil).MinTimes(1)
+
+		_, _, actualErr := issuer.IssueClientCert("some-user", nil)
+		cage_require.MatchRegexp(t, fmt.Sprintf("%v", actualErr), `\[some-csr\] was not approved and issued after`)
+	})
+}
+
+func TestRequestName(t *testing.T) {
+	name := csr.RequestName("Jane.Doe@Example.com", []byte("some-request"))
+	require.True(t, strings.HasPrefix(name, "kubeauth-jane.doe-example.com-"), name)
+	require.Len(t, name, len("kubeauth-jane.doe-example.com-")+10)
+	require.NotEqual(t, name, csr.RequestName("Jane.Doe@Example.com", []byte("other-request")))
+}
@@ -0,0 +1,55 @@
+// Copyright (C) 2020 The CodeActual Go Environment Authors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at https://mozilla.org/MPL/2.0/.
+//
+
+// Code generated by MockGen. DO NOT EDIT.
+// Source: k8s.io/client-go/kubernetes/typed/certificates/v1beta1 (interfaces: CertificateSigningRequestsGetter)
+
+// Package mock is a generated GoMock package.
+package mock
+
+import (
+	gomock "github.com/golang/mock/gomock"
+	v1beta1 "k8s.io/client-go/kubernetes/typed/certificates/v1beta1"
+	reflect "reflect"
+)
+
+// MockCertificateSigningRequestsGetter is a mock of CertificateSigningRequestsGetter interface
+type MockCertificateSigningRequestsGetter struct {
+	ctrl     *gomock.Controller
+	recorder *MockCertificateSigningRequestsGetterMockRecorder
+}
+
+// MockCertificateSigningRequestsGetterMockRecorder is the mock recorder for MockCertificateSigningRequestsGetter
+type MockCertificateSigningRequestsGetterMockRecorder struct {
+	mock *MockCertificateSigningRequestsGetter
+}
+
+// NewMockCertificateSigningRequestsGetter creates a new mock instance
+func NewMockCertificateSigningRequestsGetter(ctrl *gomock.Controller) *MockCertificateSigningRequestsGetter {
+	mock := &MockCertificateSigningRequestsGetter{ctrl: ctrl}
+	mock.recorder = &MockCertificateSigningRequestsGetterMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use
+func (m *MockCertificateSigningRequestsGetter) EXPECT() *MockCertificateSigningRequestsGetterMockRecorder {
+	return m.recorder
+}
+
+// CertificateSigningRequests mocks base method
+func (m *MockCertificateSigningRequestsGetter) CertificateSigningRequests() v1beta1.CertificateSigningRequestInterface {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CertificateSigningRequests")
+	ret0, _ := ret[0].(v1beta1.CertificateSigningRequestInterface)
+	return ret0
+}
+
+// CertificateSigningRequests indicates an expected call of CertificateSigningRequests
+func (mr *MockCertificateSigningRequestsGetterMockRecorder) CertificateSigningRequests() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CertificateSigningRequests", reflect.TypeOf((*MockCertificateSigningRequestsGetter)(nil).CertificateSigningRequests))
+}
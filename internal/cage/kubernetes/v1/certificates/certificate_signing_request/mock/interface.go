@@ -0,0 +1,197 @@
+// Copyright (C) 2020 The CodeActual Go Environment Authors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at https://mozilla.org/MPL/2.0/.
+//
+
+// Code generated by MockGen. DO NOT EDIT.
+// Source: k8s.io/client-go/kubernetes/typed/certificates/v1beta1 (interfaces: CertificateSigningRequestInterface)
+
+// Package mock is a generated GoMock package.
+package mock
+
+import (
+	gomock "github.com/golang/mock/gomock"
+	v1beta1 "k8s.io/api/certificates/v1beta1"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	types "k8s.io/apimachinery/pkg/types"
+	watch "k8s.io/apimachinery/pkg/watch"
+	reflect "reflect"
+)
+
+// MockCertificateSigningRequestInterface is a mock of CertificateSigningRequestInterface interface
+type MockCertificateSigningRequestInterface struct {
+	ctrl     *gomock.Controller
+	recorder *MockCertificateSigningRequestInterfaceMockRecorder
+}
+
+// MockCertificateSigningRequestInterfaceMockRecorder is the mock recorder for MockCertificateSigningRequestInterface
+type MockCertificateSigningRequestInterfaceMockRecorder struct {
+	mock *MockCertificateSigningRequestInterface
+}
+
+// NewMockCertificateSigningRequestInterface creates a new mock instance
+func NewMockCertificateSigningRequestInterface(ctrl *gomock.Controller) *MockCertificateSigningRequestInterface {
+	mock := &MockCertificateSigningRequestInterface{ctrl: ctrl}
+	mock.recorder = &MockCertificateSigningRequestInterfaceMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use
+func (m *MockCertificateSigningRequestInterface) EXPECT() *MockCertificateSigningRequestInterfaceMockRecorder {
+	return m.recorder
+}
+
+// Create mocks base method
+func (m *MockCertificateSigningRequestInterface) Create(arg0 *v1beta1.CertificateSigningRequest) (*v1beta1.CertificateSigningRequest, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Create", arg0)
+	ret0, _ := ret[0].(*v1beta1.CertificateSigningRequest)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Create indicates an expected call of Create
+func (mr *MockCertificateSigningRequestInterfaceMockRecorder) Create(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Create", reflect.TypeOf((*MockCertificateSigningRequestInterface)(nil).Create), arg0)
+}
+
+// Delete mocks base method
+func (m *MockCertificateSigningRequestInterface) Delete(arg0 string, arg1 *v1.DeleteOptions) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Delete", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Delete indicates an expected call of Delete
+func (mr *MockCertificateSigningRequestInterfaceMockRecorder) Delete(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Delete", reflect.TypeOf((*MockCertificateSigningRequestInterface)(nil).Delete), arg0, arg1)
+}
+
+// DeleteCollection mocks base method
+func (m *MockCertificateSigningRequestInterface) DeleteCollection(arg0 *v1.DeleteOptions, arg1 v1.ListOptions) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteCollection", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteCollection indicates an expected call of DeleteCollection
+func (mr *MockCertificateSigningRequestInterfaceMockRecorder) DeleteCollection(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteCollection", reflect.TypeOf((*MockCertificateSigningRequestInterface)(nil).DeleteCollection), arg0, arg1)
+}
+
+// Get mocks base method
+func (m *MockCertificateSigningRequestInterface) Get(arg0 string, arg1 v1.GetOptions) (*v1beta1.CertificateSigningRequest, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Get", arg0, arg1)
+	ret0, _ := ret[0].(*v1beta1.CertificateSigningRequest)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Get indicates an expected call of Get
+func (mr *MockCertificateSigningRequestInterfaceMockRecorder) Get(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Get", reflect.TypeOf((*MockCertificateSigningRequestInterface)(nil).Get), arg0, arg1)
+}
+
+// List mocks base method
+func (m *MockCertificateSigningRequestInterface) List(arg0 v1.ListOptions) (*v1beta1.CertificateSigningRequestList, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "List", arg0)
+	ret0, _ := ret[0].(*v1beta1.CertificateSigningRequestList)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// List indicates an expected call of List
+func (mr *MockCertificateSigningRequestInterfaceMockRecorder) List(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "List", reflect.TypeOf((*MockCertificateSigningRequestInterface)(nil).List), arg0)
+}
+
+// Patch mocks base method
+func (m *MockCertificateSigningRequestInterface) Patch(arg0 string, arg1 types.PatchType, arg2 []byte, arg3 ...string) (*v1beta1.CertificateSigningRequest, error) {
+	m.ctrl.T.Helper()
+	varargs := []interface{}{arg0, arg1, arg2}
+	for _, a := range arg3 {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "Patch", varargs...)
+	ret0, _ := ret[0].(*v1beta1.CertificateSigningRequest)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Patch indicates an expected call of Patch
+func (mr *MockCertificateSigningRequestInterfaceMockRecorder) Patch(arg0, arg1, arg2 interface{}, arg3 ...interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]interface{}{arg0, arg1, arg2}, arg3...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Patch", reflect.TypeOf((*MockCertificateSigningRequestInterface)(nil).Patch), varargs...)
+}
+
+// Update mocks base method
+func (m *MockCertificateSigningRequestInterface) Update(arg0 *v1beta1.CertificateSigningRequest) (*v1beta1.CertificateSigningRequest, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Update", arg0)
+	ret0, _ := ret[0].(*v1beta1.CertificateSigningRequest)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Update indicates an expected call of Update
+func (mr *MockCertificateSigningRequestInterfaceMockRecorder) Update(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Update", reflect.TypeOf((*MockCertificateSigningRequestInterface)(nil).Update), arg0)
+}
+
+// UpdateApproval mocks base method
+func (m *MockCertificateSigningRequestInterface) UpdateApproval(arg0 *v1beta1.CertificateSigningRequest) (*v1beta1.CertificateSigningRequest, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateApproval", arg0)
+	ret0, _ := ret[0].(*v1beta1.CertificateSigningRequest)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// UpdateApproval indicates an expected call of UpdateApproval
+func (mr *MockCertificateSigningRequestInterfaceMockRecorder) UpdateApproval(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateApproval", reflect.TypeOf((*MockCertificateSigningRequestInterface)(nil).UpdateApproval), arg0)
+}
+
+// UpdateStatus mocks base method
+func (m *MockCertificateSigningRequestInterface) UpdateStatus(arg0 *v1beta1.CertificateSigningRequest) (*v1beta1.CertificateSigningRequest, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateStatus", arg0)
+	ret0, _ := ret[0].(*v1beta1.CertificateSigningRequest)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// UpdateStatus indicates an expected call of UpdateStatus
+func (mr *MockCertificateSigningRequestInterfaceMockRecorder) UpdateStatus(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateStatus", reflect.TypeOf((*MockCertificateSigningRequestInterface)(nil).UpdateStatus), arg0)
+}
+
+// Watch mocks base method
+func (m *MockCertificateSigningRequestInterface) Watch(arg0 v1.ListOptions) (watch.Interface, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Watch", arg0)
+	ret0, _ := ret[0].(watch.Interface)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Watch indicates an expected call of Watch
+func (mr *MockCertificateSigningRequestInterfaceMockRecorder) Watch(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Watch", reflect.TypeOf((*MockCertificateSigningRequestInterface)(nil).Watch), arg0)
+}
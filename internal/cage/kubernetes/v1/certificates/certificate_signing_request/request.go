@@ -0,0 +1,47 @@
+// Copyright (C) 2020 The CodeActual Go Environment Authors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package certificate_signing_request
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509/pkix"
+
+	"github.com/pkg/errors"
+	certs "k8s.io/api/certificates/v1beta1"
+	"k8s.io/client-go/util/cert"
+	"k8s.io/client-go/util/keyutil"
+)
+
+// ClientAuthUsages are the key usages requested for certificates which authenticate users.
+var ClientAuthUsages = []certs.KeyUsage{certs.UsageDigitalSignature, certs.UsageKeyEncipherment, certs.UsageClientAuth}
+
+// NewClientRequest generates a private key and a certificate request for it.
+//
+// The API server authenticates the certificate's holder as the user named by the common name,
+// and as a member of the groups named by the organizations.
+//
+// Both return values are PEM-encoded.
+func NewClientRequest(commonName string, organizations []string) (key, request []byte, _ error) {
+	privateKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "failed to generate private key")
+	}
+
+	key, err = keyutil.MarshalPrivateKeyToPEM(privateKey)
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "failed to encode private key")
+	}
+
+	request, err = cert.MakeCSR(privateKey, &pkix.Name{CommonName: commonName, Organization: organizations}, nil, nil)
+	if err != nil {
+		return nil, nil, errors.Wrapf(err, "failed to create certificate request for [%s]", commonName)
+	}
+
+	return key, request, nil
+}
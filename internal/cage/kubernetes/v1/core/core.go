@@ -9,7 +9,16 @@ package core
 import (
 	"k8s.io/client-go/kubernetes"
 
+	cage_k8s_daemon_set "github.com/codeactual/kubeauth/internal/cage/kubernetes/v1/apps/daemon_set"
+	cage_k8s_deployment "github.com/codeactual/kubeauth/internal/cage/kubernetes/v1/apps/deployment"
+	cage_k8s_stateful_set "github.com/codeactual/kubeauth/internal/cage/kubernetes/v1/apps/stateful_set"
+	cage_k8s_cron_job "github.com/codeactual/kubeauth/internal/cage/kubernetes/v1/batch/cron_job"
+	cage_k8s_job "github.com/codeactual/kubeauth/internal/cage/kubernetes/v1/batch/job"
+	cage_k8s_csr "github.com/codeactual/kubeauth/internal/cage/kubernetes/v1/certificates/certificate_signing_request"
+	cage_k8s_discovery "github.com/codeactual/kubeauth/internal/cage/kubernetes/v1/discovery"
 	cage_k8s_namespace "github.com/codeactual/kubeauth/internal/cage/kubernetes/v1/namespace"
+	cage_k8s_node "github.com/codeactual/kubeauth/internal/cage/kubernetes/v1/node"
+	cage_k8s_pod "github.com/codeactual/kubeauth/internal/cage/kubernetes/v1/pod"
 	cage_k8s_cluster_role "github.com/codeactual/kubeauth/internal/cage/kubernetes/v1/rbac/cluster_role"
 	cage_k8s_cluster_role_binding "github.com/codeactual/kubeauth/internal/cage/kubernetes/v1/rbac/cluster_role_binding"
 	cage_k8s_role "github.com/codeactual/kubeauth/internal/cage/kubernetes/v1/rbac/role"
@@ -22,23 +31,41 @@ import (
 //
 // Its naming is modeled after k8s.io/client-go/kubernetes.Clientset.
 type Clientset struct {
-	ClusterRoles        cage_k8s_cluster_role.Client
-	ClusterRoleBindings cage_k8s_cluster_role_binding.Client
-	Namespaces          cage_k8s_namespace.Client
-	Roles               cage_k8s_role.Client
-	RoleBindings        cage_k8s_role_binding.Client
-	Secrets             cage_k8s_secret.Client
-	ServiceAccounts     cage_k8s_sa.Client
+	CertificateSigningRequests cage_k8s_csr.Client
+	ClusterRoles               cage_k8s_cluster_role.Client
+	ClusterRoleBindings        cage_k8s_cluster_role_binding.Client
+	CronJobs                   cage_k8s_cron_job.Client
+	DaemonSets                 cage_k8s_daemon_set.Client
+	Deployments                cage_k8s_deployment.Client
+	Discovery                  cage_k8s_discovery.Client
+	Jobs                       cage_k8s_job.Client
+	Namespaces                 cage_k8s_namespace.Client
+	Nodes                      cage_k8s_node.Client
+	Pods                       cage_k8s_pod.Client
+	Roles                      cage_k8s_role.Client
+	RoleBindings               cage_k8s_role_binding.Client
+	Secrets                    cage_k8s_secret.Client
+	ServiceAccounts            cage_k8s_sa.Client
+	StatefulSets               cage_k8s_stateful_set.Client
 }
 
 func NewClientset(all kubernetes.Interface) *Clientset {
 	return &Clientset{
-		ClusterRoles:        cage_k8s_cluster_role.NewDefaultClient(all.RbacV1()),
-		ClusterRoleBindings: cage_k8s_cluster_role_binding.NewDefaultClient(all.RbacV1()),
-		Namespaces:          cage_k8s_namespace.NewDefaultClient(all.CoreV1()),
-		Roles:               cage_k8s_role.NewDefaultClient(all.RbacV1()),
-		RoleBindings:        cage_k8s_role_binding.NewDefaultClient(all.RbacV1()),
-		Secrets:             cage_k8s_secret.NewDefaultClient(all.CoreV1()),
-		ServiceAccounts:     cage_k8s_sa.NewDefaultClient(all.CoreV1()),
+		CertificateSigningRequests: cage_k8s_csr.NewDefaultClient(all.CertificatesV1beta1()),
+		ClusterRoles:               cage_k8s_cluster_role.NewDefaultClient(all.RbacV1()),
+		ClusterRoleBindings:        cage_k8s_cluster_role_binding.NewDefaultClient(all.RbacV1()),
+		CronJobs:                   cage_k8s_cron_job.NewDefaultClient(all.BatchV1beta1()),
+		DaemonSets:                 cage_k8s_daemon_set.NewDefaultClient(all.AppsV1()),
+		Deployments:                cage_k8s_deployment.NewDefaultClient(all.AppsV1()),
+		Discovery:                  cage_k8s_discovery.NewDefaultClient(all.Discovery()),
+		Jobs:                       cage_k8s_job.NewDefaultClient(all.BatchV1()),
+		Namespaces:                 cage_k8s_namespace.NewDefaultClient(all.CoreV1()),
+		Nodes:                      cage_k8s_node.NewDefaultClient(all.CoreV1()),
+		Pods:                       cage_k8s_pod.NewDefaultClient(all.CoreV1()),
+		Roles:                      cage_k8s_role.NewDefaultClient(all.RbacV1()),
+		RoleBindings:               cage_k8s_role_binding.NewDefaultClient(all.RbacV1()),
+		Secrets:                    cage_k8s_secret.NewDefaultClient(all.CoreV1()),
+		ServiceAccounts:            cage_k8s_sa.NewDefaultClient(all.CoreV1()),
+		StatefulSets:               cage_k8s_stateful_set.NewDefaultClient(all.AppsV1()),
 	}
 }
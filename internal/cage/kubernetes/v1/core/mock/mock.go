@@ -9,8 +9,17 @@ package mock
 import (
 	"github.com/golang/mock/gomock"
 
+	mock_daemon_set "github.com/codeactual/kubeauth/internal/cage/kubernetes/v1/apps/daemon_set/mock"
+	mock_deployment "github.com/codeactual/kubeauth/internal/cage/kubernetes/v1/apps/deployment/mock"
+	mock_stateful_set "github.com/codeactual/kubeauth/internal/cage/kubernetes/v1/apps/stateful_set/mock"
+	mock_cron_job "github.com/codeactual/kubeauth/internal/cage/kubernetes/v1/batch/cron_job/mock"
+	mock_job "github.com/codeactual/kubeauth/internal/cage/kubernetes/v1/batch/job/mock"
+	mock_csr "github.com/codeactual/kubeauth/internal/cage/kubernetes/v1/certificates/certificate_signing_request/mock"
 	cage_k8s_core "github.com/codeactual/kubeauth/internal/cage/kubernetes/v1/core"
+	mock_discovery "github.com/codeactual/kubeauth/internal/cage/kubernetes/v1/discovery/mock"
 	mock_namespace "github.com/codeactual/kubeauth/internal/cage/kubernetes/v1/namespace/mock"
+	mock_node "github.com/codeactual/kubeauth/internal/cage/kubernetes/v1/node/mock"
+	mock_pod "github.com/codeactual/kubeauth/internal/cage/kubernetes/v1/pod/mock"
 	mock_cluster_role "github.com/codeactual/kubeauth/internal/cage/kubernetes/v1/rbac/cluster_role/mock"
 	mock_cluster_role_binding "github.com/codeactual/kubeauth/internal/cage/kubernetes/v1/rbac/cluster_role_binding/mock"
 	mock_role "github.com/codeactual/kubeauth/internal/cage/kubernetes/v1/rbac/role/mock"
@@ -21,36 +30,63 @@ import (
 
 // Clientset fields mirror the non-mock Clientset so the latter's values can be replaced.
 type Clientset struct {
-	ClusterRoles        *mock_cluster_role.MockClient
-	ClusterRoleBindings *mock_cluster_role_binding.MockClient
-	Namespaces          *mock_namespace.MockClient
-	Roles               *mock_role.MockClient
-	RoleBindings        *mock_role_binding.MockClient
-	Secrets             *mock_secret.MockClient
-	ServiceAccounts     *mock_service_account.MockClient
+	CertificateSigningRequests *mock_csr.MockClient
+	ClusterRoles               *mock_cluster_role.MockClient
+	ClusterRoleBindings        *mock_cluster_role_binding.MockClient
+	CronJobs                   *mock_cron_job.MockClient
+	DaemonSets                 *mock_daemon_set.MockClient
+	Deployments                *mock_deployment.MockClient
+	Discovery                  *mock_discovery.MockClient
+	Jobs                       *mock_job.MockClient
+	Namespaces                 *mock_namespace.MockClient
+	Nodes                      *mock_node.MockClient
+	Pods                       *mock_pod.MockClient
+	Roles                      *mock_role.MockClient
+	RoleBindings               *mock_role_binding.MockClient
+	Secrets                    *mock_secret.MockClient
+	ServiceAccounts            *mock_service_account.MockClient
+	StatefulSets               *mock_stateful_set.MockClient
 }
 
 func (c *Clientset) ToReal() *cage_k8s_core.Clientset {
 	return &cage_k8s_core.Clientset{
-		ClusterRoles:        c.ClusterRoles,
-		ClusterRoleBindings: c.ClusterRoleBindings,
-		Namespaces:          c.Namespaces,
-		Roles:               c.Roles,
-		RoleBindings:        c.RoleBindings,
-		Secrets:             c.Secrets,
-		ServiceAccounts:     c.ServiceAccounts,
+		CertificateSigningRequests: c.CertificateSigningRequests,
+		ClusterRoles:               c.ClusterRoles,
+		ClusterRoleBindings:        c.ClusterRoleBindings,
+		CronJobs:                   c.CronJobs,
+		DaemonSets:                 c.DaemonSets,
+		Deployments:                c.Deployments,
+		Discovery:                  c.Discovery,
+		Jobs:                       c.Jobs,
+		Namespaces:                 c.Namespaces,
+		Nodes:                      c.Nodes,
+		Pods:                       c.Pods,
+		Roles:                      c.Roles,
+		RoleBindings:               c.RoleBindings,
+		Secrets:                    c.Secrets,
+		ServiceAccounts:            c.ServiceAccounts,
+		StatefulSets:               c.StatefulSets,
 	}
 }
 
 func NewClientset(ctrl *gomock.Controller) *Clientset {
 	return &Clientset{
-		ClusterRoles:        mock_cluster_role.NewMockClient(ctrl),
-		ClusterRoleBindings: mock_cluster_role_binding.NewMockClient(ctrl),
-		Namespaces:          mock_namespace.NewMockClient(ctrl),
-		Roles:               mock_role.NewMockClient(ctrl),
-		RoleBindings:        mock_role_binding.NewMockClient(ctrl),
-		Secrets:             mock_secret.NewMockClient(ctrl),
-		ServiceAccounts:     mock_service_account.NewMockClient(ctrl),
+		CertificateSigningRequests: mock_csr.NewMockClient(ctrl),
+		ClusterRoles:               mock_cluster_role.NewMockClient(ctrl),
+		ClusterRoleBindings:        mock_cluster_role_binding.NewMockClient(ctrl),
+		CronJobs:                   mock_cron_job.NewMockClient(ctrl),
+		DaemonSets:                 mock_daemon_set.NewMockClient(ctrl),
+		Deployments:                mock_deployment.NewMockClient(ctrl),
+		Discovery:                  mock_discovery.NewMockClient(ctrl),
+		Jobs:                       mock_job.NewMockClient(ctrl),
+		Namespaces:                 mock_namespace.NewMockClient(ctrl),
+		Nodes:                      mock_node.NewMockClient(ctrl),
+		Pods:                       mock_pod.NewMockClient(ctrl),
+		Roles:                      mock_role.NewMockClient(ctrl),
+		RoleBindings:               mock_role_binding.NewMockClient(ctrl),
+		Secrets:                    mock_secret.NewMockClient(ctrl),
+		ServiceAccounts:            mock_service_account.NewMockClient(ctrl),
+		StatefulSets:               mock_stateful_set.NewMockClient(ctrl),
 	}
 }
 
@@ -62,13 +98,22 @@ func (m *matchClientset) Matches(x interface{}) bool {
 	actual, ok := x.(*cage_k8s_core.Clientset)
 
 	return ok && actual != nil &&
+		gomock.Eq(m.expected.CertificateSigningRequests).Matches(actual.CertificateSigningRequests) &&
 		gomock.Eq(m.expected.ClusterRoles).Matches(actual.ClusterRoles) &&
 		gomock.Eq(m.expected.ClusterRoleBindings).Matches(actual.ClusterRoleBindings) &&
+		gomock.Eq(m.expected.CronJobs).Matches(actual.CronJobs) &&
+		gomock.Eq(m.expected.DaemonSets).Matches(actual.DaemonSets) &&
+		gomock.Eq(m.expected.Deployments).Matches(actual.Deployments) &&
+		gomock.Eq(m.expected.Discovery).Matches(actual.Discovery) &&
+		gomock.Eq(m.expected.Jobs).Matches(actual.Jobs) &&
 		gomock.Eq(m.expected.Namespaces).Matches(actual.Namespaces) &&
+		gomock.Eq(m.expected.Nodes).Matches(actual.Nodes) &&
+		gomock.Eq(m.expected.Pods).Matches(actual.Pods) &&
 		gomock.Eq(m.expected.Roles).Matches(actual.Roles) &&
 		gomock.Eq(m.expected.RoleBindings).Matches(actual.RoleBindings) &&
 		gomock.Eq(m.expected.Secrets).Matches(actual.Secrets) &&
-		gomock.Eq(m.expected.ServiceAccounts).Matches(actual.ServiceAccounts)
+		gomock.Eq(m.expected.ServiceAccounts).Matches(actual.ServiceAccounts) &&
+		gomock.Eq(m.expected.StatefulSets).Matches(actual.StatefulSets)
 }
 
 func (m *matchClientset) String() string {
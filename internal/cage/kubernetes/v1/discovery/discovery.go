@@ -0,0 +1,294 @@
+// Copyright (C) 2020 The CodeActual Go Environment Authors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at https://mozilla.org/MPL/2.0/.
+
+//go:generate mockgen -copyright_file=$LICENSE_HEADER -package=mock -destination=$GODIR/mock/wrapper.go -source=$GODIR/$GOFILE
+package discovery
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	rbac "k8s.io/api/rbac/v1"
+	meta "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/version"
+	k8s_discovery "k8s.io/client-go/discovery"
+
+	"github.com/pkg/errors"
+)
+
+// virtualResources holds, by group, resources which rules may refer to but the API does not serve,
+// e.g. the targets of the impersonate verb.
+var virtualResources = map[string][]string{
+	"":                    {"groups", "uids", "userextras", "users"},
+	"certificates.k8s.io": {"signers"},
+}
+
+// virtualVerbs holds verbs which rules may grant but discovery does not report, because they are
+// checked by authorizers rather than served as requests, e.g. bind and escalate on roles.
+var virtualVerbs = []string{"approve", "attest", "bind", "escalate", "impersonate", "sign", "use"}
+
+// Client provides an interface to the API discovery endpoints.
+type Client interface {
+	Resources() (*Resources, error)
+	Version() (*Version, error)
+}
+
+// ServerInterface is the subset of k8s.io/client-go/discovery.DiscoveryInterface used by DefaultClient.
+type ServerInterface interface {
+	k8s_discovery.ServerResourcesInterface
+	k8s_discovery.ServerVersionInterface
+}
+
+// DefaultClient implementation of Client operates on a real kubernetes API.
+type DefaultClient struct {
+	ServerInterface
+}
+
+// NewDefaultClient returns an initialized DefaultClient.
+func NewDefaultClient(iface ServerInterface) *DefaultClient {
+	return &DefaultClient{ServerInterface: iface}
+}
+
+// Version returns the API server's release.
+//
+// It implements Client.
+func (c *DefaultClient) Version() (*Version, error) {
+	info, err := c.ServerVersion()
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to discover API server version")
+	}
+
+	v, err := ParseVersion(info)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	return v, nil
+}
+
+// Version is an API server release, e.g. 1.17.
+type Version struct {
+	Major int
+	Minor int
+}
+
+// String returns the version in <major>.<minor> format.
+func (v Version) String() string {
+	return fmt.Sprintf("%d.%d", v.Major, v.Minor)
+}
+
+// AtLeast reports whether the version is the same as, or newer than, the <major>.<minor> release.
+func (v Version) AtLeast(major, minor int) bool {
+	return v.Major > major || (v.Major == major && v.Minor >= minor)
+}
+
+var versionDigits = regexp.MustCompile(`^v?(\d+)\.(\d+)`)
+
+// ParseVersion returns the release described by the discovered version.
+//
+// Provider-specific suffixes are ignored, e.g. the "+" of minor version "17+". If the major or minor
+// version is missing, they are read from the git version, e.g. "v1.17.3".
+func ParseVersion(info *version.Info) (*Version, error) {
+	if info == nil {
+		return nil, errors.New("API server version is missing")
+	}
+
+	major, minor := strings.TrimRight(info.Major, "+"), strings.TrimRight(info.Minor, "+")
+	if major == "" || minor == "" {
+		m := versionDigits.FindStringSubmatch(info.GitVersion)
+		if m == nil {
+			return nil, errors.Errorf("failed to parse API server version [%s]", info.GitVersion)
+		}
+		major, minor = m[1], m[2]
+	}
+
+	var v Version
+	var err error
+	if v.Major, err = strconv.Atoi(major); err != nil {
+		return nil, errors.Wrapf(err, "failed to parse API server major version [%s]", info.Major)
+	}
+	if v.Minor, err = strconv.Atoi(minor); err != nil {
+		return nil, errors.Wrapf(err, "failed to parse API server minor version [%s]", info.Minor)
+	}
+
+	return &v, nil
+}
+
+// Resources returns the resources served by the API in all groups and versions.
+//
+// If only some groups could not be queried, e.g. due to an unavailable aggregated API,
+// the partial results are returned and the groups are listed in Resources.FailedGroups.
+//
+// It implements Client.
+func (c *DefaultClient) Resources() (*Resources, error) {
+	_, lists, err := c.ServerGroupsAndResources()
+	if err != nil {
+		if failedErr, ok := err.(*k8s_discovery.ErrGroupDiscoveryFailed); ok {
+			resources := NewResources(lists)
+			for gv := range failedErr.Groups {
+				resources.FailedGroups[gv.Group] = true
+			}
+			return resources, nil
+		}
+		return nil, errors.Wrap(err, "failed to discover API resources")
+	}
+
+	return NewResources(lists), nil
+}
+
+// Resources indexes discovered API resources by group and name.
+type Resources struct {
+	// FailedGroups holds the names of groups whose resources could not be discovered.
+	FailedGroups map[string]bool
+
+	// byGroup maps group names, with an empty name for the core group, to resource names,
+	// including subresources such as "pods/exec", to their descriptions.
+	byGroup map[string]map[string]meta.APIResource
+}
+
+// NewResources returns an index of the resources, e.g. from a ServerGroupsAndResources query.
+//
+// If a resource is served by multiple versions of a group, their verbs are combined.
+func NewResources(lists []*meta.APIResourceList) *Resources {
+	r := &Resources{
+		FailedGroups: map[string]bool{},
+		byGroup:      map[string]map[string]meta.APIResource{},
+	}
+
+	for _, list := range lists {
+		if list == nil {
+			continue
+		}
+
+		gv, err := schema.ParseGroupVersion(list.GroupVersion)
+		if err != nil {
+			continue
+		}
+
+		if r.byGroup[gv.Group] == nil {
+			r.byGroup[gv.Group] = map[string]meta.APIResource{}
+		}
+
+		for _, res := range list.APIResources {
+			existing, ok := r.byGroup[gv.Group][res.Name]
+			if !ok {
+				r.byGroup[gv.Group][res.Name] = res
+				continue
+			}
+
+			// Copy the verbs to avoid modifying the input list.
+			existing.Verbs = append(meta.Verbs{}, existing.Verbs...)
+			for _, v := range res.Verbs {
+				if !hasString(existing.Verbs, v) {
+					existing.Verbs = append(existing.Verbs, v)
+				}
+			}
+			r.byGroup[gv.Group][res.Name] = existing
+		}
+	}
+
+	return r
+}
+
+// HasGroup reports whether the API serves the group. The core group has an empty name.
+func (r *Resources) HasGroup(group string) bool {
+	_, ok := r.byGroup[group]
+	return ok
+}
+
+// Get returns the resource, or subresource in "<resource>/<subresource>" format, if the group serves it.
+func (r *Resources) Get(group, resource string) (_ meta.APIResource, found bool) {
+	res, ok := r.byGroup[group][resource]
+	return res, ok
+}
+
+// GroupsOf returns the sorted names of the groups which serve the resource, e.g. to suggest
+// corrections for a resource selected in the wrong group.
+func (r *Resources) GroupsOf(resource string) (groups []string) {
+	for group, resources := range r.byGroup {
+		if _, ok := resources[resource]; ok {
+			groups = append(groups, group)
+		}
+	}
+	sort.Strings(groups)
+	return groups
+}
+
+// Names returns the sorted names of the resources served by the group.
+func (r *Resources) Names(group string) (names []string) {
+	for name := range r.byGroup[group] {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// CheckRule returns descriptions of the API groups, resources, and verbs selected by the rule
+// which the API does not serve, e.g. due to a misspelled resource.
+//
+// Wildcards, non-resource URLs, and groups whose discovery failed are not checked.
+func (r *Resources) CheckRule(rule rbac.PolicyRule) (problems []string) {
+	for _, group := range rule.APIGroups {
+		if group == "*" || r.FailedGroups[group] {
+			continue
+		}
+
+		if !r.HasGroup(group) && virtualResources[group] == nil {
+			problems = append(problems, fmt.Sprintf("API group [%s] is not served", group))
+			continue
+		}
+
+		for _, resource := range rule.Resources {
+			if strings.Contains(resource, "*") || hasString(virtualResources[group], strings.SplitN(resource, "/", 2)[0]) {
+				continue
+			}
+
+			res, found := r.Get(group, resource)
+			if !found {
+				msg := fmt.Sprintf("resource [%s] is not served by API group [%s]", resource, group)
+				if _, plural := r.Get(group, resource+"s"); plural {
+					msg += fmt.Sprintf(" (did you mean [%s]?)", resource+"s")
+				} else if groups := r.GroupsOf(resource); len(groups) > 0 {
+					msg += fmt.Sprintf(" (served by API group(s) %q)", groups)
+				}
+				problems = append(problems, msg)
+				continue
+			}
+
+			for _, verb := range rule.Verbs {
+				if hasString(virtualVerbs, verb) || HasVerb(res, verb) {
+					continue
+				}
+				problems = append(problems, fmt.Sprintf("verb [%s] is not supported by resource [%s] in API group [%s]", verb, resource, group))
+			}
+		}
+	}
+
+	return problems
+}
+
+// HasVerb reports whether the resource supports the verb, or any verb if it is "*".
+func HasVerb(res meta.APIResource, verb string) bool {
+	if verb == "*" {
+		return len(res.Verbs) > 0
+	}
+	return hasString(res.Verbs, verb)
+}
+
+func hasString(list []string, s string) bool {
+	for _, l := range list {
+		if l == s {
+			return true
+		}
+	}
+	return false
+}
+
+var _ Client = (*DefaultClient)(nil)
@@ -0,0 +1,191 @@
+// Copyright (C) 2020 The CodeActual Go Environment Authors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package discovery_test
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+	rbac "k8s.io/api/rbac/v1"
+	meta "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/version"
+	k8s_discovery "k8s.io/client-go/discovery"
+
+	"github.com/codeactual/kubeauth/internal/cage/kubernetes/v1/discovery"
+	mock_discovery "github.com/codeactual/kubeauth/internal/cage/kubernetes/v1/discovery/mock"
+	cage_require "github.com/codeactual/kubeauth/internal/cage/testkit/testify/require"
+)
+
+func newLists() []*meta.APIResourceList {
+	return []*meta.APIResourceList{
+		{
+			GroupVersion: "v1",
+			APIResources: []meta.APIResource{
+				{Name: "pods", Namespaced: true, Kind: "Pod", Verbs: []string{"get", "list"}},
+				{Name: "pods/exec", Namespaced: true, Kind: "PodExecOptions", Verbs: []string{"create"}},
+				{Name: "nodes", Kind: "Node", Verbs: []string{"get"}},
+			},
+		},
+		{
+			GroupVersion: "apps/v1",
+			APIResources: []meta.APIResource{
+				{Name: "deployments", Namespaced: true, Kind: "Deployment", Verbs: []string{"get"}},
+			},
+		},
+		{
+			GroupVersion: "apps/v1beta1",
+			APIResources: []meta.APIResource{
+				{Name: "deployments", Namespaced: true, Kind: "Deployment", Verbs: []string{"get", "patch"}},
+			},
+		},
+	}
+}
+
+func TestResources(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	mockInterface := mock_discovery.NewMockServerInterface(mockCtrl)
+	mockInterface.EXPECT().ServerGroupsAndResources().Return(nil, newLists(), nil)
+
+	resources, err := discovery.NewDefaultClient(mockInterface).Resources()
+	require.NoError(t, err)
+	require.Empty(t, resources.FailedGroups)
+
+	require.True(t, resources.HasGroup(""))
+	require.True(t, resources.HasGroup("apps"))
+	require.False(t, resources.HasGroup("batch"))
+
+	res, found := resources.Get("", "pods/exec")
+	require.True(t, found)
+	require.True(t, discovery.HasVerb(res, "create"))
+	require.False(t, discovery.HasVerb(res, "get"))
+	require.True(t, discovery.HasVerb(res, "*"))
+
+	// Verbs from all versions are combined.
+	res, found = resources.Get("apps", "deployments")
+	require.True(t, found)
+	require.Exactly(t, meta.Verbs{"get", "patch"}, res.Verbs)
+
+	_, found = resources.Get("", "deployments")
+	require.False(t, found)
+	require.Exactly(t, []string{"apps"}, resources.GroupsOf("deployments"))
+
+	require.Exactly(t, []string{"nodes", "pods", "pods/exec"}, resources.Names(""))
+}
+
+func TestResourcesPartial(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	failedErr := &k8s_discovery.ErrGroupDiscoveryFailed{
+		Groups: map[schema.GroupVersion]error{{Group: "metrics.k8s.io", Version: "v1beta1"}: errors.New("unavailable")},
+	}
+
+	mockInterface := mock_discovery.NewMockServerInterface(mockCtrl)
+	mockInterface.EXPECT().ServerGroupsAndResources().Return(nil, newLists(), failedErr)
+
+	resources, err := discovery.NewDefaultClient(mockInterface).Resources()
+	require.NoError(t, err)
+	require.Exactly(t, map[string]bool{"metrics.k8s.io": true}, resources.FailedGroups)
+	require.True(t, resources.HasGroup("apps"))
+}
+
+func TestResourcesError(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	mockInterface := mock_discovery.NewMockServerInterface(mockCtrl)
+	mockInterface.EXPECT().ServerGroupsAndResources().Return(nil, nil, errors.New("expectErr"))
+
+	resources, err := discovery.NewDefaultClient(mockInterface).Resources()
+	cage_require.MatchRegexp(t, fmt.Sprintf("%v", err), "failed to discover API resources.*expectErr")
+	require.Nil(t, resources)
+}
+
+func TestCheckRule(t *testing.T) {
+	resources := discovery.NewResources(newLists())
+
+	// Served, wildcard, non-resource, and virtual selections.
+	for _, rule := range []rbac.PolicyRule{
+		{Verbs: []string{"get", "list"}, APIGroups: []string{""}, Resources: []string{"pods"}},
+		{Verbs: []string{"*"}, APIGroups: []string{"apps"}, Resources: []string{"deployments"}},
+		{Verbs: []string{"get"}, APIGroups: []string{"*"}, Resources: []string{"*"}},
+		{Verbs: []string{"get"}, APIGroups: []string{""}, Resources: []string{"pods/*"}},
+		{Verbs: []string{"get"}, NonResourceURLs: []string{"/healthz"}},
+		{Verbs: []string{"impersonate"}, APIGroups: []string{""}, Resources: []string{"users", "userextras/scopes"}},
+	} {
+		require.Empty(t, resources.CheckRule(rule), fmt.Sprintf("%+v", rule))
+	}
+
+	require.Exactly(
+		t,
+		[]string{
+			"API group [batch] is not served",
+			"resource [deployment] is not served by API group [apps] (did you mean [deployments]?)",
+			"verb [delete] is not supported by resource [deployments] in API group [apps]",
+		},
+		resources.CheckRule(rbac.PolicyRule{
+			Verbs:     []string{"get", "delete"},
+			APIGroups: []string{"batch", "apps"},
+			Resources: []string{"deployment", "deployments"},
+		}),
+	)
+
+	require.Exactly(
+		t,
+		[]string{`resource [deployments] is not served by API group [] (served by API group(s) ["apps"])`},
+		resources.CheckRule(rbac.PolicyRule{Verbs: []string{"get"}, APIGroups: []string{""}, Resources: []string{"deployments"}}),
+	)
+
+	// Groups whose discovery failed are not checked.
+	resources.FailedGroups["metrics.k8s.io"] = true
+	require.Empty(t, resources.CheckRule(rbac.PolicyRule{Verbs: []string{"get"}, APIGroups: []string{"metrics.k8s.io"}, Resources: []string{"pods"}}))
+}
+
+func TestVersion(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	mockInterface := mock_discovery.NewMockServerInterface(mockCtrl)
+	mockInterface.EXPECT().ServerVersion().Return(&version.Info{Major: "1", Minor: "17+", GitVersion: "v1.17.3-gke.1"}, nil)
+
+	v, err := discovery.NewDefaultClient(mockInterface).Version()
+	require.NoError(t, err)
+	require.Exactly(t, "1.17", v.String())
+	require.True(t, v.AtLeast(1, 17))
+	require.True(t, v.AtLeast(1, 6))
+	require.False(t, v.AtLeast(1, 18))
+}
+
+func TestVersionError(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	mockInterface := mock_discovery.NewMockServerInterface(mockCtrl)
+	mockInterface.EXPECT().ServerVersion().Return(nil, errors.New("expectErr"))
+
+	v, err := discovery.NewDefaultClient(mockInterface).Version()
+	cage_require.MatchRegexp(t, fmt.Sprintf("%v", err), "failed to discover API server version.*expectErr")
+	require.Nil(t, v)
+}
+
+func TestParseVersion(t *testing.T) {
+	v, err := discovery.ParseVersion(&version.Info{GitVersion: "v1.22.1"})
+	require.NoError(t, err)
+	require.Exactly(t, discovery.Version{Major: 1, Minor: 22}, *v)
+
+	_, err = discovery.ParseVersion(&version.Info{GitVersion: "unknown"})
+	require.EqualError(t, err, "failed to parse API server version [unknown]")
+
+	_, err = discovery.ParseVersion(&version.Info{Major: "1", Minor: "x"})
+	cage_require.MatchRegexp(t, fmt.Sprintf("%v", err), `failed to parse API server minor version \[x\]`)
+}
@@ -0,0 +1,186 @@
+// Copyright (C) 2020 The CodeActual Go Environment Authors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at https://mozilla.org/MPL/2.0/.
+//
+
+// Code generated by MockGen. DO NOT EDIT.
+
+// Package mock is a generated GoMock package.
+package mock
+
+import (
+	discovery "github.com/codeactual/kubeauth/internal/cage/kubernetes/v1/discovery"
+	gomock "github.com/golang/mock/gomock"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	version "k8s.io/apimachinery/pkg/version"
+	reflect "reflect"
+)
+
+// MockClient is a mock of Client interface
+type MockClient struct {
+	ctrl     *gomock.Controller
+	recorder *MockClientMockRecorder
+}
+
+// MockClientMockRecorder is the mock recorder for MockClient
+type MockClientMockRecorder struct {
+	mock *MockClient
+}
+
+// NewMockClient creates a new mock instance
+func NewMockClient(ctrl *gomock.Controller) *MockClient {
+	mock := &MockClient{ctrl: ctrl}
+	mock.recorder = &MockClientMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use
+func (m *MockClient) EXPECT() *MockClientMockRecorder {
+	return m.recorder
+}
+
+// Resources mocks base method
+func (m *MockClient) Resources() (*discovery.Resources, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Resources")
+	ret0, _ := ret[0].(*discovery.Resources)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Resources indicates an expected call of Resources
+func (mr *MockClientMockRecorder) Resources() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Resources", reflect.TypeOf((*MockClient)(nil).Resources))
+}
+
+// Version mocks base method
+func (m *MockClient) Version() (*discovery.Version, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Version")
+	ret0, _ := ret[0].(*discovery.Version)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Version indicates an expected call of Version
+func (mr *MockClientMockRecorder) Version() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Version", reflect.TypeOf((*MockClient)(nil).Version))
+}
+
+// MockServerInterface is a mock of ServerInterface interface
+type MockServerInterface struct {
+	ctrl     *gomock.Controller
+	recorder *MockServerInterfaceMockRecorder
+}
+
+// MockServerInterfaceMockRecorder is the mock recorder for MockServerInterface
+type MockServerInterfaceMockRecorder struct {
+	mock *MockServerInterface
+}
+
+// NewMockServerInterface creates a new mock instance
+func NewMockServerInterface(ctrl *gomock.Controller) *MockServerInterface {
+	mock := &MockServerInterface{ctrl: ctrl}
+	mock.recorder = &MockServerInterfaceMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use
+func (m *MockServerInterface) EXPECT() *MockServerInterfaceMockRecorder {
+	return m.recorder
+}
+
+// ServerGroupsAndResources mocks base method
+func (m *MockServerInterface) ServerGroupsAndResources() ([]*v1.APIGroup, []*v1.APIResourceList, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ServerGroupsAndResources")
+	ret0, _ := ret[0].([]*v1.APIGroup)
+	ret1, _ := ret[1].([]*v1.APIResourceList)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// ServerGroupsAndResources indicates an expected call of ServerGroupsAndResources
+func (mr *MockServerInterfaceMockRecorder) ServerGroupsAndResources() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ServerGroupsAndResources", reflect.TypeOf((*MockServerInterface)(nil).ServerGroupsAndResources))
+}
+
+// ServerPreferredNamespacedResources mocks base method
+func (m *MockServerInterface) ServerPreferredNamespacedResources() ([]*v1.APIResourceList, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ServerPreferredNamespacedResources")
+	ret0, _ := ret[0].([]*v1.APIResourceList)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ServerPreferredNamespacedResources indicates an expected call of ServerPreferredNamespacedResources
+func (mr *MockServerInterfaceMockRecorder) ServerPreferredNamespacedResources() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ServerPreferredNamespacedResources", reflect.TypeOf((*MockServerInterface)(nil).ServerPreferredNamespacedResources))
+}
+
+// ServerPreferredResources mocks base method
+func (m *MockServerInterface) ServerPreferredResources() ([]*v1.APIResourceList, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ServerPreferredResources")
+	ret0, _ := ret[0].([]*v1.APIResourceList)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ServerPreferredResources indicates an expected call of ServerPreferredResources
+func (mr *MockServerInterfaceMockRecorder) ServerPreferredResources() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ServerPreferredResources", reflect.TypeOf((*MockServerInterface)(nil).ServerPreferredResources))
+}
+
+// ServerResources mocks base method
+func (m *MockServerInterface) ServerResources() ([]*v1.APIResourceList, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ServerResources")
+	ret0, _ := ret[0].([]*v1.APIResourceList)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ServerResources indicates an expected call of ServerResources
+func (mr *MockServerInterfaceMockRecorder) ServerResources() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ServerResources", reflect.TypeOf((*MockServerInterface)(nil).ServerResources))
+}
+
+// ServerResourcesForGroupVersion mocks base method
+func (m *MockServerInterface) ServerResourcesForGroupVersion(arg0 string) (*v1.APIResourceList, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ServerResourcesForGroupVersion", arg0)
+	ret0, _ := ret[0].(*v1.APIResourceList)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ServerResourcesForGroupVersion indicates an expected call of ServerResourcesForGroupVersion
+func (mr *MockServerInterfaceMockRecorder) ServerResourcesForGroupVersion(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ServerResourcesForGroupVersion", reflect.TypeOf((*MockServerInterface)(nil).ServerResourcesForGroupVersion), arg0)
+}
+
+// ServerVersion mocks base method
+func (m *MockServerInterface) ServerVersion() (*version.Info, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ServerVersion")
+	ret0, _ := ret[0].(*version.Info)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ServerVersion indicates an expected call of ServerVersion
+func (mr *MockServerInterfaceMockRecorder) ServerVersion() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ServerVersion", reflect.TypeOf((*MockServerInterface)(nil).ServerVersion))
+}
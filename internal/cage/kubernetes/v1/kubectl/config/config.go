@@ -9,7 +9,10 @@ package config
 
 import (
 	"context"
+	"io/ioutil"
+	"path/filepath"
 	"strings"
+	"time"
 
 	"github.com/pkg/errors"
 
@@ -18,13 +21,28 @@ import (
 	clientcmdapi "k8s.io/client-go/tools/clientcmd/api"
 
 	cage_exec "github.com/codeactual/kubeauth/internal/cage/os/exec"
+	cage_file "github.com/codeactual/kubeauth/internal/cage/os/file"
 )
 
 // File represents a kubectl config file parsed by a Client implementation.
+//
+// If the config was loaded from multiple files, e.g. from a KUBECONFIG list, it represents
+// the merged result and tracks which file each cluster, user, and context came from.
 type File struct {
 	// Name is the path to the kubectl config file.
+	//
+	// If the config was loaded from multiple files, it is the file which kubectl itself would
+	// select to receive new entries: the first existing file in the loading precedence.
 	Name string
 
+	// Sources holds the paths of all files from which the config was loaded, in order of precedence.
+	Sources []string
+
+	// Destination, if non-empty, overrides Name as the file which receives new entries.
+	//
+	// Existing entries are always written back to the file they came from.
+	Destination string
+
 	// ClientCmdConfig represents the parsed config file.
 	//
 	// It is derived from the kubectl config file by client-go.
@@ -34,6 +52,47 @@ type File struct {
 	RestConfig *rest.Config
 }
 
+// DestinationFilename returns the path of the file which receives new entries.
+func (f *File) DestinationFilename() string {
+	if f.Destination != "" {
+		return f.Destination
+	}
+	return f.Name
+}
+
+// ClusterFilename returns the path of the file which should receive an upsert of the named cluster.
+//
+// Like kubectl, it selects the file from which an existing entry was loaded, or the
+// destination file if the entry is new.
+func (f *File) ClusterFilename(name string) string {
+	if obj, ok := f.ClientCmdConfig.Clusters[name]; ok && obj != nil && obj.LocationOfOrigin != "" {
+		return obj.LocationOfOrigin
+	}
+	return f.DestinationFilename()
+}
+
+// ContextFilename returns the path of the file which should receive an upsert of the named context.
+//
+// Like kubectl, it selects the file from which an existing entry was loaded, or the
+// destination file if the entry is new.
+func (f *File) ContextFilename(name string) string {
+	if obj, ok := f.ClientCmdConfig.Contexts[name]; ok && obj != nil && obj.LocationOfOrigin != "" {
+		return obj.LocationOfOrigin
+	}
+	return f.DestinationFilename()
+}
+
+// UserFilename returns the path of the file which should receive an upsert of the named user.
+//
+// Like kubectl, it selects the file from which an existing entry was loaded, or the
+// destination file if the entry is new.
+func (f *File) UserFilename(name string) string {
+	if obj, ok := f.ClientCmdConfig.AuthInfos[name]; ok && obj != nil && obj.LocationOfOrigin != "" {
+		return obj.LocationOfOrigin
+	}
+	return f.DestinationFilename()
+}
+
 // GetCurrentContext returns the context selected in the config.
 func (f *File) GetCurrentContext() (name string, _ *clientcmdapi.Context, _ error) {
 	if len(f.ClientCmdConfig.Contexts) == 0 {
@@ -72,29 +131,71 @@ func (f *File) GetCurrentCluster() (name string, _ *clientcmdapi.Cluster, _ erro
 }
 
 // Client provides an interface to kubectl config files.
+//
+// Implementations which modify files should exclude concurrent writers, including kubectl,
+// and store a backup of each file before modifying it.
 type Client interface {
 	// Parse returns a Config based on the contents of the namedfile.
 	Parse(filename string) (*File, error)
 
 	// UpsertUserToken adds/updates a user's bearer token.
+	//
+	// An existing user is updated in the file it was loaded from, and a new user is added
+	// to the File's destination file.
 	UpsertUserToken(ctx context.Context, parsed *File, user string, token []byte) error
 
+	// UpsertUserCert adds/updates a user's client certificate and key, both PEM-encoded.
+	//
+	// The data is embedded in the file rather than referenced by path.
+	//
+	// An existing user is updated in the file it was loaded from, and a new user is added
+	// to the File's destination file.
+	UpsertUserCert(ctx context.Context, parsed *File, user string, cert, key []byte) error
+
 	// UpsertContext adds or updates a context.
+	//
+	// An existing context is updated in the file it was loaded from, and a new context is added
+	// to the File's destination file.
 	UpsertContext(ctx context.Context, parsed *File, name, cluster, ns, user string) error
+
+	// Backups returns the backups of the named file, newest first.
+	Backups(filename string) ([]Backup, error)
+
+	// Restore replaces the named file with the contents of a backup.
+	Restore(ctx context.Context, filename string, backup Backup) error
 }
 
 // DefaultClient implementation of Client operates on real config files.
+//
+// It modifies a file by running kubectl against a temporary copy and then renaming the copy over
+// the original. While doing so it holds the file's lock, which follows the convention used
+// by kubectl, and stores a backup of the original.
 type DefaultClient struct {
 	// Executor provides an os/exec.Command API for running the kubectl CLI.
 	Executor cage_exec.Executor
+
+	// BackupDir, if non-empty, stores the backups of all modified files instead of a BackupDirName
+	// directory next to each file. Backup names then include a hash of the file's absolute path.
+	BackupDir string
+
+	// BackupLimit is the number of backups retained per file. If it is zero, backups are disabled.
+	BackupLimit int
+
+	// LockTimeout is the duration to wait for another process to release a file's lock.
+	LockTimeout time.Duration
 }
 
+// NewDefaultClient returns a DefaultClient with default backup and lock settings.
 func NewDefaultClient() *DefaultClient {
-	return &DefaultClient{Executor: cage_exec.CommonExecutor{}}
+	return &DefaultClient{
+		Executor:    cage_exec.CommonExecutor{},
+		BackupLimit: DefaultBackupLimit,
+		LockTimeout: DefaultLockTimeout,
+	}
 }
 
-// NewDefaultClient returns a DefaultClient initialized by the config file named by the
-// input or, if the latter is empty, by the default from k8s.io/client-go.
+// Parse returns a File initialized by the config file named by the input or, if the latter
+// is empty, by the defaults from k8s.io/client-go, e.g. the KUBECONFIG file list.
 func (c *DefaultClient) Parse(filename string) (*File, error) {
 	file := File{}
 
@@ -102,9 +203,11 @@ func (c *DefaultClient) Parse(filename string) (*File, error) {
 	if filename == "" {
 		loadRules = clientcmd.NewDefaultClientConfigLoadingRules()
 		file.Name = loadRules.GetDefaultFilename()
+		file.Sources = loadRules.GetLoadingPrecedence()
 	} else {
 		loadRules = &clientcmd.ClientConfigLoadingRules{ExplicitPath: filename}
 		file.Name = filename
+		file.Sources = []string{filename}
 	}
 
 	clientConfig := clientcmd.NewNonInteractiveDeferredLoadingClientConfig(
@@ -135,14 +238,67 @@ func (c *DefaultClient) Parse(filename string) (*File, error) {
 //
 // It implements Client.
 func (c *DefaultClient) UpsertUserToken(ctx context.Context, file *File, user string, token []byte) error {
-	_, stderrBuf, _, err := c.Executor.Buffered(ctx, c.Executor.Command(
-		"kubectl", "config", "set-credentials", user,
-		"--kubeconfig", file.Name,
-		"--token", string(token),
-	))
+	err := c.modify(ctx, file.UserFilename(user), func(tmpName string) error {
+		_, stderrBuf, _, err := c.Executor.Buffered(ctx, c.Executor.Command(
+			"kubectl", "config", "set-credentials", user,
+			"--kubeconfig", tmpName,
+			"--token", string(token),
+		))
+		if err != nil {
+			return errors.Wrap(err, strings.TrimSpace(stderrBuf.String()))
+		}
+		return nil
+	})
+	if err != nil {
+		return errors.WithStack(err)
+	}
 
+	ctxErr := ctx.Err()
+	if ctxErr != nil {
+		return errors.WithStack(ctxErr)
+	}
+
+	return nil
+}
+
+// UpsertUserCert adds/updates a user's client certificate and key.
+//
+// kubectl only reads certificate data from files, so the data is first written to a temporary
+// directory which only the current user can access.
+//
+// It implements Client.
+func (c *DefaultClient) UpsertUserCert(ctx context.Context, file *File, user string, cert, key []byte) error {
+	dir, err := ioutil.TempDir("", "kubeauth-cert-")
 	if err != nil {
-		return errors.Wrap(err, strings.TrimSpace(stderrBuf.String()))
+		return errors.Wrap(err, "failed to create temporary dir for client certificate")
+	}
+	defer cage_file.RemoveAllSafer(dir)
+
+	certFilename := filepath.Join(dir, "client.crt")
+	if err = ioutil.WriteFile(certFilename, cert, 0600); err != nil {
+		return errors.Wrapf(err, "failed to write temporary file [%s]", certFilename)
+	}
+
+	keyFilename := filepath.Join(dir, "client.key")
+	if err = ioutil.WriteFile(keyFilename, key, 0600); err != nil {
+		return errors.Wrapf(err, "failed to write temporary file [%s]", keyFilename)
+	}
+
+	err = c.modify(ctx, file.UserFilename(user), func(tmpName string) error {
+		_, stderrBuf, _, err := c.Executor.Buffered(ctx, c.Executor.Command(
+			"kubectl", "config", "set-credentials", user,
+			"--kubeconfig", tmpName,
+			"--client-certificate", certFilename,
+			"--client-key", keyFilename,
+			"--embed-certs=true",
+		))
+		if err != nil {
+			return errors.Wrap(err, strings.TrimSpace(stderrBuf.String()))
+		}
+		return nil
+	})
+	if err != nil {
+		return errors.WithStack(err)
 	}
 
 	ctxErr := ctx.Err()
@@ -157,16 +313,21 @@ func (c *DefaultClient) UpsertUserToken(ctx context.Context, file *File, user st
 //
 // It implements Client.
 func (c *DefaultClient) UpsertContext(ctx context.Context, file *File, name, cluster, ns, user string) error {
-	_, stderrBuf, _, err := c.Executor.Buffered(ctx, c.Executor.Command(
-		"kubectl", "config", "set-context", name,
-		"--kubeconfig", file.Name,
-		"--cluster", cluster,
-		"--namespace", ns,
-		"--user", user,
-	))
-
+	err := c.modify(ctx, file.ContextFilename(name), func(tmpName string) error {
+		_, stderrBuf, _, err := c.Executor.Buffered(ctx, c.Executor.Command(
+			"kubectl", "config", "set-context", name,
+			"--kubeconfig", tmpName,
+			"--cluster", cluster,
+			"--namespace", ns,
+			"--user", user,
+		))
+		if err != nil {
+			return errors.Wrap(err, strings.TrimSpace(stderrBuf.String()))
+		}
+		return nil
+	})
 	if err != nil {
-		return errors.Wrap(err, strings.TrimSpace(stderrBuf.String()))
+		return errors.WithStack(err)
 	}
 
 	ctxErr := ctx.Err()
@@ -9,13 +9,20 @@ package config_test
 import (
 	"bytes"
 	"context"
+	"fmt"
+	"io/ioutil"
+	"os"
 	"os/exec"
 	"path/filepath"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/golang/mock/gomock"
+	"github.com/pkg/errors"
 	"github.com/stretchr/testify/require"
 	"github.com/stretchr/testify/suite"
+	"k8s.io/client-go/tools/clientcmd"
 
 	"github.com/codeactual/kubeauth/internal/cage/kubernetes/v1/kubectl/config"
 	cage_exec "github.com/codeactual/kubeauth/internal/cage/os/exec"
@@ -31,6 +38,95 @@ func TestConfigSuite(t *testing.T) {
 	suite.Run(t, new(ConfigSuite))
 }
 
+func (s *ConfigSuite) SetupTest() {
+	testkit_file.ResetTestdata(s.T())
+}
+
+// kubectlEdit is appended to a file by the simulated kubectl invocations.
+const kubectlEdit = "# edited by kubectl\n"
+
+// copyFixture copies a fixture file into the dynamic data dir so that test cases can modify it.
+func copyFixture(t *testing.T, name string) string {
+	data, err := ioutil.ReadFile(filepath.Join(testkit_file.FixtureDataDir(), name))
+	require.NoError(t, err)
+
+	dst, _ := testkit_file.CreatePath(t, name)
+	require.NoError(t, ioutil.WriteFile(dst, data, 0600))
+
+	return dst
+}
+
+// requireFile asserts the contents of a file.
+func requireFile(t *testing.T, expected, filename string) {
+	actual, err := ioutil.ReadFile(filename)
+	require.NoError(t, err)
+	require.Exactly(t, expected, string(actual))
+}
+
+// requireNoTempFiles asserts that no lock or temporary files remain next to the named file.
+func requireNoTempFiles(t *testing.T, filename string) {
+	matches, err := filepath.Glob(filename + ".*")
+	require.NoError(t, err)
+	require.Empty(t, matches)
+}
+
+type matchTempCopy struct {
+	filename string
+}
+
+// Matches returns true if the input is the name of a temporary copy of the expected file.
+func (m *matchTempCopy) Matches(x interface{}) bool {
+	actual, ok := x.(string)
+	if !ok || filepath.Dir(actual) != filepath.Dir(m.filename) {
+		return false
+	}
+	base := filepath.Base(actual)
+	return strings.HasPrefix(base, filepath.Base(m.filename)+".kubeauth-") && strings.HasSuffix(base, ".tmp")
+}
+
+func (m *matchTempCopy) String() string {
+	return fmt.Sprintf("is a temporary copy of [%s]", m.filename)
+}
+
+// tempCopyOf returns a matcher which expects the name of a temporary copy of the input file.
+func tempCopyOf(filename string) gomock.Matcher {
+	return &matchTempCopy{filename: filename}
+}
+
+// kubectlCommand returns a mock Command implementation which simulates kubectl's edit of
+// the file selected by the --kubeconfig argument.
+func kubectlCommand(t *testing.T, cmd *exec.Cmd) func(string, ...string) *exec.Cmd {
+	return func(_ string, args ...string) *exec.Cmd {
+		for n := range args {
+			if args[n] == "--kubeconfig" {
+				f, err := os.OpenFile(args[n+1], os.O_APPEND|os.O_WRONLY, 0)
+				require.NoError(t, err)
+				_, err = f.WriteString(kubectlEdit)
+				require.NoError(t, err)
+				require.NoError(t, f.Close())
+			}
+		}
+		return cmd
+	}
+}
+
+// expectSetCredentials configures the executor to expect kubectl to set a user's token in a
+// temporary copy of the named file.
+func expectSetCredentials(t *testing.T, mockExecutor *mock_exec.MockExecutor, filename, user, token string) {
+	ctx := context.Background()
+	expectCmd := &exec.Cmd{}
+	var expectStdout, expectStderr *bytes.Buffer // non-SUT
+
+	mockExecutor.EXPECT().
+		Command(
+			"kubectl", "config", "set-credentials", user,
+			"--kubeconfig", tempCopyOf(filename),
+			"--token", token,
+		).
+		DoAndReturn(kubectlCommand(t, expectCmd))
+	mockExecutor.EXPECT().Buffered(ctx, expectCmd).Return(expectStdout, expectStderr, cage_exec.PipelineResult{}, nil)
+}
+
 func (s *ConfigSuite) TestFileGetCurrentContext() {
 	t := s.T()
 
@@ -83,58 +179,468 @@ func (s *ConfigSuite) TestClientUpsertToken() {
 	mockCtrl := gomock.NewController(t)
 	defer mockCtrl.Finish()
 
-	filename := filepath.Join(testkit_file.FixtureDataDir(), "kubeconfig-orig.yml")
+	filename := copyFixture(t, "kubeconfig-orig.yml")
+	client := config.NewDefaultClient()
+
+	file, err := client.Parse(filename)
+	require.NoError(t, err)
+
+	orig, err := ioutil.ReadFile(filename)
+	require.NoError(t, err)
+
+	mockExecutor := mock_exec.NewMockExecutor(mockCtrl)
+	expectSetCredentials(t, mockExecutor, filename, "some-user", "some-bytes")
+	client.Executor = mockExecutor
+
+	require.NoError(t, client.UpsertUserToken(ctx, file, "some-user", []byte("some-bytes")))
+
+	requireFile(t, string(orig)+kubectlEdit, filename)
+	requireNoTempFiles(t, filename)
+
+	backups, err := client.Backups(filename)
+	require.NoError(t, err)
+	require.Len(t, backups, 1)
+	requireFile(t, string(orig), backups[0].Name)
+}
+
+func (s *ConfigSuite) TestClientUpsertContext() {
+	t := s.T()
+	ctx := context.Background()
+
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	filename := copyFixture(t, "kubeconfig-orig.yml")
 	client := config.NewDefaultClient()
 
 	file, err := client.Parse(filename)
 	require.NoError(t, err)
 
-	expectToken := []byte("some-bytes")
+	orig, err := ioutil.ReadFile(filename)
+	require.NoError(t, err)
+
 	expectCmd := &exec.Cmd{}
 	var expectStdout, expectStderr *bytes.Buffer // non-SUT
 
+	mockExecutor := mock_exec.NewMockExecutor(mockCtrl)
+	mockExecutor.EXPECT().
+		Command(
+			"kubectl", "config", "set-context", "some-context",
+			"--kubeconfig", tempCopyOf(filename),
+			"--cluster", "some-cluster",
+			"--namespace", "some-namespace",
+			"--user", "some-user",
+		).
+		DoAndReturn(kubectlCommand(t, expectCmd))
+	mockExecutor.EXPECT().Buffered(ctx, expectCmd).Return(expectStdout, expectStderr, cage_exec.PipelineResult{}, nil)
+	client.Executor = mockExecutor
+
+	require.NoError(t, client.UpsertContext(ctx, file, "some-context", "some-cluster", "some-namespace", "some-user"))
+
+	requireFile(t, string(orig)+kubectlEdit, filename)
+	requireNoTempFiles(t, filename)
+}
+
+// TestClientUpsertCert asserts that kubectl embeds the certificate and key from temporary files
+// which are removed afterward.
+func (s *ConfigSuite) TestClientUpsertCert() {
+	t := s.T()
+	ctx := context.Background()
+
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	filename := copyFixture(t, "kubeconfig-orig.yml")
+	client := config.NewDefaultClient()
+
+	file, err := client.Parse(filename)
+	require.NoError(t, err)
+
+	orig, err := ioutil.ReadFile(filename)
+	require.NoError(t, err)
+
+	expectCmd := &exec.Cmd{}
+	var expectStdout, expectStderr *bytes.Buffer // non-SUT
+	var certFilename, keyFilename string
+
 	mockExecutor := mock_exec.NewMockExecutor(mockCtrl)
 	mockExecutor.EXPECT().
 		Command(
 			"kubectl", "config", "set-credentials", "some-user",
-			"--kubeconfig", filename,
+			"--kubeconfig", tempCopyOf(filename),
+			"--client-certificate", gomock.Any(),
+			"--client-key", gomock.Any(),
+			"--embed-certs=true",
+		).
+		DoAndReturn(func(name string, args ...string) *exec.Cmd {
+			certFilename, keyFilename = args[6], args[8]
+			requireFile(t, "some-cert", certFilename)
+			requireFile(t, "some-key", keyFilename)
+			return kubectlCommand(t, expectCmd)(name, args...)
+		})
+	mockExecutor.EXPECT().Buffered(ctx, expectCmd).Return(expectStdout, expectStderr, cage_exec.PipelineResult{}, nil)
+	client.Executor = mockExecutor
+
+	require.NoError(t, client.UpsertUserCert(ctx, file, "some-user", []byte("some-cert"), []byte("some-key")))
+
+	requireFile(t, string(orig)+kubectlEdit, filename)
+	requireNoTempFiles(t, filename)
+
+	for _, f := range []string{certFilename, keyFilename} {
+		_, err = os.Stat(f)
+		require.True(t, os.IsNotExist(err), "temporary file [%s] should be removed", f)
+	}
+}
+
+// TestClientUpsertKubectlErr asserts that the file is unchanged if kubectl fails.
+func (s *ConfigSuite) TestClientUpsertKubectlErr() {
+	t := s.T()
+	ctx := context.Background()
+
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	filename := copyFixture(t, "kubeconfig-orig.yml")
+	client := config.NewDefaultClient()
+
+	file, err := client.Parse(filename)
+	require.NoError(t, err)
+
+	orig, err := ioutil.ReadFile(filename)
+	require.NoError(t, err)
+
+	expectCmd := &exec.Cmd{}
+	expectStderr := bytes.NewBufferString("some-stderr")
+
+	mockExecutor := mock_exec.NewMockExecutor(mockCtrl)
+	mockExecutor.EXPECT().
+		Command(
+			"kubectl", "config", "set-credentials", "some-user",
+			"--kubeconfig", tempCopyOf(filename),
 			"--token", "some-bytes",
 		).
 		Return(expectCmd)
-	mockExecutor.EXPECT().Buffered(ctx, expectCmd).Return(expectStdout, expectStderr, cage_exec.PipelineResult{}, nil)
+	mockExecutor.EXPECT().Buffered(ctx, expectCmd).Return(nil, expectStderr, cage_exec.PipelineResult{}, errors.New("some-error"))
 	client.Executor = mockExecutor
 
-	require.NoError(t, client.UpsertUserToken(ctx, file, "some-user", expectToken))
+	err = client.UpsertUserToken(ctx, file, "some-user", []byte("some-bytes"))
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "some-stderr")
+
+	requireFile(t, string(orig), filename)
+	requireNoTempFiles(t, filename)
+
+	backups, err := client.Backups(filename)
+	require.NoError(t, err)
+	require.Empty(t, backups)
 }
 
-func (s *ConfigSuite) TestClientUpsertContext() {
+// TestClientUpsertLockTimeout asserts that the file is unchanged if another process holds its lock.
+func (s *ConfigSuite) TestClientUpsertLockTimeout() {
 	t := s.T()
 	ctx := context.Background()
 
 	mockCtrl := gomock.NewController(t)
 	defer mockCtrl.Finish()
 
-	filename := filepath.Join(testkit_file.FixtureDataDir(), "kubeconfig-orig.yml")
+	filename := copyFixture(t, "kubeconfig-orig.yml")
 	client := config.NewDefaultClient()
+	client.Executor = mock_exec.NewMockExecutor(mockCtrl)
+	client.LockTimeout = 200 * time.Millisecond
 
 	file, err := client.Parse(filename)
 	require.NoError(t, err)
 
+	orig, err := ioutil.ReadFile(filename)
+	require.NoError(t, err)
+
+	lockName := filename + config.LockSuffix
+	require.NoError(t, ioutil.WriteFile(lockName, []byte{}, 0600))
+
+	err = client.UpsertUserToken(ctx, file, "some-user", []byte("some-bytes"))
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "was not released")
+
+	requireFile(t, string(orig), filename)
+
+	_, err = os.Stat(lockName)
+	require.NoError(t, err, "lock file of another process should not be removed")
+}
+
+// TestClientUpsertLockWait asserts that a lock released by another process before the timeout is acquired.
+func (s *ConfigSuite) TestClientUpsertLockWait() {
+	t := s.T()
+	ctx := context.Background()
+
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	filename := copyFixture(t, "kubeconfig-orig.yml")
+	client := config.NewDefaultClient()
+
+	file, err := client.Parse(filename)
+	require.NoError(t, err)
+
+	orig, err := ioutil.ReadFile(filename)
+	require.NoError(t, err)
+
+	mockExecutor := mock_exec.NewMockExecutor(mockCtrl)
+	expectSetCredentials(t, mockExecutor, filename, "some-user", "some-bytes")
+	client.Executor = mockExecutor
+
+	lockName := filename + config.LockSuffix
+	require.NoError(t, ioutil.WriteFile(lockName, []byte{}, 0600))
+	time.AfterFunc(300*time.Millisecond, func() {
+		_ = os.Remove(lockName)
+	})
+
+	require.NoError(t, client.UpsertUserToken(ctx, file, "some-user", []byte("some-bytes")))
+
+	requireFile(t, string(orig)+kubectlEdit, filename)
+	requireNoTempFiles(t, filename)
+}
+
+// TestClientBackupLimit asserts that only the newest backups are retained.
+func (s *ConfigSuite) TestClientBackupLimit() {
+	t := s.T()
+	ctx := context.Background()
+
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	filename := copyFixture(t, "kubeconfig-orig.yml")
+	client := config.NewDefaultClient()
+	client.BackupLimit = 2
+
+	file, err := client.Parse(filename)
+	require.NoError(t, err)
+
+	orig, err := ioutil.ReadFile(filename)
+	require.NoError(t, err)
+
+	mockExecutor := mock_exec.NewMockExecutor(mockCtrl)
+	client.Executor = mockExecutor
+
+	for n := 0; n < 3; n++ {
+		expectSetCredentials(t, mockExecutor, filename, "some-user", "some-bytes")
+		require.NoError(t, client.UpsertUserToken(ctx, file, "some-user", []byte("some-bytes")))
+	}
+
+	requireFile(t, string(orig)+strings.Repeat(kubectlEdit, 3), filename)
+
+	backups, err := client.Backups(filename)
+	require.NoError(t, err)
+	require.Len(t, backups, 2)
+	require.True(t, backups[0].Time.After(backups[1].Time))
+	requireFile(t, string(orig)+strings.Repeat(kubectlEdit, 2), backups[0].Name)
+	requireFile(t, string(orig)+kubectlEdit, backups[1].Name)
+}
+
+// TestClientBackupDisabled asserts that no backups are stored if the limit is zero.
+func (s *ConfigSuite) TestClientBackupDisabled() {
+	t := s.T()
+	ctx := context.Background()
+
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	filename := copyFixture(t, "kubeconfig-orig.yml")
+	client := config.NewDefaultClient()
+	client.BackupLimit = 0
+
+	file, err := client.Parse(filename)
+	require.NoError(t, err)
+
+	mockExecutor := mock_exec.NewMockExecutor(mockCtrl)
+	expectSetCredentials(t, mockExecutor, filename, "some-user", "some-bytes")
+	client.Executor = mockExecutor
+
+	require.NoError(t, client.UpsertUserToken(ctx, file, "some-user", []byte("some-bytes")))
+
+	backups, err := client.Backups(filename)
+	require.NoError(t, err)
+	require.Empty(t, backups)
+}
+
+// TestClientBackupDirShared asserts that files with the same base name do not share backups in a
+// shared backup dir.
+func (s *ConfigSuite) TestClientBackupDirShared() {
+	t := s.T()
+	ctx := context.Background()
+
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	data, err := ioutil.ReadFile(filepath.Join(testkit_file.FixtureDataDir(), "kubeconfig-orig.yml"))
+	require.NoError(t, err)
+
+	var filenames []string
+	for _, dir := range []string{"a", "b"} {
+		_, filename := testkit_file.CreateFile(t, dir, "config")
+		require.NoError(t, ioutil.WriteFile(filename, data, 0600))
+		filenames = append(filenames, filename)
+	}
+
+	backupDir, _ := testkit_file.CreatePath(t, "backup")
+
+	client := config.NewDefaultClient()
+	client.BackupDir = backupDir
+	client.BackupLimit = 1
+
+	mockExecutor := mock_exec.NewMockExecutor(mockCtrl)
+	client.Executor = mockExecutor
+
+	for _, filename := range filenames {
+		file, err := client.Parse(filename)
+		require.NoError(t, err)
+
+		expectSetCredentials(t, mockExecutor, filename, "some-user", "some-bytes")
+		require.NoError(t, client.UpsertUserToken(ctx, file, "some-user", []byte("some-bytes")))
+	}
+
+	// Pruning the second file's backups to the limit must not remove the first file's backup.
+	for _, filename := range filenames {
+		backups, err := client.Backups(filename)
+		require.NoError(t, err)
+		require.Len(t, backups, 1)
+		require.Exactly(t, backupDir, filepath.Dir(backups[0].Name))
+		requireFile(t, string(data), backups[0].Name)
+	}
+
+	first, err := client.Backups(filenames[0])
+	require.NoError(t, err)
+	second, err := client.Backups(filenames[1])
+	require.NoError(t, err)
+	require.NotEqual(t, first[0].Name, second[0].Name)
+}
+
+// TestClientRestore asserts that a backup replaces the file and the replaced contents are backed up.
+func (s *ConfigSuite) TestClientRestore() {
+	t := s.T()
+	ctx := context.Background()
+
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	filename := copyFixture(t, "kubeconfig-orig.yml")
+	client := config.NewDefaultClient()
+
+	file, err := client.Parse(filename)
+	require.NoError(t, err)
+
+	orig, err := ioutil.ReadFile(filename)
+	require.NoError(t, err)
+
+	mockExecutor := mock_exec.NewMockExecutor(mockCtrl)
+	expectSetCredentials(t, mockExecutor, filename, "some-user", "some-bytes")
+	client.Executor = mockExecutor
+
+	require.NoError(t, client.UpsertUserToken(ctx, file, "some-user", []byte("some-bytes")))
+
+	backups, err := client.Backups(filename)
+	require.NoError(t, err)
+	require.Len(t, backups, 1)
+
+	require.NoError(t, client.Restore(ctx, filename, backups[0]))
+
+	requireFile(t, string(orig), filename)
+	requireNoTempFiles(t, filename)
+
+	backups, err = client.Backups(filename)
+	require.NoError(t, err)
+	require.Len(t, backups, 2)
+	requireFile(t, string(orig)+kubectlEdit, backups[0].Name)
+}
+
+// parseMerged returns a File loaded from a KUBECONFIG list of two fixture files.
+func parseMerged(t *testing.T) (file *config.File, first, second string) {
+	first = copyFixture(t, "kubeconfig-merge-first.yml")
+	second = copyFixture(t, "kubeconfig-merge-second.yml")
+
+	orig, origExists := os.LookupEnv(clientcmd.RecommendedConfigPathEnvVar)
+	require.NoError(t, os.Setenv(clientcmd.RecommendedConfigPathEnvVar, first+string(filepath.ListSeparator)+second))
+	defer func() {
+		if origExists {
+			require.NoError(t, os.Setenv(clientcmd.RecommendedConfigPathEnvVar, orig))
+		} else {
+			require.NoError(t, os.Unsetenv(clientcmd.RecommendedConfigPathEnvVar))
+		}
+	}()
+
+	file, err := config.NewDefaultClient().Parse("")
+	require.NoError(t, err)
+
+	return file, first, second
+}
+
+func (s *ConfigSuite) TestClientParseMerged() {
+	t := s.T()
+
+	file, first, second := parseMerged(t)
+
+	require.Exactly(t, first, file.Name)
+	require.Exactly(t, []string{first, second}, file.Sources)
+
+	require.Exactly(t, first, file.ClusterFilename("first-cluster"))
+	require.Exactly(t, second, file.ClusterFilename("second-cluster"))
+	require.Exactly(t, first, file.ContextFilename("first-context"))
+	require.Exactly(t, second, file.ContextFilename("second-context"))
+	require.Exactly(t, first, file.UserFilename("first-user"))
+	require.Exactly(t, second, file.UserFilename("second-user"))
+
+	// New entries are written to the first file, as kubectl would.
+	require.Exactly(t, first, file.ClusterFilename("new-cluster"))
+	require.Exactly(t, first, file.ContextFilename("new-context"))
+	require.Exactly(t, first, file.UserFilename("new-user"))
+
+	// New entries are written to an explicit destination, if selected. Existing entries are not moved.
+	file.Destination = "some-destination"
+	require.Exactly(t, "some-destination", file.ContextFilename("new-context"))
+	require.Exactly(t, "some-destination", file.UserFilename("new-user"))
+	require.Exactly(t, second, file.UserFilename("second-user"))
+}
+
+func (s *ConfigSuite) TestClientUpsertMerged() {
+	t := s.T()
+	ctx := context.Background()
+
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	file, first, second := parseMerged(t)
+	client := config.NewDefaultClient()
+
 	expectCmd := &exec.Cmd{}
 	var expectStdout, expectStderr *bytes.Buffer // non-SUT
 
 	mockExecutor := mock_exec.NewMockExecutor(mockCtrl)
 	mockExecutor.EXPECT().
 		Command(
-			"kubectl", "config", "set-context", "some-context",
-			"--kubeconfig", filename,
-			"--cluster", "some-cluster",
+			"kubectl", "config", "set-credentials", "second-user",
+			"--kubeconfig", tempCopyOf(second),
+			"--token", "some-bytes",
+		).
+		DoAndReturn(kubectlCommand(t, expectCmd))
+	mockExecutor.EXPECT().
+		Command(
+			"kubectl", "config", "set-context", "new-context",
+			"--kubeconfig", tempCopyOf(first),
+			"--cluster", "second-cluster",
 			"--namespace", "some-namespace",
-			"--user", "some-user",
+			"--user", "second-user",
 		).
-		Return(expectCmd)
-	mockExecutor.EXPECT().Buffered(ctx, expectCmd).Return(expectStdout, expectStderr, cage_exec.PipelineResult{}, nil)
+		DoAndReturn(kubectlCommand(t, expectCmd))
+	mockExecutor.EXPECT().Buffered(ctx, expectCmd).Return(expectStdout, expectStderr, cage_exec.PipelineResult{}, nil).Times(2)
 	client.Executor = mockExecutor
 
-	require.NoError(t, client.UpsertContext(ctx, file, "some-context", "some-cluster", "some-namespace", "some-user"))
+	origFirst, err := ioutil.ReadFile(first)
+	require.NoError(t, err)
+	origSecond, err := ioutil.ReadFile(second)
+	require.NoError(t, err)
+
+	require.NoError(t, client.UpsertUserToken(ctx, file, "second-user", []byte("some-bytes")))
+	require.NoError(t, client.UpsertContext(ctx, file, "new-context", "second-cluster", "some-namespace", "second-user"))
+
+	requireFile(t, string(origFirst)+kubectlEdit, first)
+	requireFile(t, string(origSecond)+kubectlEdit, second)
 }
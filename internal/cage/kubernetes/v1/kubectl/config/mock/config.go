@@ -69,6 +69,20 @@ func (mr *MockClientMockRecorder) UpsertUserToken(ctx, parsed, user, token inter
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpsertUserToken", reflect.TypeOf((*MockClient)(nil).UpsertUserToken), ctx, parsed, user, token)
 }
 
+// UpsertUserCert mocks base method
+func (m *MockClient) UpsertUserCert(ctx context.Context, parsed *config.File, user string, cert, key []byte) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpsertUserCert", ctx, parsed, user, cert, key)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpsertUserCert indicates an expected call of UpsertUserCert
+func (mr *MockClientMockRecorder) UpsertUserCert(ctx, parsed, user, cert, key interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpsertUserCert", reflect.TypeOf((*MockClient)(nil).UpsertUserCert), ctx, parsed, user, cert, key)
+}
+
 // UpsertContext mocks base method
 func (m *MockClient) UpsertContext(ctx context.Context, parsed *config.File, name, cluster, ns, user string) error {
 	m.ctrl.T.Helper()
@@ -82,3 +96,32 @@ func (mr *MockClientMockRecorder) UpsertContext(ctx, parsed, name, cluster, ns,
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpsertContext", reflect.TypeOf((*MockClient)(nil).UpsertContext), ctx, parsed, name, cluster, ns, user)
 }
+
+// Backups mocks base method
+func (m *MockClient) Backups(filename string) ([]config.Backup, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Backups", filename)
+	ret0, _ := ret[0].([]config.Backup)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Backups indicates an expected call of Backups
+func (mr *MockClientMockRecorder) Backups(filename interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Backups", reflect.TypeOf((*MockClient)(nil).Backups), filename)
+}
+
+// Restore mocks base method
+func (m *MockClient) Restore(ctx context.Context, filename string, backup config.Backup) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Restore", ctx, filename, backup)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Restore indicates an expected call of Restore
+func (mr *MockClientMockRecorder) Restore(ctx, filename, backup interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Restore", reflect.TypeOf((*MockClient)(nil).Restore), ctx, filename, backup)
+}
@@ -0,0 +1,297 @@
+// Copyright (C) 2020 The CodeActual Go Environment Authors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package config
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+
+	cage_file "github.com/codeactual/kubeauth/internal/cage/os/file"
+)
+
+const (
+	// LockSuffix is appended to a kubectl config file's name to create its lock file.
+	//
+	// It follows the convention of k8s.io/client-go/tools/clientcmd, which kubectl uses to guard
+	// its own writes, so that kubectl and Client implementations exclude each other.
+	LockSuffix = ".lock"
+
+	// BackupDirName is the default directory, relative to a kubectl config file's own directory,
+	// which stores the file's backups.
+	BackupDirName = ".kubeauth-backup"
+
+	// DefaultBackupLimit is the default number of backups retained per file.
+	DefaultBackupLimit = 5
+
+	// DefaultLockTimeout is the default duration to wait for another process to release a file's lock.
+	DefaultLockTimeout = 10 * time.Second
+
+	// backupTimeLayout formats backup timestamps so that lexical and chronological order agree.
+	backupTimeLayout = "20060102T150405.000000000Z"
+
+	// backupHashLen is the number of hex digits of the path hash in backup names stored in a shared BackupDir.
+	backupHashLen = 8
+
+	// lockPollInterval is the delay between attempts to acquire a lock held by another process.
+	lockPollInterval = 100 * time.Millisecond
+)
+
+// Backup describes a copy of a kubectl config file stored before the file was modified.
+type Backup struct {
+	// Name is the path to the backup file.
+	Name string
+
+	// Time is when the backup was stored.
+	Time time.Time
+}
+
+// Backups returns the backups of the named file, newest first.
+//
+// It implements Client.
+func (c *DefaultClient) Backups(filename string) ([]Backup, error) {
+	dir := c.backupDir(filename)
+	prefix, err := c.backupPrefix(filename)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	infos, err := ioutil.ReadDir(dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return []Backup{}, nil
+		}
+		return nil, errors.Wrapf(err, "failed to read backup dir [%s]", dir)
+	}
+
+	backups := []Backup{}
+	for _, fi := range infos {
+		if fi.IsDir() || !strings.HasPrefix(fi.Name(), prefix) {
+			continue
+		}
+
+		// Skip files from other sources, e.g. backups of a file whose name has this one's as a prefix.
+		t, err := time.Parse(backupTimeLayout, strings.TrimPrefix(fi.Name(), prefix))
+		if err != nil {
+			continue
+		}
+
+		backups = append(backups, Backup{Name: filepath.Join(dir, fi.Name()), Time: t})
+	}
+
+	sort.Slice(backups, func(i, j int) bool {
+		return backups[i].Time.After(backups[j].Time)
+	})
+
+	return backups, nil
+}
+
+// Restore replaces the named file with the contents of a backup.
+//
+// The file's current contents are backed up first so that the restoration can itself be reverted.
+//
+// It implements Client.
+func (c *DefaultClient) Restore(ctx context.Context, filename string, backup Backup) error {
+	data, err := ioutil.ReadFile(backup.Name)
+	if err != nil {
+		return errors.Wrapf(err, "failed to read backup [%s]", backup.Name)
+	}
+
+	err = c.modify(ctx, filename, func(tmpName string) error {
+		return errors.Wrapf(ioutil.WriteFile(tmpName, data, 0600), "failed to write temporary file [%s]", tmpName)
+	})
+	if err != nil {
+		return errors.Wrapf(err, "failed to restore file [%s] from backup [%s]", filename, backup.Name)
+	}
+
+	return nil
+}
+
+// modify applies a change to a temporary copy of the named file and then replaces the file with the copy.
+//
+// It holds the file's lock until the replacement is complete, and stores a backup of the file's
+// current contents (if any) just before replacing it. Because the replacement is a rename, other
+// readers observe either the old or new contents but never a partial write.
+//
+// The change function receives the name of the temporary copy, which is located in the same directory.
+func (c *DefaultClient) modify(ctx context.Context, filename string, change func(tmpName string) error) (err error) {
+	unlock, err := c.lock(ctx, filename)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	defer func() {
+		if unlockErr := unlock(); unlockErr != nil && err == nil {
+			err = errors.Wrapf(unlockErr, "failed to remove lock file of [%s]", filename)
+		}
+	}()
+
+	exists, fi, err := cage_file.Exists(filename)
+	if err != nil {
+		return errors.Wrapf(err, "failed to check if file [%s] exists", filename)
+	}
+
+	var orig []byte
+	perm := os.FileMode(0600)
+
+	if exists {
+		if orig, err = ioutil.ReadFile(filename); err != nil {
+			return errors.Wrapf(err, "failed to read file [%s]", filename)
+		}
+		perm = fi.Mode().Perm()
+	}
+
+	tmpFile, err := ioutil.TempFile(filepath.Dir(filename), filepath.Base(filename)+".kubeauth-*.tmp")
+	if err != nil {
+		return errors.Wrapf(err, "failed to create temporary copy of file [%s]", filename)
+	}
+
+	tmpName := tmpFile.Name()
+	defer func() {
+		// The copy only remains if the change or replacement failed.
+		if err != nil {
+			_ = os.Remove(tmpName)
+		}
+	}()
+
+	if _, err = tmpFile.Write(orig); err != nil {
+		_ = tmpFile.Close()
+		return errors.Wrapf(err, "failed to write temporary file [%s]", tmpName)
+	}
+	if err = tmpFile.Close(); err != nil {
+		return errors.Wrapf(err, "failed to close temporary file [%s]", tmpName)
+	}
+	if err = os.Chmod(tmpName, perm); err != nil {
+		return errors.Wrapf(err, "failed to set mode of temporary file [%s]", tmpName)
+	}
+
+	if err = change(tmpName); err != nil {
+		return errors.WithStack(err)
+	}
+
+	if exists && c.BackupLimit > 0 {
+		if err = c.backup(filename, orig); err != nil {
+			return errors.WithStack(err)
+		}
+	}
+
+	if err = os.Rename(tmpName, filename); err != nil {
+		return errors.Wrapf(err, "failed to replace file [%s] with temporary file [%s]", filename, tmpName)
+	}
+
+	return nil
+}
+
+// lock creates the named file's lock file, waiting up to LockTimeout for another process to remove
+// an existing one.
+//
+// It returns a function which removes the lock file.
+func (c *DefaultClient) lock(ctx context.Context, filename string) (unlock func() error, _ error) {
+	// Like client-go, make sure the dir exists before trying to create a lock file in it.
+	dir := filepath.Dir(filename)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, errors.Wrapf(err, "failed to create dir [%s]", dir)
+	}
+
+	lockName := filename + LockSuffix
+	deadline := time.Now().Add(c.LockTimeout)
+
+	for {
+		// Use the same flags and mode as client-go.
+		f, err := os.OpenFile(lockName, os.O_CREATE|os.O_EXCL, 0)
+		if err == nil {
+			if err = f.Close(); err != nil {
+				return nil, errors.Wrapf(err, "failed to close lock file [%s]", lockName)
+			}
+			return func() error { return os.Remove(lockName) }, nil
+		}
+
+		if !os.IsExist(err) {
+			return nil, errors.Wrapf(err, "failed to create lock file [%s]", lockName)
+		}
+
+		if !time.Now().Before(deadline) {
+			return nil, errors.Errorf(
+				"lock file [%s] was not released after [%s], remove it if no other kubectl/kubeauth process is running",
+				lockName, c.LockTimeout,
+			)
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil, errors.Wrapf(ctx.Err(), "cancelled while waiting for lock file [%s]", lockName)
+		case <-time.After(lockPollInterval):
+		}
+	}
+}
+
+// backup stores a copy of the named file's contents and removes the oldest backups beyond BackupLimit.
+func (c *DefaultClient) backup(filename string, data []byte) error {
+	dir := c.backupDir(filename)
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return errors.Wrapf(err, "failed to create backup dir [%s]", dir)
+	}
+
+	prefix, err := c.backupPrefix(filename)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	name := filepath.Join(dir, prefix+time.Now().UTC().Format(backupTimeLayout))
+	if err := ioutil.WriteFile(name, data, 0600); err != nil {
+		return errors.Wrapf(err, "failed to write backup [%s]", name)
+	}
+
+	backups, err := c.Backups(filename)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	if len(backups) > c.BackupLimit {
+		for _, b := range backups[c.BackupLimit:] {
+			if err := os.Remove(b.Name); err != nil {
+				return errors.Wrapf(err, "failed to remove expired backup [%s]", b.Name)
+			}
+		}
+	}
+
+	return nil
+}
+
+// backupPrefix returns the prefix of the named file's backup names, which are followed by a timestamp.
+//
+// If BackupDir is shared by multiple files, the prefix also includes a short hash of the file's absolute
+// path so that files with the same base name, e.g. a/config and b/config, do not share backups.
+func (c *DefaultClient) backupPrefix(filename string) (string, error) {
+	base := filepath.Base(filename)
+	if c.BackupDir == "" {
+		return base + ".", nil
+	}
+
+	abs, err := filepath.Abs(filename)
+	if err != nil {
+		return "", errors.Wrapf(err, "failed to get absolute path of file [%s]", filename)
+	}
+
+	sum := sha256.Sum256([]byte(abs))
+	return base + "." + hex.EncodeToString(sum[:])[:backupHashLen] + ".", nil
+}
+
+// backupDir returns the directory which stores the named file's backups.
+func (c *DefaultClient) backupDir(filename string) string {
+	if c.BackupDir != "" {
+		return c.BackupDir
+	}
+	return filepath.Join(filepath.Dir(filename), BackupDirName)
+}
@@ -22,6 +22,7 @@ import (
 
 // Client provides an interface to namespaces.
 type Client interface {
+	Create(obj *core.Namespace) (*core.Namespace, error)
 	Get(name string, options ...meta.GetOptions) (_ *core.Namespace, exists bool, _ error)
 }
 
@@ -35,6 +36,16 @@ func NewDefaultClient(getter core_type.NamespacesGetter) *DefaultClient {
 	return &DefaultClient{NamespacesGetter: getter}
 }
 
+// Create adds a namespace based on the object's metadata, e.g. to include labels.
+func (c *DefaultClient) Create(obj *core.Namespace) (*core.Namespace, error) {
+	created, err := c.Namespaces().Create(obj)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to create namespace [%s]", obj.Name)
+	}
+
+	return created, nil
+}
+
 // Get returns the object if found, reports that the object does not exist, or returns an error.
 //
 // A single GetOptions value can be passed as the final argument to customize the query.
@@ -81,3 +81,37 @@ func TestGet(t *testing.T) {
 		require.Nil(t, actualNs)
 	})
 }
+
+func TestCreate(t *testing.T) {
+	t.Run("created", func(t *testing.T) {
+		mockCtrl := gomock.NewController(t)
+		defer mockCtrl.Finish()
+
+		expectNs := &core.Namespace{ObjectMeta: meta.ObjectMeta{
+			Name:   Namespace,
+			Labels: map[string]string{"some-label": "some-value"},
+		}}
+
+		mockInterface, wrapperClient := newClient(mockCtrl)
+		mockInterface.EXPECT().Create(expectNs).Return(expectNs, nil)
+
+		actualNs, err := wrapperClient.Create(expectNs)
+		require.NoError(t, err)
+		require.Exactly(t, expectNs, actualNs)
+	})
+
+	t.Run("error", func(t *testing.T) {
+		mockCtrl := gomock.NewController(t)
+		defer mockCtrl.Finish()
+
+		expectNs := &core.Namespace{ObjectMeta: meta.ObjectMeta{Name: Namespace}}
+		expectErr := errors.New("expectErr")
+
+		mockInterface, wrapperClient := newClient(mockCtrl)
+		mockInterface.EXPECT().Create(expectNs).Return(nil, expectErr)
+
+		actualNs, actualErr := wrapperClient.Create(expectNs)
+		cage_require.MatchRegexp(t, fmt.Sprintf("%v", actualErr), "failed to create namespace.*expectErr")
+		require.Nil(t, actualNs)
+	})
+}
@@ -0,0 +1,55 @@
+// Copyright (C) 2020 The CodeActual Go Environment Authors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at https://mozilla.org/MPL/2.0/.
+//
+
+// Code generated by MockGen. DO NOT EDIT.
+// Source: k8s.io/client-go/kubernetes/typed/core/v1 (interfaces: NodesGetter)
+
+// Package mock is a generated GoMock package.
+package mock
+
+import (
+	gomock "github.com/golang/mock/gomock"
+	v1 "k8s.io/client-go/kubernetes/typed/core/v1"
+	reflect "reflect"
+)
+
+// MockNodesGetter is a mock of NodesGetter interface
+type MockNodesGetter struct {
+	ctrl     *gomock.Controller
+	recorder *MockNodesGetterMockRecorder
+}
+
+// MockNodesGetterMockRecorder is the mock recorder for MockNodesGetter
+type MockNodesGetterMockRecorder struct {
+	mock *MockNodesGetter
+}
+
+// NewMockNodesGetter creates a new mock instance
+func NewMockNodesGetter(ctrl *gomock.Controller) *MockNodesGetter {
+	mock := &MockNodesGetter{ctrl: ctrl}
+	mock.recorder = &MockNodesGetterMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use
+func (m *MockNodesGetter) EXPECT() *MockNodesGetterMockRecorder {
+	return m.recorder
+}
+
+// Nodes mocks base method
+func (m *MockNodesGetter) Nodes() v1.NodeInterface {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Nodes")
+	ret0, _ := ret[0].(v1.NodeInterface)
+	return ret0
+}
+
+// Nodes indicates an expected call of Nodes
+func (mr *MockNodesGetterMockRecorder) Nodes() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Nodes", reflect.TypeOf((*MockNodesGetter)(nil).Nodes))
+}
@@ -0,0 +1,83 @@
+// Copyright (C) 2020 The CodeActual Go Environment Authors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package node_test
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+	core "k8s.io/api/core/v1"
+	meta "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/codeactual/kubeauth/internal/cage/kubernetes/v1/node"
+	mock_node "github.com/codeactual/kubeauth/internal/cage/kubernetes/v1/node/mock"
+	cage_k8s_testkit "github.com/codeactual/kubeauth/internal/cage/kubernetes/v1/testkit"
+	cage_require "github.com/codeactual/kubeauth/internal/cage/testkit/testify/require"
+)
+
+const (
+	Name = "some-node"
+)
+
+func newClient(mockCtrl *gomock.Controller) (*mock_node.MockNodeInterface, *node.DefaultClient) {
+	mockInterface := mock_node.NewMockNodeInterface(mockCtrl)
+	mockGetter := mock_node.NewMockNodesGetter(mockCtrl)
+	mockGetter.EXPECT().Nodes().Return(mockInterface)
+	return mockInterface, node.NewDefaultClient(mockGetter)
+}
+
+func TestGet(t *testing.T) {
+	t.Run("found", func(t *testing.T) {
+		mockCtrl := gomock.NewController(t)
+		defer mockCtrl.Finish()
+
+		expectOptions := meta.GetOptions{}
+		expectNode := &core.Node{ObjectMeta: meta.ObjectMeta{Name: Name}}
+
+		mockInterface, wrapperClient := newClient(mockCtrl)
+		mockInterface.EXPECT().Get(Name, expectOptions).Return(expectNode, nil)
+
+		actualNode, exists, err := wrapperClient.Get(Name, expectOptions)
+		require.NoError(t, err)
+		require.True(t, exists)
+		require.Exactly(t, expectNode, actualNode)
+	})
+
+	t.Run("not found", func(t *testing.T) {
+		mockCtrl := gomock.NewController(t)
+		defer mockCtrl.Finish()
+
+		expectOptions := meta.GetOptions{}
+
+		mockInterface, wrapperClient := newClient(mockCtrl)
+		mockInterface.EXPECT().Get(Name, expectOptions).Return(nil, cage_k8s_testkit.NotFound())
+
+		actualNode, exists, err := wrapperClient.Get(Name, expectOptions)
+		require.NoError(t, err)
+		require.False(t, exists)
+		require.Nil(t, actualNode)
+	})
+
+	t.Run("error", func(t *testing.T) {
+		mockCtrl := gomock.NewController(t)
+		defer mockCtrl.Finish()
+
+		expectOptions := meta.GetOptions{}
+		expectErr := errors.New("expectErr")
+
+		mockInterface, wrapperClient := newClient(mockCtrl)
+		mockInterface.EXPECT().Get(Name, expectOptions).Return(nil, expectErr)
+
+		actualNode, exists, actualErr := wrapperClient.Get(Name, expectOptions)
+		cage_require.MatchRegexp(t, fmt.Sprintf("%v", actualErr), "failed to get node.*expectErr")
+		require.False(t, exists)
+		require.Nil(t, actualNode)
+	})
+}
@@ -0,0 +1,55 @@
+// Copyright (C) 2020 The CodeActual Go Environment Authors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at https://mozilla.org/MPL/2.0/.
+//
+
+// Code generated by MockGen. DO NOT EDIT.
+// Source: k8s.io/client-go/kubernetes/typed/core/v1 (interfaces: PodsGetter)
+
+// Package mock is a generated GoMock package.
+package mock
+
+import (
+	gomock "github.com/golang/mock/gomock"
+	v1 "k8s.io/client-go/kubernetes/typed/core/v1"
+	reflect "reflect"
+)
+
+// MockPodsGetter is a mock of PodsGetter interface
+type MockPodsGetter struct {
+	ctrl     *gomock.Controller
+	recorder *MockPodsGetterMockRecorder
+}
+
+// MockPodsGetterMockRecorder is the mock recorder for MockPodsGetter
+type MockPodsGetterMockRecorder struct {
+	mock *MockPodsGetter
+}
+
+// NewMockPodsGetter creates a new mock instance
+func NewMockPodsGetter(ctrl *gomock.Controller) *MockPodsGetter {
+	mock := &MockPodsGetter{ctrl: ctrl}
+	mock.recorder = &MockPodsGetterMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use
+func (m *MockPodsGetter) EXPECT() *MockPodsGetterMockRecorder {
+	return m.recorder
+}
+
+// Pods mocks base method
+func (m *MockPodsGetter) Pods(arg0 string) v1.PodInterface {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Pods", arg0)
+	ret0, _ := ret[0].(v1.PodInterface)
+	return ret0
+}
+
+// Pods indicates an expected call of Pods
+func (mr *MockPodsGetterMockRecorder) Pods(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Pods", reflect.TypeOf((*MockPodsGetter)(nil).Pods), arg0)
+}
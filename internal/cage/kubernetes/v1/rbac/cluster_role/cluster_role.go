@@ -22,8 +22,11 @@ import (
 
 // Client provides an interface to cluster roles.
 type Client interface {
+	Create(obj *rbac.ClusterRole) (*rbac.ClusterRole, error)
+	Delete(role string) error
 	Get(role string, options ...meta.GetOptions) (_ *rbac.ClusterRole, exists bool, _ error)
 	List(options ...meta.ListOptions) (*rbac.ClusterRoleList, error)
+	Update(obj *rbac.ClusterRole) (*rbac.ClusterRole, error)
 }
 
 // DefaultClient implementation of Client operates on a real kubernetes API.
@@ -36,6 +39,35 @@ func NewDefaultClient(getter rbac_type.ClusterRolesGetter) *DefaultClient {
 	return &DefaultClient{ClusterRolesGetter: getter}
 }
 
+// Create adds the cluster role.
+//
+// It implements Client.
+func (c *DefaultClient) Create(obj *rbac.ClusterRole) (*rbac.ClusterRole, error) {
+	created, err := c.ClusterRoles().Create(obj)
+	if err != nil {
+		// Allow caller to perform the same check and decide how to handle it.
+		if k8s_errors.IsAlreadyExists(err) {
+			return nil, err
+		}
+
+		return nil, errors.Wrapf(err, "failed to create cluster role [%s]", obj.Name)
+	}
+
+	return created, nil
+}
+
+// Delete removes the cluster role.
+//
+// It implements Client.
+func (c *DefaultClient) Delete(role string) error {
+	err := c.ClusterRoles().Delete(role, &meta.DeleteOptions{})
+	if err != nil {
+		return errors.Wrapf(err, "failed to delete cluster role [%s]", role)
+	}
+
+	return nil
+}
+
 // Get returns the object if found, reports that the object does not exist, or returns an error.
 //
 // A single GetOptions value can be passed as the final argument to customize the query.
@@ -68,4 +100,16 @@ func (c *DefaultClient) List(options ...meta.ListOptions) (*rbac.ClusterRoleList
 	return list, nil
 }
 
+// Update replaces the cluster role.
+//
+// It implements Client.
+func (c *DefaultClient) Update(obj *rbac.ClusterRole) (*rbac.ClusterRole, error) {
+	updated, err := c.ClusterRoles().Update(obj)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to update cluster role [%s]", obj.Name)
+	}
+
+	return updated, nil
+}
+
 var _ Client = (*DefaultClient)(nil)
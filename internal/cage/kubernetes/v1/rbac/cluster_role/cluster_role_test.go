@@ -118,3 +118,90 @@ func TestList(t *testing.T) {
 		require.Nil(t, actualList)
 	})
 }
+
+func TestCreate(t *testing.T) {
+	t.Run("created", func(t *testing.T) {
+		mockCtrl := gomock.NewController(t)
+		defer mockCtrl.Finish()
+
+		expectRole := &rbac.ClusterRole{ObjectMeta: meta.ObjectMeta{Name: ClusterRole}, Rules: []rbac.PolicyRule{{Verbs: []string{"get"}, Resources: []string{"pods"}}}}
+
+		mockInterface, wrapperClient := newClient(mockCtrl)
+		mockInterface.EXPECT().Create(expectRole).Return(expectRole, nil)
+
+		actualRole, err := wrapperClient.Create(expectRole)
+		require.NoError(t, err)
+		require.Exactly(t, expectRole, actualRole)
+	})
+
+	t.Run("error", func(t *testing.T) {
+		mockCtrl := gomock.NewController(t)
+		defer mockCtrl.Finish()
+
+		expectRole := &rbac.ClusterRole{ObjectMeta: meta.ObjectMeta{Name: ClusterRole}}
+		expectErr := errors.New("expectErr")
+
+		mockInterface, wrapperClient := newClient(mockCtrl)
+		mockInterface.EXPECT().Create(expectRole).Return(nil, expectErr)
+
+		actualRole, actualErr := wrapperClient.Create(expectRole)
+		cage_require.MatchRegexp(t, fmt.Sprintf("%v", actualErr), "failed to create cluster role.*expectErr")
+		require.Nil(t, actualRole)
+	})
+}
+
+func TestUpdate(t *testing.T) {
+	t.Run("updated", func(t *testing.T) {
+		mockCtrl := gomock.NewController(t)
+		defer mockCtrl.Finish()
+
+		expectRole := &rbac.ClusterRole{ObjectMeta: meta.ObjectMeta{Name: ClusterRole}}
+
+		mockInterface, wrapperClient := newClient(mockCtrl)
+		mockInterface.EXPECT().Update(expectRole).Return(expectRole, nil)
+
+		actualRole, err := wrapperClient.Update(expectRole)
+		require.NoError(t, err)
+		require.Exactly(t, expectRole, actualRole)
+	})
+
+	t.Run("error", func(t *testing.T) {
+		mockCtrl := gomock.NewController(t)
+		defer mockCtrl.Finish()
+
+		expectRole := &rbac.ClusterRole{ObjectMeta: meta.ObjectMeta{Name: ClusterRole}}
+		expectErr := errors.New("expectErr")
+
+		mockInterface, wrapperClient := newClient(mockCtrl)
+		mockInterface.EXPECT().Update(expectRole).Return(nil, expectErr)
+
+		actualRole, actualErr := wrapperClient.Update(expectRole)
+		cage_require.MatchRegexp(t, fmt.Sprintf("%v", actualErr), "failed to update cluster role.*expectErr")
+		require.Nil(t, actualRole)
+	})
+}
+
+func TestDelete(t *testing.T) {
+	t.Run("deleted", func(t *testing.T) {
+		mockCtrl := gomock.NewController(t)
+		defer mockCtrl.Finish()
+
+		mockInterface, wrapperClient := newClient(mockCtrl)
+		mockInterface.EXPECT().Delete(ClusterRole, &meta.DeleteOptions{}).Return(nil)
+
+		require.NoError(t, wrapperClient.Delete(ClusterRole))
+	})
+
+	t.Run("error", func(t *testing.T) {
+		mockCtrl := gomock.NewController(t)
+		defer mockCtrl.Finish()
+
+		expectErr := errors.New("expectErr")
+
+		mockInterface, wrapperClient := newClient(mockCtrl)
+		mockInterface.EXPECT().Delete(ClusterRole, &meta.DeleteOptions{}).Return(expectErr)
+
+		actualErr := wrapperClient.Delete(ClusterRole)
+		cage_require.MatchRegexp(t, fmt.Sprintf("%v", actualErr), "failed to delete cluster role.*expectErr")
+	})
+}
@@ -40,6 +40,35 @@ func (m *MockClient) EXPECT() *MockClientMockRecorder {
 	return m.recorder
 }
 
+// Create mocks base method
+func (m *MockClient) Create(obj *v1.ClusterRole) (*v1.ClusterRole, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Create", obj)
+	ret0, _ := ret[0].(*v1.ClusterRole)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Create indicates an expected call of Create
+func (mr *MockClientMockRecorder) Create(obj interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Create", reflect.TypeOf((*MockClient)(nil).Create), obj)
+}
+
+// Delete mocks base method
+func (m *MockClient) Delete(role string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Delete", role)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Delete indicates an expected call of Delete
+func (mr *MockClientMockRecorder) Delete(role interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Delete", reflect.TypeOf((*MockClient)(nil).Delete), role)
+}
+
 // Get mocks base method
 func (m *MockClient) Get(role string, options ...v10.GetOptions) (*v1.ClusterRole, bool, error) {
 	m.ctrl.T.Helper()
@@ -79,3 +108,18 @@ func (mr *MockClientMockRecorder) List(options ...interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "List", reflect.TypeOf((*MockClient)(nil).List), options...)
 }
+
+// Update mocks base method
+func (m *MockClient) Update(obj *v1.ClusterRole) (*v1.ClusterRole, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Update", obj)
+	ret0, _ := ret[0].(*v1.ClusterRole)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Update indicates an expected call of Update
+func (mr *MockClientMockRecorder) Update(obj interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Update", reflect.TypeOf((*MockClient)(nil).Update), obj)
+}
@@ -18,12 +18,16 @@ import (
 	"github.com/pkg/errors"
 
 	cage_k8s "github.com/codeactual/kubeauth/internal/cage/kubernetes/v1"
+	cage_k8s_rbac "github.com/codeactual/kubeauth/internal/cage/kubernetes/v1/rbac"
 )
 
 // Client provides an interface to cluster role bindings.
 type Client interface {
-	Create(name, role string, subject rbac.Subject) (*rbac.ClusterRoleBinding, error)
+	Create(objMeta meta.ObjectMeta, role string, subjects ...rbac.Subject) (*rbac.ClusterRoleBinding, error)
+	Get(name string, options ...meta.GetOptions) (_ *rbac.ClusterRoleBinding, exists bool, _ error)
 	List(options ...meta.ListOptions) (*rbac.ClusterRoleBindingList, error)
+	Update(obj *rbac.ClusterRoleBinding) (*rbac.ClusterRoleBinding, error)
+	Delete(name string) error
 }
 
 // DefaultClient implementation of Client operates on a real kubernetes API.
@@ -36,34 +40,79 @@ func NewDefaultClient(getter rbac_type.ClusterRoleBindingsGetter) *DefaultClient
 	return &DefaultClient{ClusterRoleBindingsGetter: getter}
 }
 
-// Create binds the role to a single subject.
-func (c *DefaultClient) Create(name, role string, subject rbac.Subject) (*rbac.ClusterRoleBinding, error) {
-	obj, err := c.ClusterRoleBindings().Create(&rbac.ClusterRoleBinding{
-		ObjectMeta: meta.ObjectMeta{Name: name},
+// NewObject returns the binding which Create would add, e.g. to render it instead.
+func NewObject(objMeta meta.ObjectMeta, role string, subjects ...rbac.Subject) *rbac.ClusterRoleBinding {
+	return &rbac.ClusterRoleBinding{
+		ObjectMeta: objMeta,
 		RoleRef:    rbac.RoleRef{Kind: cage_k8s.KindClusterRole, Name: role},
-		Subjects:   []rbac.Subject{subject},
-	})
+		Subjects:   subjects,
+	}
+}
+
+// Create binds the role to the subjects.
+//
+// The metadata selects the binding name and may include labels and annotations.
+//
+// It implements Client.
+func (c *DefaultClient) Create(objMeta meta.ObjectMeta, role string, subjects ...rbac.Subject) (*rbac.ClusterRoleBinding, error) {
+	obj, err := c.ClusterRoleBindings().Create(NewObject(objMeta, role, subjects...))
 	if err != nil {
 		// Allow caller to perform the same check and decide whether how to handlei it.
 		if k8s_errors.IsAlreadyExists(err) {
 			return nil, err
 		}
 
-		nsId := subject.Namespace
-		if nsId == "" {
-			nsId = cage_k8s.EmptyNamespace
-		}
-
 		return nil, errors.Wrapf(
 			err,
-			"failed to bind cluster role [%s] to subject [%s] (kind: %s ns: %s)",
-			role, subject.Name, subject.Kind, nsId,
+			"failed to bind cluster role [%s] to subjects %s",
+			role, cage_k8s_rbac.SubjectsString(subjects),
 		)
 	}
 
 	return obj, nil
 }
 
+// Get returns the object if found, reports that the object does not exist, or returns an error.
+//
+// A single GetOptions value can be passed as the final argument to customize the query.
+//
+// It implements Client.
+func (c *DefaultClient) Get(name string, options ...meta.GetOptions) (_ *rbac.ClusterRoleBinding, exists bool, _ error) {
+	obj, err := c.ClusterRoleBindings().Get(name, cage_k8s.GetOptionsFromVariadic(options))
+	if err != nil {
+		if k8s_errors.IsNotFound(err) {
+			return nil, false, nil
+		}
+		return nil, false, errors.Wrapf(err, "failed to get cluster role binding [%s]", name)
+	}
+
+	return obj, true, nil
+}
+
+// Update replaces the object.
+//
+// It implements Client.
+func (c *DefaultClient) Update(obj *rbac.ClusterRoleBinding) (*rbac.ClusterRoleBinding, error) {
+	updated, err := c.ClusterRoleBindings().Update(obj)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to update cluster role binding [%s]", obj.Name)
+	}
+
+	return updated, nil
+}
+
+// Delete removes the object.
+//
+// It implements Client.
+func (c *DefaultClient) Delete(name string) error {
+	err := c.ClusterRoleBindings().Delete(name, &meta.DeleteOptions{})
+	if err != nil {
+		return errors.Wrapf(err, "failed to delete cluster role binding [%s]", name)
+	}
+
+	return nil
+}
+
 // List returns the matching objects.
 //
 // A single ListOptions value can be passed as the final argument to customize the query.
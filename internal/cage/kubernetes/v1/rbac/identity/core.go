@@ -0,0 +1,167 @@
+// Copyright (C) 2020 The CodeActual Go Environment Authors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package identity
+
+import (
+	"context"
+	"regexp"
+	"strings"
+	"sync"
+
+	"github.com/pkg/errors"
+	meta "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	cage_k8s "github.com/codeactual/kubeauth/internal/cage/kubernetes/v1"
+	cage_k8s_core "github.com/codeactual/kubeauth/internal/cage/kubernetes/v1/core"
+	cage_k8s_discovery "github.com/codeactual/kubeauth/internal/cage/kubernetes/v1/discovery"
+)
+
+const (
+	// NodeUserPrefix begins the names of users which kubelets authenticate as, e.g. "system:node:worker-1".
+	NodeUserPrefix = "system:node:"
+
+	// BootstrapUserPrefix begins the names of users which bootstrap tokens authenticate as, e.g. "system:bootstrap:abcdef".
+	BootstrapUserPrefix = "system:bootstrap:"
+
+	// BootstrapGroupPrefix begins the names of the extra groups of bootstrap tokens,
+	// e.g. "system:bootstrappers:kubeadm:default-node-token".
+	BootstrapGroupPrefix = "system:bootstrappers:"
+)
+
+var (
+	// bootstrapTokenID matches the ID part of a bootstrap token.
+	//
+	// https://github.com/kubernetes/cluster-bootstrap/blob/kubernetes-1.17.0/token/api/types.go#L97
+	bootstrapTokenID = regexp.MustCompile(`^[a-z0-9]{6}$`)
+
+	// bootstrapGroupSuffix matches the part of a bootstrap token's extra group after BootstrapGroupPrefix.
+	//
+	// https://github.com/kubernetes/cluster-bootstrap/blob/kubernetes-1.17.0/token/api/types.go#L106
+	bootstrapGroupSuffix = regexp.MustCompile(`^[a-z0-9:-]{0,255}[a-z0-9]$`)
+)
+
+// coreIdentity is a user or group which the API server, or the components it trusts, authenticate
+// without a corresponding API object.
+type coreIdentity struct {
+	kind string
+
+	// name is the identity's name, or the prefix of a pattern's names if validate is non-nil.
+	name string
+
+	// validate (if non-nil) reports whether the remainder of a name, after the prefix, uses a valid format.
+	validate func(suffix string) bool
+
+	// since is the minor version of the 1.x API server release which introduced the identity.
+	since int
+}
+
+// coreIdentities lists the core identities of all API server releases.
+//
+// Node users, e.g. "system:node:<name>", are instead queried by NodeQuerier.
+//
+// They're included as string literals instead of imported constants in order to avoid k8s.io/apiserver
+// and its transitive dependencies.
+//
+// https://github.com/kubernetes/apiserver/blob/kubernetes-1.22.0/pkg/authentication/user/user.go#L69
+var coreIdentities = []coreIdentity{
+	{kind: cage_k8s.KindGroup, name: "system:masters"},
+	{kind: cage_k8s.KindGroup, name: "system:nodes"},
+	{kind: cage_k8s.KindGroup, name: "system:unauthenticated"},
+	{kind: cage_k8s.KindGroup, name: "system:authenticated"},
+	{kind: cage_k8s.KindGroup, name: "system:bootstrappers", since: 6},
+	{kind: cage_k8s.KindGroup, name: BootstrapGroupPrefix, validate: validBootstrapGroup, since: 6},
+	{kind: cage_k8s.KindGroup, name: "system:monitoring", since: 22},
+
+	{kind: cage_k8s.KindUser, name: "system:anonymous"},
+	{kind: cage_k8s.KindUser, name: "system:apiserver"},
+	{kind: cage_k8s.KindUser, name: "system:kube-proxy"},
+	{kind: cage_k8s.KindUser, name: "system:kube-controller-manager"},
+	{kind: cage_k8s.KindUser, name: "system:kube-scheduler"},
+	{kind: cage_k8s.KindUser, name: BootstrapUserPrefix, validate: validBootstrapUser, since: 6},
+}
+
+// queryCoreIdentities returns the core identities of the kind which the API server's release supports.
+//
+// If the release cannot be discovered, the identities of all releases are returned. If versions is
+// non-nil, the release is only discovered once.
+//
+// Pattern identities, e.g. "system:bootstrap:<token id>", are only included if the query selects a name
+// which matches the pattern and is valid.
+func queryCoreIdentities(ctx context.Context, clientset *cage_k8s_core.Clientset, versions *versionCache, query *Query, kind string) (*IdentityList, error) {
+	select {
+	case <-ctx.Done():
+		return nil, errors.Wrap(ctx.Err(), "query cancelled")
+	default:
+	}
+
+	// If the release is unknown, fall back to the identities of all releases so that lookups of
+	// static identities, e.g. "system:masters", do not depend on the discovery endpoint.
+	version, _ := versions.get(clientset.Discovery)
+
+	var found IdentityList
+
+	for _, id := range coreIdentities {
+		if id.kind != kind || (version != nil && !version.AtLeast(1, id.since)) {
+			continue
+		}
+
+		name := id.name
+
+		if id.validate == nil {
+			if query.Name != "" && query.Name != name {
+				continue
+			}
+		} else {
+			if !strings.HasPrefix(query.Name, id.name) || len(query.Name) == len(id.name) {
+				continue
+			}
+
+			if !id.validate(strings.TrimPrefix(query.Name, id.name)) {
+				continue
+			}
+
+			name = query.Name
+		}
+
+		found.Items = append(found.Items, Identity{
+			TypeMeta:   meta.TypeMeta{Kind: kind},
+			ObjectMeta: meta.ObjectMeta{Name: name},
+		})
+	}
+
+	return &found, nil
+}
+
+// versionCache holds the first discovered API server release, or the error which prevented discovery.
+type versionCache struct {
+	once    sync.Once
+	version *cage_k8s_discovery.Version
+	err     error
+}
+
+// get returns the cached release, discovering it with the client on the first call.
+//
+// If the cache is nil, the release is discovered on every call.
+func (c *versionCache) get(client cage_k8s_discovery.Client) (*cage_k8s_discovery.Version, error) {
+	if c == nil {
+		return client.Version()
+	}
+	c.once.Do(func() {
+		c.version, c.err = client.Version()
+	})
+	return c.version, c.err
+}
+
+// validBootstrapUser reports whether the ID uses the bootstrap token ID format.
+func validBootstrapUser(id string) bool {
+	return bootstrapTokenID.MatchString(id)
+}
+
+// validBootstrapGroup reports whether the suffix uses the bootstrap token extra group format.
+func validBootstrapGroup(suffix string) bool {
+	return bootstrapGroupSuffix.MatchString(suffix)
+}
@@ -0,0 +1,141 @@
+// Copyright (C) 2020 The CodeActual Go Environment Authors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package identity
+
+import (
+	"fmt"
+
+	"github.com/pkg/errors"
+	rbac "k8s.io/api/rbac/v1"
+
+	cage_k8s "github.com/codeactual/kubeauth/internal/cage/kubernetes/v1"
+	cage_k8s_core "github.com/codeactual/kubeauth/internal/cage/kubernetes/v1/core"
+)
+
+// danglingCheck detects binding subjects and role references which no longer resolve to existing objects.
+//
+// It caches existence checks because many bindings commonly refer to the same objects.
+type danglingCheck struct {
+	clientset *cage_k8s_core.Clientset
+
+	// exists is keyed by the output of danglingKey.
+	exists map[string]bool
+}
+
+func newDanglingCheck(clientset *cage_k8s_core.Clientset) *danglingCheck {
+	return &danglingCheck{clientset: clientset, exists: map[string]bool{}}
+}
+
+func danglingKey(kind, namespace, name string) string {
+	return kind + "/" + namespace + "/" + name
+}
+
+// objectExists returns the cached result of the existence check, performing it if needed.
+func (c *danglingCheck) objectExists(kind, namespace, name string) (bool, error) {
+	key := danglingKey(kind, namespace, name)
+	if exists, ok := c.exists[key]; ok {
+		return exists, nil
+	}
+
+	var exists bool
+	var err error
+
+	switch kind {
+	case cage_k8s.KindNamespace:
+		_, exists, err = c.clientset.Namespaces.Get(name)
+	case cage_k8s.KindServiceAccount:
+		_, exists, err = c.clientset.ServiceAccounts.Get(namespace, name)
+	case cage_k8s.KindRole:
+		_, exists, err = c.clientset.Roles.Get(namespace, name)
+	case cage_k8s.KindClusterRole:
+		_, exists, err = c.clientset.ClusterRoles.Get(name)
+	default:
+		return false, errors.Errorf("cannot check existence of unsupported kind [%s]", kind)
+	}
+	if err != nil {
+		return false, errors.WithStack(err)
+	}
+
+	c.exists[key] = exists
+
+	return exists, nil
+}
+
+// roleRef returns a non-empty reason if the binding's Role/ClusterRole does not exist.
+//
+// The namespace should be empty for cluster role bindings.
+func (c *danglingCheck) roleRef(namespace string, ref rbac.RoleRef) (string, error) {
+	if ref.Kind == cage_k8s.KindClusterRole {
+		namespace = ""
+	}
+
+	exists, err := c.objectExists(ref.Kind, namespace, ref.Name)
+	if err != nil {
+		return "", errors.WithStack(err)
+	}
+	if exists {
+		return "", nil
+	}
+
+	return fmt.Sprintf("%s [%s] not found", ref.Kind, ref.Name), nil
+}
+
+// subject returns a non-empty reason if the subject is a ServiceAccount which does not exist
+// or whose namespace does not exist.
+//
+// If the subject omits a namespace, the binding's namespace is used.
+func (c *danglingCheck) subject(bindingNamespace string, s rbac.Subject) (string, error) {
+	if s.Kind != cage_k8s.KindServiceAccount {
+		return "", nil
+	}
+
+	namespace := s.Namespace
+	if namespace == "" {
+		namespace = bindingNamespace
+	}
+
+	exists, err := c.objectExists(cage_k8s.KindNamespace, "", namespace)
+	if err != nil {
+		return "", errors.WithStack(err)
+	}
+	if !exists {
+		return fmt.Sprintf("namespace [%s] not found", namespace), nil
+	}
+
+	exists, err = c.objectExists(cage_k8s.KindServiceAccount, namespace, s.Name)
+	if err != nil {
+		return "", errors.WithStack(err)
+	}
+	if !exists {
+		return fmt.Sprintf("%s [%s] not found in namespace [%s]", cage_k8s.KindServiceAccount, s.Name, namespace), nil
+	}
+
+	return "", nil
+}
+
+// reasons returns all reasons why the subject of the binding is dangling.
+func (c *danglingCheck) reasons(bindingNamespace string, ref rbac.RoleRef, s rbac.Subject) ([]string, error) {
+	var reasons []string
+
+	reason, err := c.roleRef(bindingNamespace, ref)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	if reason != "" {
+		reasons = append(reasons, reason)
+	}
+
+	reason, err = c.subject(bindingNamespace, s)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	if reason != "" {
+		reasons = append(reasons, reason)
+	}
+
+	return reasons, nil
+}
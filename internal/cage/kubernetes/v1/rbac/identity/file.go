@@ -0,0 +1,200 @@
+// Copyright (C) 2020 The CodeActual Go Environment Authors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package identity
+
+import (
+	"bufio"
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+	meta "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/yaml"
+
+	cage_k8s "github.com/codeactual/kubeauth/internal/cage/kubernetes/v1"
+	cage_k8s_core "github.com/codeactual/kubeauth/internal/cage/kubernetes/v1/core"
+)
+
+// IdentityFile is the YAML document format read by NewYAMLFileQuerier.
+//
+// Group members are also included in query results as users.
+type IdentityFile struct {
+	Users  []string            `json:"users"`
+	Groups []IdentityFileGroup `json:"groups"`
+}
+
+// IdentityFileGroup is an IdentityFile group.
+type IdentityFileGroup struct {
+	Name    string   `json:"name"`
+	Members []string `json:"members"`
+}
+
+// FileQuerier queries users and groups listed in local files, e.g. those managed by an OIDC
+// provider or LDAP directory, which are unknown to the API server until they authenticate.
+//
+// Add it to a Registry with Registry.Register.
+type FileQuerier struct {
+	name   string
+	users  map[string]bool
+	groups map[string]bool
+}
+
+// NewYAMLFileQuerier returns a querier of the IdentityFile document at the path, or of all
+// *.yaml and *.yml documents if the path is a directory.
+func NewYAMLFileQuerier(path string) (*FileQuerier, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to read identity file [%s]", path)
+	}
+
+	files := []string{path}
+	if info.IsDir() {
+		files = nil
+		for _, pattern := range []string{"*.yaml", "*.yml"} {
+			matches, err := filepath.Glob(filepath.Join(path, pattern))
+			if err != nil {
+				return nil, errors.Wrapf(err, "failed to list identity files in directory [%s]", path)
+			}
+			files = append(files, matches...)
+		}
+		sort.Strings(files)
+	}
+
+	q := newFileQuerier("identity file [" + path + "]")
+
+	for _, f := range files {
+		content, err := ioutil.ReadFile(f) // #nosec G304
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to read identity file [%s]", f)
+		}
+
+		var doc IdentityFile
+		if err := yaml.UnmarshalStrict(content, &doc); err != nil {
+			return nil, errors.Wrapf(err, "failed to decode identity file [%s]", f)
+		}
+
+		for _, u := range doc.Users {
+			q.users[u] = true
+		}
+		for _, g := range doc.Groups {
+			if g.Name == "" {
+				return nil, errors.Errorf("identity file [%s] contains a group without a name", f)
+			}
+			q.groups[g.Name] = true
+			for _, m := range g.Members {
+				q.users[m] = true
+			}
+		}
+	}
+
+	return q, nil
+}
+
+// NewGroupFileQuerier returns a querier of the group file at the path, e.g. one exported from an LDAP
+// directory with "getent group".
+//
+// Each line uses the format <name>:<password>:<gid>:<comma-separated members>. Blank lines and
+// lines beginning with "#" are ignored. Group members are also included in query results as users.
+func NewGroupFileQuerier(path string) (*FileQuerier, error) {
+	f, err := os.Open(path) // #nosec G304
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to read group file [%s]", path)
+	}
+	defer f.Close()
+
+	q := newFileQuerier("group file [" + path + "]")
+
+	scanner := bufio.NewScanner(f)
+	for n := 1; scanner.Scan(); n++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		fields := strings.Split(line, ":")
+		if len(fields) != 4 || fields[0] == "" {
+			return nil, errors.Errorf("line [%d] of group file [%s] does not use format <name>:<password>:<gid>:<members>", n, path)
+		}
+
+		q.groups[fields[0]] = true
+		for _, m := range strings.Split(fields[3], ",") {
+			if m = strings.TrimSpace(m); m != "" {
+				q.users[m] = true
+			}
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, errors.Wrapf(err, "failed to read group file [%s]", path)
+	}
+
+	return q, nil
+}
+
+func newFileQuerier(name string) *FileQuerier {
+	return &FileQuerier{name: name, users: map[string]bool{}, groups: map[string]bool{}}
+}
+
+// String returns a unique description of the type of result provided by the querier.
+//
+// It implements Querier.
+func (q *FileQuerier) String() string {
+	return q.name
+}
+
+// Compatible returns true if the implementation can serve the query.
+//
+// It implements Querier.
+func (q *FileQuerier) Compatible(query *Query) bool {
+	return !query.Dangling && !query.Workloads && (query.Kind == "" || query.Kind == cage_k8s.KindUser || query.Kind == cage_k8s.KindGroup)
+}
+
+// Do performs the query.
+//
+// The query's namespace is ignored because users and groups are not namespaced.
+//
+// It implements Querier.
+func (q *FileQuerier) Do(ctx context.Context, _ *cage_k8s_core.Clientset, query *Query) (*IdentityList, error) {
+	select {
+	case <-ctx.Done():
+		return nil, errors.Wrap(ctx.Err(), "query cancelled")
+	default:
+	}
+
+	var found IdentityList
+
+	add := func(kind string, names map[string]bool) {
+		if query.Kind != "" && query.Kind != kind {
+			return
+		}
+
+		var sorted []string
+		for name := range names {
+			if query.Name == "" || query.Name == name {
+				sorted = append(sorted, name)
+			}
+		}
+		sort.Strings(sorted)
+
+		for _, name := range sorted {
+			found.Items = append(found.Items, Identity{
+				TypeMeta:   meta.TypeMeta{Kind: kind},
+				ObjectMeta: meta.ObjectMeta{Name: name},
+			})
+		}
+	}
+
+	add(cage_k8s.KindGroup, q.groups)
+	add(cage_k8s.KindUser, q.users)
+
+	return &found, nil
+}
+
+var _ Querier = (*FileQuerier)(nil)
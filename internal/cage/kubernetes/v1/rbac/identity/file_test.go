@@ -0,0 +1,113 @@
+// Copyright (C) 2020 The CodeActual Go Environment Authors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package identity_test
+
+import (
+	"fmt"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	cage_k8s "github.com/codeactual/kubeauth/internal/cage/kubernetes/v1"
+	cage_k8s_identity "github.com/codeactual/kubeauth/internal/cage/kubernetes/v1/rbac/identity"
+	testkit_file "github.com/codeactual/kubeauth/internal/cage/testkit/os/file"
+)
+
+// names returns the kind and name of each identity.
+func names(list *cage_k8s_identity.IdentityList) (s []string) {
+	for _, id := range list.Items {
+		s = append(s, id.Kind+" "+id.Name)
+	}
+	return s
+}
+
+func TestYAMLFileQuerier(t *testing.T) {
+	dir := filepath.Join(testkit_file.FixtureDataDir(), "identities")
+
+	q, err := cage_k8s_identity.NewYAMLFileQuerier(dir)
+	require.NoError(t, err)
+
+	require.Exactly(t, fmt.Sprintf("identity file [%s]", dir), q.String())
+
+	t.Run("compatible with subject kind", func(t *testing.T) {
+		require.True(t, q.Compatible(&cage_k8s_identity.Query{}))
+		require.True(t, q.Compatible(&cage_k8s_identity.Query{Kind: cage_k8s.KindUser}))
+		require.True(t, q.Compatible(&cage_k8s_identity.Query{Kind: cage_k8s.KindGroup}))
+		require.False(t, q.Compatible(&cage_k8s_identity.Query{Kind: cage_k8s.KindServiceAccount}))
+	})
+
+	t.Run("incompatible with dangling or workloads query", func(t *testing.T) {
+		require.False(t, q.Compatible(&cage_k8s_identity.Query{Dangling: true}))
+		require.False(t, q.Compatible(&cage_k8s_identity.Query{Workloads: true}))
+	})
+
+	t.Run("all", func(t *testing.T) {
+		list, err := q.Do(ctx(), nil, &cage_k8s_identity.Query{Namespace: Namespace})
+		require.NoError(t, err)
+		require.Exactly(
+			t,
+			[]string{"Group developers", "Group operators", "User alice", "User bob", "User carol"},
+			names(list),
+		)
+	})
+
+	t.Run("group hit", func(t *testing.T) {
+		list, err := q.Do(ctx(), nil, &cage_k8s_identity.Query{Kind: cage_k8s.KindGroup, Name: "operators"})
+		require.NoError(t, err)
+		require.Exactly(t, []string{"Group operators"}, names(list))
+	})
+
+	t.Run("kind miss", func(t *testing.T) {
+		list, err := q.Do(ctx(), nil, &cage_k8s_identity.Query{Kind: cage_k8s.KindUser, Name: "operators"})
+		require.NoError(t, err)
+		require.Empty(t, list.Items)
+	})
+
+	t.Run("single file", func(t *testing.T) {
+		q, err := cage_k8s_identity.NewYAMLFileQuerier(filepath.Join(dir, "operators.yml"))
+		require.NoError(t, err)
+
+		list, err := q.Do(ctx(), nil, &cage_k8s_identity.Query{})
+		require.NoError(t, err)
+		require.Exactly(t, []string{"Group operators", "User carol"}, names(list))
+	})
+
+	t.Run("unknown field", func(t *testing.T) {
+		_, err := cage_k8s_identity.NewYAMLFileQuerier(filepath.Join(testkit_file.FixtureDataDir(), "invalid.yaml"))
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "failed to decode identity file")
+	})
+
+	t.Run("not found", func(t *testing.T) {
+		_, err := cage_k8s_identity.NewYAMLFileQuerier(filepath.Join(testkit_file.FixtureDataDir(), DoesNotExist))
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "failed to read identity file")
+	})
+}
+
+func TestGroupFileQuerier(t *testing.T) {
+	path := filepath.Join(testkit_file.FixtureDataDir(), "group")
+
+	q, err := cage_k8s_identity.NewGroupFileQuerier(path)
+	require.NoError(t, err)
+
+	require.Exactly(t, fmt.Sprintf("group file [%s]", path), q.String())
+
+	t.Run("all", func(t *testing.T) {
+		list, err := q.Do(ctx(), nil, &cage_k8s_identity.Query{})
+		require.NoError(t, err)
+		require.Exactly(t, []string{"Group developers", "Group operators", "User alice", "User bob"}, names(list))
+	})
+
+	t.Run("invalid line", func(t *testing.T) {
+		invalid := filepath.Join(testkit_file.FixtureDataDir(), "group-invalid")
+		_, err := cage_k8s_identity.NewGroupFileQuerier(invalid)
+		require.Error(t, err)
+		require.Contains(t, err.Error(), fmt.Sprintf("line [1] of group file [%s] does not use format", invalid))
+	})
+}
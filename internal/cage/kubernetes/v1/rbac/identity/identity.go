@@ -7,6 +7,8 @@
 package identity
 
 import (
+	"strings"
+
 	meta "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
@@ -39,10 +41,25 @@ type Identity struct {
 	meta.ObjectMeta
 
 	// Source describes the object (if any) in which this Identity was found, e.g. RoleBinding.
+	//
+	// If the Identity was merged by Registry.Query, it is the first element of Sources.
 	Source *IdentitySource
 
+	// Sources describes all objects in which this Identity was found. It is populated by Registry.Query.
+	Sources []*IdentitySource
+
 	// Querier indicates which IdentityQuerier implementation produced this value.
+	//
+	// If the Identity was merged by Registry.Query, it is the first element of Queriers.
 	Querier string
+
+	// Queriers indicates all IdentityQuerier implementations which produced this value, in priority order.
+	// It is populated by Registry.Query.
+	Queriers []string
+
+	// Dangling holds the reasons why the identity's Source no longer grants it access, e.g. its
+	// ServiceAccount was deleted. It is only populated by queries which select QueryDangling.
+	Dangling []string
 }
 
 // String returns the relevant fields in a human-readable format for use in info/error messages.
@@ -59,10 +76,31 @@ func (i Identity) String() (s string) {
 	if i.Namespace != "" {
 		s += " of namespace " + i.Namespace
 	}
-	if i.Source != nil {
-		s += " (from " + i.Source.String() + ")"
+
+	sources := i.Sources
+	if len(sources) == 0 && i.Source != nil {
+		sources = []*IdentitySource{i.Source}
+	}
+	if len(sources) > 0 {
+		var sourceStrs []string
+		for _, source := range sources {
+			sourceStrs = append(sourceStrs, source.String())
+		}
+		s += " (from " + strings.Join(sourceStrs, ", ") + ")"
+	}
+
+	queriers := i.Queriers
+	if len(queriers) == 0 {
+		queriers = []string{i.Querier}
+	}
+	s += " via [" + strings.Join(queriers, ", ") + "] querier"
+	if len(queriers) > 1 {
+		s += "s"
+	}
+
+	if len(i.Dangling) > 0 {
+		s += " is dangling: " + strings.Join(i.Dangling, ", ")
 	}
-	s += " via [" + i.Querier + "] querier"
 	return s
 }
 
@@ -74,6 +112,61 @@ type IdentityList struct {
 	Items []Identity
 }
 
+// Key returns a value which is unique to the identity's kind, namespace, and name.
+func (i Identity) Key() string {
+	return i.Kind + "/" + i.Namespace + "/" + i.Name
+}
+
+// Key returns a value which is unique to the source's kind, namespace, and name.
+func (i IdentitySource) Key() string {
+	return i.Kind + "/" + i.Namespace + "/" + i.Name
+}
+
+// merge adds the other identity's sources, queriers, and dangling reasons which are not already included.
+func (i *Identity) merge(other Identity) {
+	if other.Source != nil {
+		found := false
+		for _, source := range i.Sources {
+			if source.Key() == other.Source.Key() {
+				found = true
+				break
+			}
+		}
+		if !found {
+			i.Sources = append(i.Sources, other.Source)
+		}
+	}
+
+	found := false
+	for _, querier := range i.Queriers {
+		if querier == other.Querier {
+			found = true
+			break
+		}
+	}
+	if !found {
+		i.Queriers = append(i.Queriers, other.Querier)
+	}
+
+	for _, reason := range other.Dangling {
+		found := false
+		for _, existing := range i.Dangling {
+			if existing == reason {
+				found = true
+				break
+			}
+		}
+		if !found {
+			i.Dangling = append(i.Dangling, reason)
+		}
+	}
+
+	if len(i.Sources) > 0 {
+		i.Source = i.Sources[0]
+	}
+	i.Querier = i.Queriers[0]
+}
+
 // Add appends and returns a new list item.
 func (i *IdentityList) Add(namespace, kind, name string, source *IdentitySource) {
 	i.Items = append(i.Items, Identity{
@@ -20,24 +20,28 @@ import (
 )
 
 type Registry struct {
-	CoreGroup           *MockQuerier
-	CoreUser            *MockQuerier
-	RoleSubject         *MockQuerier
-	ClusterRoleSubject  *MockQuerier
-	ServiceAccountUser  *MockQuerier
-	ServiceAccountGroup *MockQuerier
-	ConfigUser          *MockQuerier
+	CoreGroup              *MockQuerier
+	CoreUser               *MockQuerier
+	Node                   *MockQuerier
+	RoleSubject            *MockQuerier
+	ClusterRoleSubject     *MockQuerier
+	ServiceAccountUser     *MockQuerier
+	ServiceAccountGroup    *MockQuerier
+	ServiceAccountWorkload *MockQuerier
+	ConfigUser             *MockQuerier
 }
 
 func NewRegistry(mockCtrl *gomock.Controller) *Registry {
 	r := Registry{
-		CoreGroup:           NewMockQuerier(mockCtrl),
-		CoreUser:            NewMockQuerier(mockCtrl),
-		RoleSubject:         NewMockQuerier(mockCtrl),
-		ClusterRoleSubject:  NewMockQuerier(mockCtrl),
-		ServiceAccountUser:  NewMockQuerier(mockCtrl),
-		ServiceAccountGroup: NewMockQuerier(mockCtrl),
-		ConfigUser:          NewMockQuerier(mockCtrl),
+		CoreGroup:              NewMockQuerier(mockCtrl),
+		CoreUser:               NewMockQuerier(mockCtrl),
+		Node:                   NewMockQuerier(mockCtrl),
+		RoleSubject:            NewMockQuerier(mockCtrl),
+		ClusterRoleSubject:     NewMockQuerier(mockCtrl),
+		ServiceAccountUser:     NewMockQuerier(mockCtrl),
+		ServiceAccountGroup:    NewMockQuerier(mockCtrl),
+		ServiceAccountWorkload: NewMockQuerier(mockCtrl),
+		ConfigUser:             NewMockQuerier(mockCtrl),
 	}
 
 	// Reuse real Querier.Compatible checks because they're currently fast.
@@ -47,6 +51,9 @@ func NewRegistry(mockCtrl *gomock.Controller) *Registry {
 	r.CoreUser.EXPECT().Compatible(gomock.Any()).DoAndReturn(func(query interface{}) bool {
 		return cage_k8s_identity.CoreUserQuerier{}.Compatible(query.(*cage_k8s_identity.Query))
 	}).AnyTimes()
+	r.Node.EXPECT().Compatible(gomock.Any()).DoAndReturn(func(query interface{}) bool {
+		return cage_k8s_identity.NodeQuerier{}.Compatible(query.(*cage_k8s_identity.Query))
+	}).AnyTimes()
 	r.RoleSubject.EXPECT().Compatible(gomock.Any()).DoAndReturn(func(query interface{}) bool {
 		return cage_k8s_identity.RoleSubjectQuerier{}.Compatible(query.(*cage_k8s_identity.Query))
 	}).AnyTimes()
@@ -59,6 +66,9 @@ func NewRegistry(mockCtrl *gomock.Controller) *Registry {
 	r.ServiceAccountGroup.EXPECT().Compatible(gomock.Any()).DoAndReturn(func(query interface{}) bool {
 		return cage_k8s_identity.ServiceAccountGroupQuerier{}.Compatible(query.(*cage_k8s_identity.Query))
 	}).AnyTimes()
+	r.ServiceAccountWorkload.EXPECT().Compatible(gomock.Any()).DoAndReturn(func(query interface{}) bool {
+		return cage_k8s_identity.ServiceAccountWorkloadQuerier{}.Compatible(query.(*cage_k8s_identity.Query))
+	}).AnyTimes()
 	r.ConfigUser.EXPECT().Compatible(gomock.Any()).DoAndReturn(func(query interface{}) bool {
 		return cage_k8s_identity.ConfigUserQuerier{}.Compatible(query.(*cage_k8s_identity.Query))
 	}).AnyTimes()
@@ -72,6 +82,9 @@ func NewRegistry(mockCtrl *gomock.Controller) *Registry {
 	r.CoreUser.EXPECT().String().DoAndReturn(func() string {
 		return cage_k8s_identity.CoreUserQuerier{}.String()
 	}).AnyTimes()
+	r.Node.EXPECT().String().DoAndReturn(func() string {
+		return cage_k8s_identity.NodeQuerier{}.String()
+	}).AnyTimes()
 	r.RoleSubject.EXPECT().String().DoAndReturn(func() string {
 		return cage_k8s_identity.RoleSubjectQuerier{}.String()
 	}).AnyTimes()
@@ -84,6 +97,9 @@ func NewRegistry(mockCtrl *gomock.Controller) *Registry {
 	r.ServiceAccountGroup.EXPECT().String().DoAndReturn(func() string {
 		return cage_k8s_identity.ServiceAccountGroupQuerier{}.String()
 	}).AnyTimes()
+	r.ServiceAccountWorkload.EXPECT().String().DoAndReturn(func() string {
+		return cage_k8s_identity.ServiceAccountWorkloadQuerier{}.String()
+	}).AnyTimes()
 	r.ConfigUser.EXPECT().String().DoAndReturn(func() string {
 		return cage_k8s_identity.ConfigUserQuerier{}.String()
 	}).AnyTimes()
@@ -93,14 +109,16 @@ func NewRegistry(mockCtrl *gomock.Controller) *Registry {
 
 func (r *Registry) ToReal(clientset *cage_k8s_core.Clientset) *cage_k8s_identity.Registry {
 	return &cage_k8s_identity.Registry{
-		CoreGroup:           r.CoreGroup,
-		CoreUser:            r.CoreUser,
-		RoleSubject:         r.RoleSubject,
-		ClusterRoleSubject:  r.ClusterRoleSubject,
-		ServiceAccountUser:  r.ServiceAccountUser,
-		ServiceAccountGroup: r.ServiceAccountGroup,
-		ConfigUser:          r.ConfigUser,
-		Clientset:           clientset,
+		CoreGroup:              r.CoreGroup,
+		CoreUser:               r.CoreUser,
+		Node:                   r.Node,
+		RoleSubject:            r.RoleSubject,
+		ClusterRoleSubject:     r.ClusterRoleSubject,
+		ServiceAccountUser:     r.ServiceAccountUser,
+		ServiceAccountGroup:    r.ServiceAccountGroup,
+		ServiceAccountWorkload: r.ServiceAccountWorkload,
+		ConfigUser:             r.ConfigUser,
+		Clientset:              clientset,
 	}
 }
 
@@ -113,7 +131,9 @@ func (m *matchQuery) Matches(x interface{}) bool {
 	actual, ok := x.(*cage_k8s_identity.Query)
 	matches := ok && actual != nil &&
 		m.expected.Kind == actual.Kind &&
-		m.expected.Name == actual.Name
+		m.expected.Name == actual.Name &&
+		m.expected.Dangling == actual.Dangling &&
+		m.expected.Workloads == actual.Workloads
 
 	if !m.allNamespaces {
 		matches = matches && m.expected.Namespace == actual.Namespace
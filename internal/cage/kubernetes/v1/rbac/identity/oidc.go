@@ -0,0 +1,343 @@
+// Copyright (C) 2020 The CodeActual Go Environment Authors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package identity
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+	meta "k8s.io/apimachinery/pkg/apis/meta/v1"
+	clientcmdapi "k8s.io/client-go/tools/clientcmd/api"
+
+	cage_k8s "github.com/codeactual/kubeauth/internal/cage/kubernetes/v1"
+	cage_k8s_core "github.com/codeactual/kubeauth/internal/cage/kubernetes/v1/core"
+)
+
+const (
+	// DefaultOIDCUsernameClaim is the default of the API server's --oidc-username-claim.
+	DefaultOIDCUsernameClaim = "sub"
+
+	// DefaultOIDCGroupsClaim is the claim commonly used for the API server's --oidc-groups-claim,
+	// which has no default.
+	DefaultOIDCGroupsClaim = "groups"
+
+	// OIDCPrefixDisabled is the --oidc-username-prefix value which disables the API server's
+	// default prefix.
+	OIDCPrefixDisabled = "-"
+
+	// OIDCAuthProvider is the name of the kubeconfig auth-provider which supplies ID tokens.
+	OIDCAuthProvider = "oidc"
+
+	// AuthInfoKind is the IdentitySource kind of kubeconfig users.
+	AuthInfoKind = "AuthInfo"
+)
+
+// OIDCConfig mirrors the API server's --oidc-* flags which determine how ID token claims
+// become usernames and groups.
+type OIDCConfig struct {
+	// UsernameClaim selects the claim which holds the username, e.g. "email".
+	UsernameClaim string
+
+	// UsernamePrefix is prepended to usernames, e.g. "oidc:". If empty, and UsernameClaim is not
+	// "email", the API server's default prefix of "<issuer URL>#" is used. OIDCPrefixDisabled
+	// disables prefixes.
+	UsernamePrefix string
+
+	// GroupsClaim selects the claim which holds the groups.
+	GroupsClaim string
+
+	// GroupsPrefix is prepended to groups, e.g. "oidc:".
+	GroupsPrefix string
+}
+
+// NormalizeUsername returns the name with the UsernamePrefix prepended, e.g. "alice" to "oidc:alice".
+//
+// The name is returned unchanged if it already has the prefix, is a "system:" user, or if no
+// explicit prefix is configured.
+func (c OIDCConfig) NormalizeUsername(name string) string {
+	if c.UsernamePrefix == "" || c.UsernamePrefix == OIDCPrefixDisabled {
+		return name
+	}
+	return normalizeOIDCName(c.UsernamePrefix, name)
+}
+
+// NormalizeGroup returns the name with the GroupsPrefix prepended, e.g. "qa" to "oidc:qa".
+//
+// The name is returned unchanged if it already has the prefix, is a "system:" group, or if no
+// prefix is configured.
+func (c OIDCConfig) NormalizeGroup(name string) string {
+	if c.GroupsPrefix == "" {
+		return name
+	}
+	return normalizeOIDCName(c.GroupsPrefix, name)
+}
+
+// Customized reports whether any setting differs from the API server's defaults.
+func (c OIDCConfig) Customized() bool {
+	return c.UsernamePrefix != "" || c.GroupsPrefix != "" ||
+		(c.UsernameClaim != "" && c.UsernameClaim != DefaultOIDCUsernameClaim) ||
+		(c.GroupsClaim != "" && c.GroupsClaim != DefaultOIDCGroupsClaim)
+}
+
+func normalizeOIDCName(prefix, name string) string {
+	if name == "" || strings.HasPrefix(name, prefix) || strings.HasPrefix(name, "system:") {
+		return name
+	}
+	return prefix + name
+}
+
+// username returns the name which the API server would assign to the user of the claims.
+func (c OIDCConfig) username(claims map[string]interface{}) string {
+	name, _ := claims[c.UsernameClaim].(string)
+	if name == "" {
+		return ""
+	}
+
+	switch c.UsernamePrefix {
+	case OIDCPrefixDisabled:
+		return name
+	case "":
+		if c.UsernameClaim == "email" {
+			return name
+		}
+		issuer, _ := claims["iss"].(string)
+		return issuer + "#" + name
+	default:
+		return c.UsernamePrefix + name
+	}
+}
+
+// groups returns the names which the API server would assign to the groups of the claims.
+func (c OIDCConfig) groups(claims map[string]interface{}) (names []string) {
+	switch v := claims[c.GroupsClaim].(type) {
+	case string:
+		if v != "" {
+			names = append(names, c.GroupsPrefix+v)
+		}
+	case []interface{}:
+		for _, g := range v {
+			if s, ok := g.(string); ok && s != "" {
+				names = append(names, c.GroupsPrefix+s)
+			}
+		}
+	}
+	return names
+}
+
+// OIDCQuerier queries the users, and their groups, of OpenID Connect ID tokens found in kubeconfig users.
+//
+// Tokens are read from the "id-token" of "oidc" auth-provider entries. Exec entries are not run,
+// so their tokens are only found if passed to the plugin as an argument or environment variable.
+// Tokens are decoded but not verified, and those which cannot be decoded are skipped.
+//
+// Add it to a Registry with Registry.Register.
+type OIDCQuerier struct {
+	// Notify, if non-nil, receives status messages, e.g. that a kubeconfig user's token was skipped.
+	Notify func(msg string)
+
+	config OIDCConfig
+}
+
+// NewOIDCQuerier returns a querier which applies the config to ID token claims.
+//
+// Empty claim selections default to DefaultOIDCUsernameClaim and DefaultOIDCGroupsClaim.
+func NewOIDCQuerier(config OIDCConfig) *OIDCQuerier {
+	if config.UsernameClaim == "" {
+		config.UsernameClaim = DefaultOIDCUsernameClaim
+	}
+	if config.GroupsClaim == "" {
+		config.GroupsClaim = DefaultOIDCGroupsClaim
+	}
+	return &OIDCQuerier{config: config}
+}
+
+// String returns a unique description of the type of result provided by the querier.
+//
+// It implements Querier.
+func (q *OIDCQuerier) String() string {
+	return "oidc token"
+}
+
+// Compatible returns true if the implementation can serve the query.
+//
+// It implements Querier.
+func (q *OIDCQuerier) Compatible(query *Query) bool {
+	return !query.Dangling && !query.Workloads && query.ClientCmdConfig != nil &&
+		(query.Kind == "" || query.Kind == cage_k8s.KindUser || query.Kind == cage_k8s.KindGroup)
+}
+
+// Do performs the query.
+//
+// The query's name matches both prefixed and unprefixed names, e.g. "alice" and "oidc:alice" if
+// the UsernamePrefix is "oidc:". The query's namespace is ignored because users and groups are not namespaced.
+//
+// It implements Querier.
+func (q *OIDCQuerier) Do(ctx context.Context, _ *cage_k8s_core.Clientset, query *Query) (*IdentityList, error) {
+	if query.ClientCmdConfig == nil {
+		return nil, errors.Errorf("[%s] querier received a nil kubeconfig object", q)
+	}
+
+	select {
+	case <-ctx.Done():
+		return nil, errors.Wrap(ctx.Err(), "query cancelled")
+	default:
+	}
+
+	var authInfoNames []string
+	for name := range query.ClientCmdConfig.AuthInfos {
+		authInfoNames = append(authInfoNames, name)
+	}
+	sort.Strings(authInfoNames)
+
+	var found IdentityList
+
+	add := func(kind, name, normalizedQueryName string, source *IdentitySource) {
+		if query.Kind != "" && query.Kind != kind {
+			return
+		}
+		if query.Name != "" && query.Name != name && normalizedQueryName != name {
+			return
+		}
+		found.Items = append(found.Items, Identity{
+			TypeMeta:   meta.TypeMeta{Kind: kind},
+			ObjectMeta: meta.ObjectMeta{Name: name},
+			Source:     source,
+		})
+	}
+
+	for _, authInfoName := range authInfoNames {
+		tokens, err := oidcTokens(query.ClientCmdConfig.AuthInfos[authInfoName])
+		if err != nil {
+			q.notify("skipped ID token of kubeconfig user [%s]: %s", authInfoName, err)
+		}
+
+		source := &IdentitySource{
+			TypeMeta:   meta.TypeMeta{Kind: AuthInfoKind},
+			ObjectMeta: meta.ObjectMeta{Name: authInfoName},
+		}
+
+		for _, claims := range tokens {
+			if username := q.config.username(claims); username != "" {
+				add(cage_k8s.KindUser, username, q.config.NormalizeUsername(query.Name), source)
+			}
+			for _, group := range q.config.groups(claims) {
+				add(cage_k8s.KindGroup, group, q.config.NormalizeGroup(query.Name), source)
+			}
+		}
+	}
+
+	return &found, nil
+}
+
+var _ Querier = (*OIDCQuerier)(nil)
+
+func (q *OIDCQuerier) notify(format string, vArgs ...interface{}) {
+	if q.Notify != nil {
+		q.Notify(fmt.Sprintf(format, vArgs...))
+	}
+}
+
+// HasOIDCAuthInfo reports whether any kubeconfig user has an "oidc" auth-provider entry, or an exec
+// entry which passes an ID token to its plugin.
+func HasOIDCAuthInfo(config *clientcmdapi.Config) bool {
+	if config == nil {
+		return false
+	}
+	for _, authInfo := range config.AuthInfos {
+		if authInfo == nil {
+			continue
+		}
+		if authInfo.AuthProvider != nil && authInfo.AuthProvider.Name == OIDCAuthProvider {
+			return true
+		}
+		if len(execTokens(authInfo.Exec)) > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// oidcTokens returns the claims of the ID tokens used by the kubeconfig user.
+//
+// If the auth-provider's token cannot be decoded, the claims of the exec plugin's tokens are returned
+// with the error.
+func oidcTokens(authInfo *clientcmdapi.AuthInfo) (tokens []map[string]interface{}, err error) {
+	if authInfo == nil {
+		return nil, nil
+	}
+
+	if authInfo.AuthProvider != nil && authInfo.AuthProvider.Name == OIDCAuthProvider {
+		if raw := authInfo.AuthProvider.Config["id-token"]; raw != "" {
+			var claims map[string]interface{}
+			if claims, err = DecodeIDToken(raw); err == nil {
+				tokens = append(tokens, claims)
+			}
+		}
+	}
+
+	return append(tokens, execTokens(authInfo.Exec)...), errors.WithStack(err)
+}
+
+// execTokens returns the claims of the ID tokens passed to the exec plugin.
+//
+// Plugin arguments and environment variables are only candidates, so those which cannot be decoded are skipped.
+func execTokens(exec *clientcmdapi.ExecConfig) (tokens []map[string]interface{}) {
+	if exec == nil {
+		return nil
+	}
+
+	var candidates []string
+	for _, arg := range exec.Args {
+		// Support both "--flag=<token>" and "--flag <token>" forms.
+		if parts := strings.SplitN(arg, "=", 2); len(parts) == 2 {
+			arg = parts[1]
+		}
+		candidates = append(candidates, arg)
+	}
+	for _, env := range exec.Env {
+		candidates = append(candidates, env.Value)
+	}
+
+	for _, c := range candidates {
+		if claims, err := DecodeIDToken(c); err == nil {
+			tokens = append(tokens, claims)
+		}
+	}
+
+	return tokens
+}
+
+// DecodeIDToken returns the claims of the JWT-encoded ID token.
+//
+// The signature is not verified, so the claims must not be trusted for authentication.
+func DecodeIDToken(raw string) (map[string]interface{}, error) {
+	parts := strings.Split(raw, ".")
+	if len(parts) != 3 {
+		return nil, errors.New("ID token is not a JWT")
+	}
+
+	payload, err := base64.RawURLEncoding.DecodeString(strings.TrimRight(parts[1], "="))
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to decode ID token payload")
+	}
+
+	var claims map[string]interface{}
+	if err := json.Unmarshal(payload, &claims); err != nil {
+		return nil, errors.Wrap(err, "failed to decode ID token claims")
+	}
+
+	if iss, _ := claims["iss"].(string); iss == "" {
+		return nil, errors.New("ID token has no issuer claim")
+	}
+
+	return claims, nil
+}
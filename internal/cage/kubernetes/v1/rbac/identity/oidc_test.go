@@ -0,0 +1,182 @@
+// Copyright (C) 2020 The CodeActual Go Environment Authors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package identity_test
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	clientcmdapi "k8s.io/client-go/tools/clientcmd/api"
+
+	cage_k8s "github.com/codeactual/kubeauth/internal/cage/kubernetes/v1"
+	cage_k8s_identity "github.com/codeactual/kubeauth/internal/cage/kubernetes/v1/rbac/identity"
+)
+
+const issuer = "https://issuer.example.com"
+
+// idToken returns an unsigned JWT with the claims.
+func idToken(t *testing.T, claims map[string]interface{}) string {
+	payload, err := json.Marshal(claims)
+	require.NoError(t, err)
+
+	enc := base64.RawURLEncoding
+	return enc.EncodeToString([]byte(`{"alg":"none"}`)) + "." + enc.EncodeToString(payload) + ".sig"
+}
+
+// oidcConfig returns a kubeconfig with an auth-provider user and an exec user.
+func oidcConfig(t *testing.T) *clientcmdapi.Config {
+	config := clientcmdapi.NewConfig()
+	config.AuthInfos["alice-oidc"] = &clientcmdapi.AuthInfo{
+		AuthProvider: &clientcmdapi.AuthProviderConfig{
+			Name: cage_k8s_identity.OIDCAuthProvider,
+			Config: map[string]string{
+				"id-token": idToken(t, map[string]interface{}{
+					"iss": issuer, "sub": "1234", "email": "alice", "groups": []string{"dev", "qa"},
+				}),
+			},
+		},
+	}
+	config.AuthInfos["bob-exec"] = &clientcmdapi.AuthInfo{
+		Exec: &clientcmdapi.ExecConfig{
+			Command: "token-helper",
+			Args: []string{
+				"get-token",
+				"--id-token=" + idToken(t, map[string]interface{}{"iss": issuer, "sub": "5678", "email": "bob", "groups": "ops"}),
+			},
+		},
+	}
+	config.AuthInfos["carol-cert"] = &clientcmdapi.AuthInfo{ClientCertificate: "carol.crt"}
+	return config
+}
+
+func TestOIDCQuerier(t *testing.T) {
+	q := cage_k8s_identity.NewOIDCQuerier(cage_k8s_identity.OIDCConfig{
+		UsernameClaim:  "email",
+		UsernamePrefix: "oidc:",
+		GroupsPrefix:   "oidc:",
+	})
+
+	require.Exactly(t, "oidc token", q.String())
+
+	t.Run("compatible with subject kind", func(t *testing.T) {
+		config := oidcConfig(t)
+		require.True(t, q.Compatible(&cage_k8s_identity.Query{ClientCmdConfig: config}))
+		require.True(t, q.Compatible(&cage_k8s_identity.Query{Kind: cage_k8s.KindUser, ClientCmdConfig: config}))
+		require.True(t, q.Compatible(&cage_k8s_identity.Query{Kind: cage_k8s.KindGroup, ClientCmdConfig: config}))
+		require.False(t, q.Compatible(&cage_k8s_identity.Query{Kind: cage_k8s.KindServiceAccount, ClientCmdConfig: config}))
+	})
+
+	t.Run("incompatible without kubeconfig", func(t *testing.T) {
+		require.False(t, q.Compatible(&cage_k8s_identity.Query{}))
+	})
+
+	t.Run("incompatible with dangling or workloads query", func(t *testing.T) {
+		config := oidcConfig(t)
+		require.False(t, q.Compatible(&cage_k8s_identity.Query{Dangling: true, ClientCmdConfig: config}))
+		require.False(t, q.Compatible(&cage_k8s_identity.Query{Workloads: true, ClientCmdConfig: config}))
+	})
+
+	t.Run("all", func(t *testing.T) {
+		list, err := q.Do(ctx(), nil, &cage_k8s_identity.Query{ClientCmdConfig: oidcConfig(t)})
+		require.NoError(t, err)
+		require.Exactly(
+			t,
+			[]string{"User oidc:alice", "Group oidc:dev", "Group oidc:qa", "User oidc:bob", "Group oidc:ops"},
+			names(list),
+		)
+		require.Exactly(t, "AuthInfo alice-oidc", list.Items[0].Source.String())
+		require.Exactly(t, "AuthInfo bob-exec", list.Items[3].Source.String())
+	})
+
+	t.Run("unprefixed user hit", func(t *testing.T) {
+		list, err := q.Do(ctx(), nil, &cage_k8s_identity.Query{Kind: cage_k8s.KindUser, Name: "alice", ClientCmdConfig: oidcConfig(t)})
+		require.NoError(t, err)
+		require.Exactly(t, []string{"User oidc:alice"}, names(list))
+	})
+
+	t.Run("prefixed group hit", func(t *testing.T) {
+		list, err := q.Do(ctx(), nil, &cage_k8s_identity.Query{Kind: cage_k8s.KindGroup, Name: "oidc:ops", ClientCmdConfig: oidcConfig(t)})
+		require.NoError(t, err)
+		require.Exactly(t, []string{"Group oidc:ops"}, names(list))
+	})
+
+	t.Run("kind miss", func(t *testing.T) {
+		list, err := q.Do(ctx(), nil, &cage_k8s_identity.Query{Kind: cage_k8s.KindGroup, Name: "alice", ClientCmdConfig: oidcConfig(t)})
+		require.NoError(t, err)
+		require.Empty(t, list.Items)
+	})
+
+	t.Run("default prefix", func(t *testing.T) {
+		q := cage_k8s_identity.NewOIDCQuerier(cage_k8s_identity.OIDCConfig{})
+
+		list, err := q.Do(ctx(), nil, &cage_k8s_identity.Query{Kind: cage_k8s.KindUser, ClientCmdConfig: oidcConfig(t)})
+		require.NoError(t, err)
+		require.Exactly(t, []string{"User " + issuer + "#1234", "User " + issuer + "#5678"}, names(list))
+	})
+
+	t.Run("disabled prefix", func(t *testing.T) {
+		q := cage_k8s_identity.NewOIDCQuerier(cage_k8s_identity.OIDCConfig{UsernamePrefix: cage_k8s_identity.OIDCPrefixDisabled})
+
+		list, err := q.Do(ctx(), nil, &cage_k8s_identity.Query{Kind: cage_k8s.KindUser, ClientCmdConfig: oidcConfig(t)})
+		require.NoError(t, err)
+		require.Exactly(t, []string{"User 1234", "User 5678"}, names(list))
+	})
+
+	t.Run("skip invalid auth-provider token", func(t *testing.T) {
+		config := oidcConfig(t)
+		config.AuthInfos["alice-oidc"].AuthProvider.Config["id-token"] = "not-a-jwt"
+
+		var msgs []string
+		q := cage_k8s_identity.NewOIDCQuerier(cage_k8s_identity.OIDCConfig{UsernameClaim: "email"})
+		q.Notify = func(msg string) {
+			msgs = append(msgs, msg)
+		}
+
+		list, err := q.Do(ctx(), nil, &cage_k8s_identity.Query{Kind: cage_k8s.KindUser, ClientCmdConfig: config})
+		require.NoError(t, err)
+		require.Exactly(t, []string{"User bob"}, names(list))
+		require.Exactly(t, []string{"skipped ID token of kubeconfig user [alice-oidc]: ID token is not a JWT"}, msgs)
+	})
+}
+
+func TestHasOIDCAuthInfo(t *testing.T) {
+	require.True(t, cage_k8s_identity.HasOIDCAuthInfo(oidcConfig(t)))
+
+	config := oidcConfig(t)
+	delete(config.AuthInfos, "alice-oidc")
+	require.True(t, cage_k8s_identity.HasOIDCAuthInfo(config), "expected exec entry with ID token argument")
+
+	delete(config.AuthInfos, "bob-exec")
+	require.False(t, cage_k8s_identity.HasOIDCAuthInfo(config))
+
+	require.False(t, cage_k8s_identity.HasOIDCAuthInfo(nil))
+}
+
+func TestOIDCConfigNormalize(t *testing.T) {
+	config := cage_k8s_identity.OIDCConfig{UsernamePrefix: "oidc:", GroupsPrefix: "oidc-group:"}
+
+	require.Exactly(t, "oidc:alice", config.NormalizeUsername("alice"))
+	require.Exactly(t, "oidc:alice", config.NormalizeUsername("oidc:alice"))
+	require.Exactly(t, "system:serviceaccount:dev:ci", config.NormalizeUsername("system:serviceaccount:dev:ci"))
+	require.Exactly(t, "oidc-group:qa", config.NormalizeGroup("qa"))
+	require.Exactly(t, "system:masters", config.NormalizeGroup("system:masters"))
+
+	unprefixed := cage_k8s_identity.OIDCConfig{UsernamePrefix: cage_k8s_identity.OIDCPrefixDisabled}
+	require.Exactly(t, "alice", unprefixed.NormalizeUsername("alice"))
+	require.Exactly(t, "qa", unprefixed.NormalizeGroup("qa"))
+
+	require.True(t, config.Customized())
+	require.True(t, unprefixed.Customized())
+	require.True(t, cage_k8s_identity.OIDCConfig{UsernameClaim: "email"}.Customized())
+	require.False(t, cage_k8s_identity.OIDCConfig{}.Customized())
+	require.False(t, cage_k8s_identity.OIDCConfig{
+		UsernameClaim: cage_k8s_identity.DefaultOIDCUsernameClaim,
+		GroupsClaim:   cage_k8s_identity.DefaultOIDCGroupsClaim,
+	}.Customized())
+}
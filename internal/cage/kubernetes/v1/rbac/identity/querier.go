@@ -9,8 +9,13 @@ package identity
 
 import (
 	"context"
+	"fmt"
+	"strings"
 
+	core "k8s.io/api/core/v1"
+	k8s_errors "k8s.io/apimachinery/pkg/api/errors"
 	meta "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/validation"
 
 	"github.com/pkg/errors"
 
@@ -51,7 +56,7 @@ func (q ConfigUserQuerier) String() string {
 //
 // It implements Querier.
 func (q ConfigUserQuerier) Compatible(query *Query) bool {
-	return (query.Kind == "" || query.Kind == cage_k8s.KindUser)
+	return !query.Dangling && !query.Workloads && (query.Kind == "" || query.Kind == cage_k8s.KindUser)
 }
 
 // Do performs the query.
@@ -90,11 +95,12 @@ var _ Querier = (*ConfigUserQuerier)(nil)
 
 // CoreGroupQuerier queries a hard-coded set of group names enumerated in the API server source code.
 //
-// They're included as string literals instead of imported constants in order to avoid k8s.io/apiserver
-// and its transitive dependencies.
-//
-// https://github.com/kubernetes/apiserver/blob/kubernetes-1.17.0/pkg/authentication/user/user.go#L69
-type CoreGroupQuerier struct{}
+// Only the groups of the API server's release are included. Patterns, e.g. "system:bootstrappers:<name>",
+// are only included in results if the query selects a matching name.
+type CoreGroupQuerier struct {
+	// versions (if non-nil) caches the API server's release across queries.
+	versions *versionCache
+}
 
 // String returns a unique description of the type of result provided by the querier.
 //
@@ -107,46 +113,30 @@ func (q CoreGroupQuerier) String() string {
 //
 // It implements Querier.
 func (q CoreGroupQuerier) Compatible(query *Query) bool {
-	return query.Kind == "" || query.Kind == cage_k8s.KindGroup
+	return !query.Dangling && !query.Workloads && (query.Kind == "" || query.Kind == cage_k8s.KindGroup)
 }
 
 // Do performs the query.
 //
 // It implements Querier.
-func (q CoreGroupQuerier) Do(ctx context.Context, _ *cage_k8s_core.Clientset, query *Query) (*IdentityList, error) {
-	select {
-	case <-ctx.Done():
-		return nil, errors.Wrap(ctx.Err(), "query cancelled")
-	default:
-	}
-
-	ids := []Identity{
-		{TypeMeta: meta.TypeMeta{Kind: cage_k8s.KindGroup}, ObjectMeta: meta.ObjectMeta{Name: "system:masters"}},
-		{TypeMeta: meta.TypeMeta{Kind: cage_k8s.KindGroup}, ObjectMeta: meta.ObjectMeta{Name: "system:nodes"}},
-		{TypeMeta: meta.TypeMeta{Kind: cage_k8s.KindGroup}, ObjectMeta: meta.ObjectMeta{Name: "system:unauthenticated"}},
-		{TypeMeta: meta.TypeMeta{Kind: cage_k8s.KindGroup}, ObjectMeta: meta.ObjectMeta{Name: "system:authenticated"}},
-	}
-
-	var found IdentityList
-
-	for _, id := range ids {
-		if query.Name == "" || query.Name == id.Name {
-			found.Items = append(found.Items, id)
-		}
+func (q CoreGroupQuerier) Do(ctx context.Context, clientset *cage_k8s_core.Clientset, query *Query) (*IdentityList, error) {
+	found, err := queryCoreIdentities(ctx, clientset, q.versions, query, cage_k8s.KindGroup)
+	if err != nil {
+		return nil, errors.WithStack(err)
 	}
-
-	return &found, nil
+	return found, nil
 }
 
 var _ Querier = (*CoreGroupQuerier)(nil)
 
-// CoreGroupQuerier queries a hard-coded set of user names enumerated in the API server source code.
+// CoreUserQuerier queries a hard-coded set of user names enumerated in the API server source code.
 //
-// They're included as string literals instead of imported constants in order to avoid k8s.io/apiserver
-// and its transitive dependencies.
-//
-// https://github.com/kubernetes/apiserver/blob/kubernetes-1.17.0/pkg/authentication/user/user.go#L69
-type CoreUserQuerier struct{}
+// Only the users of the API server's release are included. Patterns, e.g. "system:bootstrap:<token id>",
+// are only included in results if the query selects a matching name which is valid.
+type CoreUserQuerier struct {
+	// versions (if non-nil) caches the API server's release across queries.
+	versions *versionCache
+}
 
 // String returns a unique description of the type of result provided by the querier.
 //
@@ -159,39 +149,92 @@ func (q CoreUserQuerier) String() string {
 //
 // It implements Querier.
 func (q CoreUserQuerier) Compatible(query *Query) bool {
-	return query.Kind == "" || query.Kind == cage_k8s.KindUser
+	return !query.Dangling && !query.Workloads && (query.Kind == "" || query.Kind == cage_k8s.KindUser)
 }
 
 // Do performs the query.
 //
 // It implements Querier.
-func (q CoreUserQuerier) Do(ctx context.Context, _ *cage_k8s_core.Clientset, query *Query) (*IdentityList, error) {
+func (q CoreUserQuerier) Do(ctx context.Context, clientset *cage_k8s_core.Clientset, query *Query) (*IdentityList, error) {
+	found, err := queryCoreIdentities(ctx, clientset, q.versions, query, cage_k8s.KindUser)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	return found, nil
+}
+
+var _ Querier = (*CoreUserQuerier)(nil)
+
+// NodeQuerier queries the API for nodes, whose kubelets authenticate as "system:node:<name>" users.
+//
+// It only serves queries which select a name with the NodeUserPrefix, so that other queries do not
+// require permission to list nodes.
+type NodeQuerier struct{}
+
+// String returns a unique description of the type of result provided by the querier.
+//
+// It implements Querier.
+func (q NodeQuerier) String() string {
+	return "node"
+}
+
+// Compatible returns true if the implementation can serve the query.
+//
+// It implements Querier.
+func (q NodeQuerier) Compatible(query *Query) bool {
+	return !query.Dangling && !query.Workloads && (query.Kind == "" || query.Kind == cage_k8s.KindUser) &&
+		strings.HasPrefix(query.Name, NodeUserPrefix)
+}
+
+// Do performs the query.
+//
+// The node is only read if the query name has the NodeUserPrefix and a valid node name. If reading
+// it is forbidden, no identities are returned.
+// The query's namespace is ignored because nodes and users are not namespaced.
+//
+// It implements Querier.
+func (q NodeQuerier) Do(ctx context.Context, clientset *cage_k8s_core.Clientset, query *Query) (*IdentityList, error) {
 	select {
 	case <-ctx.Done():
 		return nil, errors.Wrap(ctx.Err(), "query cancelled")
 	default:
 	}
 
-	ids := []Identity{
-		{TypeMeta: meta.TypeMeta{Kind: cage_k8s.KindUser}, ObjectMeta: meta.ObjectMeta{Name: "system:anonymous"}},
-		{TypeMeta: meta.TypeMeta{Kind: cage_k8s.KindUser}, ObjectMeta: meta.ObjectMeta{Name: "system:apiserver"}},
-		{TypeMeta: meta.TypeMeta{Kind: cage_k8s.KindUser}, ObjectMeta: meta.ObjectMeta{Name: "system:kube-proxy"}},
-		{TypeMeta: meta.TypeMeta{Kind: cage_k8s.KindUser}, ObjectMeta: meta.ObjectMeta{Name: "system:kube-controller-manager"}},
-		{TypeMeta: meta.TypeMeta{Kind: cage_k8s.KindUser}, ObjectMeta: meta.ObjectMeta{Name: "system:kube-scheduler"}},
+	name := strings.TrimPrefix(query.Name, NodeUserPrefix)
+	if name == query.Name || len(validation.IsDNS1123Subdomain(name)) > 0 {
+		return &IdentityList{}, nil
+	}
+
+	node, exists, err := clientset.Nodes.Get(name)
+	if err != nil {
+		if k8s_errors.IsForbidden(errors.Cause(err)) {
+			return &IdentityList{}, nil
+		}
+		return nil, errors.WithStack(err)
+	}
+
+	var nodes []core.Node
+	if exists {
+		nodes = append(nodes, *node)
 	}
 
 	var found IdentityList
 
-	for _, id := range ids {
-		if query.Name == "" || query.Name == id.Name {
-			found.Items = append(found.Items, id)
-		}
+	for _, node := range nodes {
+		found.Items = append(found.Items, Identity{
+			TypeMeta:   meta.TypeMeta{Kind: cage_k8s.KindUser},
+			ObjectMeta: meta.ObjectMeta{Name: NodeUserPrefix + node.Name},
+			Source: &IdentitySource{
+				TypeMeta:   meta.TypeMeta{Kind: cage_k8s.KindNode},
+				ObjectMeta: meta.ObjectMeta{Name: node.Name},
+			},
+		})
 	}
 
 	return &found, nil
 }
 
-var _ Querier = (*CoreUserQuerier)(nil)
+var _ Querier = (*NodeQuerier)(nil)
 
 // RoleSubjectQuerier queries the API for role subjects.
 type RoleSubjectQuerier struct{}
@@ -207,7 +250,7 @@ func (q RoleSubjectQuerier) String() string {
 //
 // It implements Querier.
 func (q RoleSubjectQuerier) Compatible(query *Query) bool {
-	return query.Kind == "" || query.Kind == cage_k8s.KindUser || query.Kind == cage_k8s.KindGroup
+	return !query.Workloads && (query.Kind == "" || query.Kind == cage_k8s.KindUser || query.Kind == cage_k8s.KindGroup)
 }
 
 // Do performs the query.
@@ -247,6 +290,8 @@ func (q RoleSubjectQuerier) Do(ctx context.Context, clientset *cage_k8s_core.Cli
 		return nil, errors.Wrap(err, "failed to get role binding list")
 	}
 
+	check := newDanglingCheck(clientset)
+
 	var list IdentityList
 	for _, r := range res.Items {
 		for _, s := range r.Subjects {
@@ -270,26 +315,40 @@ func (q RoleSubjectQuerier) Do(ctx context.Context, clientset *cage_k8s_core.Cli
 				}
 			}
 
-			if match {
-				list.Items = append(list.Items, Identity{
+			if !match {
+				continue
+			}
+
+			var dangling []string
+			if query.Dangling {
+				dangling, err = check.reasons(r.Namespace, r.RoleRef, s)
+				if err != nil {
+					return nil, errors.Wrapf(err, "failed to check if subject [%s] of role binding [%s] is dangling", s.Name, r.Name)
+				}
+				if len(dangling) == 0 {
+					continue
+				}
+			}
+
+			list.Items = append(list.Items, Identity{
+				ObjectMeta: meta.ObjectMeta{
+					Name:      s.Name,
+					Namespace: s.Namespace,
+				},
+				TypeMeta: meta.TypeMeta{
+					Kind: s.Kind,
+				},
+				Source: &IdentitySource{
 					ObjectMeta: meta.ObjectMeta{
-						Name:      s.Name,
-						Namespace: s.Namespace,
+						Name:      r.Name,
+						Namespace: r.Namespace,
 					},
 					TypeMeta: meta.TypeMeta{
-						Kind: s.Kind,
-					},
-					Source: &IdentitySource{
-						ObjectMeta: meta.ObjectMeta{
-							Name:      r.Name,
-							Namespace: r.Namespace,
-						},
-						TypeMeta: meta.TypeMeta{
-							Kind: cage_k8s.KindRoleBinding, // as of v0.16.4, r.Kind is empty
-						},
+						Kind: cage_k8s.KindRoleBinding, // as of v0.16.4, r.Kind is empty
 					},
-				})
-			}
+				},
+				Dangling: dangling,
+			})
 		}
 	}
 
@@ -312,7 +371,7 @@ func (q ClusterRoleSubjectQuerier) String() string {
 //
 // It implements Querier.
 func (q ClusterRoleSubjectQuerier) Compatible(query *Query) bool {
-	return query.Kind == "" || query.Kind == cage_k8s.KindUser || query.Kind == cage_k8s.KindGroup
+	return !query.Workloads && (query.Kind == "" || query.Kind == cage_k8s.KindUser || query.Kind == cage_k8s.KindGroup)
 }
 
 // Do performs the query.
@@ -352,6 +411,8 @@ func (q ClusterRoleSubjectQuerier) Do(ctx context.Context, clientset *cage_k8s_c
 		return nil, errors.Wrap(err, "failed to get cluster role binding list")
 	}
 
+	check := newDanglingCheck(clientset)
+
 	var list IdentityList
 	for _, r := range res.Items {
 		for _, s := range r.Subjects {
@@ -375,26 +436,40 @@ func (q ClusterRoleSubjectQuerier) Do(ctx context.Context, clientset *cage_k8s_c
 				}
 			}
 
-			if match {
-				list.Items = append(list.Items, Identity{
+			if !match {
+				continue
+			}
+
+			var dangling []string
+			if query.Dangling {
+				dangling, err = check.reasons("", r.RoleRef, s)
+				if err != nil {
+					return nil, errors.Wrapf(err, "failed to check if subject [%s] of cluster role binding [%s] is dangling", s.Name, r.Name)
+				}
+				if len(dangling) == 0 {
+					continue
+				}
+			}
+
+			list.Items = append(list.Items, Identity{
+				ObjectMeta: meta.ObjectMeta{
+					Name:      s.Name,
+					Namespace: s.Namespace,
+				},
+				TypeMeta: meta.TypeMeta{
+					Kind: s.Kind,
+				},
+				Source: &IdentitySource{
 					ObjectMeta: meta.ObjectMeta{
-						Name:      s.Name,
-						Namespace: s.Namespace,
+						Name:      r.Name,
+						Namespace: r.Namespace,
 					},
 					TypeMeta: meta.TypeMeta{
-						Kind: s.Kind,
+						Kind: cage_k8s.KindClusterRoleBinding, // as of v0.16.4, r.Kind is empty
 					},
-					Source: &IdentitySource{
-						ObjectMeta: meta.ObjectMeta{
-							Name:      r.Name,
-							Namespace: r.Namespace,
-						},
-						TypeMeta: meta.TypeMeta{
-							Kind: cage_k8s.KindClusterRoleBinding, // as of v0.16.4, r.Kind is empty
-						},
-					},
-				})
-			}
+				},
+				Dangling: dangling,
+			})
 		}
 	}
 
@@ -417,7 +492,7 @@ func (q ServiceAccountUserQuerier) String() string {
 //
 // It implements Querier.
 func (q ServiceAccountUserQuerier) Compatible(query *Query) bool {
-	return query.Kind == "" || query.Kind == cage_k8s.KindUser || query.Kind == cage_k8s.KindServiceAccount
+	return !query.Dangling && !query.Workloads && (query.Kind == "" || query.Kind == cage_k8s.KindUser || query.Kind == cage_k8s.KindServiceAccount)
 }
 
 // Do performs the query.
@@ -492,7 +567,7 @@ func (q ServiceAccountGroupQuerier) String() string {
 //
 // It implements Querier.
 func (q ServiceAccountGroupQuerier) Compatible(query *Query) bool {
-	return query.Kind == "" || query.Kind == cage_k8s.KindGroup
+	return !query.Dangling && !query.Workloads && (query.Kind == "" || query.Kind == cage_k8s.KindGroup)
 }
 
 // Do performs the query.
@@ -544,3 +619,160 @@ func (q ServiceAccountGroupQuerier) Do(ctx context.Context, clientset *cage_k8s_
 }
 
 var _ Querier = (*ServiceAccountGroupQuerier)(nil)
+
+// DefaultServiceAccount is the service account which the API server assigns to pods which do not select one.
+const DefaultServiceAccount = "default"
+
+// ServiceAccountWorkloadQuerier queries the API for Pods, Deployments, StatefulSets, DaemonSets, Jobs, and CronJobs,
+// and returns one identity per workload which runs as the queried service account(s).
+//
+// The query name may be a service account's name, a service account based user, e.g. system:serviceaccount:dev:ci,
+// or a service account based group, e.g. system:serviceaccounts:dev, or empty to select all service accounts.
+//
+// Cron jobs are skipped, with a Notify message, if the API server does not serve them.
+type ServiceAccountWorkloadQuerier struct {
+	// Notify, if non-nil, receives status messages, e.g. that cron jobs could not be listed.
+	Notify func(msg string)
+}
+
+// String returns a unique description of the type of result provided by the querier.
+//
+// It implements Querier.
+func (q ServiceAccountWorkloadQuerier) String() string {
+	return "service account used by workload"
+}
+
+// Compatible returns true if the implementation can serve the query.
+//
+// It implements Querier.
+func (q ServiceAccountWorkloadQuerier) Compatible(query *Query) bool {
+	return query.Workloads && !query.Dangling && (query.Kind == "" || query.Kind == cage_k8s.KindServiceAccount)
+}
+
+// Do performs the query.
+//
+// It implements Querier.
+func (q ServiceAccountWorkloadQuerier) Do(ctx context.Context, clientset *cage_k8s_core.Clientset, query *Query) (*IdentityList, error) {
+	select {
+	case <-ctx.Done():
+		return nil, errors.Wrap(ctx.Err(), "query cancelled")
+	default:
+	}
+
+	var list IdentityList
+
+	listNamespace := query.Namespace
+	queryName := query.Name
+
+	querySaNamespace, querySaName, _, querySaIsValid := cage_k8s_rbac.ParseServiceAccount(query.Name)
+	if querySaIsValid {
+		if querySaNamespace != "" {
+			if query.Namespace != "" && querySaNamespace != query.Namespace {
+				return nil, errors.Errorf("query's namespace [%s] does not match query service account [%s]'s namespace [%s] ", query.Namespace, query.Name, querySaNamespace)
+			}
+			listNamespace = querySaNamespace
+		}
+		queryName = querySaName // empty for groups in order to select all service accounts in the namespace
+	}
+
+	add := func(kind string, obj meta.ObjectMeta, spec core.PodSpec) {
+		sa := spec.ServiceAccountName
+		if sa == "" {
+			sa = spec.DeprecatedServiceAccount
+		}
+		if sa == "" {
+			sa = DefaultServiceAccount
+		}
+
+		if queryName != "" && queryName != sa {
+			return
+		}
+
+		list.Items = append(list.Items, Identity{
+			ObjectMeta: meta.ObjectMeta{
+				Name:      sa,
+				Namespace: obj.Namespace,
+			},
+			TypeMeta: meta.TypeMeta{
+				Kind: cage_k8s.KindServiceAccount,
+			},
+			Source: &IdentitySource{
+				TypeMeta:   meta.TypeMeta{Kind: kind},
+				ObjectMeta: meta.ObjectMeta{Namespace: obj.Namespace, Name: obj.Name},
+			},
+		})
+	}
+
+	pods, err := clientset.Pods.List(listNamespace)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	if pods != nil {
+		for _, obj := range pods.Items {
+			add(cage_k8s.KindPod, obj.ObjectMeta, obj.Spec)
+		}
+	}
+
+	deployments, err := clientset.Deployments.List(listNamespace)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	if deployments != nil {
+		for _, obj := range deployments.Items {
+			add(cage_k8s.KindDeployment, obj.ObjectMeta, obj.Spec.Template.Spec)
+		}
+	}
+
+	statefulSets, err := clientset.StatefulSets.List(listNamespace)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	if statefulSets != nil {
+		for _, obj := range statefulSets.Items {
+			add(cage_k8s.KindStatefulSet, obj.ObjectMeta, obj.Spec.Template.Spec)
+		}
+	}
+
+	daemonSets, err := clientset.DaemonSets.List(listNamespace)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	if daemonSets != nil {
+		for _, obj := range daemonSets.Items {
+			add(cage_k8s.KindDaemonSet, obj.ObjectMeta, obj.Spec.Template.Spec)
+		}
+	}
+
+	jobs, err := clientset.Jobs.List(listNamespace)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	if jobs != nil {
+		for _, obj := range jobs.Items {
+			add(cage_k8s.KindJob, obj.ObjectMeta, obj.Spec.Template.Spec)
+		}
+	}
+
+	cronJobs, err := clientset.CronJobs.List(listNamespace)
+	if err != nil {
+		if !k8s_errors.IsNotFound(errors.Cause(err)) {
+			return nil, errors.WithStack(err)
+		}
+		q.notify("cron jobs were not listed because the API server does not serve them: %s", err)
+	}
+	if cronJobs != nil {
+		for _, obj := range cronJobs.Items {
+			add(cage_k8s.KindCronJob, obj.ObjectMeta, obj.Spec.JobTemplate.Spec.Template.Spec)
+		}
+	}
+
+	return &list, nil
+}
+
+func (q ServiceAccountWorkloadQuerier) notify(format string, vArgs ...interface{}) {
+	if q.Notify != nil {
+		q.Notify(fmt.Sprintf(format, vArgs...))
+	}
+}
+
+var _ Querier = (*ServiceAccountWorkloadQuerier)(nil)
@@ -8,19 +8,27 @@ package identity_test
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"testing"
 
 	"github.com/golang/mock/gomock"
+	pkg_errors "github.com/pkg/errors"
 	"github.com/stretchr/testify/require"
+	apps "k8s.io/api/apps/v1"
+	batch "k8s.io/api/batch/v1"
+	batch_beta "k8s.io/api/batch/v1beta1"
 	core "k8s.io/api/core/v1"
 	rbac "k8s.io/api/rbac/v1"
+	k8s_errors "k8s.io/apimachinery/pkg/api/errors"
 	meta "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
 	clientcmdapi "k8s.io/client-go/tools/clientcmd/api"
 
 	cage_k8s "github.com/codeactual/kubeauth/internal/cage/kubernetes/v1"
 	cage_k8s_core "github.com/codeactual/kubeauth/internal/cage/kubernetes/v1/core"
 	mock_core "github.com/codeactual/kubeauth/internal/cage/kubernetes/v1/core/mock"
+	cage_k8s_discovery "github.com/codeactual/kubeauth/internal/cage/kubernetes/v1/discovery"
 	cage_k8s_identity "github.com/codeactual/kubeauth/internal/cage/kubernetes/v1/rbac/identity"
 )
 
@@ -44,6 +52,11 @@ const (
 
 	CoreUsername = "system:anonymous"
 	CoreGroup    = "system:masters"
+	NodeName     = "some-node"
+	NodeUsername = "system:node:" + NodeName
+
+	BindingName = "some-binding"
+	RoleName    = "some-role"
 )
 
 func ctx() context.Context {
@@ -68,6 +81,14 @@ func TestConfigUserQuerier(t *testing.T) {
 		require.True(t, cage_k8s_identity.ConfigUserQuerier{}.Compatible(&cage_k8s_identity.Query{}))
 	})
 
+	t.Run("incompatible with workloads query", func(t *testing.T) {
+		require.False(t, cage_k8s_identity.ConfigUserQuerier{}.Compatible(&cage_k8s_identity.Query{Workloads: true}))
+	})
+
+	t.Run("incompatible with dangling query", func(t *testing.T) {
+		require.False(t, cage_k8s_identity.ConfigUserQuerier{}.Compatible(&cage_k8s_identity.Query{Dangling: true}))
+	})
+
 	t.Run("incompatible with non user kind", func(t *testing.T) {
 		require.False(t, cage_k8s_identity.ConfigUserQuerier{}.Compatible(&cage_k8s_identity.Query{Kind: cage_k8s.KindGroup}))
 	})
@@ -116,6 +137,13 @@ func TestConfigUserQuerier(t *testing.T) {
 	})
 }
 
+// newVersionedClientset returns a mock clientset which reports the 1.x API server release.
+func newVersionedClientset(mockCtrl *gomock.Controller, minor int) *mock_core.Clientset {
+	mockClientset := mock_core.NewClientset(mockCtrl)
+	mockClientset.Discovery.EXPECT().Version().Return(&cage_k8s_discovery.Version{Major: 1, Minor: minor}, nil)
+	return mockClientset
+}
+
 func TestCoreUserQuerier(t *testing.T) {
 	// Assert that if the query does not require a specific object kind, still support the query
 	// and filter on name/namespace.
@@ -123,19 +151,29 @@ func TestCoreUserQuerier(t *testing.T) {
 		require.True(t, cage_k8s_identity.CoreUserQuerier{}.Compatible(&cage_k8s_identity.Query{}))
 	})
 
+	t.Run("incompatible with workloads query", func(t *testing.T) {
+		require.False(t, cage_k8s_identity.CoreUserQuerier{}.Compatible(&cage_k8s_identity.Query{Workloads: true}))
+	})
+
+	t.Run("incompatible with dangling query", func(t *testing.T) {
+		require.False(t, cage_k8s_identity.CoreUserQuerier{}.Compatible(&cage_k8s_identity.Query{Dangling: true}))
+	})
+
 	t.Run("incompatible with non user kind", func(t *testing.T) {
 		require.False(t, cage_k8s_identity.CoreUserQuerier{}.Compatible(&cage_k8s_identity.Query{Kind: cage_k8s.KindGroup}))
 	})
 
 	t.Run("hit", func(t *testing.T) {
+		mockCtrl := gomock.NewController(t)
+		defer mockCtrl.Finish()
+
 		username := CoreUsername
 		query := cage_k8s_identity.Query{
 			Kind: cage_k8s.KindUser,
 			Name: username,
 		}
-		var nonSut *cage_k8s_core.Clientset
 
-		list, err := cage_k8s_identity.CoreUserQuerier{}.Do(ctx(), nonSut, &query)
+		list, err := cage_k8s_identity.CoreUserQuerier{}.Do(ctx(), newVersionedClientset(mockCtrl, 17).ToReal(), &query)
 		require.NoError(t, err)
 		require.Len(t, list.Items, 1)
 		require.Exactly(t, cage_k8s.KindUser, list.Items[0].Kind)
@@ -143,16 +181,173 @@ func TestCoreUserQuerier(t *testing.T) {
 	})
 
 	t.Run("name miss", func(t *testing.T) {
+		mockCtrl := gomock.NewController(t)
+		defer mockCtrl.Finish()
+
 		query := cage_k8s_identity.Query{
 			Kind: cage_k8s.KindUser,
 			Name: DoesNotExist,
 		}
-		var nonSut *cage_k8s_core.Clientset
 
-		list, err := cage_k8s_identity.CoreUserQuerier{}.Do(ctx(), nonSut, &query)
+		list, err := cage_k8s_identity.CoreUserQuerier{}.Do(ctx(), newVersionedClientset(mockCtrl, 17).ToReal(), &query)
 		require.NoError(t, err)
 		require.Len(t, list.Items, 0)
 	})
+
+	t.Run("all omits patterns", func(t *testing.T) {
+		mockCtrl := gomock.NewController(t)
+		defer mockCtrl.Finish()
+
+		list, err := cage_k8s_identity.CoreUserQuerier{}.Do(ctx(), newVersionedClientset(mockCtrl, 17).ToReal(), &cage_k8s_identity.Query{})
+		require.NoError(t, err)
+		require.Exactly(
+			t,
+			[]string{
+				"User system:anonymous", "User system:apiserver", "User system:kube-proxy",
+				"User system:kube-controller-manager", "User system:kube-scheduler",
+			},
+			names(list),
+		)
+	})
+
+	t.Run("bootstrap token", func(t *testing.T) {
+		mockCtrl := gomock.NewController(t)
+		defer mockCtrl.Finish()
+
+		valid := cage_k8s_identity.BootstrapUserPrefix + "abc123"
+		list, err := cage_k8s_identity.CoreUserQuerier{}.Do(ctx(), newVersionedClientset(mockCtrl, 17).ToReal(), &cage_k8s_identity.Query{Name: valid})
+		require.NoError(t, err)
+		require.Exactly(t, []string{"User " + valid}, names(list))
+
+		list, err = cage_k8s_identity.CoreUserQuerier{}.Do(ctx(), newVersionedClientset(mockCtrl, 17).ToReal(), &cage_k8s_identity.Query{Name: valid + "4"})
+		require.NoError(t, err)
+		require.Empty(t, list.Items)
+
+		// Bootstrap tokens were introduced in 1.6.
+		list, err = cage_k8s_identity.CoreUserQuerier{}.Do(ctx(), newVersionedClientset(mockCtrl, 5).ToReal(), &cage_k8s_identity.Query{Name: valid})
+		require.NoError(t, err)
+		require.Empty(t, list.Items)
+	})
+
+	t.Run("version error", func(t *testing.T) {
+		mockCtrl := gomock.NewController(t)
+		defer mockCtrl.Finish()
+
+		mockClientset := mock_core.NewClientset(mockCtrl)
+		mockClientset.Discovery.EXPECT().Version().Return(nil, errors.New("expectErr"))
+
+		// Fall back to the users of all releases.
+		valid := cage_k8s_identity.BootstrapUserPrefix + "abc123"
+		list, err := cage_k8s_identity.CoreUserQuerier{}.Do(ctx(), mockClientset.ToReal(), &cage_k8s_identity.Query{Name: valid})
+		require.NoError(t, err)
+		require.Exactly(t, []string{"User " + valid}, names(list))
+	})
+}
+
+func TestNodeQuerier(t *testing.T) {
+	t.Run("compatible with node user name", func(t *testing.T) {
+		require.True(t, cage_k8s_identity.NodeQuerier{}.Compatible(&cage_k8s_identity.Query{Name: NodeUsername}))
+		require.True(t, cage_k8s_identity.NodeQuerier{}.Compatible(&cage_k8s_identity.Query{Kind: cage_k8s.KindUser, Name: NodeUsername}))
+	})
+
+	// Assert that queries which do not select a node user, e.g. those of all identities, do not
+	// require permission to list nodes.
+	t.Run("incompatible without node user name", func(t *testing.T) {
+		require.False(t, cage_k8s_identity.NodeQuerier{}.Compatible(&cage_k8s_identity.Query{}))
+		require.False(t, cage_k8s_identity.NodeQuerier{}.Compatible(&cage_k8s_identity.Query{Kind: cage_k8s.KindUser, Name: CoreUsername}))
+	})
+
+	t.Run("incompatible with workloads query", func(t *testing.T) {
+		require.False(t, cage_k8s_identity.NodeQuerier{}.Compatible(&cage_k8s_identity.Query{Name: NodeUsername, Workloads: true}))
+	})
+
+	t.Run("incompatible with dangling query", func(t *testing.T) {
+		require.False(t, cage_k8s_identity.NodeQuerier{}.Compatible(&cage_k8s_identity.Query{Name: NodeUsername, Dangling: true}))
+	})
+
+	t.Run("incompatible with non user kind", func(t *testing.T) {
+		require.False(t, cage_k8s_identity.NodeQuerier{}.Compatible(&cage_k8s_identity.Query{Kind: cage_k8s.KindGroup, Name: NodeUsername}))
+	})
+
+	t.Run("hit", func(t *testing.T) {
+		mockCtrl := gomock.NewController(t)
+		defer mockCtrl.Finish()
+
+		mockClientset := mock_core.NewClientset(mockCtrl)
+		mockClientset.Nodes.EXPECT().Get(NodeName).Return(&core.Node{ObjectMeta: meta.ObjectMeta{Name: NodeName}}, Exists, nil)
+
+		query := cage_k8s_identity.Query{Kind: cage_k8s.KindUser, Name: NodeUsername}
+
+		list, err := cage_k8s_identity.NodeQuerier{}.Do(ctx(), mockClientset.ToReal(), &query)
+		require.NoError(t, err)
+		require.Exactly(t, []string{"User " + NodeUsername}, names(list))
+		require.Exactly(t, "Node "+NodeName, list.Items[0].Source.String())
+	})
+
+	t.Run("name miss", func(t *testing.T) {
+		mockCtrl := gomock.NewController(t)
+		defer mockCtrl.Finish()
+
+		mockClientset := mock_core.NewClientset(mockCtrl)
+		mockClientset.Nodes.EXPECT().Get(NodeName).Return(nil, NotExists, nil)
+
+		query := cage_k8s_identity.Query{Kind: cage_k8s.KindUser, Name: NodeUsername}
+
+		list, err := cage_k8s_identity.NodeQuerier{}.Do(ctx(), mockClientset.ToReal(), &query)
+		require.NoError(t, err)
+		require.Empty(t, list.Items)
+	})
+
+	t.Run("get error", func(t *testing.T) {
+		mockCtrl := gomock.NewController(t)
+		defer mockCtrl.Finish()
+
+		mockClientset := mock_core.NewClientset(mockCtrl)
+		mockClientset.Nodes.EXPECT().Get(NodeName).Return(nil, NotExists, errors.New("expectErr"))
+
+		query := cage_k8s_identity.Query{Kind: cage_k8s.KindUser, Name: NodeUsername}
+
+		_, err := cage_k8s_identity.NodeQuerier{}.Do(ctx(), mockClientset.ToReal(), &query)
+		require.EqualError(t, err, "expectErr")
+	})
+
+	t.Run("forbidden", func(t *testing.T) {
+		mockCtrl := gomock.NewController(t)
+		defer mockCtrl.Finish()
+
+		forbidden := k8s_errors.NewForbidden(schema.GroupResource{Resource: "nodes"}, NodeName, errors.New("expectErr"))
+
+		mockClientset := mock_core.NewClientset(mockCtrl)
+		mockClientset.Nodes.EXPECT().Get(NodeName).Return(nil, NotExists, forbidden)
+
+		query := cage_k8s_identity.Query{Kind: cage_k8s.KindUser, Name: NodeUsername}
+
+		list, err := cage_k8s_identity.NodeQuerier{}.Do(ctx(), mockClientset.ToReal(), &query)
+		require.NoError(t, err)
+		require.Empty(t, list.Items)
+	})
+
+	t.Run("non node name", func(t *testing.T) {
+		mockCtrl := gomock.NewController(t)
+		defer mockCtrl.Finish()
+
+		query := cage_k8s_identity.Query{Kind: cage_k8s.KindUser, Name: CoreUsername}
+
+		list, err := cage_k8s_identity.NodeQuerier{}.Do(ctx(), mock_core.NewClientset(mockCtrl).ToReal(), &query)
+		require.NoError(t, err)
+		require.Empty(t, list.Items)
+	})
+
+	t.Run("invalid node name", func(t *testing.T) {
+		mockCtrl := gomock.NewController(t)
+		defer mockCtrl.Finish()
+
+		query := cage_k8s_identity.Query{Kind: cage_k8s.KindUser, Name: cage_k8s_identity.NodeUserPrefix + "Not_Valid"}
+
+		list, err := cage_k8s_identity.NodeQuerier{}.Do(ctx(), mock_core.NewClientset(mockCtrl).ToReal(), &query)
+		require.NoError(t, err)
+		require.Empty(t, list.Items)
+	})
 }
 
 func TestCoreGroupQuerier(t *testing.T) {
@@ -162,19 +357,29 @@ func TestCoreGroupQuerier(t *testing.T) {
 		require.True(t, cage_k8s_identity.CoreGroupQuerier{}.Compatible(&cage_k8s_identity.Query{}))
 	})
 
+	t.Run("incompatible with workloads query", func(t *testing.T) {
+		require.False(t, cage_k8s_identity.CoreGroupQuerier{}.Compatible(&cage_k8s_identity.Query{Workloads: true}))
+	})
+
+	t.Run("incompatible with dangling query", func(t *testing.T) {
+		require.False(t, cage_k8s_identity.CoreGroupQuerier{}.Compatible(&cage_k8s_identity.Query{Dangling: true}))
+	})
+
 	t.Run("incompatible with non group kind", func(t *testing.T) {
 		require.False(t, cage_k8s_identity.CoreGroupQuerier{}.Compatible(&cage_k8s_identity.Query{Kind: cage_k8s.KindUser}))
 	})
 
 	t.Run("hit", func(t *testing.T) {
+		mockCtrl := gomock.NewController(t)
+		defer mockCtrl.Finish()
+
 		group := CoreGroup
 		query := cage_k8s_identity.Query{
 			Kind: cage_k8s.KindGroup,
 			Name: group,
 		}
-		var nonSut *cage_k8s_core.Clientset
 
-		list, err := cage_k8s_identity.CoreGroupQuerier{}.Do(ctx(), nonSut, &query)
+		list, err := cage_k8s_identity.CoreGroupQuerier{}.Do(ctx(), newVersionedClientset(mockCtrl, 17).ToReal(), &query)
 		require.NoError(t, err)
 		require.Len(t, list.Items, 1)
 		require.Exactly(t, cage_k8s.KindGroup, list.Items[0].Kind)
@@ -182,16 +387,52 @@ func TestCoreGroupQuerier(t *testing.T) {
 	})
 
 	t.Run("name miss", func(t *testing.T) {
+		mockCtrl := gomock.NewController(t)
+		defer mockCtrl.Finish()
+
 		query := cage_k8s_identity.Query{
 			Kind: cage_k8s.KindUser,
 			Name: DoesNotExist,
 		}
-		var nonSut *cage_k8s_core.Clientset
 
-		list, err := cage_k8s_identity.CoreGroupQuerier{}.Do(ctx(), nonSut, &query)
+		list, err := cage_k8s_identity.CoreGroupQuerier{}.Do(ctx(), newVersionedClientset(mockCtrl, 17).ToReal(), &query)
 		require.NoError(t, err)
 		require.Len(t, list.Items, 0)
 	})
+
+	t.Run("release", func(t *testing.T) {
+		mockCtrl := gomock.NewController(t)
+		defer mockCtrl.Finish()
+
+		list, err := cage_k8s_identity.CoreGroupQuerier{}.Do(ctx(), newVersionedClientset(mockCtrl, 17).ToReal(), &cage_k8s_identity.Query{})
+		require.NoError(t, err)
+		require.Exactly(
+			t,
+			[]string{
+				"Group system:masters", "Group system:nodes", "Group system:unauthenticated",
+				"Group system:authenticated", "Group system:bootstrappers",
+			},
+			names(list),
+		)
+
+		list, err = cage_k8s_identity.CoreGroupQuerier{}.Do(ctx(), newVersionedClientset(mockCtrl, 22).ToReal(), &cage_k8s_identity.Query{Name: "system:monitoring"})
+		require.NoError(t, err)
+		require.Exactly(t, []string{"Group system:monitoring"}, names(list))
+	})
+
+	t.Run("bootstrap token extra group", func(t *testing.T) {
+		mockCtrl := gomock.NewController(t)
+		defer mockCtrl.Finish()
+
+		group := cage_k8s_identity.BootstrapGroupPrefix + "kubeadm:default-node-token"
+		list, err := cage_k8s_identity.CoreGroupQuerier{}.Do(ctx(), newVersionedClientset(mockCtrl, 17).ToReal(), &cage_k8s_identity.Query{Name: group})
+		require.NoError(t, err)
+		require.Exactly(t, []string{"Group " + group}, names(list))
+
+		list, err = cage_k8s_identity.CoreGroupQuerier{}.Do(ctx(), newVersionedClientset(mockCtrl, 17).ToReal(), &cage_k8s_identity.Query{Name: group + ":"})
+		require.NoError(t, err)
+		require.Empty(t, list.Items)
+	})
 }
 
 func TestRoleSubjectQuerier(t *testing.T) {
@@ -201,6 +442,10 @@ func TestRoleSubjectQuerier(t *testing.T) {
 		require.True(t, cage_k8s_identity.RoleSubjectQuerier{}.Compatible(&cage_k8s_identity.Query{}))
 	})
 
+	t.Run("incompatible with workloads query", func(t *testing.T) {
+		require.False(t, cage_k8s_identity.RoleSubjectQuerier{}.Compatible(&cage_k8s_identity.Query{Workloads: true}))
+	})
+
 	t.Run("compatible with subject kind", func(t *testing.T) {
 		require.True(t, cage_k8s_identity.RoleSubjectQuerier{}.Compatible(&cage_k8s_identity.Query{Kind: cage_k8s.KindUser}))
 		require.True(t, cage_k8s_identity.RoleSubjectQuerier{}.Compatible(&cage_k8s_identity.Query{Kind: cage_k8s.KindGroup}))
@@ -441,6 +686,113 @@ func TestRoleSubjectQuerier(t *testing.T) {
 		require.Error(t, err)
 		require.Contains(t, err.Error(), fmt.Sprintf("query's namespace [%s] does not match query service account [%s]'s namespace [%s] ", DoesNotExist, ServiceAccountUsername, Namespace))
 	})
+
+	t.Run("dangling service account", func(t *testing.T) {
+		query := cage_k8s_identity.Query{Dangling: true}
+
+		mockCtrl := gomock.NewController(t)
+		defer mockCtrl.Finish()
+
+		mockClientset := mock_core.NewClientset(mockCtrl)
+
+		roleRef := rbac.RoleRef{Kind: cage_k8s.KindRole, Name: RoleName}
+		bindings := rbac.RoleBindingList{
+			Items: []rbac.RoleBinding{
+				{
+					ObjectMeta: meta.ObjectMeta{Namespace: Namespace, Name: BindingName},
+					RoleRef:    roleRef,
+					Subjects: []rbac.Subject{
+						{Kind: cage_k8s.KindServiceAccount, Name: ServiceAccountUsernameBase, Namespace: Namespace},
+						{Kind: cage_k8s.KindServiceAccount, Name: DoesNotExist, Namespace: Namespace},
+						{Kind: cage_k8s.KindUser, Name: CoreUsername},
+					},
+				},
+			},
+		}
+		mockClientset.RoleBindings.EXPECT().List(NoQueryNamespace, meta.ListOptions{}).Return(&bindings, nil)
+
+		// Existence checks are cached across subjects.
+		var nonSutRole *rbac.Role
+		mockClientset.Roles.EXPECT().Get(Namespace, RoleName).Return(nonSutRole, Exists, nil)
+		var nonSutNamespace *core.Namespace
+		mockClientset.Namespaces.EXPECT().Get(Namespace).Return(nonSutNamespace, Exists, nil)
+		var nonSutSa *core.ServiceAccount
+		mockClientset.ServiceAccounts.EXPECT().Get(Namespace, ServiceAccountUsernameBase).Return(nonSutSa, Exists, nil)
+		mockClientset.ServiceAccounts.EXPECT().Get(Namespace, DoesNotExist).Return(nonSutSa, NotExists, nil)
+
+		list, err := cage_k8s_identity.RoleSubjectQuerier{}.Do(ctx(), mockClientset.ToReal(), &query)
+		require.NoError(t, err)
+		require.Len(t, list.Items, 1)
+		require.Exactly(t, cage_k8s.KindServiceAccount, list.Items[0].Kind)
+		require.Exactly(t, DoesNotExist, list.Items[0].Name)
+		require.Exactly(t, BindingName, list.Items[0].Source.Name)
+		require.Exactly(t, []string{fmt.Sprintf("ServiceAccount [%s] not found in namespace [%s]", DoesNotExist, Namespace)}, list.Items[0].Dangling)
+	})
+
+	// Assert that a service account subject without a namespace is resolved to the binding's namespace.
+	t.Run("dangling service account namespace", func(t *testing.T) {
+		query := cage_k8s_identity.Query{Dangling: true}
+
+		mockCtrl := gomock.NewController(t)
+		defer mockCtrl.Finish()
+
+		mockClientset := mock_core.NewClientset(mockCtrl)
+
+		bindings := rbac.RoleBindingList{
+			Items: []rbac.RoleBinding{
+				{
+					ObjectMeta: meta.ObjectMeta{Namespace: DoesNotExist, Name: BindingName},
+					RoleRef:    rbac.RoleRef{Kind: cage_k8s.KindClusterRole, Name: RoleName},
+					Subjects: []rbac.Subject{
+						{Kind: cage_k8s.KindServiceAccount, Name: ServiceAccountUsernameBase},
+					},
+				},
+			},
+		}
+		mockClientset.RoleBindings.EXPECT().List(NoQueryNamespace, meta.ListOptions{}).Return(&bindings, nil)
+
+		var nonSutClusterRole *rbac.ClusterRole
+		mockClientset.ClusterRoles.EXPECT().Get(RoleName).Return(nonSutClusterRole, Exists, nil)
+		var nonSutNamespace *core.Namespace
+		mockClientset.Namespaces.EXPECT().Get(DoesNotExist).Return(nonSutNamespace, NotExists, nil)
+
+		list, err := cage_k8s_identity.RoleSubjectQuerier{}.Do(ctx(), mockClientset.ToReal(), &query)
+		require.NoError(t, err)
+		require.Len(t, list.Items, 1)
+		require.Exactly(t, ServiceAccountUsernameBase, list.Items[0].Name)
+		require.Exactly(t, []string{fmt.Sprintf("namespace [%s] not found", DoesNotExist)}, list.Items[0].Dangling)
+	})
+
+	t.Run("dangling role ref", func(t *testing.T) {
+		query := cage_k8s_identity.Query{Dangling: true}
+
+		mockCtrl := gomock.NewController(t)
+		defer mockCtrl.Finish()
+
+		mockClientset := mock_core.NewClientset(mockCtrl)
+
+		bindings := rbac.RoleBindingList{
+			Items: []rbac.RoleBinding{
+				{
+					ObjectMeta: meta.ObjectMeta{Namespace: Namespace, Name: BindingName},
+					RoleRef:    rbac.RoleRef{Kind: cage_k8s.KindRole, Name: RoleName},
+					Subjects: []rbac.Subject{
+						{Kind: cage_k8s.KindUser, Name: CoreUsername},
+					},
+				},
+			},
+		}
+		mockClientset.RoleBindings.EXPECT().List(NoQueryNamespace, meta.ListOptions{}).Return(&bindings, nil)
+
+		var nonSutRole *rbac.Role
+		mockClientset.Roles.EXPECT().Get(Namespace, RoleName).Return(nonSutRole, NotExists, nil)
+
+		list, err := cage_k8s_identity.RoleSubjectQuerier{}.Do(ctx(), mockClientset.ToReal(), &query)
+		require.NoError(t, err)
+		require.Len(t, list.Items, 1)
+		require.Exactly(t, CoreUsername, list.Items[0].Name)
+		require.Exactly(t, []string{fmt.Sprintf("Role [%s] not found", RoleName)}, list.Items[0].Dangling)
+	})
 }
 
 func TestClusterRoleSubjectQuerier(t *testing.T) {
@@ -450,6 +802,10 @@ func TestClusterRoleSubjectQuerier(t *testing.T) {
 		require.True(t, cage_k8s_identity.ClusterRoleSubjectQuerier{}.Compatible(&cage_k8s_identity.Query{}))
 	})
 
+	t.Run("incompatible with workloads query", func(t *testing.T) {
+		require.False(t, cage_k8s_identity.ClusterRoleSubjectQuerier{}.Compatible(&cage_k8s_identity.Query{Workloads: true}))
+	})
+
 	t.Run("compatible with subject kind", func(t *testing.T) {
 		require.True(t, cage_k8s_identity.ClusterRoleSubjectQuerier{}.Compatible(&cage_k8s_identity.Query{Kind: cage_k8s.KindUser}))
 		require.True(t, cage_k8s_identity.ClusterRoleSubjectQuerier{}.Compatible(&cage_k8s_identity.Query{Kind: cage_k8s.KindGroup}))
@@ -690,6 +1046,77 @@ func TestClusterRoleSubjectQuerier(t *testing.T) {
 		require.Error(t, err)
 		require.Contains(t, err.Error(), fmt.Sprintf("query's namespace [%s] does not match query service account [%s]'s namespace [%s] ", DoesNotExist, ServiceAccountUsername, Namespace))
 	})
+
+	t.Run("dangling role ref and service account", func(t *testing.T) {
+		query := cage_k8s_identity.Query{Dangling: true}
+
+		mockCtrl := gomock.NewController(t)
+		defer mockCtrl.Finish()
+
+		mockClientset := mock_core.NewClientset(mockCtrl)
+
+		bindings := rbac.ClusterRoleBindingList{
+			Items: []rbac.ClusterRoleBinding{
+				{
+					ObjectMeta: meta.ObjectMeta{Name: BindingName},
+					RoleRef:    rbac.RoleRef{Kind: cage_k8s.KindClusterRole, Name: RoleName},
+					Subjects: []rbac.Subject{
+						{Kind: cage_k8s.KindServiceAccount, Name: ServiceAccountUsernameBase, Namespace: Namespace},
+					},
+				},
+			},
+		}
+		mockClientset.ClusterRoleBindings.EXPECT().List(meta.ListOptions{}).Return(&bindings, nil)
+
+		var nonSutClusterRole *rbac.ClusterRole
+		mockClientset.ClusterRoles.EXPECT().Get(RoleName).Return(nonSutClusterRole, NotExists, nil)
+		var nonSutNamespace *core.Namespace
+		mockClientset.Namespaces.EXPECT().Get(Namespace).Return(nonSutNamespace, Exists, nil)
+		var nonSutSa *core.ServiceAccount
+		mockClientset.ServiceAccounts.EXPECT().Get(Namespace, ServiceAccountUsernameBase).Return(nonSutSa, NotExists, nil)
+
+		list, err := cage_k8s_identity.ClusterRoleSubjectQuerier{}.Do(ctx(), mockClientset.ToReal(), &query)
+		require.NoError(t, err)
+		require.Len(t, list.Items, 1)
+		require.Exactly(t, cage_k8s.KindClusterRoleBinding, list.Items[0].Source.Kind)
+		require.Exactly(
+			t,
+			[]string{
+				fmt.Sprintf("ClusterRole [%s] not found", RoleName),
+				fmt.Sprintf("ServiceAccount [%s] not found in namespace [%s]", ServiceAccountUsernameBase, Namespace),
+			},
+			list.Items[0].Dangling,
+		)
+	})
+
+	t.Run("dangling miss", func(t *testing.T) {
+		query := cage_k8s_identity.Query{Dangling: true}
+
+		mockCtrl := gomock.NewController(t)
+		defer mockCtrl.Finish()
+
+		mockClientset := mock_core.NewClientset(mockCtrl)
+
+		bindings := rbac.ClusterRoleBindingList{
+			Items: []rbac.ClusterRoleBinding{
+				{
+					ObjectMeta: meta.ObjectMeta{Name: BindingName},
+					RoleRef:    rbac.RoleRef{Kind: cage_k8s.KindClusterRole, Name: RoleName},
+					Subjects: []rbac.Subject{
+						{Kind: cage_k8s.KindGroup, Name: CoreGroup},
+					},
+				},
+			},
+		}
+		mockClientset.ClusterRoleBindings.EXPECT().List(meta.ListOptions{}).Return(&bindings, nil)
+
+		var nonSutClusterRole *rbac.ClusterRole
+		mockClientset.ClusterRoles.EXPECT().Get(RoleName).Return(nonSutClusterRole, Exists, nil)
+
+		list, err := cage_k8s_identity.ClusterRoleSubjectQuerier{}.Do(ctx(), mockClientset.ToReal(), &query)
+		require.NoError(t, err)
+		require.Len(t, list.Items, 0)
+	})
 }
 
 func TestServiceAccountUserQuerier(t *testing.T) {
@@ -699,6 +1126,14 @@ func TestServiceAccountUserQuerier(t *testing.T) {
 		require.True(t, cage_k8s_identity.ServiceAccountUserQuerier{}.Compatible(&cage_k8s_identity.Query{}))
 	})
 
+	t.Run("incompatible with workloads query", func(t *testing.T) {
+		require.False(t, cage_k8s_identity.ServiceAccountUserQuerier{}.Compatible(&cage_k8s_identity.Query{Workloads: true}))
+	})
+
+	t.Run("incompatible with dangling query", func(t *testing.T) {
+		require.False(t, cage_k8s_identity.ServiceAccountUserQuerier{}.Compatible(&cage_k8s_identity.Query{Dangling: true}))
+	})
+
 	t.Run("compatible with subject kind", func(t *testing.T) {
 		require.True(t, cage_k8s_identity.ServiceAccountUserQuerier{}.Compatible(&cage_k8s_identity.Query{Kind: cage_k8s.KindServiceAccount}))
 		require.True(t, cage_k8s_identity.ServiceAccountUserQuerier{}.Compatible(&cage_k8s_identity.Query{Kind: cage_k8s.KindUser}))
@@ -891,6 +1326,14 @@ func TestServiceAccountGroupQuerier(t *testing.T) {
 		require.True(t, cage_k8s_identity.ServiceAccountGroupQuerier{}.Compatible(&cage_k8s_identity.Query{}))
 	})
 
+	t.Run("incompatible with workloads query", func(t *testing.T) {
+		require.False(t, cage_k8s_identity.ServiceAccountGroupQuerier{}.Compatible(&cage_k8s_identity.Query{Workloads: true}))
+	})
+
+	t.Run("incompatible with dangling query", func(t *testing.T) {
+		require.False(t, cage_k8s_identity.ServiceAccountGroupQuerier{}.Compatible(&cage_k8s_identity.Query{Dangling: true}))
+	})
+
 	t.Run("compatible with subject kind", func(t *testing.T) {
 		require.False(t, cage_k8s_identity.ServiceAccountGroupQuerier{}.Compatible(&cage_k8s_identity.Query{Kind: cage_k8s.KindServiceAccount}))
 		require.False(t, cage_k8s_identity.ServiceAccountGroupQuerier{}.Compatible(&cage_k8s_identity.Query{Kind: cage_k8s.KindUser}))
@@ -958,3 +1401,190 @@ func TestServiceAccountGroupQuerier(t *testing.T) {
 		require.Contains(t, err.Error(), fmt.Sprintf("query's namespace [%s] does not match query service account [%s]'s namespace [%s] ", DoesNotExist, ServiceAccountGroupOneNamespace, Namespace))
 	})
 }
+
+func TestServiceAccountWorkloadQuerier(t *testing.T) {
+	t.Run("compatible with workloads query", func(t *testing.T) {
+		require.True(t, cage_k8s_identity.ServiceAccountWorkloadQuerier{}.Compatible(&cage_k8s_identity.Query{Workloads: true}))
+		require.True(t, cage_k8s_identity.ServiceAccountWorkloadQuerier{}.Compatible(&cage_k8s_identity.Query{Workloads: true, Kind: cage_k8s.KindServiceAccount}))
+	})
+
+	t.Run("incompatible with non workloads query", func(t *testing.T) {
+		require.False(t, cage_k8s_identity.ServiceAccountWorkloadQuerier{}.Compatible(&cage_k8s_identity.Query{}))
+		require.False(t, cage_k8s_identity.ServiceAccountWorkloadQuerier{}.Compatible(&cage_k8s_identity.Query{Workloads: true, Dangling: true}))
+		require.False(t, cage_k8s_identity.ServiceAccountWorkloadQuerier{}.Compatible(&cage_k8s_identity.Query{Workloads: true, Kind: cage_k8s.KindUser}))
+	})
+
+	// expectLists configures the workload list results of the namespace: a pod and a deployment which select
+	// the service account, a job which selects another, and a cron job which selects none.
+	//
+	// If cronJobErr is non-nil, it is returned instead of the cron jobs.
+	expectLists := func(mockClientset *mock_core.Clientset, namespace string, cronJobErr error) {
+		podSpec := core.PodSpec{ServiceAccountName: ServiceAccountUsernameBase}
+		templateSpec := core.PodTemplateSpec{Spec: podSpec}
+		objMeta := func(name string) meta.ObjectMeta {
+			return meta.ObjectMeta{Namespace: Namespace, Name: name}
+		}
+
+		mockClientset.Pods.EXPECT().
+			List(namespace).
+			Return(&core.PodList{Items: []core.Pod{{ObjectMeta: objMeta("some-pod"), Spec: podSpec}}}, nil)
+		mockClientset.Deployments.EXPECT().
+			List(namespace).
+			Return(&apps.DeploymentList{Items: []apps.Deployment{{ObjectMeta: objMeta("some-deployment"), Spec: apps.DeploymentSpec{Template: templateSpec}}}}, nil)
+		mockClientset.StatefulSets.EXPECT().
+			List(namespace).
+			Return(nil, nil)
+		mockClientset.DaemonSets.EXPECT().
+			List(namespace).
+			Return(&apps.DaemonSetList{}, nil)
+		mockClientset.Jobs.EXPECT().
+			List(namespace).
+			Return(&batch.JobList{Items: []batch.Job{{ObjectMeta: objMeta("some-job"), Spec: batch.JobSpec{Template: core.PodTemplateSpec{Spec: core.PodSpec{ServiceAccountName: DoesNotExist}}}}}}, nil)
+		if cronJobErr != nil {
+			mockClientset.CronJobs.EXPECT().
+				List(namespace).
+				Return(nil, cronJobErr)
+			return
+		}
+		mockClientset.CronJobs.EXPECT().
+			List(namespace).
+			Return(&batch_beta.CronJobList{Items: []batch_beta.CronJob{{ObjectMeta: objMeta("some-cron-job")}}}, nil)
+	}
+
+	sources := func(list *cage_k8s_identity.IdentityList) (s []string) {
+		for _, id := range list.Items {
+			s = append(s, id.Namespace+"/"+id.Name+" "+id.Source.Kind+"/"+id.Source.Namespace+"/"+id.Source.Name)
+		}
+		return s
+	}
+
+	t.Run("all service accounts", func(t *testing.T) {
+		query := cage_k8s_identity.Query{Workloads: true}
+
+		mockCtrl := gomock.NewController(t)
+		defer mockCtrl.Finish()
+
+		mockClientset := mock_core.NewClientset(mockCtrl)
+		expectLists(mockClientset, NoQueryNamespace, nil)
+
+		list, err := cage_k8s_identity.ServiceAccountWorkloadQuerier{}.Do(ctx(), mockClientset.ToReal(), &query)
+		require.NoError(t, err)
+		require.Exactly(
+			t,
+			[]string{
+				Namespace + "/" + ServiceAccountUsernameBase + " Pod/" + Namespace + "/some-pod",
+				Namespace + "/" + ServiceAccountUsernameBase + " Deployment/" + Namespace + "/some-deployment",
+				Namespace + "/" + DoesNotExist + " Job/" + Namespace + "/some-job",
+				Namespace + "/" + cage_k8s_identity.DefaultServiceAccount + " CronJob/" + Namespace + "/some-cron-job",
+			},
+			sources(list),
+		)
+		for _, id := range list.Items {
+			require.Exactly(t, cage_k8s.KindServiceAccount, id.Kind)
+		}
+	})
+
+	t.Run("service account user hit", func(t *testing.T) {
+		query := cage_k8s_identity.Query{Workloads: true, Name: ServiceAccountUsername}
+
+		mockCtrl := gomock.NewController(t)
+		defer mockCtrl.Finish()
+
+		mockClientset := mock_core.NewClientset(mockCtrl)
+		expectLists(mockClientset, Namespace, nil)
+
+		list, err := cage_k8s_identity.ServiceAccountWorkloadQuerier{}.Do(ctx(), mockClientset.ToReal(), &query)
+		require.NoError(t, err)
+		require.Exactly(
+			t,
+			[]string{
+				Namespace + "/" + ServiceAccountUsernameBase + " Pod/" + Namespace + "/some-pod",
+				Namespace + "/" + ServiceAccountUsernameBase + " Deployment/" + Namespace + "/some-deployment",
+			},
+			sources(list),
+		)
+	})
+
+	t.Run("service account name hit", func(t *testing.T) {
+		query := cage_k8s_identity.Query{Workloads: true, Name: cage_k8s_identity.DefaultServiceAccount, Namespace: Namespace}
+
+		mockCtrl := gomock.NewController(t)
+		defer mockCtrl.Finish()
+
+		mockClientset := mock_core.NewClientset(mockCtrl)
+		expectLists(mockClientset, Namespace, nil)
+
+		list, err := cage_k8s_identity.ServiceAccountWorkloadQuerier{}.Do(ctx(), mockClientset.ToReal(), &query)
+		require.NoError(t, err)
+		require.Exactly(
+			t,
+			[]string{Namespace + "/" + cage_k8s_identity.DefaultServiceAccount + " CronJob/" + Namespace + "/some-cron-job"},
+			sources(list),
+		)
+	})
+
+	t.Run("service account group hit", func(t *testing.T) {
+		query := cage_k8s_identity.Query{Workloads: true, Name: ServiceAccountGroupOneNamespace}
+
+		mockCtrl := gomock.NewController(t)
+		defer mockCtrl.Finish()
+
+		mockClientset := mock_core.NewClientset(mockCtrl)
+		expectLists(mockClientset, Namespace, nil)
+
+		list, err := cage_k8s_identity.ServiceAccountWorkloadQuerier{}.Do(ctx(), mockClientset.ToReal(), &query)
+		require.NoError(t, err)
+		require.Len(t, list.Items, 4)
+	})
+
+	t.Run("cron jobs not served", func(t *testing.T) {
+		query := cage_k8s_identity.Query{Workloads: true}
+
+		mockCtrl := gomock.NewController(t)
+		defer mockCtrl.Finish()
+
+		mockClientset := mock_core.NewClientset(mockCtrl)
+		notFound := k8s_errors.NewNotFound(schema.GroupResource{Group: "batch", Resource: "cronjobs"}, "")
+		expectLists(mockClientset, NoQueryNamespace, pkg_errors.Wrap(notFound, "failed to list cron jobs"))
+
+		var messages []string
+		querier := cage_k8s_identity.ServiceAccountWorkloadQuerier{
+			Notify: func(msg string) { messages = append(messages, msg) },
+		}
+
+		list, err := querier.Do(ctx(), mockClientset.ToReal(), &query)
+		require.NoError(t, err)
+		require.Len(t, list.Items, 3)
+		require.Len(t, messages, 1)
+		require.Contains(t, messages[0], "cron jobs were not listed because the API server does not serve them")
+	})
+
+	t.Run("cron jobs error", func(t *testing.T) {
+		query := cage_k8s_identity.Query{Workloads: true}
+
+		mockCtrl := gomock.NewController(t)
+		defer mockCtrl.Finish()
+
+		mockClientset := mock_core.NewClientset(mockCtrl)
+		expectLists(mockClientset, NoQueryNamespace, errors.New("expectErr"))
+
+		list, err := cage_k8s_identity.ServiceAccountWorkloadQuerier{}.Do(ctx(), mockClientset.ToReal(), &query)
+		require.Nil(t, list)
+		require.EqualError(t, err, "expectErr")
+	})
+
+	// Assert that an error is returned if a service account's namespace does not match the query's.
+	t.Run("service account namespace mismatch", func(t *testing.T) {
+		query := cage_k8s_identity.Query{Workloads: true, Name: ServiceAccountUsername, Namespace: DoesNotExist}
+
+		mockCtrl := gomock.NewController(t)
+		defer mockCtrl.Finish()
+
+		mockClientset := mock_core.NewClientset(mockCtrl)
+
+		list, err := cage_k8s_identity.ServiceAccountWorkloadQuerier{}.Do(ctx(), mockClientset.ToReal(), &query)
+		require.Nil(t, list)
+		require.Error(t, err)
+		require.Contains(t, err.Error(), fmt.Sprintf("query's namespace [%s] does not match query service account [%s]'s namespace [%s] ", DoesNotExist, ServiceAccountUsername, Namespace))
+	})
+}
@@ -51,6 +51,36 @@ func QueryClientCmdConfig(val *clientcmdapi.Config) QueryOption {
 	}
 }
 
+// QueryDangling limits the query scope of an RBAC related query to binding subjects which no longer
+// resolve to existing objects, e.g. a ServiceAccount which was deleted, or whose binding refers to
+// a missing Role or ClusterRole.
+//
+// Querier implementations which do not read bindings are incompatible with such queries.
+func QueryDangling(val bool) QueryOption {
+	return func(q *Query) {
+		q.Dangling = val
+	}
+}
+
+// QueryWorkloads limits the query scope of an RBAC related query to service accounts which are used by
+// workloads, e.g. Pods and Deployments. Each returned Identity describes one workload in its Source field.
+//
+// Querier implementations which do not read workloads are incompatible with such queries.
+func QueryWorkloads(val bool) QueryOption {
+	return func(q *Query) {
+		q.Workloads = val
+	}
+}
+
+// QueryRaw disables the merging and sorting of Registry.Query results. Each querier's results are
+// listed as-is, in priority order, so an identity found by multiple queriers, or in multiple sources,
+// is listed once per occurrence.
+func QueryRaw(val bool) QueryOption {
+	return func(q *Query) {
+		q.Raw = val
+	}
+}
+
 // NewQuery returns a Query initialized with all input options.
 func NewQuery(options ...QueryOption) *Query {
 	q := Query{}
@@ -77,4 +107,16 @@ type Query struct {
 
 	// ClientCmdConfig provides kubectl config values from which to seek query matches.
 	ClientCmdConfig *clientcmdapi.Config
+
+	// Dangling limits which identities are returned from Querier implementations to binding subjects
+	// which no longer resolve to existing objects. Each returned Identity describes the reasons in
+	// its Dangling field.
+	Dangling bool
+
+	// Workloads limits which identities are returned from Querier implementations to service accounts
+	// used by workloads. Each returned Identity's Source is the workload.
+	Workloads bool
+
+	// Raw disables the merging and sorting of Registry.Query results.
+	Raw bool
 }
@@ -8,7 +8,7 @@ package identity
 
 import (
 	"context"
-	"sync"
+	"sort"
 
 	"github.com/pkg/errors"
 	"golang.org/x/sync/errgroup"
@@ -16,59 +16,203 @@ import (
 	cage_k8s_core "github.com/codeactual/kubeauth/internal/cage/kubernetes/v1/core"
 )
 
+// DefaultPriority is the priority of the built-in queriers and of those registered without RegisterPriority.
+const DefaultPriority = 0
+
+// RegisterOption implementations accept the current registration state and update it based
+// on option-specific logic.
+//
+// It supports a functional option API based on https://dave.cheney.net/2014/10/17/functional-options-for-friendly-apis.
+type RegisterOption func(*registration)
+
+// RegisterPriority sets the querier's priority. Results of queriers with higher priorities are listed first.
+func RegisterPriority(val int) RegisterOption {
+	return func(r *registration) {
+		r.priority = val
+	}
+}
+
+// registration holds a querier added by Register.
+type registration struct {
+	querier  Querier
+	priority int
+}
+
 type Registry struct {
-	CoreGroup           Querier
-	CoreUser            Querier
-	RoleSubject         Querier
-	ClusterRoleSubject  Querier
-	ServiceAccountUser  Querier
-	ServiceAccountGroup Querier
-	ConfigUser          Querier
+	CoreGroup              Querier
+	CoreUser               Querier
+	Node                   Querier
+	RoleSubject            Querier
+	ClusterRoleSubject     Querier
+	ServiceAccountUser     Querier
+	ServiceAccountGroup    Querier
+	ServiceAccountWorkload Querier
+	ConfigUser             Querier
 
 	Clientset *cage_k8s_core.Clientset
+
+	// registered holds the queriers added by Register in the order they were added.
+	registered []registration
+
+	// disabled is keyed by Querier.String values.
+	disabled map[string]bool
 }
 
 // NewRegistry builds a registry of known and discovered users.
 func NewRegistry(clientset *cage_k8s_core.Clientset) *Registry {
+	versions := &versionCache{}
 	return &Registry{
-		CoreGroup:           CoreGroupQuerier{},
-		CoreUser:            CoreUserQuerier{},
-		RoleSubject:         RoleSubjectQuerier{},
-		ClusterRoleSubject:  ClusterRoleSubjectQuerier{},
-		ServiceAccountUser:  ServiceAccountUserQuerier{},
-		ServiceAccountGroup: ServiceAccountGroupQuerier{},
-		ConfigUser:          ConfigUserQuerier{},
-		Clientset:           clientset,
+		CoreGroup:              CoreGroupQuerier{versions: versions},
+		CoreUser:               CoreUserQuerier{versions: versions},
+		Node:                   NodeQuerier{},
+		RoleSubject:            RoleSubjectQuerier{},
+		ClusterRoleSubject:     ClusterRoleSubjectQuerier{},
+		ServiceAccountUser:     ServiceAccountUserQuerier{},
+		ServiceAccountGroup:    ServiceAccountGroupQuerier{},
+		ServiceAccountWorkload: ServiceAccountWorkloadQuerier{},
+		ConfigUser:             ConfigUserQuerier{},
+		Clientset:              clientset,
 	}
 }
 
-func (reg *Registry) Query(ctx context.Context, options ...QueryOption) (*IdentityList, error) {
-	query := NewQuery(options...)
+// Register adds a querier, e.g. one which finds identities known outside the cluster, to those
+// used by Query.
+//
+// It returns an error if a querier with the same String value is already registered.
+func (reg *Registry) Register(querier Querier, options ...RegisterOption) error {
+	name := querier.String()
+	for _, r := range reg.all() {
+		if r.querier.String() == name {
+			return errors.Errorf("querier [%s] is already registered", name)
+		}
+	}
+
+	r := registration{querier: querier, priority: DefaultPriority}
+	for _, o := range options {
+		o(&r)
+	}
+
+	reg.registered = append(reg.registered, r)
+
+	return nil
+}
+
+// Disable excludes the querier, selected by its String value, from future queries.
+//
+// It returns an error if no such querier is registered.
+func (reg *Registry) Disable(name string) error {
+	if !reg.has(name) {
+		return errors.Errorf("querier [%s] is not registered", name)
+	}
+	if reg.disabled == nil {
+		reg.disabled = map[string]bool{}
+	}
+	reg.disabled[name] = true
+	return nil
+}
 
-	// Initialize all available queriers.
+// Enable reverses Disable.
+//
+// It returns an error if no such querier is registered.
+func (reg *Registry) Enable(name string) error {
+	if !reg.has(name) {
+		return errors.Errorf("querier [%s] is not registered", name)
+	}
+	delete(reg.disabled, name)
+	return nil
+}
 
-	queriers := []Querier{
+// Queriers returns the enabled queriers in the order their results are listed.
+func (reg *Registry) Queriers() (queriers []Querier) {
+	for _, r := range reg.enabled() {
+		queriers = append(queriers, r.querier)
+	}
+	return queriers
+}
+
+// enabled returns the registrations of the queriers which are not disabled, sorted by priority.
+func (reg *Registry) enabled() (enabled []registration) {
+	for _, r := range reg.all() {
+		if !reg.disabled[r.querier.String()] {
+			enabled = append(enabled, r)
+		}
+	}
+	return enabled
+}
+
+// all returns the built-in and registered queriers sorted by priority.
+//
+// Queriers with equal priorities retain their order: built-in queriers first, then registered
+// queriers in the order they were added.
+func (reg *Registry) all() []registration {
+	var all []registration
+	for _, q := range []Querier{
 		reg.CoreGroup,
 		reg.CoreUser,
+		reg.Node,
 		reg.RoleSubject,
 		reg.ClusterRoleSubject,
 		reg.ServiceAccountUser,
 		reg.ServiceAccountGroup,
+		reg.ServiceAccountWorkload,
+		reg.ConfigUser,
+	} {
+		if q != nil {
+			all = append(all, registration{querier: q, priority: DefaultPriority})
+		}
+	}
+	all = append(all, reg.registered...)
+
+	sort.SliceStable(all, func(i, j int) bool {
+		return all[i].priority > all[j].priority
+	})
+
+	return all
+}
+
+func (reg *Registry) has(name string) bool {
+	for _, r := range reg.all() {
+		if r.querier.String() == name {
+			return true
+		}
 	}
+	return false
+}
+
+// Query runs all enabled queriers which are compatible with the query.
+//
+// Results are merged by identity kind, namespace, and name, so that an identity found by multiple
+// queriers, or in multiple sources, is listed once with all of its Sources and Queriers. Merged
+// results are ranked by the priority of the first querier which found them, and then sorted by kind,
+// namespace, and name. Select QueryRaw to receive each querier's results as-is.
+func (reg *Registry) Query(ctx context.Context, options ...QueryOption) (*IdentityList, error) {
+	query := NewQuery(options...)
 
-	if query.ClientCmdConfig != nil {
-		queriers = append(queriers, reg.ConfigUser)
+	// Initialize all enabled queriers.
+
+	var queriers []Querier
+	var priorities []int
+	for _, r := range reg.enabled() {
+		if r.querier == reg.ConfigUser && query.ClientCmdConfig == nil {
+			continue
+		}
+		if !r.querier.Compatible(query) {
+			continue
+		}
+		queriers = append(queriers, r.querier)
+		priorities = append(priorities, r.priority)
 	}
 
 	// Run compatible queriers in parallel.
+	//
+	// Each querier writes to its own slot so that results are listed in priority order.
 
 	g, gCtx := errgroup.WithContext(ctx)
-	var fullList IdentityList
-	var mu sync.Mutex
+	lists := make([]*IdentityList, len(queriers))
 
 	// Use a constructor instead of a function literal in errgroup.Group.Go calls
 	// to avoid accidental closure value issues.
-	newErrGroupFn := func(querier Querier) func() error {
+	newErrGroupFn := func(n int, querier Querier) func() error {
 		return func() error {
 			querierType := querier.String()
 
@@ -77,27 +221,94 @@ func (reg *Registry) Query(ctx context.Context, options ...QueryOption) (*Identi
 				return errors.Wrapf(err, "identity querier [%s] did not finish", querierType)
 			}
 
-			for _, item := range list.Items {
-				item.Querier = querierType
-				mu.Lock()
-				fullList.Items = append(fullList.Items, item)
-				mu.Unlock()
+			for i := range list.Items {
+				list.Items[i].Querier = querierType
 			}
+			lists[n] = list
 
 			return nil
 		}
 	}
 
-	for _, querier := range queriers {
-		if !querier.Compatible(query) {
-			continue
-		}
-		g.Go(newErrGroupFn(querier))
+	for n, querier := range queriers {
+		g.Go(newErrGroupFn(n, querier))
 	}
 
 	if err := g.Wait(); err != nil {
 		return nil, errors.WithStack(err)
 	}
 
-	return &fullList, nil
+	if query.Raw {
+		var fullList IdentityList
+		for _, list := range lists {
+			for _, item := range list.Items {
+				if item.Source != nil {
+					item.Sources = []*IdentitySource{item.Source}
+				}
+				item.Queriers = []string{item.Querier}
+				fullList.Items = append(fullList.Items, item)
+			}
+		}
+		return &fullList, nil
+	}
+
+	return mergeIdentities(lists, priorities), nil
+}
+
+// mergeIdentities returns one item per identity kind, namespace, and name found in the lists, which
+// must be in priority order. Each list's querier priority is at the same index of priorities.
+//
+// Items are ranked by the priority of the first querier which found them, and then sorted by kind,
+// namespace, and name.
+func mergeIdentities(lists []*IdentityList, priorities []int) *IdentityList {
+	var merged IdentityList
+	ranks := []int{}
+	indexes := map[string]int{}
+
+	for l, list := range lists {
+		for _, item := range list.Items {
+			key := item.Key()
+
+			n, ok := indexes[key]
+			if !ok {
+				n = len(merged.Items)
+				indexes[key] = n
+				merged.Items = append(merged.Items, Identity{
+					TypeMeta:   item.TypeMeta,
+					ObjectMeta: item.ObjectMeta,
+				})
+				ranks = append(ranks, priorities[l])
+			}
+
+			merged.Items[n].merge(item)
+		}
+	}
+
+	sort.Sort(rankedIdentities{items: merged.Items, ranks: ranks})
+
+	return &merged
+}
+
+// rankedIdentities implements sort.Interface for mergeIdentities.
+type rankedIdentities struct {
+	items []Identity
+
+	// ranks holds the querier priority of each item.
+	ranks []int
+}
+
+func (r rankedIdentities) Len() int {
+	return len(r.items)
+}
+
+func (r rankedIdentities) Less(i, j int) bool {
+	if r.ranks[i] != r.ranks[j] {
+		return r.ranks[i] > r.ranks[j]
+	}
+	return r.items[i].Key() < r.items[j].Key()
+}
+
+func (r rankedIdentities) Swap(i, j int) {
+	r.items[i], r.items[j] = r.items[j], r.items[i]
+	r.ranks[i], r.ranks[j] = r.ranks[j], r.ranks[i]
 }
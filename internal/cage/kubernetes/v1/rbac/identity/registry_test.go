@@ -0,0 +1,204 @@
+// Copyright (C) 2020 The CodeActual Go Environment Authors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package identity_test
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+	meta "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	cage_k8s "github.com/codeactual/kubeauth/internal/cage/kubernetes/v1"
+	cage_k8s_core "github.com/codeactual/kubeauth/internal/cage/kubernetes/v1/core"
+	mock_core "github.com/codeactual/kubeauth/internal/cage/kubernetes/v1/core/mock"
+	cage_k8s_discovery "github.com/codeactual/kubeauth/internal/cage/kubernetes/v1/discovery"
+	cage_k8s_identity "github.com/codeactual/kubeauth/internal/cage/kubernetes/v1/rbac/identity"
+	mock_identity "github.com/codeactual/kubeauth/internal/cage/kubernetes/v1/rbac/identity/mock"
+	testkit_file "github.com/codeactual/kubeauth/internal/cage/testkit/os/file"
+)
+
+func queriersNames(reg *cage_k8s_identity.Registry) (s []string) {
+	for _, q := range reg.Queriers() {
+		s = append(s, q.String())
+	}
+	return s
+}
+
+func TestRegistryRegister(t *testing.T) {
+	path := filepath.Join(testkit_file.FixtureDataDir(), "group")
+
+	newQuerier := func() *cage_k8s_identity.FileQuerier {
+		q, err := cage_k8s_identity.NewGroupFileQuerier(path)
+		require.NoError(t, err)
+		return q
+	}
+
+	t.Run("default priority", func(t *testing.T) {
+		reg := cage_k8s_identity.NewRegistry(nil)
+		require.NoError(t, reg.Register(newQuerier()))
+
+		queriers := queriersNames(reg)
+		require.Len(t, queriers, 10)
+		require.Exactly(t, cage_k8s_identity.CoreGroupQuerier{}.String(), queriers[0])
+		require.Exactly(t, newQuerier().String(), queriers[9])
+	})
+
+	t.Run("high priority", func(t *testing.T) {
+		reg := cage_k8s_identity.NewRegistry(nil)
+		require.NoError(t, reg.Register(newQuerier(), cage_k8s_identity.RegisterPriority(1)))
+
+		queriers := queriersNames(reg)
+		require.Len(t, queriers, 10)
+		require.Exactly(t, newQuerier().String(), queriers[0])
+	})
+
+	t.Run("duplicate", func(t *testing.T) {
+		reg := cage_k8s_identity.NewRegistry(nil)
+		require.NoError(t, reg.Register(newQuerier()))
+
+		err := reg.Register(newQuerier())
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "is already registered")
+	})
+}
+
+func TestRegistryDisable(t *testing.T) {
+	reg := cage_k8s_identity.NewRegistry(nil)
+
+	name := cage_k8s_identity.CoreGroupQuerier{}.String()
+
+	require.NoError(t, reg.Disable(name))
+	require.NotContains(t, queriersNames(reg), name)
+
+	require.NoError(t, reg.Enable(name))
+	require.Contains(t, queriersNames(reg), name)
+
+	err := reg.Disable(DoesNotExist)
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "querier [does-not-exist] is not registered")
+}
+
+func TestRegistryQuery(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	q, err := cage_k8s_identity.NewGroupFileQuerier(filepath.Join(testkit_file.FixtureDataDir(), "group"))
+	require.NoError(t, err)
+
+	mockClientset := mock_core.NewClientset(mockCtrl)
+	// The release is only queried once per registry.
+	mockClientset.Discovery.EXPECT().Version().Return(&cage_k8s_discovery.Version{Major: 1, Minor: 17}, nil).Times(1)
+
+	reg := cage_k8s_identity.NewRegistry(mockClientset.ToReal())
+	require.NoError(t, reg.Register(q, cage_k8s_identity.RegisterPriority(1)))
+
+	// Only the file and built-in group queriers are expected to perform queries because the binding
+	// queriers are disabled, and the query name is not a service account group.
+	require.NoError(t, reg.Disable(cage_k8s_identity.RoleSubjectQuerier{}.String()))
+	require.NoError(t, reg.Disable(cage_k8s_identity.ClusterRoleSubjectQuerier{}.String()))
+
+	list, err := reg.Query(ctx(), cage_k8s_identity.QueryKind(cage_k8s.KindGroup), cage_k8s_identity.QueryName("developers"))
+	require.NoError(t, err)
+	require.Len(t, list.Items, 1)
+	require.Exactly(t, "developers", list.Items[0].Name)
+	require.Exactly(t, q.String(), list.Items[0].Querier)
+
+	// Results are listed in priority order.
+	list, err = reg.Query(ctx(), cage_k8s_identity.QueryKind(cage_k8s.KindGroup))
+	require.NoError(t, err)
+	require.Len(t, list.Items, 7)
+	require.Exactly(t, q.String(), list.Items[0].Querier)
+	require.Exactly(t, q.String(), list.Items[1].Querier)
+	require.Exactly(t, cage_k8s_identity.CoreGroupQuerier{}.String(), list.Items[2].Querier)
+
+	require.NoError(t, reg.Disable(q.String()))
+	list, err = reg.Query(ctx(), cage_k8s_identity.QueryKind(cage_k8s.KindGroup), cage_k8s_identity.QueryName("developers"))
+	require.NoError(t, err)
+	require.Empty(t, list.Items)
+}
+
+func TestRegistryQueryMerge(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	binding := func(kind, namespace, name string) *cage_k8s_identity.IdentitySource {
+		return &cage_k8s_identity.IdentitySource{
+			TypeMeta:   meta.TypeMeta{Kind: kind},
+			ObjectMeta: meta.ObjectMeta{Namespace: namespace, Name: name},
+		}
+	}
+
+	coreGroups := &cage_k8s_identity.IdentityList{}
+	coreGroups.Add("", cage_k8s.KindGroup, "system:masters", nil)
+
+	roleSubjects := &cage_k8s_identity.IdentityList{}
+	roleSubjects.Add("", cage_k8s.KindGroup, "ops", binding(cage_k8s.KindRoleBinding, Namespace, "ops-binding"))
+	roleSubjects.Add("", cage_k8s.KindGroup, "dev", binding(cage_k8s.KindRoleBinding, Namespace, "dev-binding"))
+	roleSubjects.Add("", cage_k8s.KindGroup, "dev", binding(cage_k8s.KindRoleBinding, Namespace, "dev-binding"))
+
+	clusterRoleSubjects := &cage_k8s_identity.IdentityList{}
+	clusterRoleSubjects.Add("", cage_k8s.KindGroup, "dev", binding(cage_k8s.KindClusterRoleBinding, "", "dev-cluster-binding"))
+	clusterRoleSubjects.Add("", cage_k8s.KindGroup, "admins", binding(cage_k8s.KindClusterRoleBinding, "", "admins-binding"))
+
+	// Return copies because the registry updates the items of each querier's list.
+	copyList := func(list *cage_k8s_identity.IdentityList) func(context.Context, *cage_k8s_core.Clientset, *cage_k8s_identity.Query) (*cage_k8s_identity.IdentityList, error) {
+		return func(context.Context, *cage_k8s_core.Clientset, *cage_k8s_identity.Query) (*cage_k8s_identity.IdentityList, error) {
+			return &cage_k8s_identity.IdentityList{Items: append([]cage_k8s_identity.Identity{}, list.Items...)}, nil
+		}
+	}
+
+	mockRegistry := mock_identity.NewRegistry(mockCtrl)
+	mockRegistry.CoreGroup.EXPECT().Do(gomock.Any(), gomock.Any(), gomock.Any()).DoAndReturn(copyList(coreGroups)).Times(2)
+	mockRegistry.RoleSubject.EXPECT().Do(gomock.Any(), gomock.Any(), gomock.Any()).DoAndReturn(copyList(roleSubjects)).Times(2)
+	mockRegistry.ClusterRoleSubject.EXPECT().Do(gomock.Any(), gomock.Any(), gomock.Any()).DoAndReturn(copyList(clusterRoleSubjects)).Times(2)
+	mockRegistry.ServiceAccountGroup.EXPECT().Do(gomock.Any(), gomock.Any(), gomock.Any()).Return(&cage_k8s_identity.IdentityList{}, nil).Times(2)
+
+	reg := mockRegistry.ToReal(nil)
+
+	t.Run("merged", func(t *testing.T) {
+		list, err := reg.Query(ctx(), cage_k8s_identity.QueryKind(cage_k8s.KindGroup))
+		require.NoError(t, err)
+
+		// Results are ranked by the priority of the first querier which found them, and then sorted by name.
+		// The built-in queriers have equal priorities.
+		require.Exactly(t, []string{"Group admins", "Group dev", "Group ops", "Group system:masters"}, names(list))
+
+		dev := list.Items[1]
+		require.Len(t, dev.Sources, 2)
+		require.Exactly(t, dev.Sources[0], dev.Source)
+		require.Exactly(
+			t,
+			[]string{cage_k8s_identity.RoleSubjectQuerier{}.String(), cage_k8s_identity.ClusterRoleSubjectQuerier{}.String()},
+			dev.Queriers,
+		)
+		require.Exactly(t, cage_k8s_identity.RoleSubjectQuerier{}.String(), dev.Querier)
+		require.Exactly(
+			t,
+			"Group dev (from RoleBinding dev-binding of namespace "+Namespace+", ClusterRoleBinding dev-cluster-binding) "+
+				"via [role binding subject, cluster role binding subject] queriers",
+			dev.String(),
+		)
+
+		require.Empty(t, list.Items[3].Sources)
+		require.Exactly(t, "Group system:masters via [system-defined group] querier", list.Items[3].String())
+	})
+
+	t.Run("raw", func(t *testing.T) {
+		list, err := reg.Query(ctx(), cage_k8s_identity.QueryKind(cage_k8s.KindGroup), cage_k8s_identity.QueryRaw(true))
+		require.NoError(t, err)
+		require.Exactly(
+			t,
+			[]string{"Group system:masters", "Group ops", "Group dev", "Group dev", "Group dev", "Group admins"},
+			names(list),
+		)
+		require.Exactly(t, []string{cage_k8s_identity.ClusterRoleSubjectQuerier{}.String()}, list.Items[4].Queriers)
+		require.Len(t, list.Items[4].Sources, 1)
+	})
+}
@@ -10,19 +10,105 @@ import (
 	"strings"
 
 	"github.com/pkg/errors"
+	rbac "k8s.io/api/rbac/v1"
 )
 
 type BindingSelector struct {
+	// Namespace is empty if the selector did not include one, e.g. to select the effective namespace.
+	Namespace   string
 	RoleName    string
 	BindingName string
 }
 
+// NewBindingSelector parses a selector in the format [<namespace>/]<role name>:<binding name>.
 func NewBindingSelector(s string) (*BindingSelector, error) {
+	invalid := errors.Errorf("selector [%s] does not use format [<namespace>/]<role name>:<binding name>", s)
+
+	var namespace string
+	if n := strings.Index(s, "/"); n != -1 {
+		namespace = s[:n]
+		if namespace == "" {
+			return nil, invalid
+		}
+		s = s[n+1:]
+	}
+
+	parts := strings.Split(s, ":")
+	if len(parts) != 2 || parts[0] == "" || parts[1] == "" || strings.Contains(s, "/") {
+		return nil, invalid
+	}
+	return &BindingSelector{Namespace: namespace, RoleName: parts[0], BindingName: parts[1]}, nil
+}
+
+// Grant selects permissions to include in a role.
+type Grant struct {
+	Verbs []string
+
+	// Resource may be "*" to select all resources in the group.
+	Resource string
+
+	// Group is empty for the core group and may be "*" to select all groups.
+	Group string
+
+	// ResourceName is empty if the grant applies to all objects of the resource.
+	ResourceName string
+}
+
+// NewGrant parses a grant in the format <verb>[,<verb>...]:<resource>[.<group>][/<resource name>].
+//
+// For example, "get,list:deployments.apps" selects two verbs on deployments in the apps group,
+// and "get:configmaps/app-config" selects one verb on one config map in the core group.
+func NewGrant(s string) (*Grant, error) {
+	invalid := errors.Errorf("grant [%s] does not use format <verb>[,<verb>...]:<resource>[.<group>][/<resource name>]", s)
+
 	parts := strings.Split(s, ":")
 	if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
-		return nil, errors.Errorf("selector [%s] does not use format <role name>:<binding name>", s)
+		return nil, invalid
+	}
+
+	g := &Grant{}
+
+	for _, v := range strings.Split(parts[0], ",") {
+		if v == "" {
+			return nil, invalid
+		}
+		g.Verbs = append(g.Verbs, v)
+	}
+
+	target := parts[1]
+	if n := strings.Index(target, "/"); n != -1 {
+		g.ResourceName = target[n+1:]
+		target = target[:n]
+		if g.ResourceName == "" || strings.Contains(g.ResourceName, "/") {
+			return nil, invalid
+		}
+	}
+
+	g.Resource = target
+	if n := strings.Index(target, "."); n != -1 {
+		g.Resource, g.Group = target[:n], target[n+1:]
+		if g.Group == "" {
+			return nil, invalid
+		}
+	}
+	if g.Resource == "" {
+		return nil, invalid
+	}
+
+	return g, nil
+}
+
+// PolicyRule returns the rule which grants the permissions.
+func (g Grant) PolicyRule() rbac.PolicyRule {
+	rule := rbac.PolicyRule{
+		Verbs:     append([]string{}, g.Verbs...),
+		APIGroups: []string{g.Group},
+		Resources: []string{g.Resource},
+	}
+	if g.ResourceName != "" {
+		rule.ResourceNames = []string{g.ResourceName}
 	}
-	return &BindingSelector{RoleName: parts[0], BindingName: parts[1]}, nil
+	return rule
 }
 
 // ParseServiceAccountUser parses a service account user name.
@@ -0,0 +1,70 @@
+// Copyright (C) 2020 The CodeActual Go Environment Authors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package rbac_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	rbac "k8s.io/api/rbac/v1"
+
+	cage_k8s_rbac "github.com/codeactual/kubeauth/internal/cage/kubernetes/v1/rbac"
+)
+
+func TestNewBindingSelector(t *testing.T) {
+	selector, err := cage_k8s_rbac.NewBindingSelector("some-role:some-binding")
+	require.NoError(t, err)
+	require.Exactly(t, &cage_k8s_rbac.BindingSelector{RoleName: "some-role", BindingName: "some-binding"}, selector)
+
+	selector, err = cage_k8s_rbac.NewBindingSelector("some-ns/some-role:some-binding")
+	require.NoError(t, err)
+	require.Exactly(t, &cage_k8s_rbac.BindingSelector{Namespace: "some-ns", RoleName: "some-role", BindingName: "some-binding"}, selector)
+
+	for _, s := range []string{"", "some-role", "some-role:", ":some-binding", "/some-role:some-binding", "some-ns/some-role", "a/b/c:d", "a/b:c/d"} {
+		_, err = cage_k8s_rbac.NewBindingSelector(s)
+		require.Error(t, err, s)
+	}
+}
+
+func TestNewGrant(t *testing.T) {
+	grant, err := cage_k8s_rbac.NewGrant("get,list:pods")
+	require.NoError(t, err)
+	require.Exactly(t, &cage_k8s_rbac.Grant{Verbs: []string{"get", "list"}, Resource: "pods"}, grant)
+
+	grant, err = cage_k8s_rbac.NewGrant("update:deployments.apps/some-deploy")
+	require.NoError(t, err)
+	require.Exactly(t, &cage_k8s_rbac.Grant{Verbs: []string{"update"}, Resource: "deployments", Group: "apps", ResourceName: "some-deploy"}, grant)
+
+	grant, err = cage_k8s_rbac.NewGrant("get:ingresses.networking.k8s.io")
+	require.NoError(t, err)
+	require.Exactly(t, &cage_k8s_rbac.Grant{Verbs: []string{"get"}, Resource: "ingresses", Group: "networking.k8s.io"}, grant)
+
+	grant, err = cage_k8s_rbac.NewGrant("*:*.*")
+	require.NoError(t, err)
+	require.Exactly(t, &cage_k8s_rbac.Grant{Verbs: []string{"*"}, Resource: "*", Group: "*"}, grant)
+
+	for _, s := range []string{"", "get", "get:", ":pods", "get,:pods", "get:pods.", "get:.apps", "get:pods/", "get:pods/a/b", "get:pods:list"} {
+		_, err = cage_k8s_rbac.NewGrant(s)
+		require.Error(t, err, s)
+	}
+}
+
+func TestGrantPolicyRule(t *testing.T) {
+	grant := cage_k8s_rbac.Grant{Verbs: []string{"get"}, Resource: "configmaps", ResourceName: "some-config"}
+	require.Exactly(
+		t,
+		rbac.PolicyRule{Verbs: []string{"get"}, APIGroups: []string{""}, Resources: []string{"configmaps"}, ResourceNames: []string{"some-config"}},
+		grant.PolicyRule(),
+	)
+
+	grant = cage_k8s_rbac.Grant{Verbs: []string{"list"}, Resource: "deployments", Group: "apps"}
+	require.Exactly(
+		t,
+		rbac.PolicyRule{Verbs: []string{"list"}, APIGroups: []string{"apps"}, Resources: []string{"deployments"}},
+		grant.PolicyRule(),
+	)
+}
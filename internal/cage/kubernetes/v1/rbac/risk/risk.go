@@ -0,0 +1,194 @@
+// Copyright (C) 2020 The CodeActual Go Environment Authors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at https://mozilla.org/MPL/2.0/.
+
+// Package risk identifies RBAC rules which grant escalation-prone permissions, i.e. those which
+// allow a subject to obtain permissions beyond the ones it was granted.
+package risk
+
+import (
+	"strings"
+
+	apps "k8s.io/api/apps/v1"
+	batch "k8s.io/api/batch/v1"
+	rbac "k8s.io/api/rbac/v1"
+)
+
+// SystemNamespace is the namespace in which create permissions on workloads are considered
+// escalation-prone, because the control plane's service accounts are available to its pods.
+const SystemNamespace = "kube-system"
+
+// PrivilegedGroups maps groups whose members the API server grants escalation-prone permissions,
+// regardless of any bindings, to a description of those permissions.
+var PrivilegedGroups = map[string]string{
+	"system:masters": "all permissions, because members bypass authorization",
+	"system:nodes":   "kubelet permissions from the node authorizer, e.g. reading secrets of scheduled pods",
+}
+
+// Risk describes an escalation-prone permission.
+type Risk struct {
+	// ID is a stable identifier, e.g. for machine-readable reports.
+	ID string
+
+	// Description is a short summary of what the permission allows.
+	Description string
+
+	// match implements Match.
+	match func(rule rbac.PolicyRule, ns string) bool
+}
+
+// Match reports whether the rule grants the permission in the namespace, which is empty if
+// the rule is granted cluster-wide.
+func (r Risk) Match(rule rbac.PolicyRule, ns string) bool {
+	return r.match(rule, ns)
+}
+
+// Finding describes a risk and the rules which grant it.
+type Finding struct {
+	Risk  Risk
+	Rules []rbac.PolicyRule
+}
+
+// resource identifies a resource in a group.
+type resource struct {
+	group string
+	name  string
+}
+
+var workloads = []resource{
+	{group: "", name: "pods"},
+	{group: "", name: "replicationcontrollers"},
+	{group: apps.GroupName, name: "daemonsets"},
+	{group: apps.GroupName, name: "deployments"},
+	{group: apps.GroupName, name: "replicasets"},
+	{group: apps.GroupName, name: "statefulsets"},
+	{group: batch.GroupName, name: "cronjobs"},
+	{group: batch.GroupName, name: "jobs"},
+}
+
+// Catalog lists the risks which Analyze checks, in the order of its findings.
+var Catalog = []Risk{
+	{
+		ID:          "wildcard",
+		Description: "all verbs on all resources",
+		match: func(rule rbac.PolicyRule, _ string) bool {
+			return hasString(rule.Verbs, rbac.VerbAll) && hasString(rule.Resources, rbac.ResourceAll) && hasString(rule.APIGroups, rbac.APIGroupAll)
+		},
+	},
+	{
+		ID:          "escalate",
+		Description: "escalate verb on roles, which allows granting permissions the subject lacks",
+		match: func(rule rbac.PolicyRule, _ string) bool {
+			return allowsAny(rule, []resource{{rbac.GroupName, "roles"}, {rbac.GroupName, "clusterroles"}}, "escalate")
+		},
+	},
+	{
+		ID:          "bind",
+		Description: "bind verb on roles, which allows binding roles with permissions the subject lacks",
+		match: func(rule rbac.PolicyRule, _ string) bool {
+			return allowsAny(rule, []resource{{rbac.GroupName, "roles"}, {rbac.GroupName, "clusterroles"}}, "bind")
+		},
+	},
+	{
+		ID:          "impersonate",
+		Description: "impersonate verb on users, groups, or service accounts",
+		match: func(rule rbac.PolicyRule, _ string) bool {
+			return allowsAny(rule, []resource{{"", "users"}, {"", "groups"}, {"", "serviceaccounts"}}, "impersonate")
+		},
+	},
+	{
+		ID:          "secrets-read",
+		Description: "read secrets, including service account tokens",
+		match: func(rule rbac.PolicyRule, _ string) bool {
+			return allowsAny(rule, []resource{{"", "secrets"}}, "get", "list", "watch")
+		},
+	},
+	{
+		ID:          "pod-exec",
+		Description: "exec or attach to pods, which exposes their service account tokens",
+		match: func(rule rbac.PolicyRule, _ string) bool {
+			return allowsAny(rule, []resource{{"", "pods/exec"}, {"", "pods/attach"}}, "create", "get")
+		},
+	},
+	{
+		ID:          "node-proxy",
+		Description: "proxy to nodes, which exposes the kubelet API",
+		match: func(rule rbac.PolicyRule, ns string) bool {
+			// Nodes are cluster-scoped, so the rule has no effect in a namespace.
+			return ns == "" && allowsAny(rule, []resource{{"", "nodes/proxy"}}, "get", "create")
+		},
+	},
+	{
+		ID:          "system-workloads",
+		Description: "create workloads in " + SystemNamespace + ", whose pods may use its privileged service accounts",
+		match: func(rule rbac.PolicyRule, ns string) bool {
+			return (ns == "" || ns == SystemNamespace) && allowsAny(rule, workloads, "create")
+		},
+	},
+}
+
+// Analyze returns the risks of the Catalog which the rules grant in the namespace, which is
+// empty if the rules are granted cluster-wide, e.g. by a cluster role binding.
+//
+// Rules limited to specific resource names are included, because they may still select
+// sensitive objects.
+func Analyze(rules []rbac.PolicyRule, ns string) (findings []Finding) {
+	for _, r := range Catalog {
+		f := Finding{Risk: r}
+		for _, rule := range rules {
+			if r.Match(rule, ns) {
+				f.Rules = append(f.Rules, rule)
+			}
+		}
+		if len(f.Rules) > 0 {
+			findings = append(findings, f)
+		}
+	}
+	return findings
+}
+
+// allowsAny reports whether the rule grants any of the verbs on any of the resources.
+func allowsAny(rule rbac.PolicyRule, resources []resource, verbs ...string) bool {
+	for _, res := range resources {
+		if !matchGroup(rule.APIGroups, res.group) || !matchResource(rule.Resources, res.name) {
+			continue
+		}
+		for _, v := range verbs {
+			if hasString(rule.Verbs, v) || hasString(rule.Verbs, rbac.VerbAll) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func matchGroup(groups []string, group string) bool {
+	return hasString(groups, group) || hasString(groups, rbac.APIGroupAll)
+}
+
+// matchResource reports whether the resource, or subresource in "<resource>/<subresource>" format,
+// is selected, including by the "*", "<resource>/*", and "*/<subresource>" wildcards.
+func matchResource(selected []string, resource string) bool {
+	for _, s := range selected {
+		switch {
+		case s == rbac.ResourceAll, s == resource:
+			return true
+		case strings.HasSuffix(s, "/*") && strings.Contains(resource, "/") && strings.HasPrefix(resource, strings.TrimSuffix(s, "*")):
+			return true
+		case strings.HasPrefix(s, "*/") && strings.HasSuffix(resource, s[1:]):
+			return true
+		}
+	}
+	return false
+}
+
+func hasString(list []string, s string) bool {
+	for _, l := range list {
+		if l == s {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,112 @@
+// Copyright (C) 2020 The CodeActual Go Environment Authors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package risk_test
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	rbac "k8s.io/api/rbac/v1"
+
+	"github.com/codeactual/kubeauth/internal/cage/kubernetes/v1/rbac/risk"
+)
+
+// findingIDs returns the risk IDs of the findings.
+func findingIDs(findings []risk.Finding) (ids []string) {
+	for _, f := range findings {
+		ids = append(ids, f.Risk.ID)
+	}
+	return ids
+}
+
+func TestAnalyze(t *testing.T) {
+	cases := []struct {
+		rule rbac.PolicyRule
+		ns   string
+		ids  []string
+	}{
+		{
+			rule: rbac.PolicyRule{Verbs: []string{"get", "list"}, APIGroups: []string{""}, Resources: []string{"pods", "configmaps"}},
+		},
+		{
+			rule: rbac.PolicyRule{Verbs: []string{"*"}, APIGroups: []string{"*"}, Resources: []string{"*"}},
+			ids:  []string{"wildcard", "escalate", "bind", "impersonate", "secrets-read", "pod-exec", "node-proxy", "system-workloads"},
+		},
+		{
+			rule: rbac.PolicyRule{Verbs: []string{"escalate", "bind"}, APIGroups: []string{rbac.GroupName}, Resources: []string{"clusterroles"}},
+			ids:  []string{"escalate", "bind"},
+		},
+		{
+			rule: rbac.PolicyRule{Verbs: []string{"impersonate"}, APIGroups: []string{""}, Resources: []string{"serviceaccounts"}},
+			ns:   "dev",
+			ids:  []string{"impersonate"},
+		},
+		{
+			rule: rbac.PolicyRule{Verbs: []string{"get"}, APIGroups: []string{""}, Resources: []string{"secrets"}, ResourceNames: []string{"some-secret"}},
+			ns:   "dev",
+			ids:  []string{"secrets-read"},
+		},
+		{
+			rule: rbac.PolicyRule{Verbs: []string{"create"}, APIGroups: []string{""}, Resources: []string{"pods/*"}},
+			ns:   "dev",
+			ids:  []string{"pod-exec"},
+		},
+		{
+			rule: rbac.PolicyRule{Verbs: []string{"create"}, APIGroups: []string{""}, Resources: []string{"*/attach"}},
+			ns:   "dev",
+			ids:  []string{"pod-exec"},
+		},
+		{
+			// Subresource wildcards do not select the parent resource.
+			rule: rbac.PolicyRule{Verbs: []string{"create"}, APIGroups: []string{""}, Resources: []string{"pods/*"}},
+			ns:   risk.SystemNamespace,
+			ids:  []string{"pod-exec"},
+		},
+		{
+			rule: rbac.PolicyRule{Verbs: []string{"get"}, APIGroups: []string{""}, Resources: []string{"nodes/proxy"}},
+			ids:  []string{"node-proxy"},
+		},
+		{
+			// Cluster-scoped resources are not granted in a namespace.
+			rule: rbac.PolicyRule{Verbs: []string{"get"}, APIGroups: []string{""}, Resources: []string{"nodes/proxy"}},
+			ns:   "dev",
+		},
+		{
+			rule: rbac.PolicyRule{Verbs: []string{"create"}, APIGroups: []string{"apps"}, Resources: []string{"deployments"}},
+			ns:   risk.SystemNamespace,
+			ids:  []string{"system-workloads"},
+		},
+		{
+			rule: rbac.PolicyRule{Verbs: []string{"create"}, APIGroups: []string{"apps"}, Resources: []string{"deployments"}},
+			ids:  []string{"system-workloads"},
+		},
+		{
+			rule: rbac.PolicyRule{Verbs: []string{"create"}, APIGroups: []string{"apps"}, Resources: []string{"deployments"}},
+			ns:   "dev",
+		},
+		{
+			// The resource is not served by the group.
+			rule: rbac.PolicyRule{Verbs: []string{"get"}, APIGroups: []string{"apps"}, Resources: []string{"secrets"}},
+		},
+	}
+
+	for n, c := range cases {
+		require.Exactly(t, c.ids, findingIDs(risk.Analyze([]rbac.PolicyRule{c.rule}, c.ns)), fmt.Sprintf("case %d", n))
+	}
+}
+
+func TestAnalyzeRules(t *testing.T) {
+	secrets := rbac.PolicyRule{Verbs: []string{"list"}, APIGroups: []string{""}, Resources: []string{"secrets"}}
+	configMaps := rbac.PolicyRule{Verbs: []string{"list"}, APIGroups: []string{""}, Resources: []string{"configmaps"}}
+	allSecrets := rbac.PolicyRule{Verbs: []string{"*"}, APIGroups: []string{""}, Resources: []string{"secrets"}}
+
+	findings := risk.Analyze([]rbac.PolicyRule{secrets, configMaps, allSecrets}, "dev")
+	require.Len(t, findings, 1)
+	require.Exactly(t, "secrets-read", findings[0].Risk.ID)
+	require.Exactly(t, []rbac.PolicyRule{secrets, allSecrets}, findings[0].Rules)
+}
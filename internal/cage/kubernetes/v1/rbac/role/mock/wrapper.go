@@ -40,6 +40,35 @@ func (m *MockClient) EXPECT() *MockClientMockRecorder {
 	return m.recorder
 }
 
+// Create mocks base method
+func (m *MockClient) Create(obj *v1.Role) (*v1.Role, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Create", obj)
+	ret0, _ := ret[0].(*v1.Role)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Create indicates an expected call of Create
+func (mr *MockClientMockRecorder) Create(obj interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Create", reflect.TypeOf((*MockClient)(nil).Create), obj)
+}
+
+// Delete mocks base method
+func (m *MockClient) Delete(ns, role string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Delete", ns, role)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Delete indicates an expected call of Delete
+func (mr *MockClientMockRecorder) Delete(ns, role interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Delete", reflect.TypeOf((*MockClient)(nil).Delete), ns, role)
+}
+
 // List mocks base method
 func (m *MockClient) List(ns string, options ...v10.ListOptions) (*v1.RoleList, error) {
 	m.ctrl.T.Helper()
@@ -80,3 +109,18 @@ func (mr *MockClientMockRecorder) Get(ns, role interface{}, options ...interface
 	varargs := append([]interface{}{ns, role}, options...)
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Get", reflect.TypeOf((*MockClient)(nil).Get), varargs...)
 }
+
+// Update mocks base method
+func (m *MockClient) Update(obj *v1.Role) (*v1.Role, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Update", obj)
+	ret0, _ := ret[0].(*v1.Role)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Update indicates an expected call of Update
+func (mr *MockClientMockRecorder) Update(obj interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Update", reflect.TypeOf((*MockClient)(nil).Update), obj)
+}
@@ -22,8 +22,11 @@ import (
 
 // Client provides an interface to roles.
 type Client interface {
+	Create(obj *rbac.Role) (*rbac.Role, error)
+	Delete(ns, role string) error
 	List(ns string, options ...meta.ListOptions) (*rbac.RoleList, error)
 	Get(ns, role string, options ...meta.GetOptions) (_ *rbac.Role, exists bool, _ error)
+	Update(obj *rbac.Role) (*rbac.Role, error)
 }
 
 // DefaultClient implementation of Client operates on a real kubernetes API.
@@ -36,6 +39,35 @@ func NewDefaultClient(getter rbac_type.RolesGetter) *DefaultClient {
 	return &DefaultClient{RolesGetter: getter}
 }
 
+// Create adds the role to the namespace selected by its metadata.
+//
+// It implements Client.
+func (c *DefaultClient) Create(obj *rbac.Role) (*rbac.Role, error) {
+	created, err := c.Roles(obj.Namespace).Create(obj)
+	if err != nil {
+		// Allow caller to perform the same check and decide how to handle it.
+		if k8s_errors.IsAlreadyExists(err) {
+			return nil, err
+		}
+
+		return nil, errors.Wrapf(err, "failed to create role [%s] in namespace [%s]", obj.Name, obj.Namespace)
+	}
+
+	return created, nil
+}
+
+// Delete removes the role.
+//
+// It implements Client.
+func (c *DefaultClient) Delete(ns, role string) error {
+	err := c.Roles(ns).Delete(role, &meta.DeleteOptions{})
+	if err != nil {
+		return errors.Wrapf(err, "failed to delete role [%s] in namespace [%s]", role, ns)
+	}
+
+	return nil
+}
+
 // Get returns the object if found, reports that the object does not exist, or returns an error.
 //
 // A single GetOptions value can be passed as the final argument to customize the query.
@@ -68,4 +100,16 @@ func (c *DefaultClient) List(ns string, options ...meta.ListOptions) (*rbac.Role
 	return list, nil
 }
 
+// Update replaces the role in the namespace selected by its metadata.
+//
+// It implements Client.
+func (c *DefaultClient) Update(obj *rbac.Role) (*rbac.Role, error) {
+	updated, err := c.Roles(obj.Namespace).Update(obj)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to update role [%s] in namespace [%s]", obj.Name, obj.Namespace)
+	}
+
+	return updated, nil
+}
+
 var _ Client = (*DefaultClient)(nil)
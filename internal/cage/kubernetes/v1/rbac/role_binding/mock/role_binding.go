@@ -61,16 +61,71 @@ func (mr *MockClientMockRecorder) List(ns interface{}, options ...interface{}) *
 }
 
 // Create mocks base method
-func (m *MockClient) Create(ns, name, role string, subject v1.Subject) (*v1.RoleBinding, error) {
+func (m *MockClient) Create(objMeta v10.ObjectMeta, roleRef v1.RoleRef, subjects ...v1.Subject) (*v1.RoleBinding, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "Create", ns, name, role, subject)
+	varargs := []interface{}{objMeta, roleRef}
+	for _, a := range subjects {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "Create", varargs...)
 	ret0, _ := ret[0].(*v1.RoleBinding)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // Create indicates an expected call of Create
-func (mr *MockClientMockRecorder) Create(ns, name, role, subject interface{}) *gomock.Call {
+func (mr *MockClientMockRecorder) Create(objMeta, roleRef interface{}, subjects ...interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]interface{}{objMeta, roleRef}, subjects...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Create", reflect.TypeOf((*MockClient)(nil).Create), varargs...)
+}
+
+// Get mocks base method
+func (m *MockClient) Get(ns, name string, options ...v10.GetOptions) (*v1.RoleBinding, bool, error) {
+	m.ctrl.T.Helper()
+	varargs := []interface{}{ns, name}
+	for _, a := range options {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "Get", varargs...)
+	ret0, _ := ret[0].(*v1.RoleBinding)
+	ret1, _ := ret[1].(bool)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// Get indicates an expected call of Get
+func (mr *MockClientMockRecorder) Get(ns, name interface{}, options ...interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]interface{}{ns, name}, options...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Get", reflect.TypeOf((*MockClient)(nil).Get), varargs...)
+}
+
+// Update mocks base method
+func (m *MockClient) Update(obj *v1.RoleBinding) (*v1.RoleBinding, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Update", obj)
+	ret0, _ := ret[0].(*v1.RoleBinding)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Update indicates an expected call of Update
+func (mr *MockClientMockRecorder) Update(obj interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Update", reflect.TypeOf((*MockClient)(nil).Update), obj)
+}
+
+// Delete mocks base method
+func (m *MockClient) Delete(ns, name string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Delete", ns, name)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Delete indicates an expected call of Delete
+func (mr *MockClientMockRecorder) Delete(ns, name interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Create", reflect.TypeOf((*MockClient)(nil).Create), ns, name, role, subject)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Delete", reflect.TypeOf((*MockClient)(nil).Delete), ns, name)
 }
@@ -18,12 +18,16 @@ import (
 	"github.com/pkg/errors"
 
 	cage_k8s "github.com/codeactual/kubeauth/internal/cage/kubernetes/v1"
+	cage_k8s_rbac "github.com/codeactual/kubeauth/internal/cage/kubernetes/v1/rbac"
 )
 
 // Client provides an interface to role bindings.
 type Client interface {
 	List(ns string, options ...meta.ListOptions) (*rbac.RoleBindingList, error)
-	Create(ns, name, role string, subject rbac.Subject) (*rbac.RoleBinding, error)
+	Create(objMeta meta.ObjectMeta, roleRef rbac.RoleRef, subjects ...rbac.Subject) (*rbac.RoleBinding, error)
+	Get(ns, name string, options ...meta.GetOptions) (_ *rbac.RoleBinding, exists bool, _ error)
+	Update(obj *rbac.RoleBinding) (*rbac.RoleBinding, error)
+	Delete(ns, name string) error
 }
 
 // DefaultClient implementation of Client operates on a real kubernetes API.
@@ -36,34 +40,82 @@ func NewDefaultClient(getter rbac_type.RoleBindingsGetter) *DefaultClient {
 	return &DefaultClient{RoleBindingsGetter: getter}
 }
 
-// Create binds the role to a single subject.
-func (c *DefaultClient) Create(ns, name, role string, subject rbac.Subject) (*rbac.RoleBinding, error) {
-	obj, err := c.RoleBindings(ns).Create(&rbac.RoleBinding{
-		ObjectMeta: meta.ObjectMeta{Namespace: ns, Name: name},
-		RoleRef:    rbac.RoleRef{Kind: cage_k8s.KindRole, Name: role},
-		Subjects:   []rbac.Subject{subject},
-	})
+// NewObject returns the binding which Create would add, e.g. to render it instead.
+func NewObject(objMeta meta.ObjectMeta, roleRef rbac.RoleRef, subjects ...rbac.Subject) *rbac.RoleBinding {
+	return &rbac.RoleBinding{
+		ObjectMeta: objMeta,
+		RoleRef:    roleRef,
+		Subjects:   subjects,
+	}
+}
+
+// Create binds the role to the subjects.
+//
+// The reference may select a Role in the same namespace or a ClusterRole, which grants
+// the latter's permissions only within the namespace.
+//
+// The metadata selects the binding namespace and name, and may include labels and annotations.
+//
+// It implements Client.
+func (c *DefaultClient) Create(objMeta meta.ObjectMeta, roleRef rbac.RoleRef, subjects ...rbac.Subject) (*rbac.RoleBinding, error) {
+	obj, err := c.RoleBindings(objMeta.Namespace).Create(NewObject(objMeta, roleRef, subjects...))
 	if err != nil {
 		// Allow caller to perform the same check and decide whether how to handleit.
 		if k8s_errors.IsAlreadyExists(err) {
 			return nil, err
 		}
 
-		nsId := subject.Namespace
-		if nsId == "" {
-			nsId = cage_k8s.EmptyNamespace
-		}
-
 		return nil, errors.Wrapf(
 			err,
-			"failed to bind role [%s] to subjects [%s] (kind: %s ns: %s) in namespace [%s]",
-			role, subject.Name, subject.Kind, nsId, ns,
+			"failed to bind role [%s] (kind: %s) to subjects %s in namespace [%s]",
+			roleRef.Name, roleRef.Kind, cage_k8s_rbac.SubjectsString(subjects), objMeta.Namespace,
 		)
 	}
 
 	return obj, nil
 }
 
+// Get returns the object if found, reports that the object does not exist, or returns an error.
+//
+// A single GetOptions value can be passed as the final argument to customize the query.
+//
+// It implements Client.
+func (c *DefaultClient) Get(ns, name string, options ...meta.GetOptions) (_ *rbac.RoleBinding, exists bool, _ error) {
+	obj, err := c.RoleBindings(ns).Get(name, cage_k8s.GetOptionsFromVariadic(options))
+	if err != nil {
+		if k8s_errors.IsNotFound(err) {
+			return nil, false, nil
+		}
+		return nil, false, errors.Wrapf(err, "failed to get role binding [%s] in namespace [%s]", name, ns)
+	}
+
+	return obj, true, nil
+}
+
+// Update replaces the object.
+//
+// It implements Client.
+func (c *DefaultClient) Update(obj *rbac.RoleBinding) (*rbac.RoleBinding, error) {
+	updated, err := c.RoleBindings(obj.Namespace).Update(obj)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to update role binding [%s] in namespace [%s]", obj.Name, obj.Namespace)
+	}
+
+	return updated, nil
+}
+
+// Delete removes the object.
+//
+// It implements Client.
+func (c *DefaultClient) Delete(ns, name string) error {
+	err := c.RoleBindings(ns).Delete(name, &meta.DeleteOptions{})
+	if err != nil {
+		return errors.Wrapf(err, "failed to delete role binding [%s] in namespace [%s]", name, ns)
+	}
+
+	return nil
+}
+
 // List returns the matching objects.
 //
 // A single ListOptions value can be passed as the final argument to customize the query.
@@ -19,6 +19,7 @@ import (
 	cage_k8s "github.com/codeactual/kubeauth/internal/cage/kubernetes/v1"
 	"github.com/codeactual/kubeauth/internal/cage/kubernetes/v1/rbac/role_binding"
 	mock_role_binding "github.com/codeactual/kubeauth/internal/cage/kubernetes/v1/rbac/role_binding/mock"
+	cage_k8s_testkit "github.com/codeactual/kubeauth/internal/cage/kubernetes/v1/testkit"
 	cage_require "github.com/codeactual/kubeauth/internal/cage/testkit/testify/require"
 )
 
@@ -53,7 +54,7 @@ func TestCreate(t *testing.T) {
 		mockInterface, wrapperClient := newClient(mockCtrl, Namespace)
 		mockInterface.EXPECT().Create(expectBinding).Return(expectBinding, nil)
 
-		actualBinding, err := wrapperClient.Create(Namespace, Binding, Role, expectSubject)
+		actualBinding, err := wrapperClient.Create(meta.ObjectMeta{Namespace: Namespace, Name: Binding}, rbac.RoleRef{Kind: cage_k8s.KindRole, Name: Role}, expectSubject)
 		require.NoError(t, err)
 		require.Exactly(t, expectBinding, actualBinding)
 	})
@@ -73,7 +74,7 @@ func TestCreate(t *testing.T) {
 		mockInterface, wrapperClient := newClient(mockCtrl, Namespace)
 		mockInterface.EXPECT().Create(expectBinding).Return(nil, expectErr)
 
-		actualBinding, actualErr := wrapperClient.Create(Namespace, Binding, Role, expectSubject)
+		actualBinding, actualErr := wrapperClient.Create(meta.ObjectMeta{Namespace: Namespace, Name: Binding}, rbac.RoleRef{Kind: cage_k8s.KindRole, Name: Role}, expectSubject)
 		cage_require.MatchRegexp(t, fmt.Sprintf("%v", actualErr), "failed to bind role.*expectErr")
 		require.Nil(t, actualBinding)
 	})
@@ -119,3 +120,170 @@ func TestList(t *testing.T) {
 		require.Nil(t, actualRole)
 	})
 }
+
+func TestCreateMultipleSubjects(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	expectSubjects := []rbac.Subject{
+		{Name: SubjectName, Kind: SubjectKind, Namespace: SubjectNamespace},
+		{Name: "some-user", Kind: cage_k8s.KindUser, APIGroup: rbac.GroupName},
+	}
+	expectBinding := &rbac.RoleBinding{
+		ObjectMeta: meta.ObjectMeta{Namespace: Namespace, Name: Binding},
+		RoleRef:    rbac.RoleRef{Kind: cage_k8s.KindRole, Name: Role},
+		Subjects:   expectSubjects,
+	}
+
+	mockInterface, wrapperClient := newClient(mockCtrl, Namespace)
+	mockInterface.EXPECT().Create(expectBinding).Return(expectBinding, nil)
+
+	actualBinding, err := wrapperClient.Create(meta.ObjectMeta{Namespace: Namespace, Name: Binding}, rbac.RoleRef{Kind: cage_k8s.KindRole, Name: Role}, expectSubjects...)
+	require.NoError(t, err)
+	require.Exactly(t, expectBinding, actualBinding)
+}
+
+func TestCreateClusterRoleRef(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	expectSubject := rbac.Subject{Name: SubjectName, Kind: SubjectKind, Namespace: SubjectNamespace}
+	expectRoleRef := rbac.RoleRef{Kind: cage_k8s.KindClusterRole, Name: Role}
+	expectBinding := &rbac.RoleBinding{
+		ObjectMeta: meta.ObjectMeta{Namespace: Namespace, Name: Binding},
+		RoleRef:    expectRoleRef,
+		Subjects:   []rbac.Subject{expectSubject},
+	}
+
+	mockInterface, wrapperClient := newClient(mockCtrl, Namespace)
+	mockInterface.EXPECT().Create(expectBinding).Return(expectBinding, nil)
+
+	actualBinding, err := wrapperClient.Create(meta.ObjectMeta{Namespace: Namespace, Name: Binding}, expectRoleRef, expectSubject)
+	require.NoError(t, err)
+	require.Exactly(t, expectBinding, actualBinding)
+}
+
+func TestCreateMeta(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	expectMeta := meta.ObjectMeta{Namespace: Namespace, Name: Binding, Labels: map[string]string{"some-label": "some-value"}}
+	expectSubject := rbac.Subject{Name: SubjectName, Kind: SubjectKind, Namespace: SubjectNamespace}
+	expectBinding := &rbac.RoleBinding{
+		ObjectMeta: expectMeta,
+		RoleRef:    rbac.RoleRef{Kind: cage_k8s.KindRole, Name: Role},
+		Subjects:   []rbac.Subject{expectSubject},
+	}
+
+	mockInterface, wrapperClient := newClient(mockCtrl, Namespace)
+	mockInterface.EXPECT().Create(expectBinding).Return(expectBinding, nil)
+
+	actualBinding, err := wrapperClient.Create(expectMeta, rbac.RoleRef{Kind: cage_k8s.KindRole, Name: Role}, expectSubject)
+	require.NoError(t, err)
+	require.Exactly(t, expectBinding, actualBinding)
+}
+
+func TestGet(t *testing.T) {
+	t.Run("found", func(t *testing.T) {
+		mockCtrl := gomock.NewController(t)
+		defer mockCtrl.Finish()
+
+		expectOptions := meta.GetOptions{}
+		expectBinding := &rbac.RoleBinding{ObjectMeta: meta.ObjectMeta{Namespace: Namespace, Name: Binding}}
+
+		mockInterface, wrapperClient := newClient(mockCtrl, Namespace)
+		mockInterface.EXPECT().Get(Binding, expectOptions).Return(expectBinding, nil)
+
+		actualBinding, exists, err := wrapperClient.Get(Namespace, Binding, expectOptions)
+		require.NoError(t, err)
+		require.True(t, exists)
+		require.Exactly(t, expectBinding, actualBinding)
+	})
+
+	t.Run("not found", func(t *testing.T) {
+		mockCtrl := gomock.NewController(t)
+		defer mockCtrl.Finish()
+
+		expectOptions := meta.GetOptions{}
+
+		mockInterface, wrapperClient := newClient(mockCtrl, Namespace)
+		mockInterface.EXPECT().Get(Binding, expectOptions).Return(nil, cage_k8s_testkit.NotFound())
+
+		actualBinding, exists, err := wrapperClient.Get(Namespace, Binding, expectOptions)
+		require.NoError(t, err)
+		require.False(t, exists)
+		require.Nil(t, actualBinding)
+	})
+
+	t.Run("error", func(t *testing.T) {
+		mockCtrl := gomock.NewController(t)
+		defer mockCtrl.Finish()
+
+		expectOptions := meta.GetOptions{}
+		expectErr := errors.New("expectErr")
+
+		mockInterface, wrapperClient := newClient(mockCtrl, Namespace)
+		mockInterface.EXPECT().Get(Binding, expectOptions).Return(nil, expectErr)
+
+		actualBinding, exists, actualErr := wrapperClient.Get(Namespace, Binding, expectOptions)
+		cage_require.MatchRegexp(t, fmt.Sprintf("%v", actualErr), "failed to get role binding.*expectErr")
+		require.False(t, exists)
+		require.Nil(t, actualBinding)
+	})
+}
+
+func TestUpdate(t *testing.T) {
+	t.Run("updated", func(t *testing.T) {
+		mockCtrl := gomock.NewController(t)
+		defer mockCtrl.Finish()
+
+		expectBinding := &rbac.RoleBinding{ObjectMeta: meta.ObjectMeta{Namespace: Namespace, Name: Binding}}
+
+		mockInterface, wrapperClient := newClient(mockCtrl, Namespace)
+		mockInterface.EXPECT().Update(expectBinding).Return(expectBinding, nil)
+
+		actualBinding, err := wrapperClient.Update(expectBinding)
+		require.NoError(t, err)
+		require.Exactly(t, expectBinding, actualBinding)
+	})
+
+	t.Run("error", func(t *testing.T) {
+		mockCtrl := gomock.NewController(t)
+		defer mockCtrl.Finish()
+
+		expectBinding := &rbac.RoleBinding{ObjectMeta: meta.ObjectMeta{Namespace: Namespace, Name: Binding}}
+		expectErr := errors.New("expectErr")
+
+		mockInterface, wrapperClient := newClient(mockCtrl, Namespace)
+		mockInterface.EXPECT().Update(expectBinding).Return(nil, expectErr)
+
+		actualBinding, actualErr := wrapperClient.Update(expectBinding)
+		cage_require.MatchRegexp(t, fmt.Sprintf("%v", actualErr), "failed to update role binding.*expectErr")
+		require.Nil(t, actualBinding)
+	})
+}
+
+func TestDelete(t *testing.T) {
+	t.Run("deleted", func(t *testing.T) {
+		mockCtrl := gomock.NewController(t)
+		defer mockCtrl.Finish()
+
+		mockInterface, wrapperClient := newClient(mockCtrl, Namespace)
+		mockInterface.EXPECT().Delete(Binding, &meta.DeleteOptions{}).Return(nil)
+
+		require.NoError(t, wrapperClient.Delete(Namespace, Binding))
+	})
+
+	t.Run("error", func(t *testing.T) {
+		mockCtrl := gomock.NewController(t)
+		defer mockCtrl.Finish()
+
+		expectErr := errors.New("expectErr")
+
+		mockInterface, wrapperClient := newClient(mockCtrl, Namespace)
+		mockInterface.EXPECT().Delete(Binding, &meta.DeleteOptions{}).Return(expectErr)
+
+		actualErr := wrapperClient.Delete(Namespace, Binding)
+		cage_require.MatchRegexp(t, fmt.Sprintf("%v", actualErr), "failed to delete role binding.*expectErr")
+	})
+}
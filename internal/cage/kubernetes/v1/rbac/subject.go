@@ -0,0 +1,119 @@
+// Copyright (C) 2020 The CodeActual Go Environment Authors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package rbac
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/pkg/errors"
+	rbac "k8s.io/api/rbac/v1"
+
+	cage_k8s "github.com/codeactual/kubeauth/internal/cage/kubernetes/v1"
+)
+
+// NewUserSubject returns a subject which identifies a user.
+func NewUserSubject(name string) rbac.Subject {
+	return rbac.Subject{Kind: cage_k8s.KindUser, APIGroup: rbac.GroupName, Name: name}
+}
+
+// NewGroupSubject returns a subject which identifies a group.
+func NewGroupSubject(name string) rbac.Subject {
+	return rbac.Subject{Kind: cage_k8s.KindGroup, APIGroup: rbac.GroupName, Name: name}
+}
+
+// NewServiceAccountSubject returns a subject which identifies a service account.
+func NewServiceAccountSubject(namespace, name string) rbac.Subject {
+	return rbac.Subject{Kind: cage_k8s.KindServiceAccount, Namespace: namespace, Name: name}
+}
+
+// ParseServiceAccountSubject parses a service account selector in kubectl's <namespace>:<name> format.
+func ParseServiceAccountSubject(s string) (rbac.Subject, error) {
+	parts := strings.Split(s, ":")
+	if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+		return rbac.Subject{}, errors.Errorf("selector [%s] does not use format <namespace>:<name>", s)
+	}
+	return NewServiceAccountSubject(parts[0], parts[1]), nil
+}
+
+// SubjectsEqual returns true if the subjects identify the same user, group, or service account.
+//
+// It accounts for optional fields which the API server defaults, e.g. the API group of
+// users and groups, and for the namespace not applying to users and groups.
+func SubjectsEqual(a, b rbac.Subject) bool {
+	if a.Kind != b.Kind || a.Name != b.Name {
+		return false
+	}
+
+	if a.Kind == cage_k8s.KindServiceAccount {
+		return a.Namespace == b.Namespace
+	}
+
+	apiGroupA, apiGroupB := a.APIGroup, b.APIGroup
+	if apiGroupA == "" {
+		apiGroupA = rbac.GroupName
+	}
+	if apiGroupB == "" {
+		apiGroupB = rbac.GroupName
+	}
+
+	return apiGroupA == apiGroupB
+}
+
+// WithBindingNamespace returns a copy of the subjects in which service accounts without a namespace
+// use the namespace of the role binding which contains them, as the API server does.
+func WithBindingNamespace(subjects []rbac.Subject, ns string) []rbac.Subject {
+	copied := make([]rbac.Subject, len(subjects))
+	for n, s := range subjects {
+		if s.Kind == cage_k8s.KindServiceAccount && s.Namespace == "" {
+			s.Namespace = ns
+		}
+		copied[n] = s
+	}
+	return copied
+}
+
+// MergeSubjects returns the existing subjects followed by the additional subjects they lack.
+//
+// It also returns the subset of additional subjects which were appended.
+func MergeSubjects(existing []rbac.Subject, additional ...rbac.Subject) (merged, added []rbac.Subject) {
+	merged = append([]rbac.Subject{}, existing...)
+
+	for _, a := range additional {
+		var found bool
+		for _, m := range merged {
+			if SubjectsEqual(a, m) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			merged = append(merged, a)
+			added = append(added, a)
+		}
+	}
+
+	return merged, added
+}
+
+// SubjectString returns a description of the subject for use in messages.
+func SubjectString(s rbac.Subject) string {
+	ns := s.Namespace
+	if ns == "" {
+		ns = cage_k8s.EmptyNamespace
+	}
+	return fmt.Sprintf("%s (kind: %s ns: %s)", s.Name, s.Kind, ns)
+}
+
+// SubjectsString returns a description of the subjects for use in messages.
+func SubjectsString(subjects []rbac.Subject) string {
+	strs := make([]string, len(subjects))
+	for n, s := range subjects {
+		strs[n] = SubjectString(s)
+	}
+	return "[" + strings.Join(strs, ", ") + "]"
+}
@@ -0,0 +1,79 @@
+// Copyright (C) 2020 The CodeActual Go Environment Authors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package rbac_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	rbac "k8s.io/api/rbac/v1"
+
+	cage_k8s "github.com/codeactual/kubeauth/internal/cage/kubernetes/v1"
+	cage_k8s_rbac "github.com/codeactual/kubeauth/internal/cage/kubernetes/v1/rbac"
+)
+
+func TestSubjectsEqual(t *testing.T) {
+	user := cage_k8s_rbac.NewUserSubject("some-user")
+	sa := cage_k8s_rbac.NewServiceAccountSubject("some-ns", "some-sa")
+
+	require.True(t, cage_k8s_rbac.SubjectsEqual(user, user))
+	require.True(t, cage_k8s_rbac.SubjectsEqual(user, rbac.Subject{Kind: cage_k8s.KindUser, Name: "some-user"}), "API group defaults")
+	require.True(t, cage_k8s_rbac.SubjectsEqual(user, rbac.Subject{Kind: cage_k8s.KindUser, Name: "some-user", APIGroup: rbac.GroupName, Namespace: "ignored"}))
+	require.False(t, cage_k8s_rbac.SubjectsEqual(user, cage_k8s_rbac.NewGroupSubject("some-user")))
+	require.False(t, cage_k8s_rbac.SubjectsEqual(user, cage_k8s_rbac.NewUserSubject("other-user")))
+
+	require.True(t, cage_k8s_rbac.SubjectsEqual(sa, sa))
+	require.False(t, cage_k8s_rbac.SubjectsEqual(sa, cage_k8s_rbac.NewServiceAccountSubject("other-ns", "some-sa")))
+}
+
+func TestMergeSubjects(t *testing.T) {
+	existing := []rbac.Subject{
+		{Kind: cage_k8s.KindUser, Name: "user-a"},
+		cage_k8s_rbac.NewServiceAccountSubject("some-ns", "some-sa"),
+	}
+
+	merged, added := cage_k8s_rbac.MergeSubjects(
+		existing,
+		cage_k8s_rbac.NewUserSubject("user-a"),
+		cage_k8s_rbac.NewGroupSubject("group-a"),
+		cage_k8s_rbac.NewGroupSubject("group-a"),
+	)
+
+	require.Exactly(t, []rbac.Subject{cage_k8s_rbac.NewGroupSubject("group-a")}, added)
+	require.Exactly(t, append(append([]rbac.Subject{}, existing...), added...), merged)
+	require.Len(t, existing, 2, "input not modified")
+}
+
+func TestWithBindingNamespace(t *testing.T) {
+	subjects := []rbac.Subject{
+		{Kind: cage_k8s.KindServiceAccount, Name: "sa-a"},
+		cage_k8s_rbac.NewServiceAccountSubject("other-ns", "sa-b"),
+		cage_k8s_rbac.NewUserSubject("user-a"),
+	}
+
+	require.Exactly(
+		t,
+		[]rbac.Subject{
+			cage_k8s_rbac.NewServiceAccountSubject("some-ns", "sa-a"),
+			cage_k8s_rbac.NewServiceAccountSubject("other-ns", "sa-b"),
+			cage_k8s_rbac.NewUserSubject("user-a"),
+		},
+		cage_k8s_rbac.WithBindingNamespace(subjects, "some-ns"),
+	)
+	require.Empty(t, subjects[0].Namespace, "input not modified")
+}
+
+func TestParseServiceAccountSubject(t *testing.T) {
+	subject, err := cage_k8s_rbac.ParseServiceAccountSubject("some-ns:some-sa")
+	require.NoError(t, err)
+	require.Exactly(t, cage_k8s_rbac.NewServiceAccountSubject("some-ns", "some-sa"), subject)
+
+	for _, invalid := range []string{"some-sa", "some-ns:", ":some-sa", "a:b:c"} {
+		_, err = cage_k8s_rbac.ParseServiceAccountSubject(invalid)
+		require.Error(t, err, invalid)
+	}
+}
@@ -40,19 +40,33 @@ func (m *MockClient) EXPECT() *MockClientMockRecorder {
 	return m.recorder
 }
 
-// CreateBasic mocks base method
-func (m *MockClient) CreateBasic(ns, sa string) (*v1.ServiceAccount, error) {
+// Create mocks base method
+func (m *MockClient) Create(obj *v1.ServiceAccount) (*v1.ServiceAccount, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "CreateBasic", ns, sa)
+	ret := m.ctrl.Call(m, "Create", obj)
 	ret0, _ := ret[0].(*v1.ServiceAccount)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
-// CreateBasic indicates an expected call of CreateBasic
-func (mr *MockClientMockRecorder) CreateBasic(ns, sa interface{}) *gomock.Call {
+// Create indicates an expected call of Create
+func (mr *MockClientMockRecorder) Create(obj interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateBasic", reflect.TypeOf((*MockClient)(nil).CreateBasic), ns, sa)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Create", reflect.TypeOf((*MockClient)(nil).Create), obj)
+}
+
+// Delete mocks base method
+func (m *MockClient) Delete(ns, sa string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Delete", ns, sa)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Delete indicates an expected call of Delete
+func (mr *MockClientMockRecorder) Delete(ns, sa interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Delete", reflect.TypeOf((*MockClient)(nil).Delete), ns, sa)
 }
 
 // Get mocks base method
@@ -95,3 +109,18 @@ func (mr *MockClientMockRecorder) List(ns interface{}, options ...interface{}) *
 	varargs := append([]interface{}{ns}, options...)
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "List", reflect.TypeOf((*MockClient)(nil).List), varargs...)
 }
+
+// Update mocks base method
+func (m *MockClient) Update(obj *v1.ServiceAccount) (*v1.ServiceAccount, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Update", obj)
+	ret0, _ := ret[0].(*v1.ServiceAccount)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Update indicates an expected call of Update
+func (mr *MockClientMockRecorder) Update(obj interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Update", reflect.TypeOf((*MockClient)(nil).Update), obj)
+}
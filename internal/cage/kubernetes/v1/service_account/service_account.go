@@ -22,9 +22,11 @@ import (
 
 // Client provides an interface to service accounts.
 type Client interface {
-	CreateBasic(ns, sa string) (*core.ServiceAccount, error)
+	Create(obj *core.ServiceAccount) (*core.ServiceAccount, error)
+	Delete(ns, sa string) error
 	Get(ns, sa string, options ...meta.GetOptions) (_ *core.ServiceAccount, exists bool, _ error)
 	List(ns string, options ...meta.ListOptions) (*core.ServiceAccountList, error)
+	Update(obj *core.ServiceAccount) (*core.ServiceAccount, error)
 }
 
 // DefaultClient implementation of Client operates on a real kubernetes API.
@@ -37,16 +39,26 @@ func NewDefaultClient(getter core_type.ServiceAccountsGetter) *DefaultClient {
 	return &DefaultClient{ServiceAccountsGetter: getter}
 }
 
-// CreateBasic adds a service account based on only its namespace and account name.
-func (c *DefaultClient) CreateBasic(ns, sa string) (*core.ServiceAccount, error) {
-	created, err := c.ServiceAccounts(ns).Create(&core.ServiceAccount{ObjectMeta: meta.ObjectMeta{Name: sa}})
+// Create adds the service account to the namespace selected by its metadata.
+func (c *DefaultClient) Create(obj *core.ServiceAccount) (*core.ServiceAccount, error) {
+	created, err := c.ServiceAccounts(obj.Namespace).Create(obj)
 	if err != nil {
-		return nil, errors.Wrapf(err, "failed to create service account [%s] in namespace [%s]", sa, ns)
+		return nil, errors.Wrapf(err, "failed to create service account [%s] in namespace [%s]", obj.Name, obj.Namespace)
 	}
 
 	return created, nil
 }
 
+// Delete removes the service account.
+func (c *DefaultClient) Delete(ns, sa string) error {
+	err := c.ServiceAccounts(ns).Delete(sa, &meta.DeleteOptions{})
+	if err != nil {
+		return errors.Wrapf(err, "failed to delete service account [%s] in namespace [%s]", sa, ns)
+	}
+
+	return nil
+}
+
 // Get returns the object if found, reports that the object does not exist, or returns an error.
 //
 // A single GetOptions value can be passed as the final argument to customize the query.
@@ -79,4 +91,65 @@ func (c *DefaultClient) List(ns string, options ...meta.ListOptions) (*core.Serv
 	return list, nil
 }
 
+// Update replaces the service account in the namespace selected by its metadata.
+func (c *DefaultClient) Update(obj *core.ServiceAccount) (*core.ServiceAccount, error) {
+	updated, err := c.ServiceAccounts(obj.Namespace).Update(obj)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to update service account [%s] in namespace [%s]", obj.Name, obj.Namespace)
+	}
+
+	return updated, nil
+}
+
+// Merge applies the customizable fields of src to dst and reports whether dst changed.
+//
+// Labels and annotations from src replace those with the same keys in dst, image pull secrets
+// missing from dst are appended, and a non-nil AutomountServiceAccountToken replaces the dst value.
+// Other fields, e.g. the token secrets managed by the API server, are ignored.
+func Merge(dst, src *core.ServiceAccount) (changed bool) {
+	for k, v := range src.Labels {
+		if cur, ok := dst.Labels[k]; !ok || cur != v {
+			if dst.Labels == nil {
+				dst.Labels = map[string]string{}
+			}
+			dst.Labels[k] = v
+			changed = true
+		}
+	}
+
+	for k, v := range src.Annotations {
+		if cur, ok := dst.Annotations[k]; !ok || cur != v {
+			if dst.Annotations == nil {
+				dst.Annotations = map[string]string{}
+			}
+			dst.Annotations[k] = v
+			changed = true
+		}
+	}
+
+	for _, srcSecret := range src.ImagePullSecrets {
+		var found bool
+		for _, dstSecret := range dst.ImagePullSecrets {
+			if dstSecret.Name == srcSecret.Name {
+				found = true
+				break
+			}
+		}
+		if !found {
+			dst.ImagePullSecrets = append(dst.ImagePullSecrets, srcSecret)
+			changed = true
+		}
+	}
+
+	if src.AutomountServiceAccountToken != nil {
+		if dst.AutomountServiceAccountToken == nil || *dst.AutomountServiceAccountToken != *src.AutomountServiceAccountToken {
+			automount := *src.AutomountServiceAccountToken
+			dst.AutomountServiceAccountToken = &automount
+			changed = true
+		}
+	}
+
+	return changed
+}
+
 var _ Client = (*DefaultClient)(nil)
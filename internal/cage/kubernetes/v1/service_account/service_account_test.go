@@ -34,17 +34,21 @@ func newClient(mockCtrl *gomock.Controller, namespace string) (*mock_sa.MockServ
 	return mockInterface, service_account.NewDefaultClient(mockGetter)
 }
 
-func TestCreateBasic(t *testing.T) {
+func TestCreate(t *testing.T) {
 	t.Run("created", func(t *testing.T) {
 		mockCtrl := gomock.NewController(t)
 		defer mockCtrl.Finish()
 
-		expectSa := &core.ServiceAccount{ObjectMeta: meta.ObjectMeta{Name: ServiceAccount}}
+		expectSa := &core.ServiceAccount{ObjectMeta: meta.ObjectMeta{
+			Namespace: Namespace,
+			Name:      ServiceAccount,
+			Labels:    map[string]string{"some-label": "some-value"},
+		}}
 
 		mockInterface, wrapperClient := newClient(mockCtrl, Namespace)
 		mockInterface.EXPECT().Create(expectSa).Return(expectSa, nil)
 
-		actualSa, err := wrapperClient.CreateBasic(Namespace, ServiceAccount)
+		actualSa, err := wrapperClient.Create(expectSa)
 		require.NoError(t, err)
 		require.Exactly(t, expectSa, actualSa)
 	})
@@ -53,18 +57,43 @@ func TestCreateBasic(t *testing.T) {
 		mockCtrl := gomock.NewController(t)
 		defer mockCtrl.Finish()
 
-		expectSa := &core.ServiceAccount{ObjectMeta: meta.ObjectMeta{Name: ServiceAccount}}
+		expectSa := &core.ServiceAccount{ObjectMeta: meta.ObjectMeta{Namespace: Namespace, Name: ServiceAccount}}
 		expectErr := errors.New("expectErr")
 
 		mockInterface, wrapperClient := newClient(mockCtrl, Namespace)
 		mockInterface.EXPECT().Create(expectSa).Return(nil, expectErr)
 
-		actualSa, actualErr := wrapperClient.CreateBasic(Namespace, ServiceAccount)
+		actualSa, actualErr := wrapperClient.Create(expectSa)
 		cage_require.MatchRegexp(t, fmt.Sprintf("%v", actualErr), "failed to create service account.*expectErr")
 		require.Nil(t, actualSa)
 	})
 }
 
+func TestDelete(t *testing.T) {
+	t.Run("deleted", func(t *testing.T) {
+		mockCtrl := gomock.NewController(t)
+		defer mockCtrl.Finish()
+
+		mockInterface, wrapperClient := newClient(mockCtrl, Namespace)
+		mockInterface.EXPECT().Delete(ServiceAccount, &meta.DeleteOptions{}).Return(nil)
+
+		require.NoError(t, wrapperClient.Delete(Namespace, ServiceAccount))
+	})
+
+	t.Run("error", func(t *testing.T) {
+		mockCtrl := gomock.NewController(t)
+		defer mockCtrl.Finish()
+
+		expectErr := errors.New("expectErr")
+
+		mockInterface, wrapperClient := newClient(mockCtrl, Namespace)
+		mockInterface.EXPECT().Delete(ServiceAccount, &meta.DeleteOptions{}).Return(expectErr)
+
+		actualErr := wrapperClient.Delete(Namespace, ServiceAccount)
+		cage_require.MatchRegexp(t, fmt.Sprintf("%v", actualErr), "failed to delete service account.*expectErr")
+	})
+}
+
 func TestGet(t *testing.T) {
 	t.Run("found", func(t *testing.T) {
 		mockCtrl := gomock.NewController(t)
@@ -149,3 +178,89 @@ func TestList(t *testing.T) {
 		require.Nil(t, actualList)
 	})
 }
+
+func TestUpdate(t *testing.T) {
+	t.Run("updated", func(t *testing.T) {
+		mockCtrl := gomock.NewController(t)
+		defer mockCtrl.Finish()
+
+		expectSa := &core.ServiceAccount{ObjectMeta: meta.ObjectMeta{Namespace: Namespace, Name: ServiceAccount}}
+
+		mockInterface, wrapperClient := newClient(mockCtrl, Namespace)
+		mockInterface.EXPECT().Update(expectSa).Return(expectSa, nil)
+
+		actualSa, err := wrapperClient.Update(expectSa)
+		require.NoError(t, err)
+		require.Exactly(t, expectSa, actualSa)
+	})
+
+	t.Run("error", func(t *testing.T) {
+		mockCtrl := gomock.NewController(t)
+		defer mockCtrl.Finish()
+
+		expectSa := &core.ServiceAccount{ObjectMeta: meta.ObjectMeta{Namespace: Namespace, Name: ServiceAccount}}
+		expectErr := errors.New("expectErr")
+
+		mockInterface, wrapperClient := newClient(mockCtrl, Namespace)
+		mockInterface.EXPECT().Update(expectSa).Return(nil, expectErr)
+
+		actualSa, actualErr := wrapperClient.Update(expectSa)
+		cage_require.MatchRegexp(t, fmt.Sprintf("%v", actualErr), "failed to update service account.*expectErr")
+		require.Nil(t, actualSa)
+	})
+}
+
+func TestMerge(t *testing.T) {
+	automountOff, automountOn := false, true
+
+	t.Run("changed", func(t *testing.T) {
+		dst := &core.ServiceAccount{
+			ObjectMeta: meta.ObjectMeta{
+				Name:   ServiceAccount,
+				Labels: map[string]string{"label-a": "value-a", "label-b": "value-b"},
+			},
+			ImagePullSecrets:             []core.LocalObjectReference{{Name: "secret-a"}},
+			AutomountServiceAccountToken: &automountOn,
+		}
+		src := &core.ServiceAccount{
+			ObjectMeta: meta.ObjectMeta{
+				Labels:      map[string]string{"label-b": "value-c"},
+				Annotations: map[string]string{"annotation-a": "value-a"},
+			},
+			ImagePullSecrets:             []core.LocalObjectReference{{Name: "secret-a"}, {Name: "secret-b"}},
+			AutomountServiceAccountToken: &automountOff,
+		}
+
+		require.True(t, service_account.Merge(dst, src))
+		require.Exactly(
+			t,
+			&core.ServiceAccount{
+				ObjectMeta: meta.ObjectMeta{
+					Name:        ServiceAccount,
+					Labels:      map[string]string{"label-a": "value-a", "label-b": "value-c"},
+					Annotations: map[string]string{"annotation-a": "value-a"},
+				},
+				ImagePullSecrets:             []core.LocalObjectReference{{Name: "secret-a"}, {Name: "secret-b"}},
+				AutomountServiceAccountToken: &automountOff,
+			},
+			dst,
+		)
+	})
+
+	t.Run("unchanged", func(t *testing.T) {
+		dst := &core.ServiceAccount{
+			ObjectMeta:                   meta.ObjectMeta{Name: ServiceAccount, Labels: map[string]string{"label-a": "value-a"}},
+			ImagePullSecrets:             []core.LocalObjectReference{{Name: "secret-a"}},
+			AutomountServiceAccountToken: &automountOff,
+		}
+		src := &core.ServiceAccount{
+			ObjectMeta:                   meta.ObjectMeta{Labels: map[string]string{"label-a": "value-a"}},
+			ImagePullSecrets:             []core.LocalObjectReference{{Name: "secret-a"}},
+			AutomountServiceAccountToken: &automountOff,
+		}
+		expectDst := dst.DeepCopy()
+
+		require.False(t, service_account.Merge(dst, src))
+		require.Exactly(t, expectDst, dst)
+	})
+}
@@ -15,10 +15,18 @@ const (
 
 	KindClusterRole        = "ClusterRole"
 	KindClusterRoleBinding = "ClusterRoleBinding"
+	KindCronJob            = "CronJob"
+	KindDaemonSet          = "DaemonSet"
+	KindDeployment         = "Deployment"
 	KindGroup              = "Group"
+	KindJob                = "Job"
+	KindNamespace          = "Namespace"
+	KindNode               = "Node"
+	KindPod                = "Pod"
 	KindRole               = "Role"
 	KindRoleBinding        = "RoleBinding"
 	KindServiceAccount     = "ServiceAccount"
+	KindStatefulSet        = "StatefulSet"
 	KindUser               = "User"
 )
 
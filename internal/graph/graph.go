@@ -0,0 +1,269 @@
+// Copyright (C) 2020 The kubeauth Authors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at https://mozilla.org/MPL/2.0/.
+
+// Package graph renders subjects, the bindings which refer to them, the bound roles, and the
+// roles' rules as a directed graph in Graphviz DOT or Mermaid format.
+package graph
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+	rbac "k8s.io/api/rbac/v1"
+
+	cage_k8s "github.com/codeactual/kubeauth/internal/cage/kubernetes/v1"
+)
+
+const (
+	// KindRule identifies nodes which describe a role's rule.
+	KindRule = "Rule"
+
+	// KindSystem identifies the node which replaces all system identities if they are collapsed.
+	KindSystem = "System"
+
+	// SystemPrefix begins the names of users and groups which the API server defines.
+	SystemPrefix = "system:"
+
+	// SystemNamespace holds the service accounts of the control plane's components.
+	SystemNamespace = "kube-system"
+)
+
+// Object identifies a binding or role.
+type Object struct {
+	Kind      string
+	Namespace string
+	Name      string
+
+	// Missing is true if the object does not exist, e.g. a binding's role was deleted.
+	Missing bool
+}
+
+// Options customizes a Graph.
+type Options struct {
+	// CollapseSystem replaces all system identities, e.g. system:masters, with a single node.
+	CollapseSystem bool
+}
+
+// Node is a graph vertex.
+type Node struct {
+	// Key uniquely identifies the node within the graph.
+	Key   string
+	Kind  string
+	Label string
+}
+
+// Edge connects two nodes, identified by their keys.
+type Edge struct {
+	From string
+	To   string
+}
+
+// Graph holds the nodes and edges added so far.
+type Graph struct {
+	opts  Options
+	nodes map[string]Node
+	edges map[Edge]bool
+}
+
+// New returns an empty graph.
+func New(opts Options) *Graph {
+	return &Graph{opts: opts, nodes: map[string]Node{}, edges: map[Edge]bool{}}
+}
+
+// IsSystem returns true if the subject is defined by the API server or one of the control plane's
+// service accounts.
+func IsSystem(s rbac.Subject) bool {
+	if s.Kind == cage_k8s.KindServiceAccount {
+		return s.Namespace == SystemNamespace
+	}
+	return strings.HasPrefix(s.Name, SystemPrefix)
+}
+
+// Add adds the path from the subject to the binding, from the binding to the role, and from the
+// role to each of its rules.
+func (g *Graph) Add(subject rbac.Subject, binding, role Object, rules []rbac.PolicyRule) {
+	var subjectKey string
+	if g.opts.CollapseSystem && IsSystem(subject) {
+		subjectKey = g.node(KindSystem, KindSystem, "system identities")
+	} else {
+		subjectKey = g.node(objectKey(subject.Kind, subject.Namespace, subject.Name), subject.Kind, label(subject.Kind, subject.Namespace, subject.Name, false))
+	}
+
+	bindingKey := g.node(objectKey(binding.Kind, binding.Namespace, binding.Name), binding.Kind, label(binding.Kind, binding.Namespace, binding.Name, binding.Missing))
+	roleKey := g.node(objectKey(role.Kind, role.Namespace, role.Name), role.Kind, label(role.Kind, role.Namespace, role.Name, role.Missing))
+
+	g.edges[Edge{From: subjectKey, To: bindingKey}] = true
+	g.edges[Edge{From: bindingKey, To: roleKey}] = true
+
+	for n, r := range rules {
+		ruleKey := g.node(fmt.Sprintf("%s/%s/%d", roleKey, KindRule, n), KindRule, RuleString(r))
+		g.edges[Edge{From: roleKey, To: ruleKey}] = true
+	}
+}
+
+// Nodes returns the nodes sorted by key.
+func (g *Graph) Nodes() []Node {
+	nodes := make([]Node, 0, len(g.nodes))
+	for _, n := range g.nodes {
+		nodes = append(nodes, n)
+	}
+	sort.Slice(nodes, func(i, j int) bool {
+		return nodes[i].Key < nodes[j].Key
+	})
+	return nodes
+}
+
+// Edges returns the edges sorted by their nodes' keys.
+func (g *Graph) Edges() []Edge {
+	edges := make([]Edge, 0, len(g.edges))
+	for e := range g.edges {
+		edges = append(edges, e)
+	}
+	sort.Slice(edges, func(i, j int) bool {
+		if edges[i].From != edges[j].From {
+			return edges[i].From < edges[j].From
+		}
+		return edges[i].To < edges[j].To
+	})
+	return edges
+}
+
+// RuleString returns the rule in a compact format for use in node labels, e.g. "get,list pods,services".
+func RuleString(r rbac.PolicyRule) string {
+	var targets []string
+	for _, resource := range r.Resources {
+		if len(r.ResourceNames) == 0 {
+			targets = append(targets, resource)
+			continue
+		}
+		for _, name := range r.ResourceNames {
+			targets = append(targets, resource+"/"+name)
+		}
+	}
+	targets = append(targets, r.NonResourceURLs...)
+
+	s := strings.Join(r.Verbs, ",") + " " + strings.Join(targets, ",")
+
+	var groups []string
+	for _, group := range r.APIGroups {
+		if group != "" {
+			groups = append(groups, group)
+		}
+	}
+	if len(groups) > 0 {
+		s += " (" + strings.Join(groups, ",") + ")"
+	}
+
+	return s
+}
+
+// WriteDOT writes the graph in Graphviz DOT format.
+func WriteDOT(w io.Writer, g *Graph) error {
+	ids := g.ids()
+
+	var b strings.Builder
+	b.WriteString("digraph rbac {\n")
+	b.WriteString("  rankdir=LR;\n")
+	for _, n := range g.Nodes() {
+		fmt.Fprintf(&b, "  %s [label=%s shape=%s];\n", ids[n.Key], dotQuote(n.Label), dotShape(n.Kind))
+	}
+	for _, e := range g.Edges() {
+		fmt.Fprintf(&b, "  %s -> %s;\n", ids[e.From], ids[e.To])
+	}
+	b.WriteString("}\n")
+
+	if _, err := io.WriteString(w, b.String()); err != nil {
+		return errors.Wrap(err, "failed to write graph")
+	}
+	return nil
+}
+
+// WriteMermaid writes the graph in Mermaid flowchart format.
+func WriteMermaid(w io.Writer, g *Graph) error {
+	ids := g.ids()
+
+	var b strings.Builder
+	b.WriteString("flowchart LR\n")
+	for _, n := range g.Nodes() {
+		start, end := mermaidShape(n.Kind)
+		fmt.Fprintf(&b, "  %s%s\"%s\"%s\n", ids[n.Key], start, strings.Replace(n.Label, `"`, "#quot;", -1), end)
+	}
+	for _, e := range g.Edges() {
+		fmt.Fprintf(&b, "  %s --> %s\n", ids[e.From], ids[e.To])
+	}
+
+	if _, err := io.WriteString(w, b.String()); err != nil {
+		return errors.Wrap(err, "failed to write graph")
+	}
+	return nil
+}
+
+// node adds the node if needed and returns its key.
+func (g *Graph) node(key, kind, label string) string {
+	if _, ok := g.nodes[key]; !ok {
+		g.nodes[key] = Node{Key: key, Kind: kind, Label: label}
+	}
+	return key
+}
+
+// ids returns identifiers, which are valid in both formats, for each node key.
+//
+// They are assigned in key order so that the output is stable.
+func (g *Graph) ids() map[string]string {
+	ids := map[string]string{}
+	for n, node := range g.Nodes() {
+		ids[node.Key] = fmt.Sprintf("n%d", n)
+	}
+	return ids
+}
+
+func objectKey(kind, namespace, name string) string {
+	return kind + "/" + namespace + "/" + name
+}
+
+func label(kind, namespace, name string, missing bool) string {
+	s := kind + " " + name
+	if namespace != "" {
+		s = kind + " " + namespace + "/" + name
+	}
+	if missing {
+		s += " (not found)"
+	}
+	return s
+}
+
+func dotQuote(s string) string {
+	return `"` + strings.Replace(strings.Replace(s, `\`, `\\`, -1), `"`, `\"`, -1) + `"`
+}
+
+func dotShape(kind string) string {
+	switch kind {
+	case cage_k8s.KindRoleBinding, cage_k8s.KindClusterRoleBinding:
+		return "box"
+	case cage_k8s.KindRole, cage_k8s.KindClusterRole:
+		return "hexagon"
+	case KindRule:
+		return "note"
+	default:
+		return "ellipse"
+	}
+}
+
+func mermaidShape(kind string) (start, end string) {
+	switch kind {
+	case cage_k8s.KindRoleBinding, cage_k8s.KindClusterRoleBinding:
+		return "[", "]"
+	case cage_k8s.KindRole, cage_k8s.KindClusterRole:
+		return "{{", "}}"
+	case KindRule:
+		return ">", "]"
+	default:
+		return "([", "])"
+	}
+}
@@ -0,0 +1,107 @@
+// Copyright (C) 2020 The kubeauth Authors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package graph_test
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	rbac "k8s.io/api/rbac/v1"
+
+	cage_k8s "github.com/codeactual/kubeauth/internal/cage/kubernetes/v1"
+	"github.com/codeactual/kubeauth/internal/graph"
+)
+
+var (
+	podsRule    = rbac.PolicyRule{Verbs: []string{"get", "list"}, APIGroups: []string{""}, Resources: []string{"pods"}}
+	clusterRole = graph.Object{Kind: cage_k8s.KindClusterRole, Name: "view"}
+	binding     = graph.Object{Kind: cage_k8s.KindRoleBinding, Namespace: "dev", Name: "viewers"}
+)
+
+// newGraph returns a graph in which a user and a system group share a binding.
+func newGraph(opts graph.Options) *graph.Graph {
+	g := graph.New(opts)
+	g.Add(rbac.Subject{Kind: cage_k8s.KindUser, Name: "tester"}, binding, clusterRole, []rbac.PolicyRule{podsRule})
+	g.Add(rbac.Subject{Kind: cage_k8s.KindGroup, Name: "system:masters"}, binding, clusterRole, []rbac.PolicyRule{podsRule})
+	g.Add(
+		rbac.Subject{Kind: cage_k8s.KindServiceAccount, Namespace: "kube-system", Name: "coredns"},
+		graph.Object{Kind: cage_k8s.KindClusterRoleBinding, Name: "coredns"},
+		graph.Object{Kind: cage_k8s.KindClusterRole, Name: "system:coredns", Missing: true},
+		nil,
+	)
+	return g
+}
+
+func TestWriteDOT(t *testing.T) {
+	var buf bytes.Buffer
+	require.NoError(t, graph.WriteDOT(&buf, newGraph(graph.Options{})))
+	require.Exactly(
+		t,
+		`digraph rbac {
+  rankdir=LR;
+  n0 [label="ClusterRole system:coredns (not found)" shape=hexagon];
+  n1 [label="ClusterRole view" shape=hexagon];
+  n2 [label="get,list pods" shape=note];
+  n3 [label="ClusterRoleBinding coredns" shape=box];
+  n4 [label="Group system:masters" shape=ellipse];
+  n5 [label="RoleBinding dev/viewers" shape=box];
+  n6 [label="ServiceAccount kube-system/coredns" shape=ellipse];
+  n7 [label="User tester" shape=ellipse];
+  n1 -> n2;
+  n3 -> n0;
+  n4 -> n5;
+  n5 -> n1;
+  n6 -> n3;
+  n7 -> n5;
+}
+`,
+		buf.String(),
+	)
+}
+
+func TestWriteMermaid(t *testing.T) {
+	var buf bytes.Buffer
+	require.NoError(t, graph.WriteMermaid(&buf, newGraph(graph.Options{CollapseSystem: true})))
+	require.Exactly(
+		t,
+		`flowchart LR
+  n0{{"ClusterRole system:coredns (not found)"}}
+  n1{{"ClusterRole view"}}
+  n2>"get,list pods"]
+  n3["ClusterRoleBinding coredns"]
+  n4["RoleBinding dev/viewers"]
+  n5(["system identities"])
+  n6(["User tester"])
+  n1 --> n2
+  n3 --> n0
+  n4 --> n1
+  n5 --> n3
+  n5 --> n4
+  n6 --> n4
+`,
+		buf.String(),
+	)
+}
+
+func TestIsSystem(t *testing.T) {
+	require.True(t, graph.IsSystem(rbac.Subject{Kind: cage_k8s.KindUser, Name: "system:kube-scheduler"}))
+	require.True(t, graph.IsSystem(rbac.Subject{Kind: cage_k8s.KindGroup, Name: "system:authenticated"}))
+	require.True(t, graph.IsSystem(rbac.Subject{Kind: cage_k8s.KindServiceAccount, Namespace: "kube-system", Name: "coredns"}))
+	require.False(t, graph.IsSystem(rbac.Subject{Kind: cage_k8s.KindUser, Name: "tester"}))
+	require.False(t, graph.IsSystem(rbac.Subject{Kind: cage_k8s.KindServiceAccount, Namespace: "dev", Name: "system:ci"}))
+}
+
+func TestRuleString(t *testing.T) {
+	require.Exactly(t, "get,list pods", graph.RuleString(podsRule))
+	require.Exactly(
+		t,
+		"get deployments/web,deployments/api (apps)",
+		graph.RuleString(rbac.PolicyRule{Verbs: []string{"get"}, APIGroups: []string{"apps"}, Resources: []string{"deployments"}, ResourceNames: []string{"web", "api"}}),
+	)
+	require.Exactly(t, "get /metrics", graph.RuleString(rbac.PolicyRule{Verbs: []string{"get"}, NonResourceURLs: []string{"/metrics"}}))
+}
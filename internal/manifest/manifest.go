@@ -0,0 +1,144 @@
+// Copyright (C) 2020 The kubeauth Authors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at https://mozilla.org/MPL/2.0/.
+
+// Package manifest renders API objects as YAML manifests, e.g. for GitOps workflows which apply
+// them instead of kubeauth.
+package manifest
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/pkg/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/yaml"
+)
+
+const (
+	// Stdout is the destination which selects a single YAML stream instead of a directory.
+	Stdout = "-"
+
+	// KustomizationFilename is the name of the file, written to a destination directory,
+	// which lists the other files as kustomize resources.
+	KustomizationFilename = "kustomization.yaml"
+)
+
+// Encode returns the YAML document of the object.
+//
+// The object's apiVersion and kind are derived from its type, so they need not be set.
+func Encode(obj runtime.Object) ([]byte, error) {
+	gvks, _, err := scheme.Scheme.ObjectKinds(obj)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to find kind of object type [%T]", obj)
+	}
+
+	obj = obj.DeepCopyObject()
+	obj.GetObjectKind().SetGroupVersionKind(gvks[0])
+
+	doc, err := yaml.Marshal(obj)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to encode %s", gvks[0].Kind)
+	}
+
+	return doc, nil
+}
+
+// Filename returns the name of the file which holds the object in a destination directory.
+//
+// It has the format <kind>[-<namespace>]-<name>.yaml, using the lowercase kind.
+func Filename(obj runtime.Object) (string, error) {
+	gvks, _, err := scheme.Scheme.ObjectKinds(obj)
+	if err != nil {
+		return "", errors.Wrapf(err, "failed to find kind of object type [%T]", obj)
+	}
+
+	accessor, err := meta.Accessor(obj)
+	if err != nil {
+		return "", errors.Wrapf(err, "failed to access metadata of object type [%T]", obj)
+	}
+
+	parts := []string{strings.ToLower(gvks[0].Kind)}
+	if accessor.GetNamespace() != "" {
+		parts = append(parts, accessor.GetNamespace())
+	}
+	parts = append(parts, accessor.GetName())
+
+	return strings.Join(parts, "-") + ".yaml", nil
+}
+
+// Write renders the objects in the input order.
+//
+// If dest is Stdout, they are written to out as a single stream of YAML documents. Otherwise dest
+// selects a directory, created if needed, which receives one file per object and a kustomization
+// file which lists them.
+//
+// It returns the paths of the written files, or Stdout.
+func Write(dest string, out io.Writer, objs ...runtime.Object) (written []string, _ error) {
+	if dest == Stdout {
+		for n, obj := range objs {
+			doc, err := Encode(obj)
+			if err != nil {
+				return nil, errors.WithStack(err)
+			}
+
+			if n > 0 {
+				if _, err = fmt.Fprintln(out, "---"); err != nil {
+					return nil, errors.Wrap(err, "failed to write manifest")
+				}
+			}
+			if _, err = out.Write(doc); err != nil {
+				return nil, errors.Wrap(err, "failed to write manifest")
+			}
+		}
+
+		return []string{Stdout}, nil
+	}
+
+	if err := os.MkdirAll(dest, 0755); err != nil {
+		return nil, errors.Wrapf(err, "failed to create manifest dir [%s]", dest)
+	}
+
+	var resources []string
+	for _, obj := range objs {
+		doc, err := Encode(obj)
+		if err != nil {
+			return nil, errors.WithStack(err)
+		}
+
+		name, err := Filename(obj)
+		if err != nil {
+			return nil, errors.WithStack(err)
+		}
+
+		path := filepath.Join(dest, name)
+		if err = ioutil.WriteFile(path, doc, 0644); err != nil {
+			return nil, errors.Wrapf(err, "failed to write manifest [%s]", path)
+		}
+
+		resources = append(resources, name)
+		written = append(written, path)
+	}
+
+	var kustomization bytes.Buffer
+	kustomization.WriteString("apiVersion: kustomize.config.k8s.io/v1beta1\nkind: Kustomization\nresources:\n")
+	for _, r := range resources {
+		kustomization.WriteString("- " + r + "\n")
+	}
+
+	path := filepath.Join(dest, KustomizationFilename)
+	if err := ioutil.WriteFile(path, kustomization.Bytes(), 0644); err != nil {
+		return nil, errors.Wrapf(err, "failed to write kustomization [%s]", path)
+	}
+
+	return append(written, path), nil
+}
@@ -0,0 +1,98 @@
+// Copyright (C) 2020 The kubeauth Authors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package manifest_test
+
+import (
+	"bytes"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	core "k8s.io/api/core/v1"
+	rbac "k8s.io/api/rbac/v1"
+	meta "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	testkit_file "github.com/codeactual/kubeauth/internal/cage/testkit/os/file"
+	"github.com/codeactual/kubeauth/internal/manifest"
+)
+
+func newObjects() (*core.ServiceAccount, *rbac.ClusterRoleBinding) {
+	sa := &core.ServiceAccount{ObjectMeta: meta.ObjectMeta{Namespace: "ns-a", Name: "sa-a"}}
+	binding := &rbac.ClusterRoleBinding{
+		ObjectMeta: meta.ObjectMeta{Name: "bind-a"},
+		RoleRef:    rbac.RoleRef{Kind: "ClusterRole", Name: "role-a"},
+	}
+	return sa, binding
+}
+
+func TestEncode(t *testing.T) {
+	sa, _ := newObjects()
+
+	doc, err := manifest.Encode(sa)
+	require.NoError(t, err)
+	require.Contains(t, string(doc), "apiVersion: v1\nkind: ServiceAccount\n")
+	require.Contains(t, string(doc), "name: sa-a\n")
+
+	// The input is not modified.
+	require.Empty(t, sa.Kind)
+}
+
+func TestFilename(t *testing.T) {
+	sa, binding := newObjects()
+
+	name, err := manifest.Filename(sa)
+	require.NoError(t, err)
+	require.Exactly(t, "serviceaccount-ns-a-sa-a.yaml", name)
+
+	name, err = manifest.Filename(binding)
+	require.NoError(t, err)
+	require.Exactly(t, "clusterrolebinding-bind-a.yaml", name)
+}
+
+func TestWriteStdout(t *testing.T) {
+	sa, binding := newObjects()
+
+	var out bytes.Buffer
+	written, err := manifest.Write(manifest.Stdout, &out, sa, binding)
+	require.NoError(t, err)
+	require.Exactly(t, []string{manifest.Stdout}, written)
+	require.Regexp(t, `(?s)^apiVersion: v1\nkind: ServiceAccount\n.*\n---\napiVersion: rbac.authorization.k8s.io/v1\nkind: ClusterRoleBinding\n`, out.String())
+}
+
+func TestWriteDir(t *testing.T) {
+	testkit_file.ResetTestdata(t)
+	_, dir := testkit_file.CreatePath(t, "manifests")
+
+	sa, binding := newObjects()
+
+	written, err := manifest.Write(dir, nil, sa, binding)
+	require.NoError(t, err)
+	require.Exactly(
+		t,
+		[]string{
+			filepath.Join(dir, "serviceaccount-ns-a-sa-a.yaml"),
+			filepath.Join(dir, "clusterrolebinding-bind-a.yaml"),
+			filepath.Join(dir, manifest.KustomizationFilename),
+		},
+		written,
+	)
+
+	doc, err := ioutil.ReadFile(written[1])
+	require.NoError(t, err)
+	require.Contains(t, string(doc), "kind: ClusterRoleBinding\n")
+
+	kustomization, err := ioutil.ReadFile(written[2])
+	require.NoError(t, err)
+	require.Exactly(
+		t,
+		"apiVersion: kustomize.config.k8s.io/v1beta1\nkind: Kustomization\nresources:\n"+
+			"- serviceaccount-ns-a-sa-a.yaml\n"+
+			"- clusterrolebinding-bind-a.yaml\n",
+		string(kustomization),
+	)
+}
@@ -0,0 +1,335 @@
+// Copyright (C) 2020 The kubeauth Authors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at https://mozilla.org/MPL/2.0/.
+
+// Package ownership provides the labels and annotations which mark API objects created by kubeauth.
+package ownership
+
+import (
+	"encoding/json"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	rbac "k8s.io/api/rbac/v1"
+	meta "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/validation"
+)
+
+const (
+	// ManagedByLabel is the standard label which identifies the tool that manages an object.
+	ManagedByLabel = "app.kubernetes.io/managed-by"
+
+	// ManagedByValue is the ManagedByLabel value of objects created by kubeauth.
+	ManagedByValue = "kubeauth"
+
+	// UserAnnotation holds the name of the kubeconfig user for which the object was created.
+	UserAnnotation = "kubeauth/user"
+
+	// UsersAnnotation holds a JSON list of all kubeconfig users which use the object, including the
+	// one in UserAnnotation, because add-user reuses objects which it created for other users, e.g.
+	// a shared service account or binding.
+	UsersAnnotation = "kubeauth/users"
+
+	// SubjectsAnnotation holds a JSON object which maps each user of a binding to the subject which
+	// add-user bound for it, so that the subject can be removed once the user no longer exists.
+	SubjectsAnnotation = "kubeauth/subjects"
+
+	// CreatorAnnotation holds the kubeconfig user whose credentials created the object.
+	CreatorAnnotation = "kubeauth/created-by"
+
+	// CreatedAtAnnotation holds the RFC 3339 time at which the object was created.
+	CreatedAtAnnotation = "kubeauth/created-at"
+)
+
+// Selector is the label selector which matches all objects created by kubeauth.
+var Selector = ManagedByLabel + "=" + ManagedByValue
+
+// Owner describes the creation of an object by kubeauth.
+type Owner struct {
+	// User is the name of the kubeconfig user for which the object was created.
+	//
+	// It is empty for objects which are not created for a kubeconfig user, e.g. by the bind command,
+	// and then UserAnnotation is omitted.
+	User string
+
+	// Creator is the name of the kubeconfig user whose credentials created the object.
+	//
+	// It is empty if the creator is unknown, e.g. for manifests rendered by add-user --offline,
+	// and then CreatorAnnotation is omitted.
+	Creator string
+
+	// CreatedAt is the time of creation.
+	CreatedAt time.Time
+
+	// Labels holds additional user-supplied labels.
+	//
+	// They cannot replace ManagedByLabel.
+	Labels map[string]string
+
+	// Annotations holds additional user-supplied annotations.
+	//
+	// They cannot replace the annotations defined by this package.
+	Annotations map[string]string
+}
+
+// ObjectMeta returns the metadata of an object to create.
+func (o Owner) ObjectMeta(ns, name string) meta.ObjectMeta {
+	objMeta := meta.ObjectMeta{
+		Namespace:   ns,
+		Name:        name,
+		Labels:      map[string]string{},
+		Annotations: map[string]string{},
+	}
+
+	for k, v := range o.Labels {
+		objMeta.Labels[k] = v
+	}
+	for k, v := range o.Annotations {
+		objMeta.Annotations[k] = v
+	}
+
+	objMeta.Labels[ManagedByLabel] = ManagedByValue
+	if o.User != "" {
+		objMeta.Annotations[UserAnnotation] = o.User
+		setUsers(&objMeta, []string{o.User})
+	}
+	if o.Creator != "" {
+		objMeta.Annotations[CreatorAnnotation] = o.Creator
+	}
+	objMeta.Annotations[CreatedAtAnnotation] = o.CreatedAt.UTC().Format(time.RFC3339)
+
+	return objMeta
+}
+
+// BindingMeta returns the metadata of a binding to create, which also records the subject bound
+// for the user.
+func (o Owner) BindingMeta(ns, name string, subject rbac.Subject) meta.ObjectMeta {
+	objMeta := o.ObjectMeta(ns, name)
+	if o.User != "" {
+		setSubjects(&objMeta, map[string]rbac.Subject{o.User: subject})
+	}
+	return objMeta
+}
+
+// IsManaged returns true if the object was created by kubeauth.
+func IsManaged(objMeta meta.ObjectMeta) bool {
+	return objMeta.Labels[ManagedByLabel] == ManagedByValue
+}
+
+// User returns the name of the kubeconfig user for which the object was created, if any.
+func User(objMeta meta.ObjectMeta) string {
+	return objMeta.Annotations[UserAnnotation]
+}
+
+// Users returns the kubeconfig users which use the object, sorted by name.
+//
+// Objects without UsersAnnotation, e.g. created before it was introduced, are used by the user in
+// UserAnnotation, if any.
+func Users(objMeta meta.ObjectMeta) ([]string, error) {
+	raw, ok := objMeta.Annotations[UsersAnnotation]
+	if !ok {
+		if user := User(objMeta); user != "" {
+			return []string{user}, nil
+		}
+		return nil, nil
+	}
+
+	var users []string
+	if err := json.Unmarshal([]byte(raw), &users); err != nil {
+		return nil, errors.Wrapf(err, "annotation [%s] of [%s] is not a JSON list", UsersAnnotation, objMeta.Name)
+	}
+	sort.Strings(users)
+	return users, nil
+}
+
+// Subjects returns the subjects which add-user bound for the users of a binding.
+//
+// Users without a recorded subject, e.g. of bindings created before SubjectsAnnotation was
+// introduced, are omitted.
+func Subjects(objMeta meta.ObjectMeta) (map[string]rbac.Subject, error) {
+	subjects := map[string]rbac.Subject{}
+	raw, ok := objMeta.Annotations[SubjectsAnnotation]
+	if !ok {
+		return subjects, nil
+	}
+
+	if err := json.Unmarshal([]byte(raw), &subjects); err != nil {
+		return nil, errors.Wrapf(err, "annotation [%s] of [%s] is not a JSON object", SubjectsAnnotation, objMeta.Name)
+	}
+	return subjects, nil
+}
+
+// AddUser records the user as another user of an existing object, and the subject bound for it if
+// the object is a binding. It returns true if the metadata changed.
+//
+// Objects which kubeauth did not create for a user, e.g. bindings created by the bind command, are
+// left unchanged, because they are never considered orphaned.
+func AddUser(objMeta *meta.ObjectMeta, user string, subject *rbac.Subject) (changed bool, _ error) {
+	if !IsManaged(*objMeta) {
+		return false, nil
+	}
+
+	users, err := Users(*objMeta)
+	if err != nil {
+		return false, errors.WithStack(err)
+	}
+	if len(users) == 0 {
+		return false, nil
+	}
+
+	if !hasString(users, user) {
+		setUsers(objMeta, append(users, user))
+		changed = true
+	}
+
+	if subject != nil {
+		subjects, err := Subjects(*objMeta)
+		if err != nil {
+			return false, errors.WithStack(err)
+		}
+		if recorded, ok := subjects[user]; !ok || recorded != *subject {
+			subjects[user] = *subject
+			setSubjects(objMeta, subjects)
+			changed = true
+		}
+	}
+
+	return changed, nil
+}
+
+// RemoveUsers removes the users, and their subjects, from those recorded for the object.
+//
+// UserAnnotation is left unchanged, because it identifies the user for which the object was created.
+func RemoveUsers(objMeta *meta.ObjectMeta, remove []string) error {
+	users, err := Users(*objMeta)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	subjects, err := Subjects(*objMeta)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	remaining := []string{}
+	for _, u := range users {
+		if !hasString(remove, u) {
+			remaining = append(remaining, u)
+		}
+	}
+	for _, u := range remove {
+		delete(subjects, u)
+	}
+
+	setUsers(objMeta, remaining)
+	if _, ok := objMeta.Annotations[SubjectsAnnotation]; ok {
+		setSubjects(objMeta, subjects)
+	}
+
+	return nil
+}
+
+// setUsers writes the users to UsersAnnotation, sorted by name.
+func setUsers(objMeta *meta.ObjectMeta, users []string) {
+	sort.Strings(users)
+	raw, _ := json.Marshal(users) // strings always marshal
+	if objMeta.Annotations == nil {
+		objMeta.Annotations = map[string]string{}
+	}
+	objMeta.Annotations[UsersAnnotation] = string(raw)
+}
+
+// setSubjects writes the subjects to SubjectsAnnotation.
+func setSubjects(objMeta *meta.ObjectMeta, subjects map[string]rbac.Subject) {
+	raw, _ := json.Marshal(subjects) // subjects only hold strings, so they always marshal
+	if objMeta.Annotations == nil {
+		objMeta.Annotations = map[string]string{}
+	}
+	objMeta.Annotations[SubjectsAnnotation] = string(raw)
+}
+
+// ParseLabels parses label selections in the format <key>=<value>.
+func ParseLabels(selections []string) (map[string]string, error) {
+	labels := map[string]string{}
+	for _, s := range selections {
+		k, v, err := parsePair(s)
+		if err != nil {
+			return nil, errors.WithStack(err)
+		}
+		if errs := validation.IsValidLabelValue(v); len(errs) > 0 {
+			return nil, errors.Errorf("label [%s] value is invalid: %s", s, strings.Join(errs, ", "))
+		}
+		if isReservedLabel(k) {
+			return nil, errors.Errorf("label [%s] is reserved", k)
+		}
+		labels[k] = v
+	}
+	return labels, nil
+}
+
+// ParseAnnotations parses annotation selections in the format <key>=<value>.
+func ParseAnnotations(selections []string) (map[string]string, error) {
+	annotations := map[string]string{}
+	for _, s := range selections {
+		k, v, err := parsePair(s)
+		if err != nil {
+			return nil, errors.WithStack(err)
+		}
+		if isReservedAnnotation(k) {
+			return nil, errors.Errorf("annotation [%s] is reserved", k)
+		}
+		annotations[k] = v
+	}
+	return annotations, nil
+}
+
+// CheckReserved returns an error if the metadata, e.g. from a user-supplied manifest, includes a
+// label or annotation which only kubeauth may set.
+func CheckReserved(objMeta meta.ObjectMeta) error {
+	for k := range objMeta.Labels {
+		if isReservedLabel(k) {
+			return errors.Errorf("label [%s] is reserved", k)
+		}
+	}
+	for k := range objMeta.Annotations {
+		if isReservedAnnotation(k) {
+			return errors.Errorf("annotation [%s] is reserved", k)
+		}
+	}
+	return nil
+}
+
+func isReservedLabel(k string) bool {
+	return k == ManagedByLabel
+}
+
+func isReservedAnnotation(k string) bool {
+	return k == UserAnnotation || k == UsersAnnotation || k == SubjectsAnnotation || k == CreatorAnnotation || k == CreatedAtAnnotation
+}
+
+// parsePair parses a <key>=<value> selection whose key is a valid label or annotation key.
+func parsePair(s string) (k, v string, _ error) {
+	n := strings.Index(s, "=")
+	if n == -1 {
+		return "", "", errors.Errorf("selection [%s] does not use format <key>=<value>", s)
+	}
+
+	k, v = s[:n], s[n+1:]
+	if errs := validation.IsQualifiedName(k); len(errs) > 0 {
+		return "", "", errors.Errorf("selection [%s] key is invalid: %s", s, strings.Join(errs, ", "))
+	}
+
+	return k, v, nil
+}
+
+func hasString(list []string, s string) bool {
+	for _, l := range list {
+		if l == s {
+			return true
+		}
+	}
+	return false
+}
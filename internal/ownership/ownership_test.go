@@ -0,0 +1,171 @@
+// Copyright (C) 2020 The kubeauth Authors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package ownership_test
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	rbac "k8s.io/api/rbac/v1"
+	meta "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	cage_require "github.com/codeactual/kubeauth/internal/cage/testkit/testify/require"
+	"github.com/codeactual/kubeauth/internal/ownership"
+)
+
+func TestObjectMeta(t *testing.T) {
+	owner := ownership.Owner{
+		User:        "some-user",
+		Creator:     "some-admin",
+		CreatedAt:   time.Date(2020, 3, 1, 12, 0, 0, 0, time.FixedZone("", 3600)),
+		Labels:      map[string]string{"team": "a", ownership.ManagedByLabel: "other"},
+		Annotations: map[string]string{"note": "b", ownership.UserAnnotation: "other"},
+	}
+
+	objMeta := owner.ObjectMeta("some-ns", "some-name")
+
+	require.Exactly(
+		t,
+		meta.ObjectMeta{
+			Namespace: "some-ns",
+			Name:      "some-name",
+			Labels:    map[string]string{"team": "a", ownership.ManagedByLabel: ownership.ManagedByValue},
+			Annotations: map[string]string{
+				"note":                        "b",
+				ownership.UserAnnotation:      "some-user",
+				ownership.UsersAnnotation:     `["some-user"]`,
+				ownership.CreatorAnnotation:   "some-admin",
+				ownership.CreatedAtAnnotation: "2020-03-01T11:00:00Z",
+			},
+		},
+		objMeta,
+	)
+	require.True(t, ownership.IsManaged(objMeta))
+	require.Exactly(t, "some-user", ownership.User(objMeta))
+	require.False(t, ownership.IsManaged(meta.ObjectMeta{}))
+
+	// Objects which are not created for a kubeconfig user omit the user annotation.
+	objMeta = ownership.Owner{Creator: "some-admin"}.ObjectMeta("", "some-name")
+	require.True(t, ownership.IsManaged(objMeta))
+	require.NotContains(t, objMeta.Annotations, ownership.UserAnnotation)
+	require.NotContains(t, objMeta.Annotations, ownership.UsersAnnotation)
+
+	// Objects whose creator is unknown omit the creator annotation.
+	objMeta = ownership.Owner{User: "some-user"}.ObjectMeta("", "some-name")
+	require.NotContains(t, objMeta.Annotations, ownership.CreatorAnnotation)
+}
+
+func TestBindingMeta(t *testing.T) {
+	subject := rbac.Subject{Kind: rbac.ServiceAccountKind, Name: "some-sa", Namespace: "some-ns"}
+
+	objMeta := ownership.Owner{User: "some-user"}.BindingMeta("some-ns", "some-name", subject)
+	require.Exactly(t, `{"some-user":{"kind":"ServiceAccount","name":"some-sa","namespace":"some-ns"}}`, objMeta.Annotations[ownership.SubjectsAnnotation])
+
+	subjects, err := ownership.Subjects(objMeta)
+	require.NoError(t, err)
+	require.Exactly(t, map[string]rbac.Subject{"some-user": subject}, subjects)
+
+	// Bindings which are not created for a kubeconfig user have no subjects to record.
+	objMeta = ownership.Owner{}.BindingMeta("some-ns", "some-name", subject)
+	require.NotContains(t, objMeta.Annotations, ownership.SubjectsAnnotation)
+}
+
+// TestUsers asserts that objects without the users annotation are used by the user they were created for.
+func TestUsers(t *testing.T) {
+	users, err := ownership.Users(meta.ObjectMeta{Annotations: map[string]string{ownership.UserAnnotation: "user-a"}})
+	require.NoError(t, err)
+	require.Exactly(t, []string{"user-a"}, users)
+
+	users, err = ownership.Users(meta.ObjectMeta{})
+	require.NoError(t, err)
+	require.Empty(t, users)
+
+	_, err = ownership.Users(meta.ObjectMeta{Annotations: map[string]string{ownership.UsersAnnotation: "user-a"}})
+	cage_require.MatchRegexp(t, fmt.Sprintf("%v", err), "is not a JSON list")
+}
+
+func TestAddAndRemoveUsers(t *testing.T) {
+	subjectA := rbac.Subject{Kind: rbac.ServiceAccountKind, Name: "sa-a", Namespace: "some-ns"}
+	subjectB := rbac.Subject{Kind: rbac.UserKind, APIGroup: rbac.GroupName, Name: "cn-b"}
+
+	objMeta := ownership.Owner{User: "user-b"}.BindingMeta("some-ns", "some-name", subjectB)
+
+	changed, err := ownership.AddUser(&objMeta, "user-a", &subjectA)
+	require.NoError(t, err)
+	require.True(t, changed)
+
+	changed, err = ownership.AddUser(&objMeta, "user-a", &subjectA)
+	require.NoError(t, err)
+	require.False(t, changed)
+
+	users, err := ownership.Users(objMeta)
+	require.NoError(t, err)
+	require.Exactly(t, []string{"user-a", "user-b"}, users)
+	subjects, err := ownership.Subjects(objMeta)
+	require.NoError(t, err)
+	require.Exactly(t, map[string]rbac.Subject{"user-a": subjectA, "user-b": subjectB}, subjects)
+
+	require.NoError(t, ownership.RemoveUsers(&objMeta, []string{"user-b"}))
+
+	users, err = ownership.Users(objMeta)
+	require.NoError(t, err)
+	require.Exactly(t, []string{"user-a"}, users)
+	subjects, err = ownership.Subjects(objMeta)
+	require.NoError(t, err)
+	require.Exactly(t, map[string]rbac.Subject{"user-a": subjectA}, subjects)
+	require.Exactly(t, "user-b", ownership.User(objMeta))
+
+	// Objects which kubeauth did not create for a user are left unchanged.
+	objMeta = ownership.Owner{}.ObjectMeta("some-ns", "some-name")
+	changed, err = ownership.AddUser(&objMeta, "user-a", nil)
+	require.NoError(t, err)
+	require.False(t, changed)
+	require.NotContains(t, objMeta.Annotations, ownership.UsersAnnotation)
+}
+
+func TestParseLabels(t *testing.T) {
+	labels, err := ownership.ParseLabels([]string{"team=a", "example.com/tier=", "empty="})
+	require.NoError(t, err)
+	require.Exactly(t, map[string]string{"team": "a", "example.com/tier": "", "empty": ""}, labels)
+
+	for expectErr, selections := range map[string][]string{
+		"does not use format":  {"team"},
+		"key is invalid":       {"bad key=a"},
+		"value is invalid":     {"team=a b"},
+		"label .* is reserved": {ownership.ManagedByLabel + "=other"},
+	} {
+		_, err = ownership.ParseLabels(selections)
+		cage_require.MatchRegexp(t, fmt.Sprintf("%v", err), expectErr)
+	}
+}
+
+func TestParseAnnotations(t *testing.T) {
+	annotations, err := ownership.ParseAnnotations([]string{"note=a b=c"})
+	require.NoError(t, err)
+	require.Exactly(t, map[string]string{"note": "a b=c"}, annotations)
+
+	_, err = ownership.ParseAnnotations([]string{ownership.CreatedAtAnnotation + "=now"})
+	cage_require.MatchRegexp(t, fmt.Sprintf("%v", err), "annotation .* is reserved")
+
+	_, err = ownership.ParseAnnotations([]string{"note"})
+	cage_require.MatchRegexp(t, fmt.Sprintf("%v", err), "does not use format")
+}
+
+func TestCheckReserved(t *testing.T) {
+	require.NoError(t, ownership.CheckReserved(meta.ObjectMeta{
+		Labels:      map[string]string{"team": "a"},
+		Annotations: map[string]string{"note": "a"},
+	}))
+
+	err := ownership.CheckReserved(meta.ObjectMeta{Labels: map[string]string{ownership.ManagedByLabel: "other"}})
+	cage_require.MatchRegexp(t, fmt.Sprintf("%v", err), "label .* is reserved")
+
+	err = ownership.CheckReserved(meta.ObjectMeta{Annotations: map[string]string{ownership.UserAnnotation: "other"}})
+	cage_require.MatchRegexp(t, fmt.Sprintf("%v", err), "annotation .* is reserved")
+}
@@ -0,0 +1,223 @@
+// Copyright (C) 2020 The kubeauth Authors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package snapshot
+
+import (
+	"sort"
+
+	rbac "k8s.io/api/rbac/v1"
+
+	cage_k8s "github.com/codeactual/kubeauth/internal/cage/kubernetes/v1"
+)
+
+// Subject diff statuses.
+const (
+	StatusAdded   = "added"
+	StatusRemoved = "removed"
+	StatusChanged = "changed"
+)
+
+// Grant is a single permission which a binding gives its subjects.
+//
+// Rules are expanded into one Grant per verb, API group, resource, and resource name (or
+// non-resource URL) combination so that partial changes to a rule are reported precisely.
+type Grant struct {
+	// Namespace is empty if the grant applies cluster-wide.
+	Namespace      string `json:"namespace,omitempty"`
+	Verb           string `json:"verb"`
+	Group          string `json:"group,omitempty"`
+	Resource       string `json:"resource,omitempty"`
+	ResourceName   string `json:"resourceName,omitempty"`
+	NonResourceURL string `json:"nonResourceURL,omitempty"`
+}
+
+// String returns the grant in a human-readable format for use in reports.
+func (g Grant) String() string {
+	var target string
+	if g.NonResourceURL != "" {
+		target = g.NonResourceURL
+	} else {
+		target = g.Resource
+		if g.Group != "" {
+			target += "." + g.Group
+		}
+		if g.ResourceName != "" {
+			target += "/" + g.ResourceName
+		}
+	}
+
+	if g.Namespace == "" {
+		return g.Verb + " " + target + " cluster-wide"
+	}
+	return g.Verb + " " + target + " in namespace [" + g.Namespace + "]"
+}
+
+// SubjectDiff describes how a subject's grants differ between two snapshots.
+type SubjectDiff struct {
+	Subject rbac.Subject `json:"subject"`
+
+	// Status is StatusAdded if the subject only has grants in the second snapshot, StatusRemoved
+	// if it only has grants in the first, and StatusChanged otherwise.
+	Status string `json:"status"`
+
+	Added   []Grant `json:"added,omitempty"`
+	Removed []Grant `json:"removed,omitempty"`
+}
+
+// Diff returns the subjects whose grants differ between the snapshots, sorted by subject kind,
+// namespace, and name.
+func Diff(a, b *Snapshot) []SubjectDiff {
+	aGrants, bGrants := a.grants(), b.grants()
+
+	keys := map[string]rbac.Subject{}
+	for k, g := range aGrants {
+		keys[k] = g.subject
+	}
+	for k, g := range bGrants {
+		keys[k] = g.subject
+	}
+
+	diffs := []SubjectDiff{}
+	for k, s := range keys {
+		d := SubjectDiff{Subject: s}
+
+		aSet, bSet := aGrants[k].set(), bGrants[k].set()
+		for g := range bSet {
+			if !aSet[g] {
+				d.Added = append(d.Added, g)
+			}
+		}
+		for g := range aSet {
+			if !bSet[g] {
+				d.Removed = append(d.Removed, g)
+			}
+		}
+
+		if len(d.Added) == 0 && len(d.Removed) == 0 {
+			continue
+		}
+
+		switch {
+		case len(aSet) == 0:
+			d.Status = StatusAdded
+		case len(bSet) == 0:
+			d.Status = StatusRemoved
+		default:
+			d.Status = StatusChanged
+		}
+
+		sortGrants(d.Added)
+		sortGrants(d.Removed)
+		diffs = append(diffs, d)
+	}
+
+	sort.Slice(diffs, func(i, j int) bool {
+		return subjectKey(diffs[i].Subject) < subjectKey(diffs[j].Subject)
+	})
+
+	return diffs
+}
+
+// subjectGrants holds a subject's grants.
+type subjectGrants struct {
+	subject rbac.Subject
+	grants  map[Grant]bool
+}
+
+// set returns the grants, or an empty set if the subject has none.
+func (s *subjectGrants) set() map[Grant]bool {
+	if s == nil {
+		return map[Grant]bool{}
+	}
+	return s.grants
+}
+
+// grants returns the grants of each subject, keyed by subject kind, namespace, and name.
+//
+// Bindings whose role does not exist in the snapshot do not grant anything. Service account
+// subjects without a namespace are resolved to their binding's namespace.
+func (s *Snapshot) grants() map[string]*subjectGrants {
+	roles := map[string]Role{}
+	for _, r := range s.Roles {
+		roles[r.Namespace+"/"+r.Name] = r
+	}
+	clusterRoles := map[string]Role{}
+	for _, r := range s.ClusterRoles {
+		clusterRoles[r.Name] = r
+	}
+
+	all := map[string]*subjectGrants{}
+
+	add := func(namespace string, b Binding) {
+		var role Role
+		var ok bool
+		if b.RoleRef.Kind == cage_k8s.KindClusterRole {
+			role, ok = clusterRoles[b.RoleRef.Name]
+		} else {
+			role, ok = roles[b.Namespace+"/"+b.RoleRef.Name]
+		}
+		if !ok {
+			return
+		}
+
+		grants := expand(namespace, role.Rules)
+
+		for _, subject := range b.Subjects {
+			subject = rbac.Subject{Kind: subject.Kind, Namespace: subject.Namespace, Name: subject.Name}
+			if subject.Kind == cage_k8s.KindServiceAccount && subject.Namespace == "" {
+				subject.Namespace = b.Namespace
+			}
+
+			key := subjectKey(subject)
+			if all[key] == nil {
+				all[key] = &subjectGrants{subject: subject, grants: map[Grant]bool{}}
+			}
+			for _, g := range grants {
+				all[key].grants[g] = true
+			}
+		}
+	}
+
+	for _, b := range s.RoleBindings {
+		add(b.Namespace, b)
+	}
+	for _, b := range s.ClusterRoleBindings {
+		add("", b)
+	}
+
+	return all
+}
+
+// expand returns one Grant per combination of the rules' fields.
+func expand(namespace string, rules []rbac.PolicyRule) (grants []Grant) {
+	for _, r := range rules {
+		for _, verb := range r.Verbs {
+			for _, url := range r.NonResourceURLs {
+				grants = append(grants, Grant{Namespace: namespace, Verb: verb, NonResourceURL: url})
+			}
+
+			names := r.ResourceNames
+			if len(names) == 0 {
+				names = []string{""}
+			}
+			for _, group := range r.APIGroups {
+				for _, resource := range r.Resources {
+					for _, name := range names {
+						grants = append(grants, Grant{Namespace: namespace, Verb: verb, Group: group, Resource: resource, ResourceName: name})
+					}
+				}
+			}
+		}
+	}
+	return grants
+}
+
+func sortGrants(grants []Grant) {
+	sort.Slice(grants, func(i, j int) bool {
+		return grants[i].String() < grants[j].String()
+	})
+}
@@ -0,0 +1,133 @@
+// Copyright (C) 2020 The kubeauth Authors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package snapshot_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	rbac "k8s.io/api/rbac/v1"
+
+	cage_k8s "github.com/codeactual/kubeauth/internal/cage/kubernetes/v1"
+	"github.com/codeactual/kubeauth/internal/snapshot"
+)
+
+var (
+	userA = rbac.Subject{Kind: cage_k8s.KindUser, Name: "user-a"}
+	userB = rbac.Subject{Kind: cage_k8s.KindUser, Name: "user-b"}
+	saA   = rbac.Subject{Kind: cage_k8s.KindServiceAccount, Namespace: "dev", Name: "sa-a"}
+)
+
+func newSnapshot() *snapshot.Snapshot {
+	return &snapshot.Snapshot{
+		Version: snapshot.Version,
+		Roles: []snapshot.Role{
+			{
+				Namespace: "dev",
+				Name:      "pods",
+				Rules:     []rbac.PolicyRule{{Verbs: []string{"get", "list"}, APIGroups: []string{""}, Resources: []string{"pods"}}},
+			},
+		},
+		ClusterRoles: []snapshot.Role{
+			{
+				Name:  "metrics",
+				Rules: []rbac.PolicyRule{{Verbs: []string{"get"}, NonResourceURLs: []string{"/metrics"}}},
+			},
+		},
+		RoleBindings: []snapshot.Binding{
+			{
+				Namespace: "dev",
+				Name:      "pods",
+				RoleRef:   rbac.RoleRef{Kind: cage_k8s.KindRole, Name: "pods"},
+				Subjects:  []rbac.Subject{userA, {Kind: cage_k8s.KindServiceAccount, Name: "sa-a"}},
+			},
+		},
+		ClusterRoleBindings: []snapshot.Binding{
+			{
+				Name:     "metrics",
+				RoleRef:  rbac.RoleRef{Kind: cage_k8s.KindClusterRole, Name: "metrics"},
+				Subjects: []rbac.Subject{userA},
+			},
+		},
+	}
+}
+
+func TestDiffEqual(t *testing.T) {
+	require.Empty(t, snapshot.Diff(newSnapshot(), newSnapshot()))
+}
+
+func TestDiff(t *testing.T) {
+	a := newSnapshot()
+	b := newSnapshot()
+
+	// Narrow the role's verbs, remove the service account from it, grant it to another user,
+	// and remove the cluster role binding.
+	b.Roles[0].Rules = []rbac.PolicyRule{
+		{Verbs: []string{"get"}, APIGroups: []string{""}, Resources: []string{"pods"}, ResourceNames: []string{"web"}},
+	}
+	b.RoleBindings[0].Subjects = []rbac.Subject{userA, userB}
+	b.ClusterRoleBindings = nil
+
+	require.Exactly(
+		t,
+		[]snapshot.SubjectDiff{
+			{
+				Subject: saA,
+				Status:  snapshot.StatusRemoved,
+				Removed: []snapshot.Grant{
+					{Namespace: "dev", Verb: "get", Resource: "pods"},
+					{Namespace: "dev", Verb: "list", Resource: "pods"},
+				},
+			},
+			{
+				Subject: userA,
+				Status:  snapshot.StatusChanged,
+				Added: []snapshot.Grant{
+					{Namespace: "dev", Verb: "get", Resource: "pods", ResourceName: "web"},
+				},
+				Removed: []snapshot.Grant{
+					{Verb: "get", NonResourceURL: "/metrics"},
+					{Namespace: "dev", Verb: "get", Resource: "pods"},
+					{Namespace: "dev", Verb: "list", Resource: "pods"},
+				},
+			},
+			{
+				Subject: userB,
+				Status:  snapshot.StatusAdded,
+				Added: []snapshot.Grant{
+					{Namespace: "dev", Verb: "get", Resource: "pods", ResourceName: "web"},
+				},
+			},
+		},
+		snapshot.Diff(a, b),
+	)
+}
+
+// TestDiffMissingRole asserts that a binding whose role is missing does not grant anything.
+func TestDiffMissingRole(t *testing.T) {
+	a := newSnapshot()
+	b := newSnapshot()
+	b.ClusterRoles = nil
+
+	require.Exactly(
+		t,
+		[]snapshot.SubjectDiff{
+			{
+				Subject: userA,
+				Status:  snapshot.StatusChanged,
+				Removed: []snapshot.Grant{{Verb: "get", NonResourceURL: "/metrics"}},
+			},
+		},
+		snapshot.Diff(a, b),
+	)
+}
+
+func TestGrantString(t *testing.T) {
+	require.Exactly(t, "get pods in namespace [dev]", snapshot.Grant{Namespace: "dev", Verb: "get", Resource: "pods"}.String())
+	require.Exactly(t, "get deployments.apps/web cluster-wide", snapshot.Grant{Verb: "get", Group: "apps", Resource: "deployments", ResourceName: "web"}.String())
+	require.Exactly(t, "get /metrics cluster-wide", snapshot.Grant{Verb: "get", NonResourceURL: "/metrics"}.String())
+}
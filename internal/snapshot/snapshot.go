@@ -0,0 +1,231 @@
+// Copyright (C) 2020 The kubeauth Authors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at https://mozilla.org/MPL/2.0/.
+
+// Package snapshot captures a cluster's RBAC objects in a normalized document and compares
+// the grants of their subjects between two documents.
+package snapshot
+
+import (
+	"encoding/json"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+	rbac "k8s.io/api/rbac/v1"
+
+	"github.com/codeactual/kubeauth/internal/audit"
+	cage_k8s_core "github.com/codeactual/kubeauth/internal/cage/kubernetes/v1/core"
+)
+
+// Version identifies the document format which New produces and Read accepts.
+const Version = 1
+
+// Snapshot holds the RBAC-relevant fields of a cluster's objects.
+//
+// All collections are sorted, and server-managed metadata is omitted, so that two documents
+// can be compared with a generic text diff as well.
+type Snapshot struct {
+	Version             int              `json:"version"`
+	Roles               []Role           `json:"roles"`
+	ClusterRoles        []Role           `json:"clusterRoles"`
+	RoleBindings        []Binding        `json:"roleBindings"`
+	ClusterRoleBindings []Binding        `json:"clusterRoleBindings"`
+	ServiceAccounts     []ServiceAccount `json:"serviceAccounts"`
+}
+
+// Role holds the fields of a Role or ClusterRole.
+type Role struct {
+	Namespace string            `json:"namespace,omitempty"`
+	Name      string            `json:"name"`
+	Rules     []rbac.PolicyRule `json:"rules"`
+}
+
+// Binding holds the fields of a RoleBinding or ClusterRoleBinding.
+type Binding struct {
+	Namespace string         `json:"namespace,omitempty"`
+	Name      string         `json:"name"`
+	RoleRef   rbac.RoleRef   `json:"roleRef"`
+	Subjects  []rbac.Subject `json:"subjects"`
+}
+
+// ServiceAccount holds the fields of a ServiceAccount.
+type ServiceAccount struct {
+	Namespace string `json:"namespace"`
+	Name      string `json:"name"`
+}
+
+// New returns a normalized snapshot of the objects.
+func New(objs audit.Objects) *Snapshot {
+	s := Snapshot{
+		Version:             Version,
+		Roles:               []Role{},
+		ClusterRoles:        []Role{},
+		RoleBindings:        []Binding{},
+		ClusterRoleBindings: []Binding{},
+		ServiceAccounts:     []ServiceAccount{},
+	}
+
+	for _, obj := range objs.Roles {
+		s.Roles = append(s.Roles, Role{Namespace: obj.Namespace, Name: obj.Name, Rules: normalizeRules(obj.Rules)})
+	}
+	for _, obj := range objs.ClusterRoles {
+		s.ClusterRoles = append(s.ClusterRoles, Role{Name: obj.Name, Rules: normalizeRules(obj.Rules)})
+	}
+	for _, obj := range objs.RoleBindings {
+		s.RoleBindings = append(s.RoleBindings, Binding{Namespace: obj.Namespace, Name: obj.Name, RoleRef: obj.RoleRef, Subjects: normalizeSubjects(obj.Subjects)})
+	}
+	for _, obj := range objs.ClusterRoleBindings {
+		s.ClusterRoleBindings = append(s.ClusterRoleBindings, Binding{Name: obj.Name, RoleRef: obj.RoleRef, Subjects: normalizeSubjects(obj.Subjects)})
+	}
+	for _, obj := range objs.ServiceAccounts {
+		s.ServiceAccounts = append(s.ServiceAccounts, ServiceAccount{Namespace: obj.Namespace, Name: obj.Name})
+	}
+
+	sortRoles(s.Roles)
+	sortRoles(s.ClusterRoles)
+	sortBindings(s.RoleBindings)
+	sortBindings(s.ClusterRoleBindings)
+	sort.Slice(s.ServiceAccounts, func(i, j int) bool {
+		a, b := s.ServiceAccounts[i], s.ServiceAccounts[j]
+		return a.Namespace+"/"+a.Name < b.Namespace+"/"+b.Name
+	})
+
+	return &s
+}
+
+// List returns a snapshot of the objects in all namespaces.
+func List(clientset *cage_k8s_core.Clientset) (*Snapshot, error) {
+	var objs audit.Objects
+
+	roles, err := clientset.Roles.List("")
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	if roles != nil {
+		objs.Roles = roles.Items
+	}
+
+	clusterRoles, err := clientset.ClusterRoles.List()
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	if clusterRoles != nil {
+		objs.ClusterRoles = clusterRoles.Items
+	}
+
+	roleBindings, err := clientset.RoleBindings.List("")
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	if roleBindings != nil {
+		objs.RoleBindings = roleBindings.Items
+	}
+
+	clusterRoleBindings, err := clientset.ClusterRoleBindings.List()
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	if clusterRoleBindings != nil {
+		objs.ClusterRoleBindings = clusterRoleBindings.Items
+	}
+
+	serviceAccounts, err := clientset.ServiceAccounts.List("")
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	if serviceAccounts != nil {
+		objs.ServiceAccounts = serviceAccounts.Items
+	}
+
+	return New(objs), nil
+}
+
+// Write encodes the snapshot as indented JSON.
+func Write(w io.Writer, s *Snapshot) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(s); err != nil {
+		return errors.Wrap(err, "failed to encode snapshot")
+	}
+	return nil
+}
+
+// Read decodes a snapshot which Write encoded.
+func Read(r io.Reader) (*Snapshot, error) {
+	var s Snapshot
+	if err := json.NewDecoder(r).Decode(&s); err != nil {
+		return nil, errors.Wrap(err, "failed to decode snapshot")
+	}
+	if s.Version != Version {
+		return nil, errors.Errorf("snapshot version [%d] is not supported, expected [%d]", s.Version, Version)
+	}
+	return &s, nil
+}
+
+// normalizeRules returns copies of the rules whose fields, and the rules themselves, are sorted.
+//
+// The order is not significant to the API server's authorization decisions.
+func normalizeRules(rules []rbac.PolicyRule) []rbac.PolicyRule {
+	normalized := []rbac.PolicyRule{}
+	for _, r := range rules {
+		n := rbac.PolicyRule{
+			Verbs:           sortedCopy(r.Verbs),
+			APIGroups:       sortedCopy(r.APIGroups),
+			Resources:       sortedCopy(r.Resources),
+			ResourceNames:   sortedCopy(r.ResourceNames),
+			NonResourceURLs: sortedCopy(r.NonResourceURLs),
+		}
+		normalized = append(normalized, n)
+	}
+	sort.SliceStable(normalized, func(i, j int) bool {
+		return ruleKey(normalized[i]) < ruleKey(normalized[j])
+	})
+	return normalized
+}
+
+func ruleKey(r rbac.PolicyRule) string {
+	return strings.Join([]string{
+		strings.Join(r.APIGroups, ","),
+		strings.Join(r.Resources, ","),
+		strings.Join(r.ResourceNames, ","),
+		strings.Join(r.NonResourceURLs, ","),
+		strings.Join(r.Verbs, ","),
+	}, "|")
+}
+
+func normalizeSubjects(subjects []rbac.Subject) []rbac.Subject {
+	normalized := append([]rbac.Subject{}, subjects...)
+	sort.SliceStable(normalized, func(i, j int) bool {
+		return subjectKey(normalized[i]) < subjectKey(normalized[j])
+	})
+	return normalized
+}
+
+func subjectKey(s rbac.Subject) string {
+	return s.Kind + "/" + s.Namespace + "/" + s.Name
+}
+
+func sortRoles(roles []Role) {
+	sort.Slice(roles, func(i, j int) bool {
+		return roles[i].Namespace+"/"+roles[i].Name < roles[j].Namespace+"/"+roles[j].Name
+	})
+}
+
+func sortBindings(bindings []Binding) {
+	sort.Slice(bindings, func(i, j int) bool {
+		return bindings[i].Namespace+"/"+bindings[i].Name < bindings[j].Namespace+"/"+bindings[j].Name
+	})
+}
+
+func sortedCopy(s []string) []string {
+	if len(s) == 0 {
+		return nil
+	}
+	c := append([]string{}, s...)
+	sort.Strings(c)
+	return c
+}
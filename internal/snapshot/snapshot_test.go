@@ -0,0 +1,121 @@
+// Copyright (C) 2020 The kubeauth Authors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package snapshot_test
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	core "k8s.io/api/core/v1"
+	rbac "k8s.io/api/rbac/v1"
+	meta "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/codeactual/kubeauth/internal/audit"
+	cage_k8s "github.com/codeactual/kubeauth/internal/cage/kubernetes/v1"
+	"github.com/codeactual/kubeauth/internal/snapshot"
+)
+
+func TestNew(t *testing.T) {
+	s := snapshot.New(audit.Objects{
+		Roles: []rbac.Role{
+			{
+				ObjectMeta: meta.ObjectMeta{Namespace: "prod", Name: "b", ResourceVersion: "10", UID: "some-uid"},
+				Rules: []rbac.PolicyRule{
+					{Verbs: []string{"list", "get"}, APIGroups: []string{""}, Resources: []string{"pods"}},
+					{Verbs: []string{"get"}, APIGroups: []string{"apps"}, Resources: []string{"deployments"}},
+				},
+			},
+			{ObjectMeta: meta.ObjectMeta{Namespace: "dev", Name: "a"}},
+		},
+		ClusterRoleBindings: []rbac.ClusterRoleBinding{
+			{
+				ObjectMeta: meta.ObjectMeta{Name: "crb"},
+				RoleRef:    rbac.RoleRef{Kind: cage_k8s.KindClusterRole, Name: "view"},
+				Subjects: []rbac.Subject{
+					{Kind: cage_k8s.KindUser, Name: "user-b"},
+					{Kind: cage_k8s.KindGroup, Name: "group-a"},
+					{Kind: cage_k8s.KindUser, Name: "user-a"},
+				},
+			},
+		},
+		ServiceAccounts: []core.ServiceAccount{
+			{ObjectMeta: meta.ObjectMeta{Namespace: "prod", Name: "sa"}},
+			{ObjectMeta: meta.ObjectMeta{Namespace: "dev", Name: "sa"}},
+		},
+	})
+
+	require.Exactly(t, snapshot.Version, s.Version)
+
+	require.Exactly(
+		t,
+		[]snapshot.Role{
+			{Namespace: "dev", Name: "a", Rules: []rbac.PolicyRule{}},
+			{
+				Namespace: "prod",
+				Name:      "b",
+				Rules: []rbac.PolicyRule{
+					{Verbs: []string{"get"}, APIGroups: []string{"apps"}, Resources: []string{"deployments"}},
+					{Verbs: []string{"get", "list"}, APIGroups: []string{""}, Resources: []string{"pods"}},
+				},
+			},
+		},
+		s.Roles,
+	)
+	require.Empty(t, s.ClusterRoles)
+	require.Empty(t, s.RoleBindings)
+	require.Exactly(
+		t,
+		[]snapshot.Binding{
+			{
+				Name:    "crb",
+				RoleRef: rbac.RoleRef{Kind: cage_k8s.KindClusterRole, Name: "view"},
+				Subjects: []rbac.Subject{
+					{Kind: cage_k8s.KindGroup, Name: "group-a"},
+					{Kind: cage_k8s.KindUser, Name: "user-a"},
+					{Kind: cage_k8s.KindUser, Name: "user-b"},
+				},
+			},
+		},
+		s.ClusterRoleBindings,
+	)
+	require.Exactly(
+		t,
+		[]snapshot.ServiceAccount{{Namespace: "dev", Name: "sa"}, {Namespace: "prod", Name: "sa"}},
+		s.ServiceAccounts,
+	)
+}
+
+func TestReadWrite(t *testing.T) {
+	s := snapshot.New(audit.Objects{
+		ClusterRoles: []rbac.ClusterRole{
+			{
+				ObjectMeta: meta.ObjectMeta{Name: "view"},
+				Rules:      []rbac.PolicyRule{{Verbs: []string{"get"}, APIGroups: []string{""}, Resources: []string{"pods"}}},
+			},
+		},
+	})
+
+	var buf bytes.Buffer
+	require.NoError(t, snapshot.Write(&buf, s))
+
+	read, err := snapshot.Read(&buf)
+	require.NoError(t, err)
+	require.Exactly(t, s, read)
+}
+
+func TestErrOnReadVersion(t *testing.T) {
+	_, err := snapshot.Read(strings.NewReader(`{"version": 2}`))
+	require.EqualError(t, err, "snapshot version [2] is not supported, expected [1]")
+}
+
+func TestErrOnReadInvalid(t *testing.T) {
+	_, err := snapshot.Read(strings.NewReader(`{`))
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "failed to decode snapshot")
+}
@@ -0,0 +1,77 @@
+// Copyright (C) 2020 The kubeauth Authors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package testkit
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	core "k8s.io/api/core/v1"
+	meta "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/codeactual/kubeauth/internal/ownership"
+)
+
+type matchManagedMeta struct {
+	namespace string
+	name      string
+	user      string
+}
+
+func (m *matchManagedMeta) Matches(x interface{}) bool {
+	var objMeta meta.ObjectMeta
+	switch v := x.(type) {
+	case meta.ObjectMeta:
+		objMeta = v
+	case *core.ServiceAccount:
+		if v == nil {
+			return false
+		}
+		objMeta = v.ObjectMeta
+	case *core.Namespace:
+		if v == nil {
+			return false
+		}
+		objMeta = v.ObjectMeta
+	default:
+		return false
+	}
+
+	if objMeta.Namespace != m.namespace || objMeta.Name != m.name {
+		return false
+	}
+
+	if !ownership.IsManaged(objMeta) ||
+		ownership.User(objMeta) != m.user ||
+		objMeta.Annotations[ownership.CreatorAnnotation] != Username {
+		return false
+	}
+
+	_, err := time.Parse(time.RFC3339, objMeta.Annotations[ownership.CreatedAtAnnotation])
+	return err == nil
+}
+
+func (m *matchManagedMeta) String() string {
+	return fmt.Sprintf("metadata of object [%s] in namespace [%s] created by kubeauth for user [%s]", m.name, m.namespace, m.user)
+}
+
+var _ gomock.Matcher = (*matchManagedMeta)(nil)
+
+// ManagedMeta matches the metadata, or a service account or namespace with the metadata, of an object created by
+// kubeauth for the Username user with the credentials of the current context's user.
+//
+// The namespace is empty for cluster-scoped objects.
+func ManagedMeta(namespace, name string) gomock.Matcher { // must return this type for gomock to recognize it
+	return &matchManagedMeta{namespace: namespace, name: name, user: Username}
+}
+
+// UnownedManagedMeta is like ManagedMeta but matches objects which were not created for a kubeconfig user,
+// e.g. by the bind command.
+func UnownedManagedMeta(namespace, name string) gomock.Matcher {
+	return &matchManagedMeta{namespace: namespace, name: name}
+}